@@ -14,7 +14,11 @@ import (
 
 	"bike-rental/api-gateway/internal/client"
 	"bike-rental/api-gateway/internal/handlers"
+	"bike-rental/api-gateway/internal/middleware"
+	"bike-rental/api-gateway/internal/stream"
 	"bike-rental/config"
+	"bike-rental/logging"
+	"bike-rental/tlsutil"
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"gopkg.in/yaml.v3"
@@ -30,27 +34,80 @@ import (
 
 // @host localhost:8080
 // @BasePath /
+
+// @securityDefinitions.apikey OperatorToken
+// @in header
+// @name Authorization
+// @description Bearer token from gateway.operators.tokens_file
 func main() {
+	if code, ok := config.RunCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
 	cfg, err := config.LoadConfig(os.Getenv("CONFIG_PATH"))
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := logging.SetLevel(cfg.Log.Level); err != nil {
+		log.Fatalf("Failed to set log level: %v", err)
+	}
 
 	// Initialize gRPC client for Rent Service
-	rentClient, err := client.NewRentClient(cfg.Services.RentService)
+	creds, err := tlsutil.ClientCredentials(cfg.TLS.GRPC)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	rentClient, err := client.NewRentClient(cfg.Services.RentService, creds, cfg.Gateway.RentClient)
 	if err != nil {
 		log.Fatalf("Failed to create rent client: %v", err)
 	}
 	defer rentClient.Close()
 
 	// Initialize HTTP client for Stats Service
-	statsClient := client.NewStatsClient(fmt.Sprintf("http://stats-service:%d", cfg.Server.StatsServicePort))
+	statsClient := client.NewStatsClient(cfg.Services.StatsService, cfg.Gateway.StatsClient)
+
+	// Live fleet events are shared by all SSE clients
+	fleetHub := stream.NewHub(rentClient, cfg.Gateway.FleetStream.ClientBuffer)
+	fleetCtx, stopFleet := context.WithCancel(context.Background())
+	defer stopFleet()
+	go fleetHub.Run(fleetCtx)
+
+	// Operators authenticate with bearer tokens; without them the admin API
+	// stays closed
+	var operators *middleware.Operators
+	if path := cfg.Gateway.Operators.TokensFile; path != "" {
+		operators, err = middleware.LoadOperators(path)
+		if err != nil {
+			log.Fatalf("Failed to load operators: %v", err)
+		}
+	} else {
+		log.Println("gateway.operators.tokens_file is not set, admin API is closed")
+	}
 
 	// Setup handlers
-	h := handlers.NewHandlers(rentClient, statsClient)
+	h := handlers.NewHandlers(rentClient, statsClient, fleetHub, cfg.Gateway.FleetStream.Heartbeat, operators)
+
+	limiter := middleware.NewRateLimiter(cfg.Gateway.RateLimit)
+
+	// Apply live config changes
+	watcher := config.NewWatcher(os.Getenv("CONFIG_PATH"), cfg)
+	watcher.Subscribe(func(old, updated *config.Config) {
+		if updated.Log.Level != old.Log.Level {
+			logging.SetLevel(updated.Log.Level)
+		}
+		if updated.Gateway.RateLimit != old.Gateway.RateLimit {
+			limiter.Update(updated.Gateway.RateLimit)
+		}
+	})
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go watcher.Run(watchCtx)
 
 	// Setup router
 	r := chi.NewRouter()
+	r.Use(middleware.WithRequestInfo)
+	r.Use(limiter.Middleware)
+	r.Use(operators.Identify)
 
 	// Swagger - serve YAML as JSON for compatibility
 	r.Get("/swagger.json", func(w http.ResponseWriter, req *http.Request) {
@@ -114,7 +171,14 @@ func main() {
 	log.Printf("API Gateway starting on port %d", cfg.Server.APIGatewayPort)
 
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLS.HTTP.Enabled {
+			log.Println("HTTPS enabled")
+			err = server.ListenAndServeTLS(cfg.TLS.HTTP.CertFile, cfg.TLS.HTTP.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
@@ -125,6 +189,9 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down API Gateway...")
+	// Ends open SSE connections, which would otherwise hold Shutdown until
+	// its timeout
+	stopFleet()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	server.Shutdown(ctx)
@@ -2,83 +2,267 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
+	"bike-rental/api-gateway/internal/middleware"
 	"bike-rental/api-gateway/internal/models"
+	"bike-rental/config"
 	"bike-rental/rent-service/proto/rent"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type RentClient interface {
-	StartRent(ctx context.Context, userID, bikeID string) (*models.RentResponse, error)
-	EndRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error)
+	// StartRent and EndRent redeem promoCode with the rent when it is not
+	// empty.
+	StartRent(ctx context.Context, userID, bikeID, promoCode string) (*models.RentResponse, error)
+	EndRent(ctx context.Context, rentID, userID, promoCode string) (*models.RentResponse, error)
+	PauseRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error)
+	ResumeRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error)
 	GetAvailableBikes(ctx context.Context, location string) (*models.BikesList, error)
 	AddBike(ctx context.Context, name, location string) (*models.BikeResponse, error)
 	DeleteBike(ctx context.Context, bikeID string) (*models.DeleteBikeResponse, error)
+	// WatchFleet calls fn for every fleet event after resumeToken (or from
+	// now when empty) until ctx is done or the stream breaks. It always
+	// returns a non-nil error.
+	WatchFleet(ctx context.Context, location, resumeToken string, fn func(models.FleetEvent)) error
+	// ImportBikes sends rows to rent-service in one stream. It is never
+	// retried.
+	ImportBikes(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions) (*models.ImportReport, error)
+	// ExportBikes calls fn for every bike, optionally at one location, until
+	// the fleet is exhausted or fn fails.
+	ExportBikes(ctx context.Context, location string, fn func(models.Bike) error) error
+	// ListAuditLog returns rent-service audit entries, newest first.
+	ListAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	// GetRentTimeline returns the lifecycle events of a rent, oldest first.
+	GetRentTimeline(ctx context.Context, rentID string) (*models.RentTimeline, error)
+	// GetWallet returns a rider's balance and latest ledger entries.
+	GetWallet(ctx context.Context, userID string, limit int) (*models.Wallet, error)
+	// TopUpWallet is retried only with an idempotency key, which keeps a
+	// retry from charging twice.
+	TopUpWallet(ctx context.Context, userID string, amount int64, idempotencyKey string) (*models.Wallet, error)
+	ListPasses(ctx context.Context) (*models.PassList, error)
+	// BuyPass and CancelPass are not retried: after a lost response the
+	// retry would fail with FailedPrecondition although the call succeeded.
+	BuyPass(ctx context.Context, userID, pass string) (*models.Subscription, error)
+	CancelPass(ctx context.Context, userID, subscriptionID string) (*models.Subscription, error)
+	ListPromoCodes(ctx context.Context) (*models.PromoCodeList, error)
+	// CreatePromoCode and DeletePromoCode are not retried, UpdatePromoCode
+	// replaces the whole code and is.
+	CreatePromoCode(ctx context.Context, promo models.PromoCode) (*models.PromoCode, error)
+	UpdatePromoCode(ctx context.Context, promo models.PromoCode) (*models.PromoCode, error)
+	DeletePromoCode(ctx context.Context, code string) error
+	// GetPromoStats returns the use of promo codes redeemed in [from, to);
+	// zero times default to the last 30 days.
+	GetPromoStats(ctx context.Context, from, to time.Time) (*models.PromoStats, error)
+	// GetReceipt returns the itemized invoice of an ended, billed rent.
+	GetReceipt(ctx context.Context, rentID string) (*models.Receipt, error)
+	// GetStatement returns a rider's statement for month (YYYY-MM), the
+	// current one when empty.
+	GetStatement(ctx context.Context, userID, month string) (*models.Statement, error)
+	// RegisterUser is not retried: after a lost response the retry would
+	// fail with AlreadyExists. UpdateUser and SetUserStatus are.
+	RegisterUser(ctx context.Context, user models.User) (*models.User, error)
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	UpdateUser(ctx context.Context, user models.User) (*models.User, error)
+	SetUserStatus(ctx context.Context, userID, status, reason string) (*models.User, error)
+	// ListUsers returns accounts, newest first, in status when not empty.
+	ListUsers(ctx context.Context, status string, limit int) (*models.UserList, error)
+	// PlanRebalance recommends moves of available bikes; zero arguments
+	// take the rent-service defaults.
+	PlanRebalance(ctx context.Context, truckCapacity, trucks int, horizon time.Duration) (*models.RebalancePlan, error)
+	// RelocateBikes is not retried: after a lost response the retry would
+	// move the bikes again.
+	RelocateBikes(ctx context.Context, moves []models.BikeMove) (*models.RelocationReport, error)
+	Health(ctx context.Context) error
 	Close() error
 }
 
 type rentClient struct {
 	conn   *grpc.ClientConn
 	client rent.RentServiceClient
+	health healthpb.HealthClient
+	reads  callPolicy
+	writes callPolicy
 }
 
-func NewRentClient(address string) (RentClient, error) {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewRentClient(address string, creds credentials.TransportCredentials, policy config.ClientPolicyConfig) (RentClient, error) {
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(forwardRequestInfo),
+		grpc.WithChainStreamInterceptor(forwardRequestInfoStream),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to rent service: %w", err)
 	}
 
+	breaker := NewBreaker("rent-service", policy.Breaker)
+
 	return &rentClient{
 		conn:   conn,
 		client: rent.NewRentServiceClient(conn),
+		health: healthpb.NewHealthClient(conn),
+		reads: callPolicy{
+			timeout:   policy.ReadTimeout,
+			retry:     policy.Retry,
+			breaker:   breaker,
+			failure:   isGRPCFailure,
+			retryable: isGRPCRetryable,
+		},
+		writes: callPolicy{
+			timeout:   policy.WriteTimeout,
+			breaker:   breaker,
+			failure:   isGRPCFailure,
+			retryable: isGRPCRetryable,
+		},
 	}, nil
 }
 
-func (c *rentClient) StartRent(ctx context.Context, userID, bikeID string) (*models.RentResponse, error) {
-	resp, err := c.client.StartRent(ctx, &rent.StartRentRequest{
-		UserId: userID,
-		BikeId: bikeID,
+// isGRPCFailure reports errors that indicate rent-service itself is unhealthy,
+// as opposed to a rejected request.
+func isGRPCFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func isGRPCRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// Metadata rent-service reads to fill its audit log.
+const (
+	actorMetadata     = "x-actor"
+	requestIDMetadata = "x-request-id"
+)
+
+// withRequestInfo copies the request ID and actor of the incoming HTTP
+// request into outgoing gRPC metadata.
+func withRequestInfo(ctx context.Context) context.Context {
+	info := middleware.RequestInfoFromContext(ctx)
+	if info.ID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadata, info.ID)
+	}
+	if info.Actor != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, actorMetadata, info.Actor)
+	}
+	return ctx
+}
+
+func forwardRequestInfo(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(withRequestInfo(ctx), method, req, reply, cc, opts...)
+}
+
+func forwardRequestInfoStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(withRequestInfo(ctx), desc, cc, method, opts...)
+}
+
+func (c *rentClient) StartRent(ctx context.Context, userID, bikeID, promoCode string) (*models.RentResponse, error) {
+	var resp *rent.RentResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.StartRent(ctx, &rent.StartRentRequest{
+			UserId:    userID,
+			BikeId:    bikeID,
+			PromoCode: promoCode,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.RentResponse{
-		RentID:    resp.RentId,
-		UserID:    resp.UserId,
-		BikeID:    resp.BikeId,
-		Status:    resp.Status,
-		Message:   resp.Message,
-		StartTime: resp.StartTime,
-		EndTime:   resp.EndTime,
-	}, nil
+	return toRentResponse(resp), nil
+}
+
+func (c *rentClient) EndRent(ctx context.Context, rentID, userID, promoCode string) (*models.RentResponse, error) {
+	var resp *rent.RentResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.EndRent(ctx, &rent.EndRentRequest{
+			RentId:    rentID,
+			UserId:    userID,
+			PromoCode: promoCode,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toRentResponse(resp), nil
 }
 
-func (c *rentClient) EndRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error) {
-	resp, err := c.client.EndRent(ctx, &rent.EndRentRequest{
-		RentId: rentID,
-		UserId: userID,
+func (c *rentClient) PauseRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error) {
+	var resp *rent.RentResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.PauseRent(ctx, &rent.PauseRentRequest{
+			RentId: rentID,
+			UserId: userID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toRentResponse(resp), nil
+}
+
+func (c *rentClient) ResumeRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error) {
+	var resp *rent.RentResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.ResumeRent(ctx, &rent.ResumeRentRequest{
+			RentId: rentID,
+			UserId: userID,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	return toRentResponse(resp), nil
+}
+
+func toRentResponse(resp *rent.RentResponse) *models.RentResponse {
 	return &models.RentResponse{
-		RentID:    resp.RentId,
-		UserID:    resp.UserId,
-		BikeID:    resp.BikeId,
-		Status:    resp.Status,
-		Message:   resp.Message,
-		StartTime: resp.StartTime,
-		EndTime:   resp.EndTime,
-	}, nil
+		RentID:        resp.RentId,
+		UserID:        resp.UserId,
+		BikeID:        resp.BikeId,
+		Status:        resp.Status,
+		Message:       resp.Message,
+		StartTime:     resp.StartTime,
+		EndTime:       resp.EndTime,
+		PausedSeconds: resp.PausedSeconds,
+		PausedAt:      resp.PausedAt,
+		Fare:          resp.Fare,
+		Currency:      resp.Currency,
+		Pass:          resp.Pass,
+		PromoCode:     resp.PromoCode,
+		Discount:      resp.Discount,
+		InvoiceNumber: resp.InvoiceNumber,
+	}
 }
 
 func (c *rentClient) GetAvailableBikes(ctx context.Context, location string) (*models.BikesList, error) {
-	resp, err := c.client.GetAvailableBikes(ctx, &rent.AvailableBikesRequest{
-		Location: location,
+	var resp *rent.BikesList
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetAvailableBikes(ctx, &rent.AvailableBikesRequest{
+			Location: location,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -98,9 +282,13 @@ func (c *rentClient) GetAvailableBikes(ctx context.Context, location string) (*m
 }
 
 func (c *rentClient) AddBike(ctx context.Context, name, location string) (*models.BikeResponse, error) {
-	resp, err := c.client.AddBike(ctx, &rent.AddBikeRequest{
-		Name:     name,
-		Location: location,
+	var resp *rent.BikeResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.AddBike(ctx, &rent.AddBikeRequest{
+			Name:     name,
+			Location: location,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -116,8 +304,12 @@ func (c *rentClient) AddBike(ctx context.Context, name, location string) (*model
 }
 
 func (c *rentClient) DeleteBike(ctx context.Context, bikeID string) (*models.DeleteBikeResponse, error) {
-	resp, err := c.client.DeleteBike(ctx, &rent.DeleteBikeRequest{
-		BikeId: bikeID,
+	var resp *rent.DeleteBikeResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.DeleteBike(ctx, &rent.DeleteBikeRequest{
+			BikeId: bikeID,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -129,6 +321,722 @@ func (c *rentClient) DeleteBike(ctx context.Context, bikeID string) (*models.Del
 	}, nil
 }
 
+// importTimeout is the minimum deadline for ImportBikes; large imports take
+// longer than a single write.
+const importTimeout = time.Minute
+
+func (c *rentClient) ImportBikes(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions) (*models.ImportReport, error) {
+	policy := c.writes
+	policy.timeout = max(policy.timeout, importTimeout)
+
+	var resp *rent.ImportBikesResponse
+	err := policy.do(ctx, false, func(ctx context.Context) error {
+		stream, err := c.client.ImportBikes(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = stream.Send(&rent.ImportBikesRequest{Item: &rent.ImportBikesRequest_Options{
+			Options: &rent.ImportOptions{AllOrNothing: opts.AllOrNothing, DryRun: opts.DryRun},
+		}})
+		for _, row := range rows {
+			if err != nil {
+				break
+			}
+			err = stream.Send(&rent.ImportBikesRequest{Item: &rent.ImportBikesRequest_Row{
+				Row: &rent.ImportBikeRow{
+					Line:     int32(row.Line),
+					Name:     row.Name,
+					Location: row.Location,
+					Status:   row.Status,
+				},
+			}})
+		}
+		// On io.EOF the server has already failed the call; CloseAndRecv
+		// returns its status.
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		resp, err = stream.CloseAndRecv()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{
+		Total:    int(resp.Total),
+		Imported: len(resp.Imported),
+		DryRun:   resp.DryRun,
+		Bikes:    make([]models.Bike, 0, len(resp.Imported)),
+		Errors:   make([]models.ImportRowError, 0, len(resp.Errors)),
+	}
+	for _, b := range resp.Imported {
+		report.Bikes = append(report.Bikes, models.Bike{
+			ID:       b.Id,
+			Name:     b.Name,
+			Status:   b.Status,
+			Location: b.Location,
+		})
+	}
+	for _, e := range resp.Errors {
+		report.Errors = append(report.Errors, models.ImportRowError{Line: int(e.Line), Error: e.Error})
+	}
+
+	return report, nil
+}
+
+// ExportBikes streams for as long as the fleet takes, so like WatchFleet it
+// has no deadline and bypasses the circuit breaker.
+func (c *rentClient) ExportBikes(ctx context.Context, location string, fn func(models.Bike) error) error {
+	stream, err := c.client.ExportBikes(ctx, &rent.ExportBikesRequest{Location: location})
+	if err != nil {
+		return err
+	}
+
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(models.Bike{
+			ID:       b.Id,
+			Name:     b.Name,
+			Status:   b.Status,
+			Location: b.Location,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *rentClient) ListAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	req := &rent.ListAuditLogRequest{
+		Actor:     filter.Actor,
+		Action:    filter.Action,
+		TargetId:  filter.TargetID,
+		RequestId: filter.RequestID,
+		Limit:     int32(filter.Limit),
+	}
+	if !filter.From.IsZero() {
+		req.Since = filter.From.Unix()
+	}
+	if !filter.To.IsZero() {
+		req.Until = filter.To.Unix()
+	}
+
+	var resp *rent.AuditLog
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.ListAuditLog(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.AuditEntry, 0, len(resp.Entries))
+	for _, e := range resp.Entries {
+		entry := models.AuditEntry{
+			ID:         e.Id,
+			Timestamp:  time.Unix(e.Timestamp, 0).UTC(),
+			Actor:      e.Actor,
+			Peer:       e.Peer,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetId,
+			RequestID:  e.RequestId,
+			Error:      e.Error,
+		}
+		if e.Before != "" {
+			entry.Before = json.RawMessage(e.Before)
+		}
+		if e.After != "" {
+			entry.After = json.RawMessage(e.After)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (c *rentClient) GetRentTimeline(ctx context.Context, rentID string) (*models.RentTimeline, error) {
+	var resp *rent.RentTimeline
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetRentTimeline(ctx, &rent.GetRentTimelineRequest{RentId: rentID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &models.RentTimeline{
+		RentID: resp.RentId,
+		Status: resp.Status,
+		Events: make([]models.RentTimelineEvent, 0, len(resp.Events)),
+	}
+	for _, e := range resp.Events {
+		timeline.Events = append(timeline.Events, models.RentTimelineEvent{
+			Seq:       e.Seq,
+			Type:      e.Type,
+			UserID:    e.UserId,
+			BikeID:    e.BikeId,
+			Timestamp: time.Unix(e.Timestamp, 0).UTC(),
+			Reason:    e.Reason,
+		})
+	}
+
+	return timeline, nil
+}
+
+func (c *rentClient) GetWallet(ctx context.Context, userID string, limit int) (*models.Wallet, error) {
+	var resp *rent.Wallet
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetWallet(ctx, &rent.GetWalletRequest{UserId: userID, Limit: int32(limit)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := toWallet(resp)
+	wallet.Entries = make([]models.WalletEntry, 0, len(resp.Entries))
+	for _, e := range resp.Entries {
+		wallet.Entries = append(wallet.Entries, models.WalletEntry{
+			ID:        e.Id,
+			Timestamp: time.Unix(e.Timestamp, 0).UTC(),
+			Type:      e.Type,
+			Amount:    e.Amount,
+			Held:      e.Held,
+			RentID:    e.RentId,
+			Reference: e.Reference,
+			Reason:    e.Reason,
+		})
+	}
+
+	return wallet, nil
+}
+
+func (c *rentClient) TopUpWallet(ctx context.Context, userID string, amount int64, idempotencyKey string) (*models.Wallet, error) {
+	var resp *rent.Wallet
+	err := c.writes.do(ctx, idempotencyKey != "", func(ctx context.Context) (err error) {
+		resp, err = c.client.TopUpWallet(ctx, &rent.TopUpWalletRequest{
+			UserId:         userID,
+			Amount:         amount,
+			IdempotencyKey: idempotencyKey,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toWallet(resp), nil
+}
+
+func toWallet(resp *rent.Wallet) *models.Wallet {
+	wallet := &models.Wallet{
+		UserID:    resp.UserId,
+		Balance:   resp.Balance,
+		Held:      resp.Held,
+		Available: resp.Available,
+		Currency:  resp.Currency,
+	}
+	if resp.Pass != nil {
+		wallet.Pass = toSubscription(resp.Pass)
+	}
+	return wallet
+}
+
+func (c *rentClient) ListPasses(ctx context.Context) (*models.PassList, error) {
+	var resp *rent.PassList
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.ListPasses(ctx, &rent.ListPassesRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	passes := make([]models.Pass, 0, len(resp.Passes))
+	for _, p := range resp.Passes {
+		passes = append(passes, models.Pass{
+			Code:            p.Code,
+			Name:            p.Name,
+			Price:           p.Price,
+			Currency:        p.Currency,
+			DurationSeconds: p.DurationSeconds,
+			FreeMinutes:     p.FreeMinutes,
+			DiscountPercent: p.DiscountPercent,
+		})
+	}
+
+	return &models.PassList{Passes: passes}, nil
+}
+
+func (c *rentClient) BuyPass(ctx context.Context, userID, pass string) (*models.Subscription, error) {
+	var resp *rent.Subscription
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.BuyPass(ctx, &rent.BuyPassRequest{UserId: userID, Pass: pass})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSubscription(resp), nil
+}
+
+func (c *rentClient) CancelPass(ctx context.Context, userID, subscriptionID string) (*models.Subscription, error) {
+	var resp *rent.Subscription
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.CancelPass(ctx, &rent.CancelPassRequest{UserId: userID, SubscriptionId: subscriptionID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSubscription(resp), nil
+}
+
+func toSubscription(resp *rent.Subscription) *models.Subscription {
+	sub := &models.Subscription{
+		ID:              resp.Id,
+		UserID:          resp.UserId,
+		Pass:            resp.Pass,
+		Name:            resp.Name,
+		Price:           resp.Price,
+		Currency:        resp.Currency,
+		FreeMinutes:     resp.FreeMinutes,
+		DiscountPercent: resp.DiscountPercent,
+		StartsAt:        time.Unix(resp.StartsAt, 0).UTC(),
+		EndsAt:          time.Unix(resp.EndsAt, 0).UTC(),
+		Refunded:        resp.Refunded,
+	}
+	if resp.CancelledAt != 0 {
+		cancelled := time.Unix(resp.CancelledAt, 0).UTC()
+		sub.CancelledAt = &cancelled
+	}
+	return sub
+}
+
+func (c *rentClient) ListPromoCodes(ctx context.Context) (*models.PromoCodeList, error) {
+	var resp *rent.PromoCodeList
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.ListPromoCodes(ctx, &rent.ListPromoCodesRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	promos := make([]models.PromoCode, 0, len(resp.PromoCodes))
+	for _, p := range resp.PromoCodes {
+		promos = append(promos, *toPromoCode(p))
+	}
+	return &models.PromoCodeList{PromoCodes: promos}, nil
+}
+
+func (c *rentClient) CreatePromoCode(ctx context.Context, promo models.PromoCode) (*models.PromoCode, error) {
+	var resp *rent.PromoCode
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.CreatePromoCode(ctx, fromPromoCode(promo))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toPromoCode(resp), nil
+}
+
+func (c *rentClient) UpdatePromoCode(ctx context.Context, promo models.PromoCode) (*models.PromoCode, error) {
+	var resp *rent.PromoCode
+	err := c.writes.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.UpdatePromoCode(ctx, fromPromoCode(promo))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toPromoCode(resp), nil
+}
+
+func (c *rentClient) DeletePromoCode(ctx context.Context, code string) error {
+	return c.writes.do(ctx, false, func(ctx context.Context) error {
+		_, err := c.client.DeletePromoCode(ctx, &rent.DeletePromoCodeRequest{Code: code})
+		return err
+	})
+}
+
+func (c *rentClient) GetPromoStats(ctx context.Context, from, to time.Time) (*models.PromoStats, error) {
+	req := &rent.PromoStatsRequest{}
+	if !from.IsZero() {
+		req.From = from.Unix()
+	}
+	if !to.IsZero() {
+		req.To = to.Unix()
+	}
+
+	var resp *rent.PromoStatsResponse
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetPromoStats(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.PromoStats{
+		From:       time.Unix(resp.From, 0).UTC(),
+		To:         time.Unix(resp.To, 0).UTC(),
+		PromoCodes: make([]models.PromoUsage, 0, len(resp.PromoCodes)),
+	}
+	for _, p := range resp.PromoCodes {
+		stats.PromoCodes = append(stats.PromoCodes, models.PromoUsage{
+			Code:        p.Code,
+			Redemptions: p.Redemptions,
+			Users:       p.Users,
+			Charged:     p.Charged,
+			Discount:    p.Discount,
+			Currency:    p.Currency,
+		})
+	}
+	return stats, nil
+}
+
+func (c *rentClient) GetReceipt(ctx context.Context, rentID string) (*models.Receipt, error) {
+	var resp *rent.Receipt
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetReceipt(ctx, &rent.GetReceiptRequest{RentId: rentID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &models.Receipt{
+		Invoice:       toInvoice(resp.Invoice),
+		StartTime:     time.Unix(resp.StartTime, 0).UTC(),
+		EndTime:       time.Unix(resp.EndTime, 0).UTC(),
+		RidingSeconds: resp.RidingSeconds,
+		PausedSeconds: resp.PausedSeconds,
+		Pass:          resp.Pass,
+		PromoCode:     resp.PromoCode,
+		Lines:         make([]models.ReceiptLine, 0, len(resp.Lines)),
+	}
+	for _, l := range resp.Lines {
+		receipt.Lines = append(receipt.Lines, models.ReceiptLine{
+			Kind:      l.Kind,
+			Quantity:  l.Quantity,
+			UnitPrice: l.UnitPrice,
+			Amount:    l.Amount,
+		})
+	}
+	return receipt, nil
+}
+
+func (c *rentClient) GetStatement(ctx context.Context, userID, month string) (*models.Statement, error) {
+	var resp *rent.Statement
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetStatement(ctx, &rent.GetStatementRequest{UserId: userID, Month: month})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.Statement{
+		UserID:         resp.UserId,
+		Month:          resp.Month,
+		From:           time.Unix(resp.From, 0).UTC(),
+		To:             time.Unix(resp.To, 0).UTC(),
+		OpeningBalance: resp.OpeningBalance,
+		ClosingBalance: resp.ClosingBalance,
+		TopUps:         resp.TopUps,
+		Fares:          resp.Fares,
+		Refunds:        resp.Refunds,
+		Passes:         resp.Passes,
+		Tax:            resp.Tax,
+		Invoices:       make([]models.Invoice, 0, len(resp.Invoices)),
+		Currency:       resp.Currency,
+	}
+	for _, invoice := range resp.Invoices {
+		statement.Invoices = append(statement.Invoices, toInvoice(invoice))
+	}
+	return statement, nil
+}
+
+func (c *rentClient) RegisterUser(ctx context.Context, user models.User) (*models.User, error) {
+	var resp *rent.User
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.RegisterUser(ctx, &rent.RegisterUserRequest{
+			UserId: user.ID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(resp), nil
+}
+
+func (c *rentClient) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	var resp *rent.User
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.GetUser(ctx, &rent.GetUserRequest{UserId: userID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(resp), nil
+}
+
+func (c *rentClient) UpdateUser(ctx context.Context, user models.User) (*models.User, error) {
+	var resp *rent.User
+	err := c.writes.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.UpdateUser(ctx, &rent.UpdateUserRequest{
+			UserId: user.ID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(resp), nil
+}
+
+func (c *rentClient) SetUserStatus(ctx context.Context, userID, status, reason string) (*models.User, error) {
+	var resp *rent.User
+	err := c.writes.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.SetUserStatus(ctx, &rent.SetUserStatusRequest{UserId: userID, Status: status, Reason: reason})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUser(resp), nil
+}
+
+func (c *rentClient) ListUsers(ctx context.Context, status string, limit int) (*models.UserList, error) {
+	var resp *rent.UserList
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.ListUsers(ctx, &rent.ListUsersRequest{Status: status, Limit: int32(limit)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		users = append(users, *toUser(u))
+	}
+	return &models.UserList{Users: users}, nil
+}
+
+func (c *rentClient) PlanRebalance(ctx context.Context, truckCapacity, trucks int, horizon time.Duration) (*models.RebalancePlan, error) {
+	req := &rent.PlanRebalanceRequest{
+		TruckCapacity:  int32(truckCapacity),
+		Trucks:         int32(trucks),
+		HorizonSeconds: int64(horizon.Seconds()),
+	}
+
+	var resp *rent.RebalancePlan
+	err := c.reads.do(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.client.PlanRebalance(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.RebalancePlan{
+		GeneratedAt:   time.Unix(resp.GeneratedAt, 0).UTC(),
+		Horizon:       (time.Duration(resp.HorizonSeconds) * time.Second).String(),
+		TruckCapacity: int(resp.TruckCapacity),
+		Trucks:        int(resp.Trucks),
+		HistoryFrom:   resp.HistoryFrom,
+		HistoryTo:     resp.HistoryTo,
+		DemandError:   resp.DemandError,
+		Locations:     make([]models.LocationBalance, 0, len(resp.Locations)),
+		Moves:         make([]models.BikeMove, 0, len(resp.Moves)),
+	}
+	for _, l := range resp.Locations {
+		plan.Locations = append(plan.Locations, models.LocationBalance{
+			Location:       l.Location,
+			Available:      int(l.Available),
+			ExpectedStarts: l.ExpectedStarts,
+			ExpectedEnds:   l.ExpectedEnds,
+			Target:         int(l.Target),
+			After:          int(l.After),
+		})
+	}
+	for _, m := range resp.Moves {
+		plan.Moves = append(plan.Moves, toBikeMove(m))
+	}
+	return plan, nil
+}
+
+func (c *rentClient) RelocateBikes(ctx context.Context, moves []models.BikeMove) (*models.RelocationReport, error) {
+	req := &rent.RelocateBikesRequest{Moves: make([]*rent.BikeMove, 0, len(moves))}
+	for _, m := range moves {
+		req.Moves = append(req.Moves, &rent.BikeMove{From: m.From, To: m.To, Count: int32(m.Count)})
+	}
+
+	var resp *rent.RelocateBikesResponse
+	err := c.writes.do(ctx, false, func(ctx context.Context) (err error) {
+		resp, err = c.client.RelocateBikes(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RelocationReport{
+		Relocations: make([]models.BikeRelocation, 0, len(resp.Relocations)),
+		Moved:       int(resp.Moved),
+	}
+	for _, r := range resp.Relocations {
+		report.Relocations = append(report.Relocations, models.BikeRelocation{
+			BikeMove: toBikeMove(r.Move),
+			Moved:    int(r.Moved),
+			BikeIDs:  r.BikeIds,
+		})
+	}
+	return report, nil
+}
+
+func toBikeMove(m *rent.BikeMove) models.BikeMove {
+	return models.BikeMove{From: m.GetFrom(), To: m.GetTo(), Count: int(m.GetCount())}
+}
+
+func toUser(resp *rent.User) *models.User {
+	return &models.User{
+		ID:           resp.UserId,
+		Name:         resp.Name,
+		Email:        resp.Email,
+		Phone:        resp.Phone,
+		Status:       resp.Status,
+		StatusReason: resp.StatusReason,
+		CreatedAt:    time.Unix(resp.CreatedAt, 0).UTC(),
+		UpdatedAt:    time.Unix(resp.UpdatedAt, 0).UTC(),
+	}
+}
+
+func toInvoice(resp *rent.Invoice) models.Invoice {
+	return models.Invoice{
+		Number:     resp.GetNumber(),
+		RentID:     resp.GetRentId(),
+		UserID:     resp.GetUserId(),
+		IssuedAt:   time.Unix(resp.GetIssuedAt(), 0).UTC(),
+		Total:      resp.GetTotal(),
+		Tax:        resp.GetTax(),
+		TaxPercent: resp.GetTaxPercent(),
+		Refunded:   resp.GetRefunded(),
+		Currency:   resp.GetCurrency(),
+	}
+}
+
+func fromPromoCode(promo models.PromoCode) *rent.PromoCode {
+	req := &rent.PromoCode{
+		Code:           promo.Code,
+		Kind:           promo.Kind,
+		Value:          promo.Value,
+		Locations:      promo.Locations,
+		MaxRedemptions: promo.MaxRedemptions,
+		MaxPerUser:     promo.MaxPerUser,
+		Active:         promo.Active,
+	}
+	if promo.ValidFrom != nil {
+		req.ValidFrom = promo.ValidFrom.Unix()
+	}
+	if promo.ValidUntil != nil {
+		req.ValidUntil = promo.ValidUntil.Unix()
+	}
+	return req
+}
+
+func toPromoCode(resp *rent.PromoCode) *models.PromoCode {
+	promo := &models.PromoCode{
+		Code:           resp.Code,
+		Kind:           resp.Kind,
+		Value:          resp.Value,
+		Locations:      resp.Locations,
+		MaxRedemptions: resp.MaxRedemptions,
+		MaxPerUser:     resp.MaxPerUser,
+		Active:         resp.Active,
+		CreatedAt:      time.Unix(resp.CreatedAt, 0).UTC(),
+		Redemptions:    resp.Redemptions,
+	}
+	if resp.ValidFrom != 0 {
+		from := time.Unix(resp.ValidFrom, 0).UTC()
+		promo.ValidFrom = &from
+	}
+	if resp.ValidUntil != 0 {
+		until := time.Unix(resp.ValidUntil, 0).UTC()
+		promo.ValidUntil = &until
+	}
+	return promo
+}
+
+// WatchFleet is a long-lived stream, so it has no deadline and is not
+// subject to the circuit breaker; callers reconnect on error.
+func (c *rentClient) WatchFleet(ctx context.Context, location, resumeToken string, fn func(models.FleetEvent)) error {
+	stream, err := c.client.WatchFleet(ctx, &rent.WatchFleetRequest{Location: location, ResumeToken: resumeToken})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		fn(models.FleetEvent{
+			Type:         event.Type,
+			BikeID:       event.BikeId,
+			RentID:       event.RentId,
+			Location:     event.Location,
+			FromLocation: event.FromLocation,
+			BikeStatus:   event.BikeStatus,
+			Timestamp:    time.Unix(event.Timestamp, 0).UTC(),
+			ResumeToken:  event.ResumeToken,
+		})
+	}
+}
+
+// Health bypasses the circuit breaker so readiness reflects the real state
+// of rent-service.
+func (c *rentClient) Health(ctx context.Context) error {
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{
+		Service: rent.RentService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("rent service is %s", resp.Status)
+	}
+	return nil
+}
+
 func (c *rentClient) Close() error {
 	return c.conn.Close()
 }
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"bike-rental/config"
+)
+
+// ErrCircuitOpen is returned without calling the backend while its circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker fast-fails calls to a backend after repeated failures.
+type Breaker struct {
+	name        string
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(name string, cfg config.BreakerConfig) *Breaker {
+	return &Breaker{
+		name:        name,
+		threshold:   cfg.FailureThreshold,
+		openTimeout: cfg.OpenTimeout,
+		now:         time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed. In the half-open state only one
+// probe call is let through at a time.
+func (b *Breaker) Allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+		}
+		b.setState(stateHalfOpen)
+		b.probing = true
+		return nil
+	case stateHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of a call allowed by Allow.
+func (b *Breaker) Record(failed bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		if b.state != stateClosed {
+			b.setState(stateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state != stateOpen {
+			b.setState(stateOpen)
+		}
+	}
+}
+
+func (b *Breaker) setState(state breakerState) {
+	log.Printf("Circuit breaker %s: %s -> %s", b.name, b.state, state)
+	b.state = state
+}
+
+// callPolicy bundles the deadline, retry and breaker settings for one kind
+// of backend call.
+type callPolicy struct {
+	timeout   time.Duration
+	retry     config.RetryConfig
+	breaker   *Breaker
+	failure   func(error) bool
+	retryable func(error) bool
+}
+
+// do runs fn with a per-attempt deadline. Idempotent calls are retried on
+// retryable errors with jittered exponential backoff.
+func (p callPolicy) do(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if idempotent && p.retry.MaxAttempts > 1 {
+		attempts = p.retry.MaxAttempts
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			timer := time.NewTimer(backoff(p.retry, attempt-1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		if allowErr := p.breaker.Allow(); allowErr != nil {
+			if err != nil {
+				return fmt.Errorf("%w (last error: %v)", allowErr, err)
+			}
+			return allowErr
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		err = fn(callCtx)
+		cancel()
+
+		p.breaker.Record(err != nil && p.failure(err))
+		if err == nil || !p.retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// backoff returns the delay before retry n (starting at 1): exponential
+// growth capped at MaxBackoff, with the upper half randomized.
+func backoff(cfg config.RetryConfig, n int) time.Duration {
+	d := cfg.InitialBackoff << (n - 1)
+	if d <= 0 || d > cfg.MaxBackoff {
+		d = cfg.MaxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"bike-rental/config"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResilienceTestSuite - тестовый набор для retry и circuit breaker
+type ResilienceTestSuite struct {
+	suite.Suite
+	now     time.Time
+	breaker *Breaker
+	policy  callPolicy
+	ctx     context.Context
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *ResilienceTestSuite) SetupTest() {
+	suite.now = time.Now()
+	suite.breaker = NewBreaker("test", config.BreakerConfig{FailureThreshold: 2, OpenTimeout: 10 * time.Second})
+	suite.breaker.now = func() time.Time { return suite.now }
+	suite.policy = callPolicy{
+		timeout: time.Second,
+		retry: config.RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     2 * time.Millisecond,
+		},
+		breaker:   suite.breaker,
+		failure:   isGRPCFailure,
+		retryable: isGRPCRetryable,
+	}
+	suite.ctx = context.Background()
+}
+
+// TestDo_RetriesIdempotentCalls - идемпотентный вызов повторяется до успеха
+func (suite *ResilienceTestSuite) TestDo_RetriesIdempotentCalls() {
+	suite.policy.breaker = NewBreaker("test", config.BreakerConfig{FailureThreshold: 5, OpenTimeout: time.Second})
+	calls := 0
+	err := suite.policy.do(suite.ctx, true, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	})
+
+	suite.NoError(err)
+	suite.Equal(3, calls)
+}
+
+// TestDo_DoesNotRetryWrites - неидемпотентный вызов выполняется один раз
+func (suite *ResilienceTestSuite) TestDo_DoesNotRetryWrites() {
+	calls := 0
+	err := suite.policy.do(suite.ctx, false, func(ctx context.Context) error {
+		calls++
+		return status.Error(codes.Unavailable, "down")
+	})
+
+	suite.Error(err)
+	suite.Equal(1, calls)
+}
+
+// TestDo_DoesNotRetryClientErrors - ошибки запроса не повторяются
+func (suite *ResilienceTestSuite) TestDo_DoesNotRetryClientErrors() {
+	calls := 0
+	err := suite.policy.do(suite.ctx, true, func(ctx context.Context) error {
+		calls++
+		return status.Error(codes.InvalidArgument, "bad request")
+	})
+
+	suite.Error(err)
+	suite.Equal(1, calls)
+}
+
+// TestDo_AppliesDeadline - каждая попытка получает дедлайн
+func (suite *ResilienceTestSuite) TestDo_AppliesDeadline() {
+	err := suite.policy.do(suite.ctx, false, func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		suite.True(ok)
+		return nil
+	})
+
+	suite.NoError(err)
+}
+
+// TestBreaker_OpensAndRecovers - breaker открывается после серии ошибок и закрывается после успешной пробы
+func (suite *ResilienceTestSuite) TestBreaker_OpensAndRecovers() {
+	failing := func(ctx context.Context) error { return status.Error(codes.Unavailable, "down") }
+
+	suite.Error(suite.policy.do(suite.ctx, false, failing))
+	suite.Error(suite.policy.do(suite.ctx, false, failing))
+
+	calls := 0
+	err := suite.policy.do(suite.ctx, false, func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	suite.ErrorIs(err, ErrCircuitOpen)
+	suite.Equal(0, calls)
+
+	suite.now = suite.now.Add(11 * time.Second)
+	err = suite.policy.do(suite.ctx, false, func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	suite.NoError(err)
+	suite.Equal(1, calls)
+	suite.Equal(stateClosed, suite.breaker.state)
+}
+
+// TestBreaker_HalfOpenFailureReopens - неудачная проба снова открывает breaker
+func (suite *ResilienceTestSuite) TestBreaker_HalfOpenFailureReopens() {
+	suite.breaker.Record(true)
+	suite.breaker.Record(true)
+	suite.now = suite.now.Add(11 * time.Second)
+
+	suite.NoError(suite.breaker.Allow())
+	suite.ErrorIs(suite.breaker.Allow(), ErrCircuitOpen)
+	suite.breaker.Record(true)
+
+	suite.Equal(stateOpen, suite.breaker.state)
+	suite.ErrorIs(suite.breaker.Allow(), ErrCircuitOpen)
+}
+
+// TestBreaker_IgnoresClientErrors - ошибки запроса не открывают breaker
+func (suite *ResilienceTestSuite) TestBreaker_IgnoresClientErrors() {
+	for i := 0; i < 5; i++ {
+		suite.policy.do(suite.ctx, false, func(ctx context.Context) error {
+			return status.Error(codes.NotFound, "bike not found")
+		})
+	}
+	suite.NoError(suite.breaker.Allow())
+
+	// Отключившийся клиент и неразбираемый ответ stats-service - тоже не отказ сервиса
+	suite.policy.failure = isHTTPFailure
+	suite.policy.retryable = isHTTPRetryable
+	for _, clientErr := range []error{
+		&url.Error{Op: "Get", URL: "http://stats", Err: context.Canceled},
+		&decodeError{err: errors.New("unexpected EOF")},
+	} {
+		calls := 0
+		for i := 0; i < 5; i++ {
+			suite.policy.do(suite.ctx, true, func(ctx context.Context) error {
+				calls++
+				return clientErr
+			})
+		}
+		suite.Equal(5, calls, "%v is not retried", clientErr)
+		suite.NoError(suite.breaker.Allow(), "%v", clientErr)
+	}
+}
+
+// TestHTTPClassification - классификация HTTP ошибок
+func (suite *ResilienceTestSuite) TestHTTPClassification() {
+	suite.True(isHTTPFailure(errors.New("connection refused")))
+	suite.True(isHTTPRetryable(errors.New("connection refused")))
+	suite.True(isHTTPRetryable(&statusError{code: 503}))
+	suite.False(isHTTPFailure(&statusError{code: 400}))
+	suite.False(isHTTPRetryable(&statusError{code: 500}))
+}
+
+func TestResilienceTestSuite(t *testing.T) {
+	suite.Run(t, new(ResilienceTestSuite))
+}
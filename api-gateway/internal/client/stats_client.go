@@ -3,87 +3,307 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"net/url"
+
+	"bike-rental/api-gateway/internal/models"
+	"bike-rental/config"
 )
 
 type StatsClient interface {
 	GetDailyStats(ctx context.Context, date string) (map[string]interface{}, error)
-	GetActiveRents(ctx context.Context) (int64, error)
+	GetActiveRents(ctx context.Context) (*models.ActiveRents, error)
+	GetRangeStats(ctx context.Context, from, to, granularity string) (*models.RangeStats, error)
+	GetHourlyStats(ctx context.Context, date string) (*models.HourlyStats, error)
+	GetLocationBreakdown(ctx context.Context, from, to string) (*models.LocationBreakdown, error)
+	GetDurationStats(ctx context.Context, from, to, location string) (*models.DurationStats, error)
+	GetUtilization(ctx context.Context, from, to string) (*models.UtilizationStats, error)
+	GetTopStats(ctx context.Context, from, to, limit string) (*models.TopStats, error)
+	GetHeatmap(ctx context.Context, from, to, location string) (*models.Heatmap, error)
+	GetHeatmapCSV(ctx context.Context, from, to, location string) ([]byte, error)
+	GetPassStats(ctx context.Context, from, to string) (*models.PassStats, error)
+	Health(ctx context.Context) error
 }
 
 type statsClient struct {
 	baseURL string
 	client  *http.Client
+	reads   callPolicy
 }
 
-func NewStatsClient(baseURL string) StatsClient {
+func NewStatsClient(baseURL string, policy config.ClientPolicyConfig) StatsClient {
 	return &statsClient{
 		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
+		// Deadlines come from the call policy via the request context.
+		client: &http.Client{},
+		reads: callPolicy{
+			timeout:   policy.ReadTimeout,
+			retry:     policy.Retry,
+			breaker:   NewBreaker("stats-service", policy.Breaker),
+			failure:   isHTTPFailure,
+			retryable: isHTTPRetryable,
 		},
 	}
 }
 
-func (c *statsClient) GetDailyStats(ctx context.Context, date string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/internal/stats/daily?date=%s", c.baseURL, date)
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
+// statusError is returned when stats-service answers with a non-200 status.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("stats service returned %d: %s", e.code, e.body)
+}
+
+// decodeError is returned when a 200 response cannot be decoded.
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("invalid stats service response: %v", e.err)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// isHTTPFailure treats transport errors and 5xx responses as signs that
+// stats-service is unhealthy. A caller that went away or a response that
+// does not decode says nothing about its health.
+func isHTTPFailure(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError
+	}
+	var de *decodeError
+	if errors.Is(err, context.Canceled) || errors.As(err, &de) {
+		return false
 	}
+	return true
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
+// ClientErrorStatus returns the 4xx status stats-service answered with, or 0
+// if err is not a client error. The gateway passes these through unchanged.
+func ClientErrorStatus(err error) int {
+	var se *statusError
+	if errors.As(err, &se) && se.code >= 400 && se.code < 500 {
+		return se.code
 	}
-	defer resp.Body.Close()
+	return 0
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("stats service returned %d: %s", resp.StatusCode, string(body))
+func isHTTPRetryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		switch se.code {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var de *decodeError
+	if errors.Is(err, context.Canceled) || errors.As(err, &de) {
+		return false
 	}
+	return true
+}
+
+func (c *statsClient) GetDailyStats(ctx context.Context, date string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/internal/stats/daily?date=%s", c.baseURL, url.QueryEscape(date))
 
 	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.getJSON(ctx, url, &result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
 
-func (c *statsClient) GetActiveRents(ctx context.Context) (int64, error) {
+func (c *statsClient) GetActiveRents(ctx context.Context) (*models.ActiveRents, error) {
 	url := fmt.Sprintf("%s/internal/stats/active", c.baseURL)
-	
+
+	var result models.ActiveRents
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) GetRangeStats(ctx context.Context, from, to, granularity string) (*models.RangeStats, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+	setIfNotEmpty(query, "granularity", granularity)
+
+	var result models.RangeStats
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/range?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) GetHourlyStats(ctx context.Context, date string) (*models.HourlyStats, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "date", date)
+
+	var result models.HourlyStats
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/hourly?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) GetLocationBreakdown(ctx context.Context, from, to string) (*models.LocationBreakdown, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+
+	var result models.LocationBreakdown
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/locations?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) GetDurationStats(ctx context.Context, from, to, location string) (*models.DurationStats, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+	setIfNotEmpty(query, "location", location)
+
+	var result models.DurationStats
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/durations?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) GetUtilization(ctx context.Context, from, to string) (*models.UtilizationStats, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+
+	var result models.UtilizationStats
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/utilization?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetTopStats passes limit through as given so stats-service validates it.
+func (c *statsClient) GetTopStats(ctx context.Context, from, to, limit string) (*models.TopStats, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+	setIfNotEmpty(query, "limit", limit)
+
+	var result models.TopStats
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/top?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) GetHeatmap(ctx context.Context, from, to, location string) (*models.Heatmap, error) {
+	var result models.Heatmap
+	if err := c.getJSON(ctx, c.heatmapURL(from, to, location, "json"), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetHeatmapCSV returns the heatmap rendered as CSV by stats-service.
+func (c *statsClient) GetHeatmapCSV(ctx context.Context, from, to, location string) ([]byte, error) {
+	var body []byte
+	if err := c.getJSON(ctx, c.heatmapURL(from, to, location, "csv"), &body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (c *statsClient) GetPassStats(ctx context.Context, from, to string) (*models.PassStats, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+
+	var result models.PassStats
+	if err := c.getJSON(ctx, c.baseURL+"/internal/stats/passes?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *statsClient) heatmapURL(from, to, location, format string) string {
+	query := url.Values{}
+	setIfNotEmpty(query, "from", from)
+	setIfNotEmpty(query, "to", to)
+	setIfNotEmpty(query, "location", location)
+	query.Set("format", format)
+	return c.baseURL + "/internal/stats/heatmap?" + query.Encode()
+}
+
+func setIfNotEmpty(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// Health bypasses the circuit breaker so readiness reflects the real state
+// of stats-service.
+func (c *statsClient) Health(ctx context.Context) error {
+	url := fmt.Sprintf("%s/health/ready", c.baseURL)
+	return c.get(ctx, url, nil)
+}
+
+// getJSON performs an idempotent GET through the read policy and decodes
+// the JSON body into out. A *[]byte out receives the raw body instead.
+func (c *statsClient) getJSON(ctx context.Context, url string, out interface{}) error {
+	return c.reads.do(ctx, true, func(ctx context.Context) error {
+		return c.get(ctx, url, out)
+	})
+}
+
+func (c *statsClient) get(ctx context.Context, url string, out interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return 0, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("stats service returned %d: %s", resp.StatusCode, string(body))
+		return &statusError{code: resp.StatusCode, body: string(body)}
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, err
+	if out == nil {
+		return nil
 	}
-
-	activeRents, ok := result["active_rents"].(float64)
-	if !ok {
-		return 0, fmt.Errorf("invalid response format")
+	if raw, ok := out.(*[]byte); ok {
+		*raw, err = io.ReadAll(resp.Body)
+		return err
 	}
-
-	return int64(activeRents), nil
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &decodeError{err: err}
+	}
+	return nil
 }
-
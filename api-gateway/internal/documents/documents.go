@@ -0,0 +1,127 @@
+// Package documents renders receipts and monthly statements as HTML pages
+// and PDF files for riders. Amounts are in minor units with two decimals,
+// e.g. kopecks; times are shown in UTC.
+package documents
+
+import (
+	"fmt"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+)
+
+var lineLabels = map[string]string{
+	"unlock":         "Unlock",
+	"riding":         "Riding, min",
+	"free_minutes":   "Free minutes of the pass",
+	"paused":         "Paused, min",
+	"pass_discount":  "Pass discount",
+	"promo_discount": "Promo code discount",
+}
+
+// document is what both renderers lay out: a title, labelled fields, a
+// table and labelled totals under it.
+type document struct {
+	Title   string
+	Fields  []field
+	Columns []string
+	Rows    [][]string
+	Totals  []field
+}
+
+type field struct {
+	Label string
+	Value string
+}
+
+func receiptDocument(r models.Receipt) document {
+	doc := document{
+		Title: "Receipt " + r.Number,
+		Fields: []field{
+			{"Issued", formatTime(r.IssuedAt)},
+			{"Rent", r.RentID},
+			{"Rider", r.UserID},
+			{"Started", formatTime(r.StartTime)},
+			{"Ended", formatTime(r.EndTime)},
+			{"Riding", formatDuration(r.RidingSeconds)},
+		},
+		Columns: []string{"Item", "Quantity", "Unit price", "Amount"},
+	}
+	if r.PausedSeconds > 0 {
+		doc.Fields = append(doc.Fields, field{"Paused", formatDuration(r.PausedSeconds)})
+	}
+	if r.Pass != "" {
+		doc.Fields = append(doc.Fields, field{"Pass", r.Pass})
+	}
+	if r.PromoCode != "" {
+		doc.Fields = append(doc.Fields, field{"Promo code", r.PromoCode})
+	}
+
+	for _, line := range r.Lines {
+		label, ok := lineLabels[line.Kind]
+		if !ok {
+			label = line.Kind
+		}
+		doc.Rows = append(doc.Rows, []string{
+			label,
+			fmt.Sprint(line.Quantity),
+			formatAmount(line.UnitPrice, r.Currency),
+			formatAmount(line.Amount, r.Currency),
+		})
+	}
+
+	doc.Totals = []field{
+		{"Total", formatAmount(r.Total, r.Currency)},
+		{fmt.Sprintf("Including VAT %d%%", r.TaxPercent), formatAmount(r.Tax, r.Currency)},
+	}
+	if r.Refunded > 0 {
+		doc.Totals = append(doc.Totals, field{"Refunded", formatAmount(r.Refunded, r.Currency)})
+	}
+	return doc
+}
+
+func statementDocument(s models.Statement) document {
+	doc := document{
+		Title: "Statement " + s.Month,
+		Fields: []field{
+			{"Rider", s.UserID},
+			{"Period", s.From.Format("2006-01-02") + " - " + s.To.AddDate(0, 0, -1).Format("2006-01-02")},
+		},
+		Columns: []string{"Invoice", "Issued", "Total", "VAT", "Refunded"},
+		Totals: []field{
+			{"Opening balance", formatAmount(s.OpeningBalance, s.Currency)},
+			{"Top-ups", formatAmount(s.TopUps, s.Currency)},
+			{"Fares", formatAmount(-s.Fares, s.Currency)},
+			{"Passes", formatAmount(-s.Passes, s.Currency)},
+			{"Refunds", formatAmount(s.Refunds, s.Currency)},
+			{"Closing balance", formatAmount(s.ClosingBalance, s.Currency)},
+			{"VAT included in fares", formatAmount(s.Tax, s.Currency)},
+		},
+	}
+	for _, invoice := range s.Invoices {
+		doc.Rows = append(doc.Rows, []string{
+			invoice.Number,
+			invoice.IssuedAt.UTC().Format("2006-01-02"),
+			formatAmount(invoice.Total, s.Currency),
+			formatAmount(invoice.Tax, s.Currency),
+			formatAmount(invoice.Refunded, s.Currency),
+		})
+	}
+	return doc
+}
+
+func formatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, currency)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func formatDuration(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
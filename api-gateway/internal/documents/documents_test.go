@@ -0,0 +1,158 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// DocumentsTestSuite - тестовый набор для HTML и PDF документов
+type DocumentsTestSuite struct {
+	suite.Suite
+	receipt models.Receipt
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *DocumentsTestSuite) SetupTest() {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	suite.receipt = models.Receipt{
+		Invoice: models.Invoice{
+			Number: "BR-2024-000042", RentID: "r1", UserID: "<u1>", IssuedAt: start.Add(40 * time.Minute),
+			Total: 5850, Tax: 975, TaxPercent: 20, Currency: "RUB",
+		},
+		StartTime:     start,
+		EndTime:       start.Add(40 * time.Minute),
+		RidingSeconds: 600,
+		PausedSeconds: 1800,
+		Pass:          "month",
+		Lines: []models.ReceiptLine{
+			{Kind: "unlock", Quantity: 1, UnitPrice: 5000, Amount: 5000},
+			{Kind: "riding", Quantity: 10, UnitPrice: 800, Amount: 8000},
+			{Kind: "pass_discount", Quantity: 1, UnitPrice: -6500, Amount: -6500},
+			{Kind: "promo_discount", Quantity: 1, UnitPrice: -650, Amount: -650},
+		},
+	}
+}
+
+// TestFormatAmount - копейки выводятся с двумя знаками и знаком минуса
+func (suite *DocumentsTestSuite) TestFormatAmount() {
+	suite.Equal("58.50 RUB", formatAmount(5850, "RUB"))
+	suite.Equal("-0.05 RUB", formatAmount(-5, "RUB"))
+	suite.Equal("0.00 RUB", formatAmount(0, "RUB"))
+}
+
+// TestReceiptHTML - строки чека подписаны, пользовательские данные экранируются
+func (suite *DocumentsTestSuite) TestReceiptHTML() {
+	var buf bytes.Buffer
+	suite.Require().NoError(ReceiptHTML(&buf, suite.receipt))
+
+	html := buf.String()
+	suite.Contains(html, "Receipt BR-2024-000042")
+	suite.Contains(html, "Pass discount")
+	suite.Contains(html, "-65.00 RUB")
+	suite.Contains(html, "Including VAT 20%")
+	suite.Contains(html, "30m0s")
+	suite.Contains(html, "&lt;u1&gt;")
+	suite.NotContains(html, "<u1>")
+}
+
+// TestReceiptPDF - PDF содержит текст чека и встроенный шрифт
+func (suite *DocumentsTestSuite) TestReceiptPDF() {
+	var buf bytes.Buffer
+	suite.Require().NoError(ReceiptPDF(&buf, suite.receipt))
+
+	pdf := buf.Bytes()
+	suite.True(bytes.HasPrefix(pdf, []byte("%PDF-")))
+	suite.True(bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	suite.Contains(string(pdf), "/FontFile2")
+	text := suite.pdfText(pdf)
+	suite.Contains(text, "Receipt BR-2024-000042")
+	suite.Contains(text, "58.50 RUB")
+}
+
+// TestStatementPDF_Pages - длинная выписка разбивается на страницы
+func (suite *DocumentsTestSuite) TestStatementPDF_Pages() {
+	statement := models.Statement{
+		UserID: "u1", Month: "2024-03", Currency: "RUB",
+		From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for i := 0; i < 120; i++ {
+		statement.Invoices = append(statement.Invoices, models.Invoice{Number: "BR-2024-" + strconv.Itoa(i), Total: 1000})
+	}
+
+	var buf bytes.Buffer
+	suite.Require().NoError(StatementPDF(&buf, statement))
+
+	suite.Contains(buf.String(), "/Count 3\n")
+	text := suite.pdfText(buf.Bytes())
+	suite.Contains(text, "2024-03-01 - 2024-03-31")
+	suite.Contains(text, "BR-2024-119")
+}
+
+// TestReceiptPDF_Cyrillic - кириллица выводится глифами шрифта и копируется как текст
+func (suite *DocumentsTestSuite) TestReceiptPDF_Cyrillic() {
+	suite.receipt.UserID = "Анна Ёлкина"
+
+	var buf bytes.Buffer
+	suite.Require().NoError(ReceiptPDF(&buf, suite.receipt))
+
+	suite.Contains(suite.pdfText(buf.Bytes()), "Анна Ёлкина")
+}
+
+var (
+	pdfStream  = regexp.MustCompile(`(?s)<<([^<>]*)>>\s*stream\r?\n(.*?)endstream`)
+	pdfBfrange = regexp.MustCompile(`<([0-9A-F]{4})><([0-9A-F]{4})><([0-9A-F]{4})>`)
+	pdfShow    = regexp.MustCompile(`\[<([0-9A-F]*)>\] TJ`)
+)
+
+// pdfText распаковывает потоки PDF и переводит показанные глифы обратно в текст по ToUnicode,
+// как это делает программа просмотра при копировании; строки разделяются переводом строки
+func (suite *DocumentsTestSuite) pdfText(pdf []byte) string {
+	var streams []string
+	for _, m := range pdfStream.FindAllSubmatch(pdf, -1) {
+		data := m[2]
+		if bytes.Contains(m[1], []byte("/FlateDecode")) {
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			suite.Require().NoError(err)
+			data, err = io.ReadAll(r)
+			suite.Require().NoError(err)
+		}
+		streams = append(streams, string(data))
+	}
+	all := strings.Join(streams, "\n")
+
+	runes := make(map[uint64]rune)
+	for _, m := range pdfBfrange.FindAllStringSubmatch(all, -1) {
+		lo, _ := strconv.ParseUint(m[1], 16, 16)
+		hi, _ := strconv.ParseUint(m[2], 16, 16)
+		base, _ := strconv.ParseUint(m[3], 16, 16)
+		for g := lo; g <= hi; g++ {
+			runes[g] = rune(base + g - lo)
+		}
+	}
+
+	var text strings.Builder
+	for _, m := range pdfShow.FindAllStringSubmatch(all, -1) {
+		for i := 0; i+4 <= len(m[1]); i += 4 {
+			g, _ := strconv.ParseUint(m[1][i:i+4], 16, 16)
+			r, ok := runes[g]
+			suite.True(ok, "glyph %04X has no character", g)
+			text.WriteRune(r)
+		}
+		text.WriteByte('\n')
+	}
+	return text.String()
+}
+
+// TestDocumentsTestSuite - запуск тестового набора
+func TestDocumentsTestSuite(t *testing.T) {
+	suite.Run(t, new(DocumentsTestSuite))
+}
@@ -0,0 +1,55 @@
+package documents
+
+import (
+	"html/template"
+	"io"
+
+	"bike-rental/api-gateway/internal/models"
+)
+
+var page = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { padding: 0.3em 1em; text-align: left; }
+thead th { border-bottom: 1px solid #888; }
+.totals th { font-weight: normal; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+{{- range .Fields}}
+<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+<table>
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+<table class="totals">
+{{- range .Totals}}
+<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// ReceiptHTML writes r as an HTML page.
+func ReceiptHTML(w io.Writer, r models.Receipt) error {
+	return page.Execute(w, receiptDocument(r))
+}
+
+// StatementHTML writes s as an HTML page.
+func StatementHTML(w io.Writer, s models.Statement) error {
+	return page.Execute(w, statementDocument(s))
+}
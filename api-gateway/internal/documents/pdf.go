@@ -0,0 +1,122 @@
+package documents
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/signintech/gopdf"
+)
+
+// DejaVu Sans covers Latin, Cyrillic and Greek, so rider names and
+// locations print as typed. gopdf embeds only the glyphs a document uses.
+// See fonts/LICENSE.
+//
+//go:embed fonts/DejaVuSans.ttf
+var dejaVuSans []byte
+
+const fontFamily = "DejaVuSans"
+
+// A4 in points
+const (
+	pageHeight = 842
+	pageWidth  = 595
+	margin     = 50
+)
+
+// ReceiptPDF writes r as a PDF file.
+func ReceiptPDF(w io.Writer, r models.Receipt) error {
+	return writePDF(w, receiptDocument(r))
+}
+
+// StatementPDF writes s as a PDF file, on as many pages as its invoices
+// take.
+func StatementPDF(w io.Writer, s models.Statement) error {
+	return writePDF(w, statementDocument(s))
+}
+
+// pdf lays out lines of text top to bottom. The first error stops the
+// layout and is returned by writePDF.
+type pdf struct {
+	doc *gopdf.GoPdf
+	y   float64
+	err error
+}
+
+func writePDF(w io.Writer, doc document) error {
+	p := &pdf{doc: &gopdf.GoPdf{}}
+	p.doc.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	if err := p.doc.AddTTFFontData(fontFamily, dejaVuSans); err != nil {
+		return fmt.Errorf("failed to load font %s: %w", fontFamily, err)
+	}
+	p.newPage()
+
+	p.text(16, 24, []float64{margin}, []string{doc.Title})
+	p.y += 8
+	for _, f := range doc.Fields {
+		p.text(10, 14, []float64{margin, margin + 110}, []string{f.Label, f.Value})
+	}
+	p.y += 12
+
+	columns := columnPositions(len(doc.Columns))
+	p.text(10, 16, columns, doc.Columns)
+	p.rule()
+	for _, row := range doc.Rows {
+		p.text(10, 14, columns, row)
+	}
+	p.y += 12
+	for _, f := range doc.Totals {
+		p.text(10, 14, []float64{margin, margin + 200}, []string{f.Label, f.Value})
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+	_, err := p.doc.WriteTo(w)
+	return err
+}
+
+// columnPositions gives the first column 40% of the width and shares the
+// rest between the others.
+func columnPositions(n int) []float64 {
+	width := float64(pageWidth - 2*margin)
+	x := []float64{margin}
+	for i := 1; i < n; i++ {
+		x = append(x, margin+width*0.4+width*0.6*float64(i-1)/float64(n-1))
+	}
+	return x
+}
+
+func (p *pdf) newPage() {
+	p.doc.AddPage()
+	p.y = margin
+}
+
+// text prints cells at x on a line height points below the previous one,
+// starting a new page when it would not fit.
+func (p *pdf) text(size, height float64, x []float64, cells []string) {
+	if p.err != nil {
+		return
+	}
+	if p.y+height > pageHeight-margin {
+		p.newPage()
+	}
+	p.y += height
+	if p.err = p.doc.SetFont(fontFamily, "", size); p.err != nil {
+		return
+	}
+	for i, cell := range cells {
+		p.doc.SetXY(x[i], p.y)
+		if p.err = p.doc.Text(cell); p.err != nil {
+			return
+		}
+	}
+}
+
+// rule underlines the previous line, e.g. the table header.
+func (p *pdf) rule() {
+	p.doc.SetLineWidth(0.5)
+	p.doc.Line(margin, p.y+4, pageWidth-margin, p.y+4)
+	p.y += 4
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+)
+
+// @Summary Query audit log
+// @Description Who changed what: state-changing rent-service calls, newest first. from/to accept RFC3339 or YYYY-MM-DD; a date in to includes the whole day.
+// @Tags admin
+// @Produce json
+// @Param actor query string false "Operator, client certificate name or address of the caller"
+// @Param action query string false "RPC name, e.g. DeleteBike"
+// @Param target_id query string false "Bike or rent ID"
+// @Param request_id query string false "X-Request-ID of the call"
+// @Param from query string false "Entries at or after this time"
+// @Param to query string false "Entries before this time"
+// @Param limit query int false "Default 100, at most 1000"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/audit [get]
+func (h *Handlers) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.rentClient.ListAuditLog(r.Context(), filter)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Actor:     q.Get("actor"),
+		Action:    q.Get("action"),
+		TargetID:  q.Get("target_id"),
+		RequestID: q.Get("request_id"),
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime("from", q.Get("from"), false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseAuditTime("to", q.Get("to"), true); err != nil {
+		return filter, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("from must be before to")
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+
+	return filter, nil
+}
+
+// parseAuditTime accepts RFC3339 or a YYYY-MM-DD date in UTC. With endOfDay
+// a date means the end of that day, so to=2024-01-15 includes the 15th.
+func parseAuditTime(name, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be RFC3339 or YYYY-MM-DD, got %q", name, value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// AuditTestSuite - тестовый набор для запроса журнала аудита
+type AuditTestSuite struct {
+	handlerSuite
+}
+
+// TestGetAuditLog_Filters - фильтры передаются в rent-service, дата в to включает весь день
+func (suite *AuditTestSuite) TestGetAuditLog_Filters() {
+	suite.rentClient.audit = []models.AuditEntry{
+		{ID: 7, Actor: "alice", Action: "DeleteBike", TargetType: "bike", TargetID: "b1", Before: json.RawMessage(`{"name":"Bike 1"}`), RequestID: "req-1"},
+	}
+
+	rec := suite.do(http.MethodGet, "/api/v1/admin/audit?actor=alice&action=DeleteBike&target_id=b1&from=2024-01-15T10:00:00Z&to=2024-01-15&limit=5", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal(models.AuditFilter{
+		Actor:    "alice",
+		Action:   "DeleteBike",
+		TargetID: "b1",
+		From:     time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+		Limit:    5,
+	}, suite.rentClient.auditFilter)
+	suite.Contains(rec.Body.String(), `"before":{"name":"Bike 1"}`)
+	suite.NotContains(rec.Body.String(), `"after"`)
+}
+
+// TestGetAuditLog_InvalidParams - некорректные параметры возвращают 400
+func (suite *AuditTestSuite) TestGetAuditLog_InvalidParams() {
+	for _, query := range []string{"?from=yesterday", "?limit=0", "?from=2024-01-16&to=2024-01-14"} {
+		suite.Equal(http.StatusBadRequest, suite.do(http.MethodGet, "/api/v1/admin/audit"+query, "").Code, query)
+	}
+}
+
+// TestGetAuditLog_RequiresOperator - без токена оператора журнал не отдаётся, X-Actor не учитывается
+func (suite *AuditTestSuite) TestGetAuditLog_RequiresOperator() {
+	for _, auth := range []string{"", "Bearer wrong-token-0123456789", "Basic YWxpY2U6c2VjcmV0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit", nil)
+		req.Header.Set("X-Actor", "alice")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		rec := suite.serve(req)
+
+		suite.Equal(http.StatusUnauthorized, rec.Code, auth)
+		suite.NotEmpty(rec.Header().Get("WWW-Authenticate"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit", nil)
+	req.Header.Set("Authorization", "Bearer "+operatorToken)
+	req.Header.Set("X-Actor", "mallory")
+
+	suite.Equal(http.StatusOK, suite.serve(req).Code)
+	suite.Equal("alice", suite.rentClient.auditActor)
+}
+
+// TestAuditTestSuite - запуск тестового набора
+func TestAuditTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditTestSuite))
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"bike-rental/api-gateway/internal/models"
+)
+
+// maxImportBytes caps an upload; rent-service also limits the row count.
+const maxImportBytes = 10 << 20
+
+// @Summary Import bikes
+// @Description Bulk add bikes from CSV (header with name, location and optional status) or JSON lines. Every row is validated and reported by line. With all_or_nothing=true nothing is imported if any row is invalid; dry_run=true only validates.
+// @Tags admin
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or json; defaults to the Content-Type"
+// @Param all_or_nothing query bool false "Import nothing if any row is invalid"
+// @Param dry_run query bool false "Validate only"
+// @Success 200 {object} models.ImportReport
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 422 {object} models.ImportReport
+// @Security OperatorToken
+// @Router /api/v1/admin/bikes/import [post]
+func (h *Handlers) ImportBikes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []models.ImportRow
+	var rowErrs []models.ImportRowError
+	var err error
+	switch format {
+	case "csv":
+		rows, rowErrs, err = parseCSVRows(body)
+	case "json":
+		rows, rowErrs, err = parseJSONRows(body)
+	default:
+		http.Error(w, "format must be csv or json", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(rows) == 0 && len(rowErrs) == 0 {
+		http.Error(w, "no rows to import", http.StatusBadRequest)
+		return
+	}
+
+	opts := models.ImportOptions{
+		AllOrNothing: q.Get("all_or_nothing") == "true",
+		DryRun:       q.Get("dry_run") == "true",
+	}
+	// Rows that failed to parse never reach rent-service; validate the rest
+	// without writing so the report is still complete.
+	send := opts
+	if opts.AllOrNothing && len(rowErrs) > 0 {
+		send.DryRun = true
+	}
+
+	report, err := h.rentClient.ImportBikes(r.Context(), rows, send)
+	if err != nil {
+		log.Printf("API Gateway: Error calling rent service: %v", err)
+		writeClientError(w, err)
+		return
+	}
+
+	report.Total += len(rowErrs)
+	report.DryRun = opts.DryRun
+	report.Errors = append(report.Errors, rowErrs...)
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+
+	log.Printf("API Gateway: ImportBikes: total=%d, imported=%d, errors=%d", report.Total, report.Imported, len(report.Errors))
+
+	w.Header().Set("Content-Type", "application/json")
+	if opts.AllOrNothing && len(report.Errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary Export bikes
+// @Description Stream the whole fleet with status and location as CSV (default) or JSON lines
+// @Tags admin
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param location query string false "Only bikes at this location"
+// @Param format query string false "csv (default) or json"
+// @Success 200 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/bikes/export [get]
+func (h *Handlers) ExportBikes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var write func(models.Bike) error
+	var contentType, filename string
+	switch q.Get("format") {
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		header := false
+		contentType, filename = "text/csv", "bikes.csv"
+		write = func(b models.Bike) error {
+			if !header {
+				header = true
+				cw.Write([]string{"id", "name", "status", "location"})
+			}
+			cw.Write([]string{b.ID, b.Name, b.Status, b.Location})
+			cw.Flush()
+			return cw.Error()
+		}
+	case "json":
+		enc := json.NewEncoder(w)
+		contentType, filename = "application/x-ndjson", "bikes.jsonl"
+		write = func(b models.Bike) error {
+			return enc.Encode(b)
+		}
+	default:
+		http.Error(w, "format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	// Headers are sent with the first bike so an immediate backend error
+	// can still be reported with a proper status code.
+	started := false
+	err := h.rentClient.ExportBikes(r.Context(), q.Get("location"), func(b models.Bike) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		}
+		return write(b)
+	})
+	if err != nil {
+		log.Printf("API Gateway: ExportBikes failed: %v", err)
+		if !started {
+			writeClientError(w, err)
+		}
+		return
+	}
+	if !started {
+		// Empty fleet: still send a valid, empty document
+		w.Header().Set("Content-Type", contentType)
+		if contentType == "text/csv" {
+			fmt.Fprintln(w, "id,name,status,location")
+		}
+	}
+}
+
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return "json"
+	}
+	return "csv"
+}
+
+// parseCSVRows reads a CSV upload whose header names the name, location
+// and optional status columns in any order. Malformed rows are reported
+// by line instead of failing the upload.
+func parseCSVRows(body io.Reader) ([]models.ImportRow, []models.ImportRowError, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := map[string]int{"name": -1, "location": -1, "status": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; ok {
+			columns[name] = i
+		}
+	}
+	if columns["name"] < 0 || columns["location"] < 0 {
+		return nil, nil, errors.New("CSV header must contain name and location columns")
+	}
+
+	field := func(record []string, column string) string {
+		i := columns[column]
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var rows []models.ImportRow
+	var rowErrs []models.ImportRowError
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrs = append(rowErrs, models.ImportRowError{Line: parseErr.Line, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, models.ImportRow{
+			Line:     line,
+			Name:     field(record, "name"),
+			Location: field(record, "location"),
+			Status:   field(record, "status"),
+		})
+	}
+
+	return rows, rowErrs, nil
+}
+
+// parseJSONRows reads one JSON object per line; blank lines are skipped.
+func parseJSONRows(body io.Reader) ([]models.ImportRow, []models.ImportRowError, error) {
+	scanner := bufio.NewScanner(body)
+
+	var rows []models.ImportRow
+	var rowErrs []models.ImportRowError
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var row models.ImportRow
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			rowErrs = append(rowErrs, models.ImportRowError{Line: line, Error: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	return rows, rowErrs, nil
+}
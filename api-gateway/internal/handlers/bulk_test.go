@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// BulkTestSuite - тестовый набор для массового импорта и экспорта
+type BulkTestSuite struct {
+	handlerSuite
+}
+
+func (suite *BulkTestSuite) importBikes(query, contentType, body string) (*httptest.ResponseRecorder, models.ImportReport) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/bikes/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+operatorToken)
+
+	rec := suite.serve(req)
+
+	var report models.ImportReport
+	if rec.Code == http.StatusOK || rec.Code == http.StatusUnprocessableEntity {
+		suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &report))
+	}
+	return rec, report
+}
+
+// TestParseCSVRows - колонки находятся по заголовку, номера строк соответствуют файлу
+func (suite *BulkTestSuite) TestParseCSVRows() {
+	rows, rowErrs, err := parseCSVRows(strings.NewReader("location,Name\nPark,Bike 1\n\nCenter,\"Bike 2\"\nPark,\"broken\n"))
+
+	suite.NoError(err)
+	suite.Equal([]models.ImportRow{
+		{Line: 2, Name: "Bike 1", Location: "Park"},
+		{Line: 4, Name: "Bike 2", Location: "Center"},
+	}, rows)
+	suite.Len(rowErrs, 1)
+	suite.Equal(5, rowErrs[0].Line)
+}
+
+// TestParseCSVRows_MissingColumns - без колонок name и location загрузка отклоняется
+func (suite *BulkTestSuite) TestParseCSVRows_MissingColumns() {
+	_, _, err := parseCSVRows(strings.NewReader("title,place\nBike 1,Park\n"))
+
+	suite.Error(err)
+}
+
+// TestParseJSONRows - каждая строка разбирается отдельно
+func (suite *BulkTestSuite) TestParseJSONRows() {
+	rows, rowErrs, err := parseJSONRows(strings.NewReader(`{"name":"Bike 1","location":"Park"}
+
+{"name":
+{"name":"Bike 2","location":"Center","status":"maintenance"}
+`))
+
+	suite.NoError(err)
+	suite.Equal([]models.ImportRow{
+		{Line: 1, Name: "Bike 1", Location: "Park"},
+		{Line: 4, Name: "Bike 2", Location: "Center", Status: "maintenance"},
+	}, rows)
+	suite.Len(rowErrs, 1)
+	suite.Equal(3, rowErrs[0].Line)
+}
+
+// TestImportBikes_MergesParseErrors - ошибки разбора попадают в общий отчёт
+func (suite *BulkTestSuite) TestImportBikes_MergesParseErrors() {
+	rec, report := suite.importBikes("", "application/x-ndjson", "not json\n{\"name\":\"Bike 1\",\"location\":\"Park\"}\n")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal(2, report.Total)
+	suite.Equal(1, report.Imported)
+	suite.Len(report.Errors, 1)
+	suite.Equal(1, report.Errors[0].Line)
+}
+
+// TestImportBikes_AllOrNothingWithParseErrors - при ошибке разбора строки только проверяются
+func (suite *BulkTestSuite) TestImportBikes_AllOrNothingWithParseErrors() {
+	rec, report := suite.importBikes("?all_or_nothing=true", "text/csv", "name,location\nBike 1,Park\nBike 2,\"Park\n")
+
+	suite.Equal(http.StatusUnprocessableEntity, rec.Code)
+	suite.True(suite.rentClient.opts.DryRun)
+	suite.False(report.DryRun)
+	suite.Equal(0, report.Imported)
+}
+
+// TestExportBikes_CSV - экспорт отдаёт весь парк в CSV
+func (suite *BulkTestSuite) TestExportBikes_CSV() {
+	suite.rentClient.bikes = []models.Bike{{ID: "id-1", Name: "Bike 1", Status: "rented", Location: "Park"}}
+
+	rec := suite.do(http.MethodGet, "/api/v1/admin/bikes/export", "")
+
+	suite.Equal("text/csv", rec.Header().Get("Content-Type"))
+	suite.Equal("id,name,status,location\nid-1,Bike 1,rented,Park\n", rec.Body.String())
+}
+
+// TestImportExport_RequireOperator - без токена оператора парк не импортируется и не выгружается
+func (suite *BulkTestSuite) TestImportExport_RequireOperator() {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/bikes/import", strings.NewReader("name,location\nBike 1,Park\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	suite.Equal(http.StatusUnauthorized, suite.serve(req).Code)
+	suite.Nil(suite.rentClient.rows)
+
+	rec := suite.serve(httptest.NewRequest(http.MethodGet, "/api/v1/admin/bikes/export", nil))
+	suite.Equal(http.StatusUnauthorized, rec.Code)
+}
+
+func TestBulkTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkTestSuite))
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"bike-rental/api-gateway/internal/client"
+	"bike-rental/api-gateway/internal/middleware"
+	"bike-rental/api-gateway/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRentClient - подменяет rent-service в тестах обработчиков: запоминает аргументы вызовов
+// и отдаёт заготовленные ответы. Методы, которые тест не вызывает, берутся из встроенного
+// интерфейса и паникуют.
+type fakeRentClient struct {
+	client.RentClient
+	rows           []models.ImportRow
+	opts           models.ImportOptions
+	bikes          []models.Bike
+	auditFilter    models.AuditFilter
+	auditActor     string
+	audit          []models.AuditEntry
+	timelineRentID string
+	timeline       *models.RentTimeline
+	paused         []string
+	resumed        []string
+	walletLimit    int
+	topUpKey       string
+	boughtPass     string
+	promo          models.PromoCode
+	promoFrom      time.Time
+	statementMonth string
+	user           models.User
+	userStatus     string
+	usersLimit     int
+	truckCapacity  int
+	trucks         int
+	horizon        time.Duration
+	moves          []models.BikeMove
+}
+
+// operatorToken - токен оператора alice, которым подписываются запросы через do
+const operatorToken = "alice-test-token-0123456789"
+
+// handlerSuite - общая основа тестовых наборов: обработчики с fakeRentClient за маршрутизатором
+type handlerSuite struct {
+	suite.Suite
+	rentClient *fakeRentClient
+	router     *chi.Mux
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *handlerSuite) SetupTest() {
+	suite.rentClient = &fakeRentClient{}
+	suite.router = chi.NewRouter()
+	operators := middleware.NewOperators(map[string]string{"alice": operatorToken})
+	(&Handlers{rentClient: suite.rentClient, operators: operators}).RegisterRoutes(suite.router)
+}
+
+// do - выполняет запрос оператора alice через маршрутизатор
+func (suite *handlerSuite) do(method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+operatorToken)
+	return suite.serve(req)
+}
+
+// serve - выполняет подготовленный запрос через маршрутизатор
+func (suite *handlerSuite) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (f *fakeRentClient) ImportBikes(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions) (*models.ImportReport, error) {
+	f.rows = rows
+	f.opts = opts
+	report := &models.ImportReport{Total: len(rows), DryRun: opts.DryRun}
+	if !opts.DryRun {
+		report.Imported = len(rows)
+	}
+	return report, nil
+}
+
+func (f *fakeRentClient) ExportBikes(ctx context.Context, location string, fn func(models.Bike) error) error {
+	for _, b := range f.bikes {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeRentClient) ListAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	f.auditFilter = filter
+	f.auditActor = middleware.RequestInfoFromContext(ctx).Actor
+	return f.audit, nil
+}
+
+func (f *fakeRentClient) GetRentTimeline(ctx context.Context, rentID string) (*models.RentTimeline, error) {
+	f.timelineRentID = rentID
+	if f.timeline == nil {
+		return nil, status.Error(codes.NotFound, "rent not found")
+	}
+	return f.timeline, nil
+}
+
+func (f *fakeRentClient) PauseRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error) {
+	f.paused = append(f.paused, rentID+"/"+userID)
+	return &models.RentResponse{RentID: rentID, UserID: userID, Status: "paused", PausedAt: 1700000600}, nil
+}
+
+func (f *fakeRentClient) ResumeRent(ctx context.Context, rentID, userID string) (*models.RentResponse, error) {
+	f.resumed = append(f.resumed, rentID+"/"+userID)
+	return &models.RentResponse{RentID: rentID, UserID: userID, Status: "active", PausedSeconds: 300}, nil
+}
+
+func (f *fakeRentClient) GetWallet(ctx context.Context, userID string, limit int) (*models.Wallet, error) {
+	f.walletLimit = limit
+	return &models.Wallet{UserID: userID, Balance: 50000, Held: 30000, Available: 20000, Currency: "RUB"}, nil
+}
+
+func (f *fakeRentClient) TopUpWallet(ctx context.Context, userID string, amount int64, idempotencyKey string) (*models.Wallet, error) {
+	if amount > 1000000 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be between 1 and 1000000")
+	}
+	f.topUpKey = idempotencyKey
+	return &models.Wallet{UserID: userID, Balance: amount, Available: amount, Currency: "RUB"}, nil
+}
+
+func (f *fakeRentClient) ListPasses(ctx context.Context) (*models.PassList, error) {
+	return &models.PassList{Passes: []models.Pass{
+		{Code: "day", Name: "Day pass", Price: 29900, Currency: "RUB", DurationSeconds: 86400, FreeMinutes: 30},
+	}}, nil
+}
+
+func (f *fakeRentClient) BuyPass(ctx context.Context, userID, pass string) (*models.Subscription, error) {
+	if pass != "day" {
+		return nil, status.Error(codes.NotFound, "pass not found")
+	}
+	f.boughtPass = pass
+	return &models.Subscription{ID: "s1", UserID: userID, Pass: pass, Price: 29900, Currency: "RUB"}, nil
+}
+
+func (f *fakeRentClient) CancelPass(ctx context.Context, userID, subscriptionID string) (*models.Subscription, error) {
+	return nil, status.Error(codes.FailedPrecondition, "pass has already ended")
+}
+
+func (f *fakeRentClient) CreatePromoCode(ctx context.Context, promo models.PromoCode) (*models.PromoCode, error) {
+	if promo.Code == "TAKEN" {
+		return nil, status.Error(codes.AlreadyExists, "promo code already exists")
+	}
+	f.promo = promo
+	return &promo, nil
+}
+
+func (f *fakeRentClient) UpdatePromoCode(ctx context.Context, promo models.PromoCode) (*models.PromoCode, error) {
+	f.promo = promo
+	return &promo, nil
+}
+
+func (f *fakeRentClient) DeletePromoCode(ctx context.Context, code string) error {
+	return status.Error(codes.FailedPrecondition, "promo code has been redeemed")
+}
+
+func (f *fakeRentClient) GetPromoStats(ctx context.Context, from, to time.Time) (*models.PromoStats, error) {
+	f.promoFrom = from
+	return &models.PromoStats{From: from, To: to, PromoCodes: []models.PromoUsage{{Code: "SUMMER10", Redemptions: 3}}}, nil
+}
+
+func (f *fakeRentClient) GetReceipt(ctx context.Context, rentID string) (*models.Receipt, error) {
+	if rentID == "active" {
+		return nil, status.Error(codes.FailedPrecondition, "rent has not been charged")
+	}
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &models.Receipt{
+		Invoice: models.Invoice{
+			Number: "BR-2024-000042", RentID: rentID, UserID: "u1", IssuedAt: start.Add(10 * time.Minute),
+			Total: 13000, Tax: 2167, TaxPercent: 20, Currency: "RUB",
+		},
+		StartTime:     start,
+		EndTime:       start.Add(10 * time.Minute),
+		RidingSeconds: 600,
+		Lines: []models.ReceiptLine{
+			{Kind: "unlock", Quantity: 1, UnitPrice: 5000, Amount: 5000},
+			{Kind: "riding", Quantity: 10, UnitPrice: 800, Amount: 8000},
+		},
+	}, nil
+}
+
+func (f *fakeRentClient) GetStatement(ctx context.Context, userID, month string) (*models.Statement, error) {
+	f.statementMonth = month
+	return &models.Statement{
+		UserID: userID, Month: "2024-03", Currency: "RUB",
+		From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		TopUps: 50000, Fares: 13000, ClosingBalance: 37000,
+		Invoices: []models.Invoice{{Number: "BR-2024-000042", Total: 13000, Tax: 2167}},
+	}, nil
+}
+
+func (f *fakeRentClient) RegisterUser(ctx context.Context, user models.User) (*models.User, error) {
+	if user.Email == "taken@example.com" {
+		return nil, status.Error(codes.AlreadyExists, "user already exists")
+	}
+	f.user = user
+	user.Status = "pending_verification"
+	return &user, nil
+}
+
+func (f *fakeRentClient) UpdateUser(ctx context.Context, user models.User) (*models.User, error) {
+	f.user = user
+	return &user, nil
+}
+
+func (f *fakeRentClient) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	return nil, status.Error(codes.NotFound, "user not found")
+}
+
+func (f *fakeRentClient) SetUserStatus(ctx context.Context, userID, userStatus, reason string) (*models.User, error) {
+	if userStatus == "blocked" && reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "a reason is required to block a user")
+	}
+	f.userStatus = userStatus
+	return &models.User{ID: userID, Status: userStatus, StatusReason: reason}, nil
+}
+
+func (f *fakeRentClient) ListUsers(ctx context.Context, userStatus string, limit int) (*models.UserList, error) {
+	f.userStatus = userStatus
+	f.usersLimit = limit
+	return &models.UserList{Users: []models.User{{ID: "u1", Status: userStatus}}}, nil
+}
+
+func (f *fakeRentClient) PlanRebalance(ctx context.Context, truckCapacity, trucks int, horizon time.Duration) (*models.RebalancePlan, error) {
+	if horizon > 24*time.Hour {
+		return nil, status.Error(codes.InvalidArgument, "horizon must be at most 24h")
+	}
+	f.truckCapacity, f.trucks, f.horizon = truckCapacity, trucks, horizon
+	return &models.RebalancePlan{
+		Horizon: "3h0m0s",
+		Moves:   []models.BikeMove{{From: "Park", To: "Center", Count: 5}},
+	}, nil
+}
+
+func (f *fakeRentClient) RelocateBikes(ctx context.Context, moves []models.BikeMove) (*models.RelocationReport, error) {
+	f.moves = moves
+	report := &models.RelocationReport{}
+	for _, move := range moves {
+		report.Relocations = append(report.Relocations, models.BikeRelocation{BikeMove: move, Moved: move.Count})
+		report.Moved += move.Count
+	}
+	return report, nil
+}
@@ -1,24 +1,49 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"bike-rental/api-gateway/internal/client"
+	"bike-rental/api-gateway/internal/middleware"
+	"bike-rental/api-gateway/internal/stream"
+	"bike-rental/health"
+	"bike-rental/logging"
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Handlers struct {
 	rentClient client.RentClient
 	statsClient client.StatsClient
+	checker     *health.Checker
+	fleet       *stream.Hub
+	heartbeat   time.Duration
+	operators   *middleware.Operators
 }
 
-func NewHandlers(rentClient client.RentClient, statsClient client.StatsClient) *Handlers {
+// NewHandlers builds the API handlers. Admin endpoints accept only the
+// given operators; nil operators close them.
+func NewHandlers(rentClient client.RentClient, statsClient client.StatsClient, fleet *stream.Hub, heartbeat time.Duration, operators *middleware.Operators) *Handlers {
+	// Rentals depend on rent-service only; stats outages degrade but do not
+	// take the gateway out of rotation.
+	checker := health.NewChecker(2 * time.Second)
+	checker.Register("rent-service", rentClient.Health)
+	checker.RegisterOptional("stats-service", statsClient.Health)
+
 	return &Handlers{
 		rentClient:  rentClient,
 		statsClient: statsClient,
+		checker:     checker,
+		fleet:       fleet,
+		heartbeat:   heartbeat,
+		operators:   operators,
 	}
 }
 
@@ -38,12 +63,12 @@ func (h *Handlers) StartRent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log request
-	log.Printf("API Gateway: Received StartRent request: user_id=%s, bike_id=%s", req.UserID, req.BikeID)
+	logging.Debugf("API Gateway: Received StartRent request: user_id=%s, bike_id=%s", req.UserID, req.BikeID)
 
-	response, err := h.rentClient.StartRent(r.Context(), req.UserID, req.BikeID)
+	response, err := h.rentClient.StartRent(r.Context(), req.UserID, req.BikeID, req.PromoCode)
 	if err != nil {
 		log.Printf("API Gateway: Error calling rent service: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
@@ -68,9 +93,9 @@ func (h *Handlers) EndRent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.rentClient.EndRent(r.Context(), req.RentID, req.UserID)
+	response, err := h.rentClient.EndRent(r.Context(), req.RentID, req.UserID, req.PromoCode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
@@ -90,7 +115,7 @@ func (h *Handlers) GetAvailableBikes(w http.ResponseWriter, r *http.Request) {
 
 	bikes, err := h.rentClient.GetAvailableBikes(r.Context(), location)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
@@ -104,16 +129,16 @@ func (h *Handlers) GetAvailableBikes(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param date path string true "Date in YYYY-MM-DD format"
 // @Success 200 {object} DailyStatsResponse
+// @Failure 400 {string} string
 // @Router /api/v1/stats/daily/{date} [get]
 func (h *Handlers) GetDailyStats(w http.ResponseWriter, r *http.Request) {
+	// stats-service validates the date and defaults to today in its
+	// configured time zone.
 	date := chi.URLParam(r, "date")
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
-	}
 
 	stats, err := h.statsClient.GetDailyStats(r.Context(), date)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
@@ -122,35 +147,213 @@ func (h *Handlers) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get active rents count
-// @Description Get current number of active rents
+// @Description Get current number of active rents and how many of them are paused
 // @Tags stats
 // @Produce json
 // @Success 200 {object} ActiveRentsResponse
 // @Router /api/v1/stats/active [get]
 func (h *Handlers) GetActiveRents(w http.ResponseWriter, r *http.Request) {
-	count, err := h.statsClient.GetActiveRents(r.Context())
+	response, err := h.statsClient.GetActiveRents(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
-	response := map[string]interface{}{
-		"active_rents": count,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Get rents for a date range
+// @Description Daily series or weekly/monthly rollups of started rents. Defaults to the last 7 days.
+// @Tags stats
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param granularity query string false "day, week or month (default day)"
+// @Success 200 {object} models.RangeStats
+// @Failure 400 {string} string
+// @Router /api/v1/stats/range [get]
+func (h *Handlers) GetRangeStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.statsClient.GetRangeStats(r.Context(), q.Get("from"), q.Get("to"), q.Get("granularity"))
+	if err != nil {
+		writeClientError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary Get hourly rents
+// @Description Rents started in each hour of a day
+// @Tags stats
+// @Produce json
+// @Param date query string false "Date in YYYY-MM-DD format (default today)"
+// @Success 200 {object} models.HourlyStats
+// @Failure 400 {string} string
+// @Router /api/v1/stats/hourly [get]
+func (h *Handlers) GetHourlyStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.statsClient.GetHourlyStats(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
 }
 
-// @Summary Health check
-// @Description Health check endpoint
+// @Summary Get rents per location
+// @Description Rents started at each location over a date range, busiest first
+// @Tags stats
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Success 200 {object} models.LocationBreakdown
+// @Failure 400 {string} string
+// @Router /api/v1/stats/locations [get]
+func (h *Handlers) GetLocationBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.statsClient.GetLocationBreakdown(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary Get rent durations
+// @Description Average, median and p95 duration of rents started in a date range, per day and per location
+// @Tags stats
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param location query string false "Limit to one location"
+// @Success 200 {object} models.DurationStats
+// @Failure 400 {string} string
+// @Router /api/v1/stats/durations [get]
+func (h *Handlers) GetDurationStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.statsClient.GetDurationStats(r.Context(), q.Get("from"), q.Get("to"), q.Get("location"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary Get fleet utilization
+// @Description Rented minutes divided by available minutes per bike
+// @Tags stats
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Success 200 {object} models.UtilizationStats
+// @Failure 400 {string} string
+// @Router /api/v1/stats/utilization [get]
+func (h *Handlers) GetUtilization(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.statsClient.GetUtilization(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary Get top bikes and locations
+// @Description Most used bikes by rented minutes and busiest locations by rents
+// @Tags stats
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param limit query int false "Entries per list, 1-100 (default 10)"
+// @Success 200 {object} models.TopStats
+// @Failure 400 {string} string
+// @Router /api/v1/stats/top [get]
+func (h *Handlers) GetTopStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.statsClient.GetTopStats(r.Context(), q.Get("from"), q.Get("to"), q.Get("limit"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary Get peak-hour heatmap
+// @Description Rent starts, ends and net flow per location by weekday and hour, in the stats time zone. Defaults to the last 28 days.
+// @Tags stats
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param location query string false "Limit to one location"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} models.Heatmap
+// @Failure 400 {string} string
+// @Router /api/v1/stats/heatmap [get]
+func (h *Handlers) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+
+	switch format {
+	case "", "json":
+		heatmap, err := h.statsClient.GetHeatmap(r.Context(), q.Get("from"), q.Get("to"), q.Get("location"))
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(heatmap)
+	case "csv":
+		body, err := h.statsClient.GetHeatmapCSV(r.Context(), q.Get("from"), q.Get("to"), q.Get("location"))
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="heatmap.csv"`)
+		w.Write(body)
+	default:
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+	}
+}
+
+// @Summary Liveness check
+// @Description Reports that the gateway process is running
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Router /health/live [get]
+func (h *Handlers) Live(w http.ResponseWriter, r *http.Request) {
+	h.checker.Live(w, r)
+}
+
+// @Summary Readiness check
+// @Description Probes rent-service and stats-service
 // @Tags health
 // @Produce json
-// @Success 200 {object} map[string]string
-// @Router /health [get]
-func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /health/ready [get]
+func (h *Handlers) Ready(w http.ResponseWriter, r *http.Request) {
+	h.checker.Ready(w, r)
 }
 
 // @Summary Add a new bike
@@ -169,12 +372,12 @@ func (h *Handlers) AddBike(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log request
-	log.Printf("API Gateway: Received AddBike request: name=%s, location=%s", req.Name, req.Location)
+	logging.Debugf("API Gateway: Received AddBike request: name=%s, location=%s", req.Name, req.Location)
 
 	response, err := h.rentClient.AddBike(r.Context(), req.Name, req.Location)
 	if err != nil {
 		log.Printf("API Gateway: Error calling rent service: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
@@ -199,12 +402,12 @@ func (h *Handlers) DeleteBike(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log request
-	log.Printf("API Gateway: Received DeleteBike request: bike_id=%s", bikeID)
+	logging.Debugf("API Gateway: Received DeleteBike request: bike_id=%s", bikeID)
 
 	response, err := h.rentClient.DeleteBike(r.Context(), bikeID)
 	if err != nil {
 		log.Printf("API Gateway: Error calling rent service: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeClientError(w, err)
 		return
 	}
 
@@ -222,36 +425,162 @@ func (h *Handlers) DeleteBike(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// writeClientError maps backend failures to a status code: 503 when the
+// backend is unavailable or its circuit is open, 504 on deadline, else 500.
+// Client errors reported by stats-service are passed through, invalid
+// gRPC arguments become 400, missing targets 404 and failed
+// preconditions, e.g. an insufficient balance, and existing targets 409.
+func writeClientError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case client.ClientErrorStatus(err) != 0:
+		code = client.ClientErrorStatus(err)
+	case errors.Is(err, client.ErrCircuitOpen):
+		w.Header().Set("Retry-After", "5")
+		code = http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded), status.Code(err) == codes.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+	case status.Code(err) == codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	case status.Code(err) == codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case status.Code(err) == codes.NotFound:
+		code = http.StatusNotFound
+	case status.Code(err) == codes.FailedPrecondition, status.Code(err) == codes.AlreadyExists:
+		code = http.StatusConflict
+	}
+	http.Error(w, err.Error(), code)
+}
+
 func (h *Handlers) RegisterRoutes(r *chi.Mux) {
 	r.Post("/api/v1/rent/start", h.StartRent)
 	r.Post("/api/v1/rent/end", h.EndRent)
+	r.Post("/api/v1/rent/pause", h.PauseRent)
+	r.Post("/api/v1/rent/resume", h.ResumeRent)
+	r.Get("/api/v1/wallet/{user_id}", h.GetWallet)
+	r.Get("/api/v1/wallet/{user_id}/statement", h.GetStatement)
+	r.Post("/api/v1/wallet/topup", h.TopUpWallet)
+	r.Get("/api/v1/passes", h.ListPasses)
+	r.Post("/api/v1/passes/buy", h.BuyPass)
+	r.Post("/api/v1/passes/cancel", h.CancelPass)
 	r.Get("/api/v1/bikes/available", h.GetAvailableBikes)
 	r.Post("/api/v1/bikes/add", h.AddBike)
 	r.Delete("/api/v1/bikes/{bike_id}", h.DeleteBike)
+	r.Get("/api/v1/fleet/stream", h.StreamFleet)
+	r.Get("/api/v1/rents/{rent_id}/timeline", h.GetRentTimeline)
+	r.Get("/api/v1/rents/{rent_id}/receipt", h.GetReceipt)
+	r.Post("/api/v1/users", h.RegisterUser)
+	r.Get("/api/v1/users/{user_id}", h.GetUser)
+	r.Put("/api/v1/users/{user_id}", h.UpdateUser)
+	r.Route("/api/v1/admin", func(r chi.Router) {
+		r.Use(h.operators.Require)
+		r.Get("/audit", h.GetAuditLog)
+		r.Get("/users", h.ListUsers)
+		r.Get("/rebalance", h.PlanRebalance)
+		r.Post("/bikes/relocate", h.RelocateBikes)
+		r.Post("/bikes/import", h.ImportBikes)
+		r.Get("/bikes/export", h.ExportBikes)
+		r.Put("/users/{user_id}/status", h.SetUserStatus)
+		r.Get("/promos", h.ListPromoCodes)
+		r.Post("/promos", h.CreatePromoCode)
+		r.Get("/promos/stats", h.GetPromoStats)
+		r.Put("/promos/{code}", h.UpdatePromoCode)
+		r.Delete("/promos/{code}", h.DeletePromoCode)
+	})
 	r.Get("/api/v1/stats/daily/{date}", h.GetDailyStats)
 	r.Get("/api/v1/stats/active", h.GetActiveRents)
-	r.Get("/health", h.Health)
+	r.Get("/api/v1/stats/range", h.GetRangeStats)
+	r.Get("/api/v1/stats/hourly", h.GetHourlyStats)
+	r.Get("/api/v1/stats/locations", h.GetLocationBreakdown)
+	r.Get("/api/v1/stats/durations", h.GetDurationStats)
+	r.Get("/api/v1/stats/utilization", h.GetUtilization)
+	r.Get("/api/v1/stats/top", h.GetTopStats)
+	r.Get("/api/v1/stats/heatmap", h.GetHeatmap)
+	r.Get("/api/v1/stats/passes", h.GetPassStats)
+	r.Get("/health", h.Ready)
+	r.Get("/health/live", h.Live)
+	r.Get("/health/ready", h.Ready)
 }
 
 // Request/Response types
 type StartRentRequest struct {
-	UserID string `json:"user_id"`
-	BikeID string `json:"bike_id"`
+	UserID    string `json:"user_id"`
+	BikeID    string `json:"bike_id"`
+	PromoCode string `json:"promo_code,omitempty"`
 }
 
 type EndRentRequest struct {
+	RentID    string `json:"rent_id"`
+	UserID    string `json:"user_id"`
+	PromoCode string `json:"promo_code,omitempty"`
+}
+
+type PauseRentRequest struct {
 	RentID string `json:"rent_id"`
 	UserID string `json:"user_id"`
 }
 
 type RentResponse struct {
-	RentID    string `json:"rent_id"`
-	UserID    string `json:"user_id"`
-	BikeID    string `json:"bike_id"`
-	Status    string `json:"status"`
-	Message   string `json:"message"`
-	StartTime int64  `json:"start_time"`
-	EndTime   int64  `json:"end_time"`
+	RentID        string `json:"rent_id"`
+	UserID        string `json:"user_id"`
+	BikeID        string `json:"bike_id"`
+	Status        string `json:"status"`
+	Message       string `json:"message"`
+	StartTime     int64  `json:"start_time"`
+	EndTime       int64  `json:"end_time"`
+	PausedSeconds int64  `json:"paused_seconds"`
+	PausedAt      int64  `json:"paused_at,omitempty"`
+	Fare          int64  `json:"fare,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+	Pass          string `json:"pass,omitempty"`
+	PromoCode     string `json:"promo_code,omitempty"`
+	Discount      int64  `json:"discount,omitempty"`
+	InvoiceNumber string `json:"invoice_number,omitempty"`
+}
+
+type TopUpWalletRequest struct {
+	UserID         string `json:"user_id"`
+	Amount         int64  `json:"amount"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+type BuyPassRequest struct {
+	UserID string `json:"user_id"`
+	Pass   string `json:"pass"`
+}
+
+type CancelPassRequest struct {
+	UserID         string `json:"user_id"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+// PromoCodeRequest creates or replaces a promo code; Active defaults to
+// true.
+type PromoCodeRequest struct {
+	Code           string     `json:"code"`
+	Kind           string     `json:"kind"`
+	Value          int64      `json:"value"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	Locations      []string   `json:"locations,omitempty"`
+	MaxRedemptions int64      `json:"max_redemptions,omitempty"`
+	MaxPerUser     int64      `json:"max_per_user,omitempty"`
+	Active         *bool      `json:"active,omitempty"`
+}
+
+// UserRequest registers or updates a rider; user_id is generated on
+// registration when empty and taken from the path on update.
+type UserRequest struct {
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone,omitempty"`
+}
+
+// UserStatusRequest activates or blocks a rider; blocking needs a reason.
+type UserStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
 }
 
 type BikesListResponse struct {
@@ -266,12 +595,14 @@ type Bike struct {
 }
 
 type DailyStatsResponse struct {
-	Date  string `json:"date"`
-	Count int64  `json:"count"`
+	Date          string  `json:"date"`
+	Count         int64   `json:"count"`
+	PausedMinutes float64 `json:"paused_minutes"`
 }
 
 type ActiveRentsResponse struct {
 	ActiveRents int64 `json:"active_rents"`
+	PausedRents int64 `json:"paused_rents"`
 }
 
 type AddBikeRequest struct {
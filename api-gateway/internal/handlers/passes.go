@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// @Summary List passes
+// @Description Passes riders can buy, cheapest first. Prices are in minor currency units.
+// @Tags passes
+// @Produce json
+// @Success 200 {object} models.PassList
+// @Router /api/v1/passes [get]
+func (h *Handlers) ListPasses(w http.ResponseWriter, r *http.Request) {
+	passes, err := h.rentClient.ListPasses(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(passes)
+}
+
+// @Summary Buy a pass
+// @Description Pay a pass from the rider's wallet. It starts at once; rents started while it lasts get its free minutes and discount.
+// @Tags passes
+// @Accept json
+// @Produce json
+// @Param request body BuyPassRequest true "Purchase request"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Router /api/v1/passes/buy [post]
+func (h *Handlers) BuyPass(w http.ResponseWriter, r *http.Request) {
+	var req BuyPassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.rentClient.BuyPass(r.Context(), req.UserID, req.Pass)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Cancel a pass
+// @Description End the rider's pass now. The price is refunded to the wallet only if no rent was started with the pass.
+// @Tags passes
+// @Accept json
+// @Produce json
+// @Param request body CancelPassRequest true "Cancellation request"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Router /api/v1/passes/cancel [post]
+func (h *Handlers) CancelPass(w http.ResponseWriter, r *http.Request) {
+	var req CancelPassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.rentClient.CancelPass(r.Context(), req.UserID, req.SubscriptionID)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Get pass usage
+// @Description Share of rents started with a pass and rents and minutes per pass over a date range
+// @Tags stats
+// @Produce json
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Success 200 {object} models.PassStats
+// @Failure 400 {string} string
+// @Router /api/v1/stats/passes [get]
+func (h *Handlers) GetPassStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.statsClient.GetPassStats(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// PassesTestSuite - тестовый набор для абонементов
+type PassesTestSuite struct {
+	handlerSuite
+}
+
+// TestListPasses - каталог абонементов
+func (suite *PassesTestSuite) TestListPasses() {
+	rec := suite.do(http.MethodGet, "/api/v1/passes", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"code":"day"`)
+	suite.Contains(rec.Body.String(), `"free_minutes":30`)
+}
+
+// TestBuyPass - покупка передаёт код абонемента в rent-service
+func (suite *PassesTestSuite) TestBuyPass() {
+	rec := suite.do(http.MethodPost, "/api/v1/passes/buy", `{"user_id":"u1","pass":"day"}`)
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"id":"s1"`)
+	suite.Equal("day", suite.rentClient.boughtPass)
+}
+
+// TestBuyPass_NotFound - неизвестный абонемент возвращает 404
+func (suite *PassesTestSuite) TestBuyPass_NotFound() {
+	rec := suite.do(http.MethodPost, "/api/v1/passes/buy", `{"user_id":"u1","pass":"year"}`)
+
+	suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+// TestCancelPass_Ended - отмена закончившегося абонемента возвращает 409
+func (suite *PassesTestSuite) TestCancelPass_Ended() {
+	rec := suite.do(http.MethodPost, "/api/v1/passes/cancel", `{"user_id":"u1","subscription_id":"s1"}`)
+
+	suite.Equal(http.StatusConflict, rec.Code)
+}
+
+// TestPassesTestSuite - запуск тестового набора
+func TestPassesTestSuite(t *testing.T) {
+	suite.Run(t, new(PassesTestSuite))
+}
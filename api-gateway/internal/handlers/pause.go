@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"bike-rental/api-gateway/internal/models"
+)
+
+// @Summary Pause a bike rent
+// @Description Pause an active rent, e.g. while the rider is in a shop. The bike stays locked to the user; paused time is tracked apart from riding time and pauses longer than the configured maximum are resumed automatically.
+// @Tags rent
+// @Accept json
+// @Produce json
+// @Param request body PauseRentRequest true "Pause rent request"
+// @Success 200 {object} RentResponse
+// @Router /api/v1/rent/pause [post]
+func (h *Handlers) PauseRent(w http.ResponseWriter, r *http.Request) {
+	h.changeRent(w, r, h.rentClient.PauseRent)
+}
+
+// @Summary Resume a bike rent
+// @Description Resume a paused rent
+// @Tags rent
+// @Accept json
+// @Produce json
+// @Param request body PauseRentRequest true "Resume rent request"
+// @Success 200 {object} RentResponse
+// @Router /api/v1/rent/resume [post]
+func (h *Handlers) ResumeRent(w http.ResponseWriter, r *http.Request) {
+	h.changeRent(w, r, h.rentClient.ResumeRent)
+}
+
+func (h *Handlers) changeRent(w http.ResponseWriter, r *http.Request, call func(ctx context.Context, rentID, userID string) (*models.RentResponse, error)) {
+	var req PauseRentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := call(r.Context(), req.RentID, req.UserID)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// PauseTestSuite - тестовый набор для паузы аренды
+type PauseTestSuite struct {
+	handlerSuite
+}
+
+// TestPauseAndResume - пауза и продолжение передаются в rent-service для аренды пользователя
+func (suite *PauseTestSuite) TestPauseAndResume() {
+	rec := suite.do(http.MethodPost, "/api/v1/rent/pause", `{"rent_id":"r1","user_id":"u1"}`)
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"status":"paused"`)
+	suite.Contains(rec.Body.String(), `"paused_at":1700000600`)
+
+	rec = suite.do(http.MethodPost, "/api/v1/rent/resume", `{"rent_id":"r1","user_id":"u1"}`)
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"paused_seconds":300`)
+	suite.NotContains(rec.Body.String(), `"paused_at"`)
+
+	suite.Equal([]string{"r1/u1"}, suite.rentClient.paused)
+	suite.Equal([]string{"r1/u1"}, suite.rentClient.resumed)
+}
+
+// TestPause_InvalidBody - некорректное тело запроса возвращает 400
+func (suite *PauseTestSuite) TestPause_InvalidBody() {
+	suite.Equal(http.StatusBadRequest, suite.do(http.MethodPost, "/api/v1/rent/pause", "{").Code)
+	suite.Empty(suite.rentClient.paused)
+}
+
+// TestPauseTestSuite - запуск тестового набора
+func TestPauseTestSuite(t *testing.T) {
+	suite.Run(t, new(PauseTestSuite))
+}
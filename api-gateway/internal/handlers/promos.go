@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary List promo codes
+// @Description Every promo code with the number of rents it was applied to, newest first
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.PromoCodeList
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/promos [get]
+func (h *Handlers) ListPromoCodes(w http.ResponseWriter, r *http.Request) {
+	promos, err := h.rentClient.ListPromoCodes(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promos)
+}
+
+// @Summary Create a promo code
+// @Description Add a promo code riders can apply when starting or ending a rent. Codes are case-insensitive and stored in upper case.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body PromoCodeRequest true "Promo code"
+// @Success 201 {object} models.PromoCode
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/promos [post]
+func (h *Handlers) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	var req PromoCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promo, err := h.rentClient.CreatePromoCode(r.Context(), req.promoCode())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(promo)
+}
+
+// @Summary Update a promo code
+// @Description Replace the terms of a promo code. Rents it was applied to keep the terms they were redeemed with.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param code path string true "Promo code"
+// @Param request body PromoCodeRequest true "New terms; code is taken from the path"
+// @Success 200 {object} models.PromoCode
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/promos/{code} [put]
+func (h *Handlers) UpdatePromoCode(w http.ResponseWriter, r *http.Request) {
+	var req PromoCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Code = chi.URLParam(r, "code")
+
+	promo, err := h.rentClient.UpdatePromoCode(r.Context(), req.promoCode())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promo)
+}
+
+// @Summary Delete a promo code
+// @Description Remove a promo code that was never redeemed. Used codes can only be deactivated.
+// @Tags admin
+// @Param code path string true "Promo code"
+// @Success 204
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/promos/{code} [delete]
+func (h *Handlers) DeletePromoCode(w http.ResponseWriter, r *http.Request) {
+	if err := h.rentClient.DeletePromoCode(r.Context(), chi.URLParam(r, "code")); err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get promo code stats
+// @Description Redemptions, riders and discount given per promo code redeemed in a period, most redeemed first. from/to accept RFC3339 or YYYY-MM-DD; a date in to includes the whole day. Defaults to the last 30 days.
+// @Tags admin
+// @Produce json
+// @Param from query string false "Redemptions at or after this time"
+// @Param to query string false "Redemptions before this time"
+// @Success 200 {object} models.PromoStats
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/promos/stats [get]
+func (h *Handlers) GetPromoStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err := parseAuditTime("from", q.Get("from"), false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseAuditTime("to", q.Get("to"), true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.rentClient.GetPromoStats(r.Context(), from, to)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (req PromoCodeRequest) promoCode() models.PromoCode {
+	return models.PromoCode{
+		Code:           req.Code,
+		Kind:           req.Kind,
+		Value:          req.Value,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		Locations:      req.Locations,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		Active:         req.Active == nil || *req.Active,
+	}
+}
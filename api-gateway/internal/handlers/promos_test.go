@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// PromosTestSuite - тестовый набор для промокодов
+type PromosTestSuite struct {
+	handlerSuite
+}
+
+// TestCreatePromoCode - новый промокод по умолчанию активен
+func (suite *PromosTestSuite) TestCreatePromoCode() {
+	rec := suite.do(http.MethodPost, "/api/v1/admin/promos",
+		`{"code":"SUMMER10","kind":"percent","value":10,"valid_until":"2024-09-01T00:00:00Z","locations":["Park"]}`)
+
+	suite.Equal(http.StatusCreated, rec.Code)
+	suite.True(suite.rentClient.promo.Active)
+	suite.Equal([]string{"Park"}, suite.rentClient.promo.Locations)
+	suite.Require().NotNil(suite.rentClient.promo.ValidUntil)
+	suite.Equal(time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), *suite.rentClient.promo.ValidUntil)
+}
+
+// TestCreatePromoCode_Exists - занятый код возвращает 409
+func (suite *PromosTestSuite) TestCreatePromoCode_Exists() {
+	rec := suite.do(http.MethodPost, "/api/v1/admin/promos", `{"code":"TAKEN","kind":"fixed","value":1000}`)
+
+	suite.Equal(http.StatusConflict, rec.Code)
+}
+
+// TestUpdatePromoCode - код берётся из пути, промокод можно выключить
+func (suite *PromosTestSuite) TestUpdatePromoCode() {
+	rec := suite.do(http.MethodPut, "/api/v1/admin/promos/SUMMER10", `{"code":"OTHER","kind":"percent","value":15,"active":false}`)
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("SUMMER10", suite.rentClient.promo.Code)
+	suite.False(suite.rentClient.promo.Active)
+}
+
+// TestDeletePromoCode_Redeemed - использованный промокод не удаляется
+func (suite *PromosTestSuite) TestDeletePromoCode_Redeemed() {
+	suite.Equal(http.StatusConflict, suite.do(http.MethodDelete, "/api/v1/admin/promos/SUMMER10", "").Code)
+}
+
+// TestGetPromoStats - даты периода разбираются как в журнале аудита
+func (suite *PromosTestSuite) TestGetPromoStats() {
+	rec := suite.do(http.MethodGet, "/api/v1/admin/promos/stats?from=2024-06-01", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"code":"SUMMER10"`)
+	suite.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), suite.rentClient.promoFrom)
+
+	suite.Equal(http.StatusBadRequest, suite.do(http.MethodGet, "/api/v1/admin/promos/stats?from=June", "").Code)
+}
+
+// TestPromosTestSuite - запуск тестового набора
+func TestPromosTestSuite(t *testing.T) {
+	suite.Run(t, new(PromosTestSuite))
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+)
+
+// RelocateBikesRequest takes the moves of a rebalancing plan; the rest of a
+// posted plan is ignored.
+type RelocateBikesRequest struct {
+	Moves []models.BikeMove `json:"moves"`
+}
+
+// @Summary Plan fleet rebalancing
+// @Description Recommend moves of available bikes from locations with more than they need to locations short of them. Each location should keep the bikes it is expected to lose over the horizon, judged from the hour-of-week demand history in stats-service, and at least rent.rebalance.min_bikes. Nothing is moved; POST the plan to /api/v1/admin/bikes/relocate to apply it.
+// @Tags admin
+// @Produce json
+// @Param truck_capacity query int false "Bikes per move, defaults to rent.rebalance.truck_capacity"
+// @Param trucks query int false "Moves in the plan, defaults to rent.rebalance.trucks"
+// @Param horizon query string false "How far ahead to plan, e.g. 3h; at most 24h, defaults to rent.rebalance.horizon"
+// @Success 200 {object} models.RebalancePlan
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/rebalance [get]
+func (h *Handlers) PlanRebalance(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var truckCapacity, trucks int
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"truck_capacity", &truckCapacity}, {"trucks", &trucks}} {
+		if value := q.Get(p.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				http.Error(w, p.name+" must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			*p.value = n
+		}
+	}
+
+	var horizon time.Duration
+	if value := q.Get("horizon"); value != "" {
+		var err error
+		horizon, err = time.ParseDuration(value)
+		if err != nil || horizon <= 0 {
+			http.Error(w, "horizon must be a positive duration such as 3h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	plan, err := h.rentClient.PlanRebalance(r.Context(), truckCapacity, trucks, horizon)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// @Summary Relocate bikes
+// @Description Move available bikes between locations, e.g. to apply a rebalancing plan, whose JSON can be posted as is. Moves are applied in order, each on its own; bikes rented in the meantime are skipped, so a move can take fewer bikes than asked. Not retried.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body RelocateBikesRequest true "Moves, at most 100"
+// @Success 200 {object} models.RelocationReport
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/bikes/relocate [post]
+func (h *Handlers) RelocateBikes(w http.ResponseWriter, r *http.Request) {
+	var req RelocateBikesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.rentClient.RelocateBikes(r.Context(), req.Moves)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// RebalanceTestSuite - тестовый набор для перераспределения парка
+type RebalanceTestSuite struct {
+	handlerSuite
+}
+
+// TestPlanRebalance - параметры передаются в rent-service
+func (suite *RebalanceTestSuite) TestPlanRebalance() {
+	rec := suite.do(http.MethodGet, "/api/v1/admin/rebalance?truck_capacity=8&trucks=2&horizon=90m", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal(8, suite.rentClient.truckCapacity)
+	suite.Equal(2, suite.rentClient.trucks)
+	suite.Equal(90*time.Minute, suite.rentClient.horizon)
+	var plan models.RebalancePlan
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &plan))
+	suite.Len(plan.Moves, 1)
+}
+
+// TestPlanRebalance_Defaults - без параметров берутся значения из конфигурации
+func (suite *RebalanceTestSuite) TestPlanRebalance_Defaults() {
+	rec := suite.do(http.MethodGet, "/api/v1/admin/rebalance", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Zero(suite.rentClient.truckCapacity)
+	suite.Zero(suite.rentClient.trucks)
+	suite.Zero(suite.rentClient.horizon)
+}
+
+// TestPlanRebalance_InvalidParams - некорректные параметры отклоняются
+func (suite *RebalanceTestSuite) TestPlanRebalance_InvalidParams() {
+	for _, query := range []string{"truck_capacity=0", "trucks=x", "horizon=3", "horizon=-1h"} {
+		rec := suite.do(http.MethodGet, "/api/v1/admin/rebalance?"+query, "")
+		suite.Equal(http.StatusBadRequest, rec.Code, query)
+	}
+
+	rec := suite.do(http.MethodGet, "/api/v1/admin/rebalance?horizon=48h", "")
+	suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+// TestRelocateBikes - план можно отправить целиком
+func (suite *RebalanceTestSuite) TestRelocateBikes() {
+	rec := suite.do(http.MethodPost, "/api/v1/admin/bikes/relocate",
+		`{"horizon":"3h0m0s","locations":[],"moves":[{"from":"Park","to":"Center","count":5}]}`)
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal([]models.BikeMove{{From: "Park", To: "Center", Count: 5}}, suite.rentClient.moves)
+	var report models.RelocationReport
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &report))
+	suite.Equal(5, report.Moved)
+}
+
+// TestRelocateBikes_InvalidBody - некорректное тело запроса
+func (suite *RebalanceTestSuite) TestRelocateBikes_InvalidBody() {
+	rec := suite.do(http.MethodPost, "/api/v1/admin/bikes/relocate", `{"moves":`)
+
+	suite.Equal(http.StatusBadRequest, rec.Code)
+	suite.Nil(suite.rentClient.moves)
+}
+
+// TestRebalanceTestSuite - запуск тестового набора
+func TestRebalanceTestSuite(t *testing.T) {
+	suite.Run(t, new(RebalanceTestSuite))
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"bike-rental/api-gateway/internal/documents"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Get rent receipt
+// @Description Itemized invoice of an ended, billed rent: unlock fee, riding and paused minutes, pass and promo discounts and the VAT included. Invoice numbers never change once issued.
+// @Tags wallet
+// @Produce json
+// @Produce text/html
+// @Produce application/pdf
+// @Param rent_id path string true "Rent ID"
+// @Param format query string false "json (default), html or pdf"
+// @Success 200 {object} models.Receipt
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Router /api/v1/rents/{rent_id}/receipt [get]
+func (h *Handlers) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if !validDocumentFormat(format) {
+		http.Error(w, "format must be json, html or pdf", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := h.rentClient.GetReceipt(r.Context(), chi.URLParam(r, "rent_id"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	writeDocument(w, format, receipt.Number, receipt,
+		func(w io.Writer) error { return documents.ReceiptHTML(w, *receipt) },
+		func(w io.Writer) error { return documents.ReceiptPDF(w, *receipt) },
+	)
+}
+
+// @Summary Get monthly statement
+// @Description Opening and closing balance of a rider's wallet for a calendar month (UTC), top-ups, fares, passes and refunds in it and the invoices issued
+// @Tags wallet
+// @Produce json
+// @Produce text/html
+// @Produce application/pdf
+// @Param user_id path string true "User ID"
+// @Param month query string false "YYYY-MM, defaults to the current month"
+// @Param format query string false "json (default), html or pdf"
+// @Success 200 {object} models.Statement
+// @Failure 400 {string} string
+// @Router /api/v1/wallet/{user_id}/statement [get]
+func (h *Handlers) GetStatement(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if !validDocumentFormat(format) {
+		http.Error(w, "format must be json, html or pdf", http.StatusBadRequest)
+		return
+	}
+
+	statement, err := h.rentClient.GetStatement(r.Context(), chi.URLParam(r, "user_id"), q.Get("month"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	writeDocument(w, format, "statement-"+statement.UserID+"-"+statement.Month, statement,
+		func(w io.Writer) error { return documents.StatementHTML(w, *statement) },
+		func(w io.Writer) error { return documents.StatementPDF(w, *statement) },
+	)
+}
+
+func validDocumentFormat(format string) bool {
+	switch format {
+	case "", "json", "html", "pdf":
+		return true
+	}
+	return false
+}
+
+// writeDocument sends v as JSON or renders it as HTML or PDF. Documents
+// are rendered in full before anything is sent, so a failure is still
+// reported with a proper status code.
+func writeDocument(w http.ResponseWriter, format, name string, v interface{}, html, pdf func(io.Writer) error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = html(&buf)
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="`+name+`.pdf"`)
+		err = pdf(&buf)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(&buf).Encode(v)
+	}
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	buf.WriteTo(w)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// ReceiptsTestSuite - тестовый набор для чеков и выписок
+type ReceiptsTestSuite struct {
+	handlerSuite
+}
+
+// TestGetReceipt_JSON - по умолчанию чек возвращается в JSON
+func (suite *ReceiptsTestSuite) TestGetReceipt_JSON() {
+	rec := suite.do(http.MethodGet, "/api/v1/rents/r1/receipt", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("application/json", rec.Header().Get("Content-Type"))
+	suite.Contains(rec.Body.String(), `"number":"BR-2024-000042"`)
+	suite.Contains(rec.Body.String(), `"kind":"riding"`)
+}
+
+// TestGetReceipt_HTML - HTML-чек содержит строки и итог
+func (suite *ReceiptsTestSuite) TestGetReceipt_HTML() {
+	rec := suite.do(http.MethodGet, "/api/v1/rents/r1/receipt?format=html", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Header().Get("Content-Type"), "text/html")
+	suite.Contains(rec.Body.String(), "Receipt BR-2024-000042")
+	suite.Contains(rec.Body.String(), "130.00 RUB")
+}
+
+// TestGetReceipt_PDF - PDF отдаётся с именем файла по номеру чека
+func (suite *ReceiptsTestSuite) TestGetReceipt_PDF() {
+	rec := suite.do(http.MethodGet, "/api/v1/rents/r1/receipt?format=pdf", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("application/pdf", rec.Header().Get("Content-Type"))
+	suite.Contains(rec.Header().Get("Content-Disposition"), "BR-2024-000042.pdf")
+	suite.True(strings.HasPrefix(rec.Body.String(), "%PDF-"))
+}
+
+// TestGetReceipt_NotCharged - чек неоплаченной аренды возвращает 409
+func (suite *ReceiptsTestSuite) TestGetReceipt_NotCharged() {
+	suite.Equal(http.StatusConflict, suite.do(http.MethodGet, "/api/v1/rents/active/receipt", "").Code)
+}
+
+// TestGetReceipt_InvalidFormat - неизвестный формат возвращает 400
+func (suite *ReceiptsTestSuite) TestGetReceipt_InvalidFormat() {
+	suite.Equal(http.StatusBadRequest, suite.do(http.MethodGet, "/api/v1/rents/r1/receipt?format=xml", "").Code)
+}
+
+// TestGetStatement - месяц передаётся в rent-service, выписка содержит чеки
+func (suite *ReceiptsTestSuite) TestGetStatement() {
+	rec := suite.do(http.MethodGet, "/api/v1/wallet/u1/statement?month=2024-03", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("2024-03", suite.rentClient.statementMonth)
+	suite.Contains(rec.Body.String(), `"closing_balance":37000`)
+	suite.Contains(rec.Body.String(), `"number":"BR-2024-000042"`)
+}
+
+// TestReceiptsTestSuite - запуск тестового набора
+func TestReceiptsTestSuite(t *testing.T) {
+	suite.Run(t, new(ReceiptsTestSuite))
+}
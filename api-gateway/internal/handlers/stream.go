@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// @Summary Stream fleet events
+// @Description Server-Sent Events stream of rent starts/ends and bikes added/removed. Each message carries a FleetEvent as JSON. A "lagged" event reports how many events were dropped because the client read too slowly; clients should then refetch /api/v1/bikes/available.
+// @Tags fleet
+// @Produce text/event-stream
+// @Param location query string false "Only events at this location"
+// @Success 200 {object} models.FleetEvent
+// @Router /api/v1/fleet/stream [get]
+func (h *Handlers) StreamFleet(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client := h.fleet.Subscribe(r.URL.Query().Get("location"))
+	defer client.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx-style proxies
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.fleet.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event := <-client.Events:
+			if dropped := client.TakeDropped(); dropped > 0 {
+				fmt.Fprintf(w, "event: lagged\ndata: {\"dropped\":%d}\n\n", dropped)
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
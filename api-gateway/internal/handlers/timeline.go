@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Rent timeline
+// @Description Every lifecycle event of a rent, oldest first, and the status they lead to
+// @Tags rent
+// @Produce json
+// @Param rent_id path string true "Rent ID (UUID)"
+// @Success 200 {object} models.RentTimeline
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Router /api/v1/rents/{rent_id}/timeline [get]
+func (h *Handlers) GetRentTimeline(w http.ResponseWriter, r *http.Request) {
+	timeline, err := h.rentClient.GetRentTimeline(r.Context(), chi.URLParam(r, "rent_id"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// TimelineTestSuite - тестовый набор для истории аренды
+type TimelineTestSuite struct {
+	handlerSuite
+}
+
+// TestGetRentTimeline - история аренды возвращается с итоговым статусом
+func (suite *TimelineTestSuite) TestGetRentTimeline() {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	suite.rentClient.timeline = &models.RentTimeline{
+		RentID: "r1",
+		Status: "force_ended",
+		Events: []models.RentTimelineEvent{
+			{Seq: 1, Type: "started", UserID: "u1", BikeID: "b1", Timestamp: start},
+			{Seq: 2, Type: "force_ended", UserID: "u1", BikeID: "b1", Timestamp: start.Add(time.Hour), Reason: "left at depot"},
+		},
+	}
+
+	rec := suite.do(http.MethodGet, "/api/v1/rents/r1/timeline", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("r1", suite.rentClient.timelineRentID)
+	suite.Contains(rec.Body.String(), `"status":"force_ended"`)
+	suite.Contains(rec.Body.String(), `"reason":"left at depot"`)
+	suite.Contains(rec.Body.String(), `"timestamp":"2024-01-15T10:00:00Z"`)
+}
+
+// TestGetRentTimeline_NotFound - неизвестная аренда возвращает 404
+func (suite *TimelineTestSuite) TestGetRentTimeline_NotFound() {
+	suite.Equal(http.StatusNotFound, suite.do(http.MethodGet, "/api/v1/rents/missing/timeline", "").Code)
+}
+
+// TestTimelineTestSuite - запуск тестового набора
+func TestTimelineTestSuite(t *testing.T) {
+	suite.Run(t, new(TimelineTestSuite))
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Register a rider
+// @Description Create a rider account pending verification. user_id is generated when empty; the email is stored in lower case and must not be taken.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body UserRequest true "Rider profile"
+// @Success 201 {object} models.User
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Router /api/v1/users [post]
+func (h *Handlers) RegisterUser(w http.ResponseWriter, r *http.Request) {
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.rentClient.RegisterUser(r.Context(), req.user())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// @Summary Get a rider
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 404 {string} string
+// @Router /api/v1/users/{user_id} [get]
+func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.rentClient.GetUser(r.Context(), chi.URLParam(r, "user_id"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// @Summary Update a rider
+// @Description Replace the name, email and phone of a rider. The status is left alone.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param request body UserRequest true "New profile; user_id is taken from the path"
+// @Success 200 {object} models.User
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Router /api/v1/users/{user_id} [put]
+func (h *Handlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = chi.URLParam(r, "user_id")
+
+	user, err := h.rentClient.UpdateUser(r.Context(), req.user())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// @Summary List riders
+// @Description Rider accounts, newest first, e.g. those waiting for verification
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending_verification, active or blocked"
+// @Param limit query int false "Accounts to return, default 100, at most 1000"
+// @Success 200 {object} models.UserList
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/users [get]
+func (h *Handlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var limit int
+	if value := q.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, err := h.rentClient.ListUsers(r.Context(), q.Get("status"), limit)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// @Summary Set rider status
+// @Description Verify (active), block or return a rider to pending_verification. Blocking needs a reason, which the rider is shown when a rent is refused.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param request body UserStatusRequest true "New status"
+// @Success 200 {object} models.User
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 401 {string} string
+// @Security OperatorToken
+// @Router /api/v1/admin/users/{user_id}/status [put]
+func (h *Handlers) SetUserStatus(w http.ResponseWriter, r *http.Request) {
+	var req UserStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.rentClient.SetUserStatus(r.Context(), chi.URLParam(r, "user_id"), req.Status, req.Reason)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (req UserRequest) user() models.User {
+	return models.User{ID: req.UserID, Name: req.Name, Email: req.Email, Phone: req.Phone}
+}
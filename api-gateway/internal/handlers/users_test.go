@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// UsersTestSuite - тестовый набор для учётных записей
+type UsersTestSuite struct {
+	handlerSuite
+}
+
+// TestRegisterUser - новая учётная запись ждёт проверки
+func (suite *UsersTestSuite) TestRegisterUser() {
+	rec := suite.do(http.MethodPost, "/api/v1/users", `{"name":"Anna","email":"anna@example.com"}`)
+
+	suite.Equal(http.StatusCreated, rec.Code)
+	var user models.User
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &user))
+	suite.Equal("pending_verification", user.Status)
+	suite.Equal("anna@example.com", suite.rentClient.user.Email)
+}
+
+// TestRegisterUser_Exists - занятый email возвращает 409
+func (suite *UsersTestSuite) TestRegisterUser_Exists() {
+	rec := suite.do(http.MethodPost, "/api/v1/users", `{"name":"Anna","email":"taken@example.com"}`)
+
+	suite.Equal(http.StatusConflict, rec.Code)
+}
+
+// TestUpdateUser - user_id берётся из пути
+func (suite *UsersTestSuite) TestUpdateUser() {
+	rec := suite.do(http.MethodPut, "/api/v1/users/u1", `{"user_id":"other","name":"Anna","email":"anna@example.com"}`)
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("u1", suite.rentClient.user.ID)
+}
+
+// TestGetUser_NotFound - неизвестный пользователь возвращает 404
+func (suite *UsersTestSuite) TestGetUser_NotFound() {
+	rec := suite.do(http.MethodGet, "/api/v1/users/unknown", "")
+
+	suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+// TestSetUserStatus - блокировка без причины возвращает 400
+func (suite *UsersTestSuite) TestSetUserStatus() {
+	rec := suite.do(http.MethodPut, "/api/v1/admin/users/u1/status", `{"status":"blocked"}`)
+	suite.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = suite.do(http.MethodPut, "/api/v1/admin/users/u1/status", `{"status":"blocked","reason":"fraud"}`)
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("blocked", suite.rentClient.userStatus)
+}
+
+// TestSetUserStatus_RequiresOperator - без токена оператора пользователь не может сменить свой статус
+func (suite *UsersTestSuite) TestSetUserStatus_RequiresOperator() {
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/u1/status", strings.NewReader(`{"status":"active"}`))
+
+	rec := suite.serve(req)
+
+	suite.Equal(http.StatusUnauthorized, rec.Code)
+	suite.Empty(suite.rentClient.userStatus)
+}
+
+// TestListUsers - фильтр по статусу и лимит передаются в rent-service
+func (suite *UsersTestSuite) TestListUsers() {
+	rec := suite.do(http.MethodGet, "/api/v1/admin/users?status=pending_verification&limit=10", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal("pending_verification", suite.rentClient.userStatus)
+	suite.Equal(10, suite.rentClient.usersLimit)
+
+	rec = suite.do(http.MethodGet, "/api/v1/admin/users?limit=0", "")
+	suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+// TestUsersTestSuite - запуск тестового набора
+func TestUsersTestSuite(t *testing.T) {
+	suite.Run(t, new(UsersTestSuite))
+}
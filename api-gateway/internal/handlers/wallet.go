@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Get wallet
+// @Description Balance of a rider and the latest ledger entries, newest first. Amounts are in minor currency units; available is the balance not held for rents in progress.
+// @Tags wallet
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param limit query int false "Ledger entries to return, default 20"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {string} string
+// @Router /api/v1/wallet/{user_id} [get]
+func (h *Handlers) GetWallet(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	wallet, err := h.rentClient.GetWallet(r.Context(), chi.URLParam(r, "user_id"), limit)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallet)
+}
+
+// @Summary Top up wallet
+// @Description Charge the payment provider and credit the rider's wallet. Requests with the same idempotency_key are charged once, so they are safe to retry.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param request body TopUpWalletRequest true "Top-up request"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {string} string
+// @Failure 409 {string} string
+// @Router /api/v1/wallet/topup [post]
+func (h *Handlers) TopUpWallet(w http.ResponseWriter, r *http.Request) {
+	var req TopUpWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.rentClient.TopUpWallet(r.Context(), req.UserID, req.Amount, req.IdempotencyKey)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallet)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// WalletTestSuite - тестовый набор для кошелька
+type WalletTestSuite struct {
+	handlerSuite
+}
+
+// TestGetWallet - баланс возвращается вместе с доступной суммой
+func (suite *WalletTestSuite) TestGetWallet() {
+	rec := suite.do(http.MethodGet, "/api/v1/wallet/u1?limit=5", "")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"available":20000`)
+	suite.Equal(5, suite.rentClient.walletLimit)
+}
+
+// TestGetWallet_InvalidLimit - некорректный limit возвращает 400
+func (suite *WalletTestSuite) TestGetWallet_InvalidLimit() {
+	suite.Equal(http.StatusBadRequest, suite.do(http.MethodGet, "/api/v1/wallet/u1?limit=0", "").Code)
+}
+
+// TestTopUpWallet - ключ идемпотентности передаётся в rent-service
+func (suite *WalletTestSuite) TestTopUpWallet() {
+	rec := suite.do(http.MethodPost, "/api/v1/wallet/topup", `{"user_id":"u1","amount":10000,"idempotency_key":"k1"}`)
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Contains(rec.Body.String(), `"balance":10000`)
+	suite.Equal("k1", suite.rentClient.topUpKey)
+}
+
+// TestTopUpWallet_InvalidAmount - отклонённая rent-service сумма возвращает 400
+func (suite *WalletTestSuite) TestTopUpWallet_InvalidAmount() {
+	rec := suite.do(http.MethodPost, "/api/v1/wallet/topup", `{"user_id":"u1","amount":2000000}`)
+
+	suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+// TestWalletTestSuite - запуск тестового набора
+func TestWalletTestSuite(t *testing.T) {
+	suite.Run(t, new(WalletTestSuite))
+}
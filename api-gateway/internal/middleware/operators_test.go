@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// OperatorsTestSuite - тестовый набор для аутентификации операторов
+type OperatorsTestSuite struct {
+	suite.Suite
+	operators *Operators
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *OperatorsTestSuite) SetupTest() {
+	path := filepath.Join(suite.T().TempDir(), "tokens")
+	suite.Require().NoError(os.WriteFile(path, []byte("# dev operators\nalice  alice-token-0123456789\n\nbob bob-token-0123456789\n"), 0o600))
+
+	operators, err := LoadOperators(path)
+	suite.Require().NoError(err)
+	suite.operators = operators
+}
+
+// serve - выполняет запрос с заданным Authorization и возвращает код ответа и актора
+func (suite *OperatorsTestSuite) serve(middleware func(http.Handler) http.Handler, auth string) (int, string) {
+	var actor string
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = RequestInfoFromContext(r.Context()).Actor
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	WithRequestInfo(handler).ServeHTTP(rec, req)
+	return rec.Code, actor
+}
+
+// TestRequire - проходят только известные токены, актор - имя оператора
+func (suite *OperatorsTestSuite) TestRequire() {
+	code, actor := suite.serve(suite.operators.Require, "Bearer bob-token-0123456789")
+	suite.Equal(http.StatusOK, code)
+	suite.Equal("bob", actor)
+
+	for _, auth := range []string{"", "Bearer unknown-token-0123456789", "bob-token-0123456789"} {
+		code, _ := suite.serve(suite.operators.Require, auth)
+		suite.Equal(http.StatusUnauthorized, code, auth)
+	}
+}
+
+// TestRequire_NoOperators - без файла токенов админские запросы отклоняются
+func (suite *OperatorsTestSuite) TestRequire_NoOperators() {
+	var operators *Operators
+
+	code, _ := suite.serve(operators.Require, "Bearer alice-token-0123456789")
+
+	suite.Equal(http.StatusUnauthorized, code)
+}
+
+// TestIdentify - без токена запрос анонимный, с неизвестным токеном отклоняется
+func (suite *OperatorsTestSuite) TestIdentify() {
+	code, actor := suite.serve(suite.operators.Identify, "")
+	suite.Equal(http.StatusOK, code)
+	suite.Empty(actor)
+
+	code, actor = suite.serve(suite.operators.Identify, "Bearer alice-token-0123456789")
+	suite.Equal(http.StatusOK, code)
+	suite.Equal("alice", actor)
+
+	code, _ = suite.serve(suite.operators.Identify, "Bearer unknown-token-0123456789")
+	suite.Equal(http.StatusUnauthorized, code)
+}
+
+// TestLoadOperators_Invalid - некорректный файл токенов не загружается
+func (suite *OperatorsTestSuite) TestLoadOperators_Invalid() {
+	for _, content := range []string{
+		"alice\n",
+		"alice short\n",
+		"alice alice-token-0123456789\nalice other-token-0123456789\n",
+		"alice shared-token-0123456789\nbob shared-token-0123456789\n",
+	} {
+		path := filepath.Join(suite.T().TempDir(), "tokens")
+		suite.Require().NoError(os.WriteFile(path, []byte(content), 0o600))
+
+		_, err := LoadOperators(path)
+
+		suite.Error(err, content)
+	}
+}
+
+// TestOperatorsTestSuite - запуск тестового набора
+func TestOperatorsTestSuite(t *testing.T) {
+	suite.Run(t, new(OperatorsTestSuite))
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bike-rental/config"
+	"golang.org/x/time/rate"
+)
+
+const (
+	idleClientTTL   = 3 * time.Minute
+	cleanupInterval = time.Minute
+)
+
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter throttles API requests per client IP. Its settings can be
+// changed at runtime with Update.
+type RateLimiter struct {
+	mu          sync.Mutex
+	cfg         config.RateLimitConfig
+	visitors    map[string]*visitor
+	lastCleanup time.Time
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:         cfg,
+		visitors:    make(map[string]*visitor),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Update applies new limits. Existing buckets are reset so the change takes
+// effect immediately.
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.visitors = make(map[string]*visitor)
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || l.allow(clientIP(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	})
+}
+
+func (l *RateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.cfg.Enabled {
+		return true
+	}
+
+	now := time.Now()
+	if now.Sub(l.lastCleanup) > cleanupInterval {
+		for key, v := range l.visitors {
+			if now.Sub(v.lastSeen) > idleClientTTL {
+				delete(l.visitors, key)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	v, ok := l.visitors[ip]
+	if !ok {
+		v = &visitor{limiter: rate.NewLimiter(rate.Limit(l.cfg.RequestsPerSecond), l.cfg.Burst)}
+		l.visitors[ip] = v
+	}
+	v.lastSeen = now
+	return v.limiter.AllowN(now, 1)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
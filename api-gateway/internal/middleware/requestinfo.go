@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader identifies a request end to end. It is generated when
+// missing and always echoed.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestInfo is forwarded to rent-service so its audit log can be joined
+// with gateway access logs. Actor is set only by Operators once the caller
+// has authenticated; clients cannot name it.
+type RequestInfo struct {
+	ID    string
+	Actor string
+}
+
+type requestInfoKey struct{}
+
+// RequestInfoFromContext returns the info stored by WithRequestInfo, or a
+// zero value for contexts that did not come from an HTTP request.
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// WithRequestInfo assigns every request an ID and stores it in the request
+// context.
+func WithRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := RequestInfo{ID: r.Header.Get(RequestIDHeader)}
+		if info.ID == "" || len(info.ID) > 100 {
+			info.ID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, info.ID)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+	})
+}
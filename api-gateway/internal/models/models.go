@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type RentResponse struct {
 	RentID    string `json:"rent_id"`
 	UserID    string `json:"user_id"`
@@ -8,6 +13,86 @@ type RentResponse struct {
 	Message   string `json:"message"`
 	StartTime int64  `json:"start_time"`
 	EndTime   int64  `json:"end_time"`
+	// PausedSeconds is the length of the finished pauses
+	PausedSeconds int64 `json:"paused_seconds"`
+	// PausedAt is when the current pause started, 0 unless paused
+	PausedAt int64 `json:"paused_at,omitempty"`
+	// Fare charged by EndRent in minor units of Currency; unset for free rents
+	Fare     int64  `json:"fare,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// Pass is the code of the pass a billed rent is ridden on
+	Pass string `json:"pass,omitempty"`
+	// PromoCode applied to the rent and, once charged, what it took off
+	// the fare
+	PromoCode string `json:"promo_code,omitempty"`
+	Discount  int64  `json:"discount,omitempty"`
+	// InvoiceNumber of the receipt issued when the fare was charged
+	InvoiceNumber string `json:"invoice_number,omitempty"`
+}
+
+// Wallet is a rider's balance in minor units of Currency. Available is
+// what is not held for rents in progress.
+type Wallet struct {
+	UserID    string        `json:"user_id"`
+	Balance   int64         `json:"balance"`
+	Held      int64         `json:"held"`
+	Available int64         `json:"available"`
+	Currency  string        `json:"currency"`
+	Entries   []WalletEntry `json:"entries,omitempty"`
+	// Pass is the active pass, nil if none
+	Pass *Subscription `json:"pass,omitempty"`
+}
+
+// WalletEntry is one ledger entry; Amount and Held are the changes of the
+// balance and the held amount.
+type WalletEntry struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Amount    int64     `json:"amount"`
+	Held      int64     `json:"held"`
+	RentID    string    `json:"rent_id,omitempty"`
+	Reference string    `json:"reference,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// Pass is an offer of the pass catalog.
+type Pass struct {
+	Code            string `json:"code"`
+	Name            string `json:"name"`
+	Price           int64  `json:"price"`
+	Currency        string `json:"currency"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	FreeMinutes     int64  `json:"free_minutes"`
+	DiscountPercent int64  `json:"discount_percent"`
+}
+
+type PassList struct {
+	Passes []Pass `json:"passes"`
+}
+
+// Subscription is a pass bought by a rider, with the terms of the pass at
+// purchase.
+type Subscription struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	Pass            string     `json:"pass"`
+	Name            string     `json:"name"`
+	Price           int64      `json:"price"`
+	Currency        string     `json:"currency"`
+	FreeMinutes     int64      `json:"free_minutes"`
+	DiscountPercent int64      `json:"discount_percent"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+	Refunded        int64      `json:"refunded"`
+}
+
+// ActiveRents counts the rents in progress; paused ones are included in
+// ActiveRents too.
+type ActiveRents struct {
+	ActiveRents int64 `json:"active_rents"`
+	PausedRents int64 `json:"paused_rents"`
 }
 
 type BikesList struct {
@@ -34,3 +119,378 @@ type DeleteBikeResponse struct {
 	Message string `json:"message"`
 }
 
+// ImportRow is one bike from an uploaded file; Line is where it was found.
+type ImportRow struct {
+	Line     int    `json:"-"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Status   string `json:"status,omitempty"`
+}
+
+type ImportOptions struct {
+	AllOrNothing bool
+	DryRun       bool
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	DryRun   bool             `json:"dry_run"`
+	Bikes    []Bike           `json:"bikes"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// LocationBalance is the stock of a location in a rebalancing plan: Target
+// is what it should hold for the rents expected over the horizon, After
+// what it holds once the moves are made.
+type LocationBalance struct {
+	Location       string  `json:"location"`
+	Available      int     `json:"available"`
+	ExpectedStarts float64 `json:"expected_starts"`
+	ExpectedEnds   float64 `json:"expected_ends"`
+	Target         int     `json:"target"`
+	After          int     `json:"after"`
+}
+
+// BikeMove takes Count available bikes from one location to another.
+type BikeMove struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// RebalancePlan recommends at most one move per truck. DemandError says
+// why demand history was not used, in which case targets are the
+// configured minimum.
+type RebalancePlan struct {
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Horizon       string            `json:"horizon"`
+	TruckCapacity int               `json:"truck_capacity"`
+	Trucks        int               `json:"trucks"`
+	HistoryFrom   string            `json:"history_from,omitempty"`
+	HistoryTo     string            `json:"history_to,omitempty"`
+	DemandError   string            `json:"demand_error,omitempty"`
+	Locations     []LocationBalance `json:"locations"`
+	Moves         []BikeMove        `json:"moves"`
+}
+
+// BikeRelocation is a move as applied; Moved can be less than Count when
+// bikes were rented in the meantime.
+type BikeRelocation struct {
+	BikeMove
+	Moved   int      `json:"moved"`
+	BikeIDs []string `json:"bike_ids"`
+}
+
+type RelocationReport struct {
+	Relocations []BikeRelocation `json:"relocations"`
+	Moved       int              `json:"moved"`
+}
+
+// PromoCode takes Value percent (kind "percent") or Value minor units (kind
+// "fixed") off the fare of a rent. Nil times, zero limits and empty
+// Locations do not restrict it.
+type PromoCode struct {
+	Code           string     `json:"code"`
+	Kind           string     `json:"kind"`
+	Value          int64      `json:"value"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	Locations      []string   `json:"locations,omitempty"`
+	MaxRedemptions int64      `json:"max_redemptions"`
+	MaxPerUser     int64      `json:"max_per_user"`
+	Active         bool       `json:"active"`
+	// CreatedAt and Redemptions are set by rent-service
+	CreatedAt   time.Time `json:"created_at"`
+	Redemptions int64     `json:"redemptions"`
+}
+
+type PromoCodeList struct {
+	PromoCodes []PromoCode `json:"promo_codes"`
+}
+
+// PromoUsage is how one promo code was used; Charged counts the ended
+// rents whose Discount is known.
+type PromoUsage struct {
+	Code        string `json:"code"`
+	Redemptions int64  `json:"redemptions"`
+	Users       int64  `json:"users"`
+	Charged     int64  `json:"charged"`
+	Discount    int64  `json:"discount"`
+	Currency    string `json:"currency"`
+}
+
+// PromoStats is the use of promo codes redeemed in [From, To).
+type PromoStats struct {
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	PromoCodes []PromoUsage `json:"promo_codes"`
+}
+
+// Invoice is the numbered record of a charged rent. Numbers run without
+// gaps within a year. Tax is the VAT included in Total; Refunded was
+// credited back after the invoice was issued.
+type Invoice struct {
+	Number     string    `json:"number"`
+	RentID     string    `json:"rent_id"`
+	UserID     string    `json:"user_id"`
+	IssuedAt   time.Time `json:"issued_at"`
+	Total      int64     `json:"total"`
+	Tax        int64     `json:"tax"`
+	TaxPercent int64     `json:"tax_percent"`
+	Refunded   int64     `json:"refunded"`
+	Currency   string    `json:"currency"`
+}
+
+// ReceiptLine is Quantity minutes or units at UnitPrice. Kind is unlock,
+// riding, free_minutes, paused, pass_discount or promo_discount; free
+// minutes and discounts have negative amounts.
+type ReceiptLine struct {
+	Kind      string `json:"kind"`
+	Quantity  int64  `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"`
+	Amount    int64  `json:"amount"`
+}
+
+// Receipt is the invoice of a rent with what it was charged for; the
+// amounts of Lines add up to Total.
+type Receipt struct {
+	Invoice
+	StartTime     time.Time     `json:"start_time"`
+	EndTime       time.Time     `json:"end_time"`
+	RidingSeconds int64         `json:"riding_seconds"`
+	PausedSeconds int64         `json:"paused_seconds"`
+	Pass          string        `json:"pass,omitempty"`
+	PromoCode     string        `json:"promo_code,omitempty"`
+	Lines         []ReceiptLine `json:"lines"`
+}
+
+// Statement sums up a rider's wallet for a month. Amounts are positive:
+// ClosingBalance is OpeningBalance plus TopUps and Refunds less Fares and
+// Passes.
+type Statement struct {
+	UserID         string    `json:"user_id"`
+	Month          string    `json:"month"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	TopUps         int64     `json:"top_ups"`
+	Fares          int64     `json:"fares"`
+	Refunds        int64     `json:"refunds"`
+	Passes         int64     `json:"passes"`
+	// Tax is the VAT included in Invoices
+	Tax      int64     `json:"tax"`
+	Invoices []Invoice `json:"invoices"`
+	Currency string    `json:"currency"`
+}
+
+// User is a rider account. Riders wait in "pending_verification" until an
+// operator makes them "active"; "blocked" riders cannot rent, and
+// StatusReason says why.
+type User struct {
+	ID           string    `json:"user_id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Phone        string    `json:"phone,omitempty"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type UserList struct {
+	Users []User `json:"users"`
+}
+
+// AuditFilter selects audit entries; zero fields match everything.
+type AuditFilter struct {
+	Actor     string
+	Action    string
+	TargetID  string
+	RequestID string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// AuditEntry is one state-changing call recorded by rent-service. Before
+// and After hold the target and the response as JSON. Peer is the
+// authenticated caller that vouched for Actor.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Actor      string          `json:"actor"`
+	Peer       string          `json:"peer"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// RentTimeline is the lifecycle history of a rent and the status it leads
+// to.
+type RentTimeline struct {
+	RentID string              `json:"rent_id"`
+	Status string              `json:"status"`
+	Events []RentTimelineEvent `json:"events"`
+}
+
+type RentTimelineEvent struct {
+	Seq       int64     `json:"seq"`
+	Type      string    `json:"type"`
+	UserID    string    `json:"user_id"`
+	BikeID    string    `json:"bike_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+
+type SeriesPoint struct {
+	Period string `json:"period"`
+	Start  string `json:"start"`
+	Count  int64  `json:"count"`
+}
+
+type RangeStats struct {
+	From        string        `json:"from"`
+	To          string        `json:"to"`
+	Granularity string        `json:"granularity"`
+	Total       int64         `json:"total"`
+	Series      []SeriesPoint `json:"series"`
+}
+
+type HourBucket struct {
+	Hour  int   `json:"hour"`
+	Count int64 `json:"count"`
+}
+
+type HourlyStats struct {
+	Date  string       `json:"date"`
+	Total int64        `json:"total"`
+	Hours []HourBucket `json:"hours"`
+}
+
+type LocationCount struct {
+	Location string `json:"location"`
+	Count    int64  `json:"count"`
+}
+
+type LocationBreakdown struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Total     int64           `json:"total"`
+	Locations []LocationCount `json:"locations"`
+}
+
+type PassUsage struct {
+	Pass          string  `json:"pass"`
+	Rents         int64   `json:"rents"`
+	RentedMinutes float64 `json:"rented_minutes"`
+}
+
+// PassStats is pass usage over a date range; PassShare is the part of all
+// rents started in it that were ridden on a pass.
+type PassStats struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Rents     int64       `json:"rents"`
+	PassRents int64       `json:"pass_rents"`
+	PassShare float64     `json:"pass_share"`
+	Passes    []PassUsage `json:"passes"`
+}
+
+type DurationSummary struct {
+	Count         int     `json:"count"`
+	AvgSeconds    float64 `json:"avg_seconds"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P95Seconds    float64 `json:"p95_seconds"`
+}
+
+type DayDurations struct {
+	Date string `json:"date"`
+	DurationSummary
+}
+
+type LocationDurations struct {
+	Location string `json:"location"`
+	DurationSummary
+}
+
+type DurationStats struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Location  string              `json:"location,omitempty"`
+	Overall   DurationSummary     `json:"overall"`
+	Days      []DayDurations      `json:"days"`
+	Locations []LocationDurations `json:"locations,omitempty"`
+}
+
+type BikeUsage struct {
+	BikeID        string  `json:"bike_id"`
+	Rents         int64   `json:"rents"`
+	RentedMinutes float64 `json:"rented_minutes"`
+	Utilization   float64 `json:"utilization"`
+}
+
+type UtilizationStats struct {
+	From             string      `json:"from"`
+	To               string      `json:"to"`
+	AvailableMinutes float64     `json:"available_minutes"`
+	FleetUtilization float64     `json:"fleet_utilization"`
+	Bikes            []BikeUsage `json:"bikes"`
+}
+
+type LocationUsage struct {
+	Location      string  `json:"location"`
+	Rents         int64   `json:"rents"`
+	RentedMinutes float64 `json:"rented_minutes"`
+}
+
+type TopStats struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Bikes     []BikeUsage     `json:"bikes"`
+	Locations []LocationUsage `json:"locations"`
+}
+
+// HeatmapGrid is indexed by weekday (Monday = 0) and hour of day.
+type HeatmapGrid [7][24]int64
+
+type LocationHeatmap struct {
+	Location string      `json:"location"`
+	Starts   HeatmapGrid `json:"starts"`
+	Ends     HeatmapGrid `json:"ends"`
+	Net      HeatmapGrid `json:"net"`
+}
+
+type Heatmap struct {
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	TimeZone  string            `json:"time_zone"`
+	Weekdays  []string          `json:"weekdays"`
+	Locations []LocationHeatmap `json:"locations"`
+}
+
+// FleetEvent is a live change to the fleet. User IDs are not exposed to
+// dashboard clients.
+type FleetEvent struct {
+	Type     string `json:"type"`
+	BikeID   string `json:"bike_id"`
+	RentID   string `json:"rent_id,omitempty"`
+	Location string `json:"location"`
+	// FromLocation is where a relocated bike was taken from
+	FromLocation string    `json:"from_location,omitempty"`
+	BikeStatus   string    `json:"bike_status,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	// ResumeToken continues the upstream stream after this event
+	ResumeToken string `json:"-"`
+}
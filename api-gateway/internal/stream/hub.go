@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bike-rental/api-gateway/internal/client"
+	"bike-rental/api-gateway/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	initialReconnectDelay = time.Second
+	maxReconnectDelay     = 30 * time.Second
+)
+
+// Hub keeps a single WatchFleet stream to rent-service open and fans its
+// events out to local clients, so the number of dashboards does not affect
+// rent-service.
+type Hub struct {
+	rentClient client.RentClient
+	buffer     int
+
+	done chan struct{}
+	// token is the resume token of the last upstream event; it is only
+	// used by the Run goroutine.
+	token string
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+}
+
+func NewHub(rentClient client.RentClient, buffer int) *Hub {
+	return &Hub{
+		rentClient: rentClient,
+		buffer:     buffer,
+		done:       make(chan struct{}),
+		clients:    make(map[*Client]struct{}),
+	}
+}
+
+// Done is closed when Run returns; clients should disconnect then.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Client receives fleet events for one location (or all when empty).
+// Events that do not fit into the buffer are dropped and counted instead
+// of blocking other clients.
+type Client struct {
+	Events <-chan models.FleetEvent
+
+	ch       chan models.FleetEvent
+	location string
+	dropped  atomic.Int64
+	hub      *Hub
+}
+
+func (h *Hub) Subscribe(location string) *Client {
+	ch := make(chan models.FleetEvent, h.buffer)
+	c := &Client{Events: ch, ch: ch, location: location, hub: h}
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	return c
+}
+
+func (c *Client) Close() {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	delete(c.hub.clients, c)
+}
+
+// TakeDropped returns the number of events dropped since the last call.
+func (c *Client) TakeDropped() int64 {
+	return c.dropped.Swap(0)
+}
+
+// Run keeps the upstream stream open until ctx is done, reconnecting with
+// exponential backoff. Reconnects resume after the last received event, so
+// short outages do not lose events.
+func (h *Hub) Run(ctx context.Context) {
+	defer close(h.done)
+
+	delay := initialReconnectDelay
+	for {
+		started := time.Now()
+		err := h.rentClient.WatchFleet(ctx, "", h.token, h.receive)
+		if ctx.Err() != nil {
+			return
+		}
+		if status.Code(err) == codes.FailedPrecondition {
+			// Events since the token are gone; continue from now on
+			log.Printf("Fleet stream resume token expired, some events were missed")
+			h.token = ""
+		}
+		if time.Since(started) > maxReconnectDelay {
+			delay = initialReconnectDelay
+		}
+		log.Printf("Fleet stream disconnected: %v (reconnecting in %s)", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (h *Hub) receive(event models.FleetEvent) {
+	h.token = event.ResumeToken
+	h.broadcast(event)
+}
+
+func (h *Hub) broadcast(event models.FleetEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		if c.location != "" && c.location != event.Location && c.location != event.FromLocation {
+			continue
+		}
+		select {
+		case c.ch <- event:
+		default:
+			c.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"bike-rental/api-gateway/internal/client"
+	"bike-rental/api-gateway/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeRentClient - отдаёт заранее заданные события и ждёт отмены контекста
+type fakeRentClient struct {
+	client.RentClient
+	events []models.FleetEvent
+}
+
+func (f *fakeRentClient) WatchFleet(ctx context.Context, location, resumeToken string, fn func(models.FleetEvent)) error {
+	for _, event := range f.events {
+		fn(event)
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// HubTestSuite - тестовый набор для раздачи событий парка
+type HubTestSuite struct {
+	suite.Suite
+	hub *Hub
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *HubTestSuite) SetupTest() {
+	suite.hub = NewHub(&fakeRentClient{}, 2)
+}
+
+// TestBroadcast_FiltersByLocation - клиент получает только события своей локации
+func (suite *HubTestSuite) TestBroadcast_FiltersByLocation() {
+	park := suite.hub.Subscribe("Park")
+	all := suite.hub.Subscribe("")
+
+	suite.hub.broadcast(models.FleetEvent{Type: "rent_started", Location: "Center"})
+	suite.hub.broadcast(models.FleetEvent{Type: "rent_ended", Location: "Park"})
+
+	suite.Len(park.Events, 1)
+	suite.Equal("rent_ended", (<-park.Events).Type)
+	suite.Len(all.Events, 2)
+}
+
+// TestBroadcast_DropsForSlowClient - медленный клиент теряет события, но не блокирует остальных
+func (suite *HubTestSuite) TestBroadcast_DropsForSlowClient() {
+	slow := suite.hub.Subscribe("")
+
+	for i := 0; i < 5; i++ {
+		suite.hub.broadcast(models.FleetEvent{Type: "rent_started"})
+	}
+
+	suite.Len(slow.Events, 2)
+	suite.Equal(int64(3), slow.TakeDropped())
+	suite.Equal(int64(0), slow.TakeDropped())
+}
+
+// TestClose_StopsDelivery - после Close события не доставляются
+func (suite *HubTestSuite) TestClose_StopsDelivery() {
+	c := suite.hub.Subscribe("")
+	c.Close()
+
+	suite.hub.broadcast(models.FleetEvent{Type: "bike_added"})
+
+	suite.Len(c.Events, 0)
+}
+
+// TestRun_ForwardsUpstreamEvents - события из rent-service доходят до клиентов
+func (suite *HubTestSuite) TestRun_ForwardsUpstreamEvents() {
+	hub := NewHub(&fakeRentClient{events: []models.FleetEvent{{Type: "bike_added", Location: "Park"}}}, 2)
+	c := hub.Subscribe("Park")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hub.Run(ctx)
+		close(done)
+	}()
+
+	suite.Equal("bike_added", (<-c.Events).Type)
+	cancel()
+	<-done
+}
+
+// TestReceive_TracksResumeToken - хаб запоминает токен последнего события для переподключения
+func (suite *HubTestSuite) TestReceive_TracksResumeToken() {
+	suite.hub.receive(models.FleetEvent{Type: "bike_added", ResumeToken: "e.1"})
+	suite.hub.receive(models.FleetEvent{Type: "bike_removed", ResumeToken: "e.2"})
+
+	suite.Equal("e.2", suite.hub.token)
+}
+
+func TestHubTestSuite(t *testing.T) {
+	suite.Run(t, new(HubTestSuite))
+}
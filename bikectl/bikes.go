@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"bike-rental/rent-service/proto/rent"
+)
+
+type bike struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Location string `json:"location"`
+}
+
+func printBikes(p printer, bikes []bike) error {
+	rows := make([][]string, 0, len(bikes))
+	for _, b := range bikes {
+		rows = append(rows, []string{b.ID, b.Name, b.Status, b.Location})
+	}
+	return p.print(bikes, []string{"ID", "NAME", "STATUS", "LOCATION"}, rows)
+}
+
+// bikesList lists the whole fleet, whatever the status, unlike the
+// public available bikes endpoint.
+func (c *cli) bikesList(args []string) error {
+	fs := flag.NewFlagSet("bikes list", flag.ContinueOnError)
+	location := fs.String("location", "", "only bikes at this location")
+	status := fs.String("status", "", "only bikes with this status")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	stream, err := client.ExportBikes(ctx, &rent.ExportBikesRequest{Location: *location})
+	if err != nil {
+		return err
+	}
+
+	bikes := []bike{}
+	for {
+		b, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if *status != "" && b.Status != *status {
+			continue
+		}
+		bikes = append(bikes, bike{ID: b.Id, Name: b.Name, Status: b.Status, Location: b.Location})
+	}
+
+	return printBikes(c.out, bikes)
+}
+
+func (c *cli) bikesAdd(args []string) error {
+	fs := flag.NewFlagSet("bikes add", flag.ContinueOnError)
+	name := fs.String("name", "", "bike name")
+	location := fs.String("location", "", "bike location")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" || *location == "" {
+		return fmt.Errorf("%w: bikes add requires -name and -location", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.AddBike(ctx, &rent.AddBikeRequest{Name: *name, Location: *location})
+	if err != nil {
+		return err
+	}
+	// AddBike reports failures in the message rather than as a status.
+	if resp.Id == "" {
+		return errors.New(resp.Message)
+	}
+
+	return printBikes(c.out, []bike{{ID: resp.Id, Name: resp.Name, Status: resp.Status, Location: resp.Location}})
+}
+
+func (c *cli) bikesRemove(args []string) error {
+	fs := flag.NewFlagSet("bikes remove", flag.ContinueOnError)
+	rest, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.DeleteBike(ctx, &rent.DeleteBikeRequest{BikeId: rest[0]})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+
+	return c.out.print(
+		map[string]string{"id": rest[0], "message": resp.Message},
+		[]string{"ID", "MESSAGE"},
+		[][]string{{rest[0], resp.Message}},
+	)
+}
+
+// bikesReset frees a bike left in a wrong status, e.g. rented without an
+// active rent after a manual database fix.
+func (c *cli) bikesReset(args []string) error {
+	fs := flag.NewFlagSet("bikes reset", flag.ContinueOnError)
+	status := fs.String("status", "available", "new status: available or maintenance")
+	reason := fs.String("reason", "", "why the status is changed (required)")
+	rest, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return fmt.Errorf("%w: bikes reset requires -reason", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	b, err := client.SetBikeStatus(ctx, &rent.SetBikeStatusRequest{
+		BikeId: rest[0],
+		Status: *status,
+		Reason: *reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return printBikes(c.out, []bike{{ID: b.Id, Name: b.Name, Status: b.Status, Location: b.Location}})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"bike-rental/rent-service/proto/rent"
+)
+
+// eventsReplay republishes rent events for the days from..to inclusive,
+// typically after stats were cleared with quick-cleanup.
+func (c *cli) eventsReplay(args []string) error {
+	fs := flag.NewFlagSet("events replay", flag.ContinueOnError)
+	fromFlag := fs.String("from", "", "first day, YYYY-MM-DD")
+	toFlag := fs.String("to", "", "last day, YYYY-MM-DD")
+	dryRun := fs.Bool("dry-run", false, "only count the events")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *fromFlag == "" || *toFlag == "" {
+		return fmt.Errorf("%w: events replay requires -from and -to", errUsage)
+	}
+	from, err := parseDay("from", *fromFlag)
+	if err != nil {
+		return err
+	}
+	to, err := parseDay("to", *toFlag)
+	if err != nil {
+		return err
+	}
+	if to.Before(from) {
+		return fmt.Errorf("%w: -to is before -from", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.ReplayRentEvents(ctx, &rent.ReplayRentEventsRequest{
+		From:   from.Unix(),
+		To:     to.AddDate(0, 0, 1).Unix(),
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		Events int32 `json:"events"`
+		DryRun bool  `json:"dry_run"`
+	}{resp.Events, *dryRun}
+	return c.out.print(result,
+		[]string{"EVENTS", "DRY RUN"},
+		[][]string{{strconv.Itoa(int(resp.Events)), strconv.FormatBool(*dryRun)}},
+	)
+}
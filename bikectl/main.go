@@ -0,0 +1,264 @@
+// Command bikectl is the operator CLI for the bike rental services. It
+// talks to rent-service over gRPC and to stats-service over HTTP, using the
+// same configuration file and BIKE_* overrides as the services:
+//
+//	bikectl rents list -status active -older-than 24h
+//	bikectl rents end -reason "lost bike" <rent-id>
+//	bikectl -o json bikes list -location Center
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/proto/rent"
+	"bike-rental/tlsutil"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const usage = `usage: bikectl [flags] <command> <subcommand> [args]
+
+Commands:
+  bikes list [-location L] [-status S]
+  bikes add -name N -location L
+  bikes remove <bike-id>
+  bikes reset -reason R [-status available|maintenance] <bike-id>
+  rents list [-status S] [-user U] [-bike B] [-older-than D] [-limit N]
+  rents end -reason R <rent-id>
+  rents dispute -reason R <rent-id>
+  rents timeline <rent-id>
+  rents rebuild [-rent ID]
+  rents refund -reason R [-amount N] <rent-id>
+  stats active
+  stats daily [-date YYYY-MM-DD]
+  stats range -from YYYY-MM-DD -to YYYY-MM-DD [-granularity day|week|month]
+  events replay -from YYYY-MM-DD -to YYYY-MM-DD [-dry-run]
+  audit list [-actor A] [-action RPC] [-target ID] [-request ID] [-since D] [-limit N]
+  rebalance plan [-capacity N] [-trucks N] [-horizon D]
+  rebalance apply -f plan.json|-
+
+Flags:
+`
+
+// errUsage is returned for bad arguments; run prints the usage and exits
+// with code 2 like the flag package does.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// cli holds the connections and output settings shared by all commands.
+type cli struct {
+	out    printer
+	stdin  io.Reader
+	stderr io.Writer
+
+	timeout  time.Duration
+	actor    string
+	statsURL string
+	http     *http.Client
+
+	// rent is dialed on first use so stats commands work without
+	// rent-service.
+	rent    rent.RentServiceClient
+	dial    func() (rent.RentServiceClient, error)
+	closers []func() error
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.LoadConfig(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("bikectl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	output := fs.String("o", "table", "output format: table or json")
+	rentAddr := fs.String("rent-service", cfg.Services.RentService, "rent-service gRPC address")
+	statsURL := fs.String("stats-service", cfg.Services.StatsService, "stats-service base URL")
+	timeout := fs.Duration("timeout", 30*time.Second, "deadline for each request")
+	actor := fs.String("actor", defaultActor(), "name recorded in the rent-service audit log")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	out, err := newPrinter(*output, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	c := &cli{
+		out:      out,
+		stdin:    os.Stdin,
+		stderr:   stderr,
+		timeout:  *timeout,
+		actor:    *actor,
+		statsURL: *statsURL,
+		http:     &http.Client{},
+	}
+	c.dial = func() (rent.RentServiceClient, error) {
+		creds, err := tlsutil.ClientCredentials(cfg.TLS.GRPC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gRPC TLS credentials: %w", err)
+		}
+		conn, err := grpc.NewClient(*rentAddr, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to rent-service: %w", err)
+		}
+		c.closers = append(c.closers, conn.Close)
+		return rent.NewRentServiceClient(conn), nil
+	}
+	defer c.close()
+
+	err = c.dispatch(fs.Args())
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return 2
+	default:
+		if s, ok := status.FromError(err); ok {
+			fmt.Fprintf(stderr, "error: %s\n", s.Message())
+		} else {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+		}
+		return 1
+	}
+}
+
+func (c *cli) dispatch(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%w: a command and subcommand are required", errUsage)
+	}
+
+	commands := map[string]map[string]func([]string) error{
+		"bikes": {
+			"list":   c.bikesList,
+			"add":    c.bikesAdd,
+			"remove": c.bikesRemove,
+			"reset":  c.bikesReset,
+		},
+		"rents": {
+			"list":     c.rentsList,
+			"end":      c.rentsEnd,
+			"dispute":  c.rentsDispute,
+			"timeline": c.rentsTimeline,
+			"rebuild":  c.rentsRebuild,
+			"refund":   c.rentsRefund,
+		},
+		"stats": {
+			"active": c.statsActive,
+			"daily":  c.statsDaily,
+			"range":  c.statsRange,
+		},
+		"events": {
+			"replay": c.eventsReplay,
+		},
+		"audit": {
+			"list": c.auditList,
+		},
+		"rebalance": {
+			"plan":  c.rebalancePlan,
+			"apply": c.rebalanceApply,
+		},
+	}
+
+	sub, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+	cmd, ok := sub[args[1]]
+	if !ok {
+		return fmt.Errorf("%w: unknown %s subcommand %q", errUsage, args[0], args[1])
+	}
+	return cmd(args[2:])
+}
+
+func (c *cli) rentClient() (rent.RentServiceClient, error) {
+	if c.rent == nil {
+		client, err := c.dial()
+		if err != nil {
+			return nil, err
+		}
+		c.rent = client
+	}
+	return c.rent, nil
+}
+
+// context returns a request context carrying the actor and a fresh request
+// ID for the rent-service audit log.
+func (c *cli) context() (context.Context, context.CancelFunc) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-actor", c.actor,
+		"x-request-id", uuid.NewString(),
+	)
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// defaultActor names the operator running bikectl.
+func defaultActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return "bikectl:" + u.Username
+	}
+	return "bikectl"
+}
+
+func (c *cli) close() {
+	for _, closer := range c.closers {
+		closer()
+	}
+}
+
+// parseFlags parses subcommand flags, reporting problems as errUsage.
+// Flags may appear before or after the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", errUsage, fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+
+	if len(rest) != positional {
+		return nil, fmt.Errorf("%w: %s takes %d argument(s), got %d", errUsage, fs.Name(), positional, len(rest))
+	}
+	return rest, nil
+}
+
+// parseDay parses a YYYY-MM-DD flag value as midnight UTC.
+func parseDay(name, value string) (time.Time, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: -%s must be a YYYY-MM-DD date, got %q", errUsage, name, value)
+	}
+	return t, nil
+}
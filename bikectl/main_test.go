@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bike-rental/rent-service/proto/rent"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRentClient - отдаёт заданный парк и запоминает запросы операторских RPC
+type fakeRentClient struct {
+	rent.RentServiceClient
+	bikes      []*rent.Bike
+	listReq    *rent.ListRentsRequest
+	endReq     *rent.ForceEndRentRequest
+	disputeReq *rent.DisputeRentRequest
+	replayReq  *rent.ReplayRentEventsRequest
+	refundReq  *rent.RefundRentRequest
+	timeline   *rent.RentTimeline
+	planReq    *rent.PlanRebalanceRequest
+	plan       *rent.RebalancePlan
+	moves      []*rent.BikeMove
+}
+
+type fakeBikeStream struct {
+	grpc.ServerStreamingClient[rent.Bike]
+	bikes []*rent.Bike
+}
+
+func (s *fakeBikeStream) Recv() (*rent.Bike, error) {
+	if len(s.bikes) == 0 {
+		return nil, io.EOF
+	}
+	b := s.bikes[0]
+	s.bikes = s.bikes[1:]
+	return b, nil
+}
+
+func (f *fakeRentClient) ExportBikes(ctx context.Context, in *rent.ExportBikesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[rent.Bike], error) {
+	return &fakeBikeStream{bikes: f.bikes}, nil
+}
+
+func (f *fakeRentClient) ListRents(ctx context.Context, in *rent.ListRentsRequest, opts ...grpc.CallOption) (*rent.RentsList, error) {
+	f.listReq = in
+	return &rent.RentsList{}, nil
+}
+
+func (f *fakeRentClient) ForceEndRent(ctx context.Context, in *rent.ForceEndRentRequest, opts ...grpc.CallOption) (*rent.RentResponse, error) {
+	f.endReq = in
+	if in.RentId == "missing" {
+		return nil, status.Error(codes.NotFound, "rent not found")
+	}
+	return &rent.RentResponse{RentId: in.RentId, UserId: "user-1", BikeId: "bike-1", Status: "force_ended", StartTime: 1700000000, EndTime: 1700003600}, nil
+}
+
+func (f *fakeRentClient) DisputeRent(ctx context.Context, in *rent.DisputeRentRequest, opts ...grpc.CallOption) (*rent.RentResponse, error) {
+	f.disputeReq = in
+	return &rent.RentResponse{RentId: in.RentId, UserId: "user-1", BikeId: "bike-1", Status: "disputed", StartTime: 1700000000, EndTime: 1700003600}, nil
+}
+
+func (f *fakeRentClient) GetRentTimeline(ctx context.Context, in *rent.GetRentTimelineRequest, opts ...grpc.CallOption) (*rent.RentTimeline, error) {
+	return f.timeline, nil
+}
+
+func (f *fakeRentClient) ReplayRentEvents(ctx context.Context, in *rent.ReplayRentEventsRequest, opts ...grpc.CallOption) (*rent.ReplayRentEventsResponse, error) {
+	f.replayReq = in
+	return &rent.ReplayRentEventsResponse{Events: 42}, nil
+}
+
+func (f *fakeRentClient) RefundRent(ctx context.Context, in *rent.RefundRentRequest, opts ...grpc.CallOption) (*rent.Wallet, error) {
+	f.refundReq = in
+	return &rent.Wallet{UserId: "user-1", Balance: 12000, Available: 12000, Currency: "RUB"}, nil
+}
+
+func (f *fakeRentClient) PlanRebalance(ctx context.Context, in *rent.PlanRebalanceRequest, opts ...grpc.CallOption) (*rent.RebalancePlan, error) {
+	f.planReq = in
+	return f.plan, nil
+}
+
+func (f *fakeRentClient) RelocateBikes(ctx context.Context, in *rent.RelocateBikesRequest, opts ...grpc.CallOption) (*rent.RelocateBikesResponse, error) {
+	f.moves = in.Moves
+	resp := &rent.RelocateBikesResponse{}
+	for _, m := range in.Moves {
+		resp.Relocations = append(resp.Relocations, &rent.BikeRelocation{Move: m, Moved: m.Count - 1})
+		resp.Moved += m.Count - 1
+	}
+	return resp, nil
+}
+
+// CLITestSuite - тестовый набор для команд bikectl
+type CLITestSuite struct {
+	suite.Suite
+	rentClient *fakeRentClient
+	stdout     *bytes.Buffer
+	stderr     *bytes.Buffer
+	cli        *cli
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *CLITestSuite) SetupTest() {
+	suite.rentClient = &fakeRentClient{}
+	suite.stdout = &bytes.Buffer{}
+	suite.stderr = &bytes.Buffer{}
+	suite.cli = &cli{
+		out:     printer{w: suite.stdout},
+		stdin:   strings.NewReader(""),
+		stderr:  suite.stderr,
+		timeout: time.Second,
+		http:    http.DefaultClient,
+		rent:    suite.rentClient,
+	}
+}
+
+// TestBikesList_FiltersByStatusAsTable - фильтр по статусу применяется к выгрузке парка
+func (suite *CLITestSuite) TestBikesList_FiltersByStatusAsTable() {
+	suite.rentClient.bikes = []*rent.Bike{
+		{Id: "b1", Name: "Bike 1", Status: "rented", Location: "Center"},
+		{Id: "b2", Name: "Bike 2", Status: "available", Location: "Park"},
+	}
+
+	err := suite.cli.dispatch([]string{"bikes", "list", "-status", "rented"})
+
+	suite.NoError(err)
+	suite.Equal("ID  NAME    STATUS  LOCATION\nb1  Bike 1  rented  Center\n", suite.stdout.String())
+}
+
+// TestRentsEnd_RequiresReason - принудительное завершение без причины не отправляется
+func (suite *CLITestSuite) TestRentsEnd_RequiresReason() {
+	err := suite.cli.dispatch([]string{"rents", "end", "rent-1"})
+
+	suite.ErrorIs(err, errUsage)
+	suite.Nil(suite.rentClient.endReq)
+}
+
+// TestRentsEnd_JSON - флаги после позиционного аргумента тоже разбираются
+func (suite *CLITestSuite) TestRentsEnd_JSON() {
+	suite.cli.out.json = true
+
+	err := suite.cli.dispatch([]string{"rents", "end", "rent-1", "-reason", "lost bike"})
+
+	suite.NoError(err)
+	suite.Equal("lost bike", suite.rentClient.endReq.Reason)
+	var got rentInfo
+	suite.Require().NoError(json.Unmarshal(suite.stdout.Bytes(), &got))
+	suite.Equal("force_ended", got.Status)
+	suite.Equal(int64(1700003600), got.EndTime)
+}
+
+// TestRentsEnd_NotFound - ошибка gRPC возвращается как есть
+func (suite *CLITestSuite) TestRentsEnd_NotFound() {
+	err := suite.cli.dispatch([]string{"rents", "end", "-reason", "x", "missing"})
+
+	suite.Equal(codes.NotFound, status.Code(err))
+}
+
+// TestRentsDispute - причина спора обязательна и передаётся в DisputeRent
+func (suite *CLITestSuite) TestRentsDispute() {
+	suite.ErrorIs(suite.cli.dispatch([]string{"rents", "dispute", "rent-1"}), errUsage)
+	suite.Nil(suite.rentClient.disputeReq)
+
+	err := suite.cli.dispatch([]string{"rents", "dispute", "-reason", "charged twice", "rent-1"})
+
+	suite.NoError(err)
+	suite.Equal(&rent.DisputeRentRequest{RentId: "rent-1", Reason: "charged twice"}, suite.rentClient.disputeReq)
+	suite.Contains(suite.stdout.String(), "disputed")
+}
+
+// TestRentsRefund - сумма и причина возврата передаются в RefundRent
+func (suite *CLITestSuite) TestRentsRefund() {
+	err := suite.cli.dispatch([]string{"rents", "refund", "-amount", "2500", "-reason", "broken brakes", "rent-1"})
+
+	suite.NoError(err)
+	suite.Equal(&rent.RefundRentRequest{RentId: "rent-1", Amount: 2500, Reason: "broken brakes"}, suite.rentClient.refundReq)
+	suite.Equal("USER    BALANCE  HELD  AVAILABLE  CURRENCY\nuser-1  12000    0     12000      RUB\n", suite.stdout.String())
+}
+
+// TestRentsRefund_RequiresReason - возврат без причины не отправляется
+func (suite *CLITestSuite) TestRentsRefund_RequiresReason() {
+	err := suite.cli.dispatch([]string{"rents", "refund", "rent-1"})
+
+	suite.ErrorIs(err, errUsage)
+	suite.Nil(suite.rentClient.refundReq)
+}
+
+// TestRentsList_OlderThan - -older-than превращается в верхнюю границу времени начала
+func (suite *CLITestSuite) TestRentsList_OlderThan() {
+	before := time.Now().Add(-time.Hour).Unix()
+
+	err := suite.cli.dispatch([]string{"rents", "list", "-status", "active", "-older-than", "1h"})
+
+	suite.NoError(err)
+	suite.Equal("active", suite.rentClient.listReq.Status)
+	suite.InDelta(before, suite.rentClient.listReq.StartedBefore, 1)
+}
+
+// TestRentsTimeline - история аренды выводится таблицей по порядку событий
+func (suite *CLITestSuite) TestRentsTimeline() {
+	suite.rentClient.timeline = &rent.RentTimeline{
+		RentId: "rent-1",
+		Status: "force_ended",
+		Events: []*rent.RentTimelineEvent{
+			{Seq: 1, Type: "started", UserId: "u1", BikeId: "b1", Timestamp: 1700000000},
+			{Seq: 2, Type: "force_ended", UserId: "u1", BikeId: "b1", Timestamp: 1700003600, Reason: "lost"},
+		},
+	}
+
+	err := suite.cli.dispatch([]string{"rents", "timeline", "rent-1"})
+
+	suite.NoError(err)
+	lines := strings.Split(strings.TrimSpace(suite.stdout.String()), "\n")
+	suite.Require().Len(lines, 3)
+	suite.Contains(lines[0], "EVENT")
+	suite.Contains(lines[1], "started")
+	suite.Contains(lines[2], "force_ended")
+	suite.Contains(lines[2], "lost")
+}
+
+// TestEventsReplay_IncludesLastDay - диапазон дат включает последний день целиком
+func (suite *CLITestSuite) TestEventsReplay_IncludesLastDay() {
+	err := suite.cli.dispatch([]string{"events", "replay", "-from", "2024-01-01", "-to", "2024-01-02", "-dry-run"})
+
+	suite.NoError(err)
+	suite.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), suite.rentClient.replayReq.From)
+	suite.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC).Unix(), suite.rentClient.replayReq.To)
+	suite.True(suite.rentClient.replayReq.DryRun)
+}
+
+// TestStatsDaily - статистика запрашивается у stats-service по HTTP
+func (suite *CLITestSuite) TestStatsDaily() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/internal/stats/daily", r.URL.Path)
+		suite.Equal("2024-01-15", r.URL.Query().Get("date"))
+		w.Write([]byte(`{"date":"2024-01-15","count":7}`))
+	}))
+	defer srv.Close()
+	suite.cli.statsURL = srv.URL
+
+	err := suite.cli.dispatch([]string{"stats", "daily", "-date", "2024-01-15"})
+
+	suite.NoError(err)
+	suite.Equal("DATE        RENTS\n2024-01-15  7\n", suite.stdout.String())
+}
+
+// TestStatsDaily_ServerError - ответ stats-service с ошибкой попадает в сообщение
+func (suite *CLITestSuite) TestStatsDaily_ServerError() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	suite.cli.statsURL = srv.URL
+
+	err := suite.cli.dispatch([]string{"stats", "daily", "-date", "bad"})
+
+	suite.ErrorContains(err, "invalid date")
+}
+
+// TestRebalancePlan - таблица остатков и перемещений, предупреждение без истории спроса
+func (suite *CLITestSuite) TestRebalancePlan() {
+	suite.rentClient.plan = &rent.RebalancePlan{
+		HorizonSeconds: 7200,
+		DemandError:    "stats service returned 503",
+		Locations: []*rent.LocationBalance{
+			{Location: "Center", Available: 0, Target: 2, After: 2},
+			{Location: "Park", Available: 6, Target: 2, After: 4},
+		},
+		Moves: []*rent.BikeMove{{From: "Park", To: "Center", Count: 2}},
+	}
+
+	err := suite.cli.dispatch([]string{"rebalance", "plan", "-trucks", "2", "-horizon", "2h"})
+
+	suite.NoError(err)
+	suite.Equal(int32(2), suite.rentClient.planReq.Trucks)
+	suite.Equal(int64(7200), suite.rentClient.planReq.HorizonSeconds)
+	suite.Equal("LOCATION  AVAILABLE  STARTS  ENDS  TARGET  AFTER\n"+
+		"Center    0          0.0     0.0   2       2\n"+
+		"Park      6          0.0     0.0   2       4\n"+
+		"\n"+
+		"FROM  TO      COUNT\n"+
+		"Park  Center  2\n", suite.stdout.String())
+	suite.Contains(suite.stderr.String(), "stats service returned 503")
+}
+
+// TestRebalanceApply - план в JSON читается со стандартного ввода
+func (suite *CLITestSuite) TestRebalanceApply() {
+	suite.cli.stdin = strings.NewReader(`{"horizon":"2h0m0s","moves":[{"from":"Park","to":"Center","count":2}]}`)
+
+	err := suite.cli.dispatch([]string{"rebalance", "apply", "-f", "-"})
+
+	suite.NoError(err)
+	suite.Require().Len(suite.rentClient.moves, 1)
+	suite.Equal("Center", suite.rentClient.moves[0].To)
+	suite.Equal("FROM  TO      COUNT  MOVED\nPark  Center  2      1\n", suite.stdout.String())
+}
+
+// TestRebalanceApply_EmptyPlan - план без перемещений не отправляется
+func (suite *CLITestSuite) TestRebalanceApply_EmptyPlan() {
+	suite.cli.stdin = strings.NewReader(`{"moves":[]}`)
+
+	err := suite.cli.dispatch([]string{"rebalance", "apply", "-f", "-"})
+
+	suite.ErrorContains(err, "no moves")
+	suite.Nil(suite.rentClient.moves)
+}
+
+// TestContext_CarriesActor - каждый запрос несёт актора и новый ID запроса для журнала аудита
+func (suite *CLITestSuite) TestContext_CarriesActor() {
+	suite.cli.actor = "bikectl:alice"
+
+	ctx, cancel := suite.cli.context()
+	defer cancel()
+	other, cancelOther := suite.cli.context()
+	defer cancelOther()
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	otherMD, _ := metadata.FromOutgoingContext(other)
+	suite.Equal([]string{"bikectl:alice"}, md.Get("x-actor"))
+	suite.Len(md.Get("x-request-id"), 1)
+	suite.NotEqual(md.Get("x-request-id"), otherMD.Get("x-request-id"))
+}
+
+// TestDispatch_UnknownCommand - неизвестная команда считается ошибкой использования
+func (suite *CLITestSuite) TestDispatch_UnknownCommand() {
+	err := suite.cli.dispatch([]string{"bikes", "paint"})
+
+	suite.True(errors.Is(err, errUsage))
+}
+
+// TestCLITestSuite - запуск тестового набора
+func TestCLITestSuite(t *testing.T) {
+	suite.Run(t, new(CLITestSuite))
+}
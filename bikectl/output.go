@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printer writes command results either as an aligned table for people or
+// as indented JSON for scripts.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case "table":
+		return printer{w: w}, nil
+	case "json":
+		return printer{w: w, json: true}, nil
+	default:
+		return printer{}, fmt.Errorf("unknown output format %q, expected table or json", format)
+	}
+}
+
+// print writes v as JSON, or header and rows as a table.
+func (p printer) print(v interface{}, header []string, rows [][]string) error {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// formatUnix renders a unix timestamp for tables, leaving zero blank.
+func formatUnix(sec int64) string {
+	if sec == 0 {
+		return ""
+	}
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"bike-rental/rent-service/proto/rent"
+)
+
+type bikeMove struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+type locationBalance struct {
+	Location       string  `json:"location"`
+	Available      int     `json:"available"`
+	ExpectedStarts float64 `json:"expected_starts"`
+	ExpectedEnds   float64 `json:"expected_ends"`
+	Target         int     `json:"target"`
+	After          int     `json:"after"`
+}
+
+// rebalancePlan has the shape of the gateway's plan, so either can be fed
+// to rebalance apply or POST /api/v1/admin/bikes/relocate.
+type rebalancePlan struct {
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Horizon       string            `json:"horizon"`
+	TruckCapacity int               `json:"truck_capacity"`
+	Trucks        int               `json:"trucks"`
+	HistoryFrom   string            `json:"history_from,omitempty"`
+	HistoryTo     string            `json:"history_to,omitempty"`
+	DemandError   string            `json:"demand_error,omitempty"`
+	Locations     []locationBalance `json:"locations"`
+	Moves         []bikeMove        `json:"moves"`
+}
+
+type bikeRelocation struct {
+	bikeMove
+	Moved   int      `json:"moved"`
+	BikeIDs []string `json:"bike_ids"`
+}
+
+// rebalancePlan recommends moves without applying them; save it with
+// -o json to review and apply it later.
+func (c *cli) rebalancePlan(args []string) error {
+	fs := flag.NewFlagSet("rebalance plan", flag.ContinueOnError)
+	capacity := fs.Int("capacity", 0, "bikes per truck (default rent.rebalance.truck_capacity)")
+	trucks := fs.Int("trucks", 0, "number of moves (default rent.rebalance.trucks)")
+	horizon := fs.Duration("horizon", 0, "how far ahead to plan, at most 24h (default rent.rebalance.horizon)")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *capacity < 0 || *trucks < 0 || *horizon < 0 {
+		return fmt.Errorf("%w: rebalance plan takes positive -capacity, -trucks and -horizon", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.PlanRebalance(ctx, &rent.PlanRebalanceRequest{
+		TruckCapacity:  int32(*capacity),
+		Trucks:         int32(*trucks),
+		HorizonSeconds: int64(horizon.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	plan := rebalancePlan{
+		GeneratedAt:   time.Unix(resp.GeneratedAt, 0).UTC(),
+		Horizon:       (time.Duration(resp.HorizonSeconds) * time.Second).String(),
+		TruckCapacity: int(resp.TruckCapacity),
+		Trucks:        int(resp.Trucks),
+		HistoryFrom:   resp.HistoryFrom,
+		HistoryTo:     resp.HistoryTo,
+		DemandError:   resp.DemandError,
+		Locations:     make([]locationBalance, 0, len(resp.Locations)),
+		Moves:         make([]bikeMove, 0, len(resp.Moves)),
+	}
+	locationRows := make([][]string, 0, len(resp.Locations))
+	for _, l := range resp.Locations {
+		plan.Locations = append(plan.Locations, locationBalance{
+			Location:       l.Location,
+			Available:      int(l.Available),
+			ExpectedStarts: l.ExpectedStarts,
+			ExpectedEnds:   l.ExpectedEnds,
+			Target:         int(l.Target),
+			After:          int(l.After),
+		})
+		locationRows = append(locationRows, []string{
+			l.Location,
+			strconv.Itoa(int(l.Available)),
+			strconv.FormatFloat(l.ExpectedStarts, 'f', 1, 64),
+			strconv.FormatFloat(l.ExpectedEnds, 'f', 1, 64),
+			strconv.Itoa(int(l.Target)),
+			strconv.Itoa(int(l.After)),
+		})
+	}
+	moveRows := make([][]string, 0, len(resp.Moves))
+	for _, m := range resp.Moves {
+		plan.Moves = append(plan.Moves, bikeMove{From: m.From, To: m.To, Count: int(m.Count)})
+		moveRows = append(moveRows, []string{m.From, m.To, strconv.Itoa(int(m.Count))})
+	}
+
+	if plan.DemandError != "" {
+		fmt.Fprintf(c.stderr, "warning: demand history unavailable, targets are the minimum: %s\n", plan.DemandError)
+	}
+	if c.out.json {
+		return c.out.print(plan, nil, nil)
+	}
+	if err := c.out.print(nil, []string{"LOCATION", "AVAILABLE", "STARTS", "ENDS", "TARGET", "AFTER"}, locationRows); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out.w)
+	return c.out.print(nil, []string{"FROM", "TO", "COUNT"}, moveRows)
+}
+
+// rebalanceApply relocates bikes by the moves of a saved plan. Moves are
+// applied in order; a failed move stops the rest.
+func (c *cli) rebalanceApply(args []string) error {
+	fs := flag.NewFlagSet("rebalance apply", flag.ContinueOnError)
+	file := fs.String("f", "", "plan JSON from bikectl -o json rebalance plan, - for stdin (required)")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("%w: rebalance apply requires -f", errUsage)
+	}
+
+	var r io.Reader = c.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var plan rebalancePlan
+	if err := json.NewDecoder(r).Decode(&plan); err != nil {
+		return fmt.Errorf("failed to read plan: %w", err)
+	}
+	if len(plan.Moves) == 0 {
+		return errors.New("the plan has no moves")
+	}
+
+	req := &rent.RelocateBikesRequest{Moves: make([]*rent.BikeMove, 0, len(plan.Moves))}
+	for _, m := range plan.Moves {
+		req.Moves = append(req.Moves, &rent.BikeMove{From: m.From, To: m.To, Count: int32(m.Count)})
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.RelocateBikes(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	relocations := make([]bikeRelocation, 0, len(resp.Relocations))
+	rows := make([][]string, 0, len(resp.Relocations))
+	for _, rel := range resp.Relocations {
+		move := bikeMove{From: rel.Move.GetFrom(), To: rel.Move.GetTo(), Count: int(rel.Move.GetCount())}
+		relocations = append(relocations, bikeRelocation{bikeMove: move, Moved: int(rel.Moved), BikeIDs: rel.BikeIds})
+		rows = append(rows, []string{move.From, move.To, strconv.Itoa(move.Count), strconv.Itoa(int(rel.Moved))})
+	}
+
+	result := struct {
+		Relocations []bikeRelocation `json:"relocations"`
+		Moved       int              `json:"moved"`
+	}{relocations, int(resp.Moved)}
+	return c.out.print(result, []string{"FROM", "TO", "COUNT", "MOVED"}, rows)
+}
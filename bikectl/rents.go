@@ -0,0 +1,273 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"bike-rental/rent-service/proto/rent"
+)
+
+type rentInfo struct {
+	RentID    string `json:"rent_id"`
+	UserID    string `json:"user_id"`
+	BikeID    string `json:"bike_id"`
+	Location  string `json:"location"`
+	Status    string `json:"status"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time,omitempty"`
+}
+
+func (c *cli) rentsList(args []string) error {
+	fs := flag.NewFlagSet("rents list", flag.ContinueOnError)
+	status := fs.String("status", "", "active, paused, completed or force_ended")
+	user := fs.String("user", "", "only rents of this user")
+	bikeID := fs.String("bike", "", "only rents of this bike")
+	olderThan := fs.Duration("older-than", 0, "only rents started at least this long ago, e.g. 24h")
+	limit := fs.Int("limit", 0, "maximum number of rents (server default 100)")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	req := &rent.ListRentsRequest{
+		Status: *status,
+		UserId: *user,
+		BikeId: *bikeID,
+		Limit:  int32(*limit),
+	}
+	if *olderThan > 0 {
+		req.StartedBefore = time.Now().Add(-*olderThan).Unix()
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.ListRents(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	rents := make([]rentInfo, 0, len(resp.Rents))
+	rows := make([][]string, 0, len(resp.Rents))
+	for _, r := range resp.Rents {
+		rents = append(rents, rentInfo{
+			RentID:    r.RentId,
+			UserID:    r.UserId,
+			BikeID:    r.BikeId,
+			Location:  r.Location,
+			Status:    r.Status,
+			StartTime: r.StartTime,
+			EndTime:   r.EndTime,
+		})
+		rows = append(rows, []string{
+			r.RentId, r.UserId, r.BikeId, r.Location, r.Status,
+			formatUnix(r.StartTime), formatUnix(r.EndTime),
+		})
+	}
+
+	return c.out.print(rents, []string{"RENT", "USER", "BIKE", "LOCATION", "STATUS", "STARTED", "ENDED"}, rows)
+}
+
+func (c *cli) rentsEnd(args []string) error {
+	fs := flag.NewFlagSet("rents end", flag.ContinueOnError)
+	reason := fs.String("reason", "", "why the rent is ended (required)")
+	rest, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return fmt.Errorf("%w: rents end requires -reason", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.ForceEndRent(ctx, &rent.ForceEndRentRequest{RentId: rest[0], Reason: *reason})
+	if err != nil {
+		return err
+	}
+	return c.printRent(resp)
+}
+
+// rentsDispute marks an ended rent as disputed, e.g. when the rider
+// contests the fare.
+func (c *cli) rentsDispute(args []string) error {
+	fs := flag.NewFlagSet("rents dispute", flag.ContinueOnError)
+	reason := fs.String("reason", "", "what the rider disputes (required)")
+	rest, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return fmt.Errorf("%w: rents dispute requires -reason", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.DisputeRent(ctx, &rent.DisputeRentRequest{RentId: rest[0], Reason: *reason})
+	if err != nil {
+		return err
+	}
+	return c.printRent(resp)
+}
+
+func (c *cli) printRent(resp *rent.RentResponse) error {
+	r := rentInfo{
+		RentID:    resp.RentId,
+		UserID:    resp.UserId,
+		BikeID:    resp.BikeId,
+		Status:    resp.Status,
+		StartTime: resp.StartTime,
+		EndTime:   resp.EndTime,
+	}
+	return c.out.print(r,
+		[]string{"RENT", "USER", "BIKE", "STATUS", "STARTED", "ENDED"},
+		[][]string{{r.RentID, r.UserID, r.BikeID, r.Status, formatUnix(r.StartTime), formatUnix(r.EndTime)}},
+	)
+}
+
+// rentsRefund credits part or all of a rent's fare back to the rider's
+// wallet.
+func (c *cli) rentsRefund(args []string) error {
+	fs := flag.NewFlagSet("rents refund", flag.ContinueOnError)
+	amount := fs.Int64("amount", 0, "amount in minor units; default everything not refunded yet")
+	reason := fs.String("reason", "", "why the fare is refunded (required)")
+	rest, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return fmt.Errorf("%w: rents refund requires -reason", errUsage)
+	}
+	if *amount < 0 {
+		return fmt.Errorf("%w: -amount must not be negative", errUsage)
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.RefundRent(ctx, &rent.RefundRentRequest{RentId: rest[0], Amount: *amount, Reason: *reason})
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		UserID    string `json:"user_id"`
+		Balance   int64  `json:"balance"`
+		Held      int64  `json:"held"`
+		Available int64  `json:"available"`
+		Currency  string `json:"currency"`
+	}{resp.UserId, resp.Balance, resp.Held, resp.Available, resp.Currency}
+	return c.out.print(result,
+		[]string{"USER", "BALANCE", "HELD", "AVAILABLE", "CURRENCY"},
+		[][]string{{
+			resp.UserId,
+			strconv.FormatInt(resp.Balance, 10),
+			strconv.FormatInt(resp.Held, 10),
+			strconv.FormatInt(resp.Available, 10),
+			resp.Currency,
+		}},
+	)
+}
+
+type timelineEvent struct {
+	Seq       int64  `json:"seq"`
+	Type      string `json:"type"`
+	UserID    string `json:"user_id"`
+	BikeID    string `json:"bike_id"`
+	Timestamp int64  `json:"timestamp"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// rentsTimeline prints every lifecycle event of a rent, oldest first.
+func (c *cli) rentsTimeline(args []string) error {
+	fs := flag.NewFlagSet("rents timeline", flag.ContinueOnError)
+	rest, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.GetRentTimeline(ctx, &rent.GetRentTimelineRequest{RentId: rest[0]})
+	if err != nil {
+		return err
+	}
+
+	events := make([]timelineEvent, 0, len(resp.Events))
+	rows := make([][]string, 0, len(resp.Events))
+	for _, e := range resp.Events {
+		events = append(events, timelineEvent{
+			Seq:       e.Seq,
+			Type:      e.Type,
+			UserID:    e.UserId,
+			BikeID:    e.BikeId,
+			Timestamp: e.Timestamp,
+			Reason:    e.Reason,
+		})
+		rows = append(rows, []string{
+			strconv.FormatInt(e.Seq, 10), formatUnix(e.Timestamp), e.Type, e.UserId, e.BikeId, e.Reason,
+		})
+	}
+
+	result := struct {
+		RentID string          `json:"rent_id"`
+		Status string          `json:"status"`
+		Events []timelineEvent `json:"events"`
+	}{resp.RentId, resp.Status, events}
+	return c.out.print(result, []string{"SEQ", "TIME", "EVENT", "USER", "BIKE", "REASON"}, rows)
+}
+
+// rentsRebuild derives rents again from their lifecycle events, e.g. after
+// rows were edited by hand.
+func (c *cli) rentsRebuild(args []string) error {
+	fs := flag.NewFlagSet("rents rebuild", flag.ContinueOnError)
+	rentID := fs.String("rent", "", "only this rent; default all rents")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	client, err := c.rentClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := client.RebuildRentProjections(ctx, &rent.RebuildRentProjectionsRequest{RentId: *rentID})
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		Rents   int32 `json:"rents"`
+		Changed int32 `json:"changed"`
+	}{resp.Rents, resp.Changed}
+	return c.out.print(result,
+		[]string{"RENTS", "CHANGED"},
+		[][]string{{strconv.Itoa(int(resp.Rents)), strconv.Itoa(int(resp.Changed))}},
+	)
+}
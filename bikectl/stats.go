@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type dailyStats struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type seriesPoint struct {
+	Period string `json:"period"`
+	Start  string `json:"start"`
+	Count  int64  `json:"count"`
+}
+
+type rangeStats struct {
+	From        string        `json:"from"`
+	To          string        `json:"to"`
+	Granularity string        `json:"granularity"`
+	Total       int64         `json:"total"`
+	Series      []seriesPoint `json:"series"`
+}
+
+func (c *cli) statsActive(args []string) error {
+	fs := flag.NewFlagSet("stats active", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	var resp struct {
+		ActiveRents int64 `json:"active_rents"`
+		PausedRents int64 `json:"paused_rents"`
+	}
+	if err := c.getStats("/internal/stats/active", nil, &resp); err != nil {
+		return err
+	}
+
+	return c.out.print(resp, []string{"ACTIVE RENTS", "PAUSED"}, [][]string{{
+		strconv.FormatInt(resp.ActiveRents, 10),
+		strconv.FormatInt(resp.PausedRents, 10),
+	}})
+}
+
+func (c *cli) statsDaily(args []string) error {
+	fs := flag.NewFlagSet("stats daily", flag.ContinueOnError)
+	date := fs.String("date", "", "YYYY-MM-DD, today by default")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *date != "" {
+		query.Set("date", *date)
+	}
+
+	var resp dailyStats
+	if err := c.getStats("/internal/stats/daily", query, &resp); err != nil {
+		return err
+	}
+
+	return c.out.print(resp, []string{"DATE", "RENTS"}, [][]string{{resp.Date, strconv.FormatInt(resp.Count, 10)}})
+}
+
+func (c *cli) statsRange(args []string) error {
+	fs := flag.NewFlagSet("stats range", flag.ContinueOnError)
+	from := fs.String("from", "", "first day, YYYY-MM-DD")
+	to := fs.String("to", "", "last day, YYYY-MM-DD")
+	granularity := fs.String("granularity", "", "day, week or month")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return fmt.Errorf("%w: stats range requires -from and -to", errUsage)
+	}
+
+	query := url.Values{"from": {*from}, "to": {*to}}
+	if *granularity != "" {
+		query.Set("granularity", *granularity)
+	}
+
+	var resp rangeStats
+	if err := c.getStats("/internal/stats/range", query, &resp); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(resp.Series)+1)
+	for _, p := range resp.Series {
+		rows = append(rows, []string{p.Period, p.Start, strconv.FormatInt(p.Count, 10)})
+	}
+	rows = append(rows, []string{"total", "", strconv.FormatInt(resp.Total, 10)})
+	return c.out.print(resp, []string{"PERIOD", "START", "RENTS"}, rows)
+}
+
+func (c *cli) getStats(path string, query url.Values, v interface{}) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	u := strings.TrimSuffix(c.statsURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call stats-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("stats-service returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode stats response: %w", err)
+	}
+	return nil
+}
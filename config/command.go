@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// RunCommand runs the "config print" and "config validate" subcommands
+// shared by every service binary and returns the process exit code. handled
+// is false when args do not start with "config", so the caller can continue
+// with normal startup.
+func RunCommand(args []string) (code int, handled bool) {
+	if len(args) == 0 || args[0] != "config" {
+		return 0, false
+	}
+	return runCommand(args[1:], os.Stdout, os.Stderr), true
+}
+
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: config [print|validate]")
+		return 2
+	}
+
+	cfg, err := LoadConfig(os.Getenv("CONFIG_PATH"))
+
+	switch args[0] {
+	case "validate":
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintln(stdout, "configuration is valid")
+		return 0
+
+	case "print":
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to marshal config: %v\n", err)
+			return 1
+		}
+		stdout.Write(out)
+		return 0
+
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\n", args[0])
+		return 2
+	}
+}
@@ -1,17 +1,31 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+	// Embedded zone database so stats.time_zone works in minimal images.
+	_ "time/tzdata"
 
 	"go.yaml.in/yaml/v4"
 )
 
+const defaultConfigFile = "config.yaml"
+
+// Fields tagged reload:"true" are applied by Watcher without a restart;
+// everything else is only read at startup.
 type Config struct {
 	Database DatabaseConfig `yaml:"database"`
 	Kafka    KafkaConfig    `yaml:"kafka"`
 	Services ServicesConfig `yaml:"services"`
 	Server   ServerConfig   `yaml:"server"`
+	Gateway  GatewayConfig  `yaml:"gateway"`
+	Rent     RentConfig     `yaml:"rent"`
+	Stats    StatsConfig    `yaml:"stats"`
+	Log      LogConfig      `yaml:"log"`
+	TLS      TLSConfig      `yaml:"tls"`
 }
 
 type DatabaseConfig struct {
@@ -20,55 +34,410 @@ type DatabaseConfig struct {
 }
 
 type PostgresConfig struct {
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	DBName   string `yaml:"dbname"`
-	SSLMode  string `yaml:"ssl_mode"`
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"`
+	User         string `yaml:"user"`
+	Password     string `yaml:"password" secret:"true"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+	DBName       string `yaml:"dbname"`
+	SSLMode      string `yaml:"ssl_mode"`
 }
 
 type RedisConfig struct {
-	Address  string `yaml:"address"`
-	Password string `yaml:"password"`
-	DB       int    `yaml:"db"`
+	Address      string `yaml:"address"`
+	Password     string `yaml:"password" secret:"true"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+	DB           int    `yaml:"db"`
 }
 
 type KafkaConfig struct {
-	Brokers []string    `yaml:"brokers"`
+	Brokers []string     `yaml:"brokers"`
 	Topics  TopicsConfig `yaml:"topics"`
+	// EventEncoding is how rent-service writes rent events: "json" or
+	// "protobuf". Consumers older than the protobuf contract only read json,
+	// so it stays the default until every consumer is upgraded.
+	EventEncoding string `yaml:"event_encoding" reload:"true"`
 }
 
 type TopicsConfig struct {
-	RentEvents   string `yaml:"rent_events"`
-	StatusEvents string `yaml:"status_events"`
+	RentEvents   string `yaml:"rent_events" reload:"true"`
+	StatusEvents string `yaml:"status_events" reload:"true"`
 }
 
 type ServicesConfig struct {
-	RentService string `yaml:"rent_service"`
+	RentService  string `yaml:"rent_service"`
+	StatsService string `yaml:"stats_service"`
 }
 
 type ServerConfig struct {
-	APIGatewayPort int `yaml:"api_gateway_port"`
-	RentServicePort int `yaml:"rent_service_port"`
-	StatsServicePort int `yaml:"stats_service_port"`
+	APIGatewayPort        int `yaml:"api_gateway_port"`
+	RentServicePort       int `yaml:"rent_service_port"`
+	RentServiceHealthPort int `yaml:"rent_service_health_port"`
+	StatsServicePort      int `yaml:"stats_service_port"`
+}
+
+type GatewayConfig struct {
+	RateLimit   RateLimitConfig    `yaml:"rate_limit"`
+	RentClient  ClientPolicyConfig `yaml:"rent_client"`
+	StatsClient ClientPolicyConfig `yaml:"stats_client"`
+	FleetStream FleetStreamConfig  `yaml:"fleet_stream"`
+	Operators   OperatorsConfig    `yaml:"operators"`
+}
+
+// OperatorsConfig names the file of operator bearer tokens, one "name token"
+// pair per line. Admin endpoints reject every request until it is set.
+type OperatorsConfig struct {
+	TokensFile string `yaml:"tokens_file,omitempty"`
+}
+
+// FleetStreamConfig tunes the live fleet event stream. Each client may lag
+// client_buffer events behind before events are dropped for it; heartbeat
+// keeps idle connections open through proxies.
+type FleetStreamConfig struct {
+	ClientBuffer int           `yaml:"client_buffer"`
+	Heartbeat    time.Duration `yaml:"heartbeat"`
+}
+
+// ClientPolicyConfig controls deadlines, retries and circuit breaking for
+// the gateway's calls to a backend. Only idempotent reads are retried.
+type ClientPolicyConfig struct {
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	Retry        RetryConfig   `yaml:"retry"`
+	Breaker      BreakerConfig `yaml:"breaker"`
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// BreakerConfig opens the circuit after failure_threshold consecutive
+// failures and lets a single probe through after open_timeout. A zero
+// threshold disables the breaker.
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"`
+	OpenTimeout      time.Duration `yaml:"open_timeout"`
+}
+
+// RateLimitConfig limits API requests per client IP with a token bucket.
+type RateLimitConfig struct {
+	Enabled           bool    `yaml:"enabled" reload:"true"`
+	RequestsPerSecond float64 `yaml:"requests_per_second" reload:"true"`
+	Burst             int     `yaml:"burst" reload:"true"`
+}
+
+type TLSConfig struct {
+	GRPC GRPCTLSConfig `yaml:"grpc"`
+	HTTP HTTPTLSConfig `yaml:"http"`
+}
+
+// GRPCTLSConfig secures the link between the gateway and rent-service. With
+// require_client_cert both sides present certificates signed by ca_file.
+type GRPCTLSConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	CAFile            string `yaml:"ca_file"`
+	ServerCertFile    string `yaml:"server_cert_file"`
+	ServerKeyFile     string `yaml:"server_key_file"`
+	ClientCertFile    string `yaml:"client_cert_file"`
+	ClientKeyFile     string `yaml:"client_key_file"`
+	ServerName        string `yaml:"server_name"`
+	RequireClientCert bool   `yaml:"require_client_cert"`
+}
+
+// HTTPTLSConfig enables HTTPS on the gateway's public listener.
+type HTTPTLSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// RentConfig holds rent-service settings.
+type RentConfig struct {
+	BikeCache  BikeCacheConfig  `yaml:"bike_cache"`
+	StaleRents StaleRentsConfig `yaml:"stale_rents"`
+	Pauses     PauseConfig      `yaml:"pauses"`
+	Wallet     WalletConfig     `yaml:"wallet"`
+	Users      UsersConfig      `yaml:"users"`
+	Rebalance  RebalanceConfig  `yaml:"rebalance"`
+}
+
+// UsersConfig controls who may rent. Blocked and unverified accounts are
+// always refused; with RequireRegistration riders without an account are
+// refused too. Turning it off is only meant for migrating riders who rented
+// before accounts existed: a blocked rider could then rent under any unused
+// user ID.
+type UsersConfig struct {
+	RequireRegistration bool `yaml:"require_registration" reload:"true"`
+}
+
+// RebalanceConfig drives fleet rebalancing plans. Every location should
+// hold the available bikes it is expected to lose over Horizon, judged from
+// the last HistoryDays of rents, and at least MinBikes. A plan is one round
+// of Trucks moves of at most TruckCapacity bikes each.
+type RebalanceConfig struct {
+	TruckCapacity int           `yaml:"truck_capacity" reload:"true"`
+	Trucks        int           `yaml:"trucks" reload:"true"`
+	MinBikes      int           `yaml:"min_bikes" reload:"true"`
+	Horizon       time.Duration `yaml:"horizon" reload:"true"`
+	HistoryDays   int           `yaml:"history_days" reload:"true"`
+}
+
+// BikeCacheConfig controls the Redis cache for GetAvailableBikes. Writes
+// invalidate it; TTL bounds how long a racing read can stay stale.
+type BikeCacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+// StaleRentsConfig controls the background check for rents active longer
+// than MaxDuration. They are logged and counted, and with AutoClose also
+// force-ended so the bike is freed and an end event is published.
+type StaleRentsConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Interval    time.Duration `yaml:"interval"`
+	MaxDuration time.Duration `yaml:"max_duration" reload:"true"`
+	AutoClose   bool          `yaml:"auto_close" reload:"true"`
+}
+
+// PauseConfig limits how long a rent may stay paused. Every Interval,
+// rents paused longer than MaxDuration are resumed, so the rest of the
+// pause is billed as riding.
+type PauseConfig struct {
+	MaxDuration time.Duration `yaml:"max_duration" reload:"true"`
+	Interval    time.Duration `yaml:"interval"`
+}
+
+// WalletConfig controls how riders pay. When enabled, StartRent holds
+// Pricing.Hold of the rider's balance and refuses riders who cannot cover
+// it; the fare is captured when the rent ends, at the prices in effect when
+// it started. Amounts are in minor units of Currency, e.g. kopecks.
+type WalletConfig struct {
+	Enabled  bool   `yaml:"enabled" reload:"true"`
+	Currency string `yaml:"currency"`
+	// Provider charges top-ups; only "fake", which approves everything, so far
+	Provider string        `yaml:"provider"`
+	MaxTopUp int64         `yaml:"max_top_up" reload:"true"`
+	Pricing  PricingConfig `yaml:"pricing" reload:"true"`
 }
 
+// PricingConfig is the tariff of new rents. Riding and paused time are
+// billed per started minute.
+type PricingConfig struct {
+	Hold            int64 `yaml:"hold"`
+	UnlockFee       int64 `yaml:"unlock_fee"`
+	PerMinute       int64 `yaml:"per_minute"`
+	PausedPerMinute int64 `yaml:"paused_per_minute"`
+	// TaxPercent is the VAT rate included in the prices, shown on receipts
+	TaxPercent int64 `yaml:"tax_percent"`
+}
+
+// StatsConfig controls how stats-service buckets events by day and hour.
+// TimeZone is an IANA name such as "Europe/Moscow"; day boundaries follow
+// it rather than the container clock.
+type StatsConfig struct {
+	TimeZone string `yaml:"time_zone"`
+}
+
+// Location returns the time zone named by TimeZone.
+func (s StatsConfig) Location() (*time.Location, error) {
+	return time.LoadLocation(s.TimeZone)
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" reload:"true"`
+}
+
+// Default returns the configuration used for any field not set in the file
+// or the environment. It matches the docker-compose setup.
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Postgres: PostgresConfig{
+				Host:    "postgres",
+				Port:    5432,
+				User:    "user",
+				DBName:  "bikerent",
+				SSLMode: "disable",
+			},
+			Redis: RedisConfig{
+				Address: "redis:6379",
+			},
+		},
+		Kafka: KafkaConfig{
+			Brokers: []string{"kafka:9092"},
+			Topics: TopicsConfig{
+				RentEvents:   "bike-rent-events",
+				StatusEvents: "bike-status-events",
+			},
+			EventEncoding: "json",
+		},
+		Services: ServicesConfig{
+			RentService:  "rent-service:50051",
+			StatsService: "http://stats-service:8081",
+		},
+		Server: ServerConfig{
+			APIGatewayPort:        8080,
+			RentServicePort:       50051,
+			RentServiceHealthPort: 8084,
+			StatsServicePort:      8081,
+		},
+		Gateway: GatewayConfig{
+			RateLimit: RateLimitConfig{
+				Enabled:           false,
+				RequestsPerSecond: 20,
+				Burst:             40,
+			},
+			RentClient:  defaultClientPolicy(),
+			StatsClient: defaultClientPolicy(),
+			FleetStream: FleetStreamConfig{
+				ClientBuffer: 64,
+				Heartbeat:    15 * time.Second,
+			},
+		},
+		Rent: RentConfig{
+			BikeCache: BikeCacheConfig{
+				Enabled: true,
+				TTL:     30 * time.Second,
+			},
+			StaleRents: StaleRentsConfig{
+				Enabled:     true,
+				Interval:    5 * time.Minute,
+				MaxDuration: 24 * time.Hour,
+			},
+			Pauses: PauseConfig{
+				MaxDuration: 30 * time.Minute,
+				Interval:    time.Minute,
+			},
+			Wallet: WalletConfig{
+				Currency: "RUB",
+				Provider: "fake",
+				MaxTopUp: 1000000,
+				Pricing: PricingConfig{
+					Hold:            30000,
+					UnlockFee:       5000,
+					PerMinute:       800,
+					PausedPerMinute: 300,
+					TaxPercent:      20,
+				},
+			},
+			Users: UsersConfig{
+				RequireRegistration: true,
+			},
+			Rebalance: RebalanceConfig{
+				TruckCapacity: 10,
+				Trucks:        3,
+				MinBikes:      2,
+				Horizon:       3 * time.Hour,
+				HistoryDays:   28,
+			},
+		},
+		Stats: StatsConfig{
+			TimeZone: "UTC",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+	}
+}
+
+func defaultClientPolicy() ClientPolicyConfig {
+	return ClientPolicyConfig{
+		ReadTimeout:  2 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		Retry: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     time.Second,
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      10 * time.Second,
+		},
+	}
+}
+
+// LoadConfig builds the configuration from defaults, the YAML file and
+// BIKE_* environment variables (in that order of precedence), resolves
+// secret files and validates the result.
+//
+// An empty filename falls back to config.yaml; if that file does not exist
+// the configuration is built from defaults and the environment only.
 func LoadConfig(filename string) (*Config, error) {
-	if filename == "" {
-		filename = "config.yaml"
+	config := Default()
+
+	optional := filename == ""
+	if optional {
+		filename = defaultConfigFile
 	}
-	
+
 	data, err := os.ReadFile(filename)
-	if err != nil {
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+		}
+	case optional && errors.Is(err, os.ErrNotExist):
+	default:
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	err = yaml.Unmarshal(data, &config)
+	if err := applyEnv(config); err != nil {
+		return nil, err
+	}
+
+	if err := config.loadSecrets(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+func (c *Config) loadSecrets() error {
+	if path := c.Database.Postgres.PasswordFile; path != "" {
+		secret, err := readSecret(path)
+		if err != nil {
+			return fmt.Errorf("database.postgres.password_file: %w", err)
+		}
+		c.Database.Postgres.Password = secret
+	}
+
+	if path := c.Database.Redis.PasswordFile; path != "" {
+		secret, err := readSecret(path)
+		if err != nil {
+			return fmt.Errorf("database.redis.password_file: %w", err)
+		}
+		c.Database.Redis.Password = secret
+	}
+
+	return nil
+}
+
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+		return "", fmt.Errorf("failed to read secret: %w", err)
 	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
 
-	return &config, nil
+// Redacted returns a copy of the configuration with secrets masked, safe to
+// print or log.
+func (c *Config) Redacted() *Config {
+	copied := *c
+	copied.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	if copied.Database.Postgres.Password != "" {
+		copied.Database.Postgres.Password = redacted
+	}
+	if copied.Database.Redis.Password != "" {
+		copied.Database.Redis.Password = redacted
+	}
+	return &copied
 }
+
+const redacted = "******"
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// ConfigTestSuite - тестовый набор для загрузки конфигурации
+type ConfigTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *ConfigTestSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+}
+
+func (suite *ConfigTestSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.dir, name)
+	suite.Require().NoError(os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestLoadConfig_Defaults - пустой файл дает значения по умолчанию
+func (suite *ConfigTestSuite) TestLoadConfig_Defaults() {
+	path := suite.writeFile("config.yaml", "")
+
+	cfg, err := LoadConfig(path)
+
+	suite.Require().NoError(err)
+	suite.Equal(Default(), cfg)
+}
+
+// TestLoadConfig_FileOverridesDefaults - значения из файла перекрывают значения по умолчанию
+func (suite *ConfigTestSuite) TestLoadConfig_FileOverridesDefaults() {
+	path := suite.writeFile("config.yaml", "database:\n  postgres:\n    host: db.local\n")
+
+	cfg, err := LoadConfig(path)
+
+	suite.Require().NoError(err)
+	suite.Equal("db.local", cfg.Database.Postgres.Host)
+	suite.Equal(5432, cfg.Database.Postgres.Port)
+}
+
+// TestLoadConfig_EnvOverridesFile - переменные окружения перекрывают файл
+func (suite *ConfigTestSuite) TestLoadConfig_EnvOverridesFile() {
+	path := suite.writeFile("config.yaml", "server:\n  api_gateway_port: 9000\n")
+	suite.T().Setenv("BIKE_SERVER_API_GATEWAY_PORT", "9100")
+	suite.T().Setenv("BIKE_KAFKA_BROKERS", "k1:9092, k2:9092")
+
+	cfg, err := LoadConfig(path)
+
+	suite.Require().NoError(err)
+	suite.Equal(9100, cfg.Server.APIGatewayPort)
+	suite.Equal([]string{"k1:9092", "k2:9092"}, cfg.Kafka.Brokers)
+}
+
+// TestLoadConfig_SecretFiles - пароли читаются из файлов
+func (suite *ConfigTestSuite) TestLoadConfig_SecretFiles() {
+	pgSecret := suite.writeFile("pg-password", "s3cret\n")
+	redisSecret := suite.writeFile("redis-password", "r3dis")
+	path := suite.writeFile("config.yaml", "database:\n  postgres:\n    password_file: "+pgSecret+"\n")
+	suite.T().Setenv("BIKE_DATABASE_REDIS_PASSWORD_FILE", redisSecret)
+
+	cfg, err := LoadConfig(path)
+
+	suite.Require().NoError(err)
+	suite.Equal("s3cret", cfg.Database.Postgres.Password)
+	suite.Equal("r3dis", cfg.Database.Redis.Password)
+	suite.Equal(redacted, cfg.Redacted().Database.Postgres.Password)
+}
+
+// TestLoadConfig_InvalidEnv - некорректное значение в окружении
+func (suite *ConfigTestSuite) TestLoadConfig_InvalidEnv() {
+	path := suite.writeFile("config.yaml", "")
+	suite.T().Setenv("BIKE_DATABASE_POSTGRES_PORT", "abc")
+
+	_, err := LoadConfig(path)
+
+	suite.Error(err)
+	suite.Contains(err.Error(), "BIKE_DATABASE_POSTGRES_PORT")
+}
+
+// TestLoadConfig_MissingFile - явно указанный файл должен существовать
+func (suite *ConfigTestSuite) TestLoadConfig_MissingFile() {
+	_, err := LoadConfig(filepath.Join(suite.dir, "missing.yaml"))
+
+	suite.Error(err)
+}
+
+// TestValidate_ReportsAllErrors - валидация возвращает все ошибки сразу
+func (suite *ConfigTestSuite) TestValidate_ReportsAllErrors() {
+	cfg := Default()
+	cfg.Database.Postgres.Host = ""
+	cfg.Server.APIGatewayPort = 70000
+	cfg.Services.StatsService = "stats-service:8081"
+	cfg.Stats.TimeZone = "Mars/Olympus"
+	cfg.Rent.BikeCache.TTL = 0
+	cfg.Rent.StaleRents.MaxDuration = 0
+	cfg.Rent.Pauses.MaxDuration = 0
+	cfg.Rent.Wallet.Pricing.PerMinute = -1
+	cfg.Rent.Rebalance.Horizon = 30 * time.Minute
+	cfg.Kafka.EventEncoding = "avro"
+
+	err := cfg.Validate()
+
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "database.postgres.host: is required")
+	suite.Contains(err.Error(), "server.api_gateway_port")
+	suite.Contains(err.Error(), "services.stats_service")
+	suite.Contains(err.Error(), "stats.time_zone")
+	suite.Contains(err.Error(), "rent.bike_cache.ttl")
+	suite.Contains(err.Error(), "rent.stale_rents.max_duration")
+	suite.Contains(err.Error(), "rent.pauses.max_duration")
+	suite.Contains(err.Error(), "rent.wallet.pricing.per_minute")
+	suite.Contains(err.Error(), "rent.rebalance.horizon")
+	suite.Contains(err.Error(), "kafka.event_encoding")
+}
+
+// TestWatcher_ReloadAppliesOnlyReloadableFields - горячая перезагрузка применяет только безопасные поля
+func (suite *ConfigTestSuite) TestWatcher_ReloadAppliesOnlyReloadableFields() {
+	path := suite.writeFile("config.yaml", "log:\n  level: info\n")
+	cfg, err := LoadConfig(path)
+	suite.Require().NoError(err)
+
+	watcher := NewWatcher(path, cfg)
+	var notified *Config
+	watcher.Subscribe(func(old, updated *Config) {
+		notified = updated
+	})
+
+	suite.writeFile("config.yaml", "log:\n  level: debug\nserver:\n  api_gateway_port: 9000\n")
+	changes, err := watcher.Reload()
+
+	suite.Require().NoError(err)
+	suite.Require().Len(changes, 1)
+	suite.Equal("log.level", changes[0].Path)
+	suite.Require().NotNil(notified)
+	suite.Equal("debug", watcher.Current().Log.Level)
+	suite.Equal(8080, watcher.Current().Server.APIGatewayPort)
+}
+
+// TestWatcher_ReloadInvalidKeepsCurrent - при ошибке остается прежняя конфигурация
+func (suite *ConfigTestSuite) TestWatcher_ReloadInvalidKeepsCurrent() {
+	path := suite.writeFile("config.yaml", "")
+	cfg, err := LoadConfig(path)
+	suite.Require().NoError(err)
+	watcher := NewWatcher(path, cfg)
+
+	suite.writeFile("config.yaml", "log:\n  level: loud\n")
+	_, err = watcher.Reload()
+
+	suite.Error(err)
+	suite.Same(cfg, watcher.Current())
+}
+
+// TestDiff_MasksSecrets - пароли не попадают в лог изменений
+func (suite *ConfigTestSuite) TestDiff_MasksSecrets() {
+	a, b := Default(), Default()
+	b.Database.Postgres.Password = "new-secret"
+
+	changes := Diff(a, b)
+
+	suite.Require().Len(changes, 1)
+	suite.Equal(redacted, changes[0].New)
+	suite.False(changes[0].Reloadable)
+}
+
+func TestConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigTestSuite))
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"bike-rental/logging"
+)
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+var eventEncodings = map[string]bool{
+	"protobuf": true,
+	"json":     true,
+}
+
+var paymentProviders = map[string]bool{
+	"fake": true,
+}
+
+// Validate checks the configuration and returns every problem found, one
+// per line, so a bad deployment fails at startup with a clear message.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+	required := func(field, value string) {
+		if value == "" {
+			fail(field, "is required")
+		}
+	}
+	port := func(field string, value int) {
+		if value < 1 || value > 65535 {
+			fail(field, "must be between 1 and 65535, got %d", value)
+		}
+	}
+
+	pg := c.Database.Postgres
+	required("database.postgres.host", pg.Host)
+	port("database.postgres.port", pg.Port)
+	required("database.postgres.user", pg.User)
+	required("database.postgres.dbname", pg.DBName)
+	if !sslModes[pg.SSLMode] {
+		fail("database.postgres.ssl_mode", "unknown mode %q", pg.SSLMode)
+	}
+
+	required("database.redis.address", c.Database.Redis.Address)
+	if c.Database.Redis.DB < 0 {
+		fail("database.redis.db", "must not be negative")
+	}
+
+	if len(c.Kafka.Brokers) == 0 {
+		fail("kafka.brokers", "at least one broker is required")
+	}
+	for i, broker := range c.Kafka.Brokers {
+		if broker == "" {
+			fail(fmt.Sprintf("kafka.brokers[%d]", i), "is empty")
+		}
+	}
+	required("kafka.topics.rent_events", c.Kafka.Topics.RentEvents)
+	required("kafka.topics.status_events", c.Kafka.Topics.StatusEvents)
+	if !eventEncodings[c.Kafka.EventEncoding] {
+		fail("kafka.event_encoding", "must be protobuf or json, got %q", c.Kafka.EventEncoding)
+	}
+
+	required("services.rent_service", c.Services.RentService)
+	if u, err := url.Parse(c.Services.StatsService); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fail("services.stats_service", "must be an http(s) URL, got %q", c.Services.StatsService)
+	}
+
+	port("server.api_gateway_port", c.Server.APIGatewayPort)
+	port("server.rent_service_port", c.Server.RentServicePort)
+	port("server.rent_service_health_port", c.Server.RentServiceHealthPort)
+	port("server.stats_service_port", c.Server.StatsServicePort)
+	if c.Server.RentServicePort == c.Server.RentServiceHealthPort {
+		fail("server.rent_service_health_port", "must differ from rent_service_port")
+	}
+
+	rl := c.Gateway.RateLimit
+	if rl.Enabled {
+		if rl.RequestsPerSecond <= 0 {
+			fail("gateway.rate_limit.requests_per_second", "must be positive")
+		}
+		if rl.Burst < 1 {
+			fail("gateway.rate_limit.burst", "must be at least 1")
+		}
+	}
+
+	c.Gateway.RentClient.validate("gateway.rent_client", fail)
+	c.Gateway.StatsClient.validate("gateway.stats_client", fail)
+	if c.Gateway.FleetStream.ClientBuffer < 1 {
+		fail("gateway.fleet_stream.client_buffer", "must be at least 1")
+	}
+	if c.Gateway.FleetStream.Heartbeat <= 0 {
+		fail("gateway.fleet_stream.heartbeat", "must be positive")
+	}
+
+	if g := c.TLS.GRPC; g.Enabled {
+		required("tls.grpc.ca_file", g.CAFile)
+		required("tls.grpc.server_cert_file", g.ServerCertFile)
+		required("tls.grpc.server_key_file", g.ServerKeyFile)
+		if g.RequireClientCert {
+			required("tls.grpc.client_cert_file", g.ClientCertFile)
+			required("tls.grpc.client_key_file", g.ClientKeyFile)
+		}
+		if (g.ClientCertFile == "") != (g.ClientKeyFile == "") {
+			fail("tls.grpc.client_key_file", "client_cert_file and client_key_file must be set together")
+		}
+	}
+	if h := c.TLS.HTTP; h.Enabled {
+		required("tls.http.cert_file", h.CertFile)
+		required("tls.http.key_file", h.KeyFile)
+	}
+
+	if c.Rent.BikeCache.Enabled && c.Rent.BikeCache.TTL <= 0 {
+		fail("rent.bike_cache.ttl", "must be positive")
+	}
+	if sr := c.Rent.StaleRents; sr.Enabled {
+		if sr.Interval <= 0 {
+			fail("rent.stale_rents.interval", "must be positive")
+		}
+		if sr.MaxDuration <= 0 {
+			fail("rent.stale_rents.max_duration", "must be positive")
+		}
+	}
+	if c.Rent.Pauses.MaxDuration <= 0 {
+		fail("rent.pauses.max_duration", "must be positive")
+	}
+	if c.Rent.Pauses.Interval <= 0 {
+		fail("rent.pauses.interval", "must be positive")
+	}
+	w := c.Rent.Wallet
+	required("rent.wallet.currency", w.Currency)
+	if !paymentProviders[w.Provider] {
+		fail("rent.wallet.provider", "unknown provider %q", w.Provider)
+	}
+	if w.MaxTopUp <= 0 {
+		fail("rent.wallet.max_top_up", "must be positive")
+	}
+	price := func(field string, value int64) {
+		if value < 0 {
+			fail("rent.wallet.pricing."+field, "must not be negative")
+		}
+	}
+	price("hold", w.Pricing.Hold)
+	price("unlock_fee", w.Pricing.UnlockFee)
+	price("per_minute", w.Pricing.PerMinute)
+	price("paused_per_minute", w.Pricing.PausedPerMinute)
+	if w.Pricing.TaxPercent < 0 || w.Pricing.TaxPercent > 100 {
+		fail("rent.wallet.pricing.tax_percent", "must be between 0 and 100")
+	}
+	rb := c.Rent.Rebalance
+	if rb.TruckCapacity <= 0 {
+		fail("rent.rebalance.truck_capacity", "must be positive")
+	}
+	if rb.Trucks <= 0 {
+		fail("rent.rebalance.trucks", "must be positive")
+	}
+	if rb.MinBikes < 0 {
+		fail("rent.rebalance.min_bikes", "must not be negative")
+	}
+	if rb.Horizon < time.Hour || rb.Horizon > 24*time.Hour {
+		fail("rent.rebalance.horizon", "must be between 1h and 24h")
+	}
+	if rb.HistoryDays < 7 || rb.HistoryDays > 366 {
+		fail("rent.rebalance.history_days", "must be between 7 and 366")
+	}
+
+	if c.Stats.TimeZone == "" {
+		fail("stats.time_zone", "is required")
+	} else if _, err := c.Stats.Location(); err != nil {
+		fail("stats.time_zone", "unknown time zone %q", c.Stats.TimeZone)
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		fail("log.level", "%v", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p ClientPolicyConfig) validate(prefix string, fail func(field, format string, args ...interface{})) {
+	if p.ReadTimeout <= 0 {
+		fail(prefix+".read_timeout", "must be positive")
+	}
+	if p.WriteTimeout <= 0 {
+		fail(prefix+".write_timeout", "must be positive")
+	}
+	if p.Retry.MaxAttempts < 1 {
+		fail(prefix+".retry.max_attempts", "must be at least 1")
+	}
+	if p.Retry.MaxAttempts > 1 && (p.Retry.InitialBackoff <= 0 || p.Retry.MaxBackoff < p.Retry.InitialBackoff) {
+		fail(prefix+".retry", "initial_backoff must be positive and not exceed max_backoff")
+	}
+	if p.Breaker.FailureThreshold < 0 {
+		fail(prefix+".breaker.failure_threshold", "must not be negative")
+	}
+	if p.Breaker.FailureThreshold > 0 && p.Breaker.OpenTimeout <= 0 {
+		fail(prefix+".breaker.open_timeout", "must be positive")
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Change describes a single field that differs between two configurations.
+type Change struct {
+	Path       string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Subscriber is notified with the configuration before and after a reload.
+type Subscriber func(old, updated *Config)
+
+// Watcher reloads the configuration when the file changes or the process
+// receives SIGHUP and notifies subscribers about reloadable fields.
+type Watcher struct {
+	path     string
+	interval time.Duration
+
+	mu          sync.RWMutex
+	current     *Config
+	subscribers []Subscriber
+	modTime     time.Time
+	size        int64
+}
+
+func NewWatcher(path string, initial *Config) *Watcher {
+	w := &Watcher{
+		path:     path,
+		interval: 2 * time.Second,
+		current:  initial,
+	}
+	w.modTime, w.size = w.stat()
+	return w
+}
+
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called after each reload that changed at
+// least one reloadable field. Callbacks run sequentially on the watcher
+// goroutine and must not block.
+func (w *Watcher) Subscribe(fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Run polls the file for changes and listens for SIGHUP until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("SIGHUP received, reloading configuration")
+			w.reloadAndLog()
+		case <-ticker.C:
+			modTime, size := w.stat()
+			if modTime.Equal(w.modTime) && size == w.size {
+				continue
+			}
+			w.modTime, w.size = modTime, size
+			log.Printf("Config file %s changed, reloading", w.filename())
+			w.reloadAndLog()
+		}
+	}
+}
+
+func (w *Watcher) reloadAndLog() {
+	if _, err := w.Reload(); err != nil {
+		log.Printf("Config reload failed, keeping previous configuration: %v", err)
+	}
+}
+
+// Reload reads the configuration again and applies the reloadable fields.
+// Changes to other fields are logged and ignored until restart.
+func (w *Watcher) Reload() ([]Change, error) {
+	loaded, err := LoadConfig(w.path)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	old := w.current
+	changes := Diff(old, loaded)
+
+	next := *old
+	var applied []Change
+	for _, change := range changes {
+		if !change.Reloadable {
+			log.Printf("Config change requires restart, ignored: %s", change)
+			continue
+		}
+		applied = append(applied, change)
+	}
+	if len(applied) == 0 {
+		w.mu.Unlock()
+		log.Println("Config reloaded: no live changes")
+		return nil, nil
+	}
+
+	copyReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem(), false)
+	w.current = &next
+	subscribers := append([]Subscriber(nil), w.subscribers...)
+	w.mu.Unlock()
+
+	for _, change := range applied {
+		log.Printf("Config reloaded: %s", change)
+	}
+	for _, fn := range subscribers {
+		fn(old, &next)
+	}
+
+	return applied, nil
+}
+
+func (w *Watcher) filename() string {
+	if w.path == "" {
+		return defaultConfigFile
+	}
+	return w.path
+}
+
+func (w *Watcher) stat() (time.Time, int64) {
+	info, err := os.Stat(w.filename())
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+// Diff lists the leaf fields that differ between a and b, using YAML paths
+// and masking secrets.
+func Diff(a, b *Config) []Change {
+	var changes []Change
+	diffStruct(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), "", false, &changes)
+	return changes
+}
+
+func diffStruct(a, b reflect.Value, prefix string, reloadable bool, changes *[]Change) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		path := tag
+		if prefix != "" {
+			path = prefix + "." + tag
+		}
+		fieldReloadable := reloadable || field.Tag.Get("reload") == "true"
+
+		av, bv := a.Field(i), b.Field(i)
+		if av.Kind() == reflect.Struct && av.Type() != durationType {
+			diffStruct(av, bv, path, fieldReloadable, changes)
+			continue
+		}
+		if reflect.DeepEqual(av.Interface(), bv.Interface()) {
+			continue
+		}
+
+		change := Change{
+			Path:       path,
+			Old:        fmt.Sprint(av.Interface()),
+			New:        fmt.Sprint(bv.Interface()),
+			Reloadable: fieldReloadable,
+		}
+		if field.Tag.Get("secret") == "true" {
+			change.Old, change.New = redacted, redacted
+		}
+		*changes = append(*changes, change)
+	}
+}
+
+func copyReloadable(dst, src reflect.Value, reloadable bool) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldReloadable := reloadable || field.Tag.Get("reload") == "true"
+
+		dv, sv := dst.Field(i), src.Field(i)
+		if dv.Kind() == reflect.Struct && dv.Type() != durationType {
+			copyReloadable(dv, sv, fieldReloadable)
+			continue
+		}
+		if fieldReloadable {
+			dv.Set(sv)
+		}
+	}
+}
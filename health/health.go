@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Check probes a single dependency and returns an error if it is unavailable.
+type Check func(ctx context.Context) error
+
+type probe struct {
+	name     string
+	check    Check
+	critical bool
+}
+
+// Checker runs dependency probes for liveness and readiness endpoints.
+type Checker struct {
+	mu      sync.RWMutex
+	probes  []probe
+	timeout time.Duration
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a probe that must pass for the service to be ready.
+func (c *Checker) Register(name string, check Check) {
+	c.add(probe{name: name, check: check, critical: true})
+}
+
+// RegisterOptional adds a probe that is reported but does not affect readiness.
+func (c *Checker) RegisterOptional(name string, check Check) {
+	c.add(probe{name: name, check: check})
+}
+
+func (c *Checker) add(p probe) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probes = append(c.probes, p)
+}
+
+// Run executes all probes concurrently and reports whether the service is ready.
+func (c *Checker) Run(ctx context.Context) (Report, bool) {
+	c.mu.RLock()
+	probes := make([]probe, len(c.probes))
+	copy(probes, c.probes)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]error, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p probe) {
+			defer wg.Done()
+			results[i] = p.check(ctx)
+		}(i, p)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(probes))}
+	ready := true
+	for i, p := range probes {
+		if results[i] == nil {
+			report.Checks[p.name] = StatusOK
+			continue
+		}
+		report.Checks[p.name] = results[i].Error()
+		if p.critical {
+			ready = false
+			report.Status = StatusDown
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report, ready
+}
+
+// Live reports that the process is up and able to serve HTTP.
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// Ready reports 200 only when every critical dependency is reachable.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	report, ready := c.Run(r.Context())
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeReport(w, code, report)
+}
+
+func (c *Checker) RegisterRoutes(r chi.Router) {
+	r.Get("/health", c.Ready)
+	r.Get("/health/live", c.Live)
+	r.Get("/health/ready", c.Ready)
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
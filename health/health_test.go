@@ -0,0 +1,172 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/suite"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("connection refused") }
+
+// HealthTestSuite - тестовый набор для проверок готовности
+type HealthTestSuite struct {
+	suite.Suite
+	checker *Checker
+	router  *chi.Mux
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *HealthTestSuite) SetupTest() {
+	suite.checker = NewChecker(time.Second)
+	suite.router = chi.NewRouter()
+	suite.checker.RegisterRoutes(suite.router)
+}
+
+func (suite *HealthTestSuite) get(path string) (*httptest.ResponseRecorder, Report) {
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec, report
+}
+
+// TestRun_AllPass - все проверки прошли
+func (suite *HealthTestSuite) TestRun_AllPass() {
+	suite.checker.Register("postgres", ok)
+	suite.checker.RegisterOptional("kafka", ok)
+
+	report, ready := suite.checker.Run(context.Background())
+
+	suite.True(ready)
+	suite.Equal(StatusOK, report.Status)
+	suite.Equal(map[string]string{"postgres": StatusOK, "kafka": StatusOK}, report.Checks)
+}
+
+// TestRun_OptionalFailure - необязательная зависимость не влияет на готовность
+func (suite *HealthTestSuite) TestRun_OptionalFailure() {
+	suite.checker.Register("postgres", ok)
+	suite.checker.RegisterOptional("kafka", failing)
+
+	report, ready := suite.checker.Run(context.Background())
+
+	suite.True(ready)
+	suite.Equal(StatusDegraded, report.Status)
+	suite.Equal("connection refused", report.Checks["kafka"])
+}
+
+// TestRun_CriticalFailure - критичная зависимость важнее необязательной
+func (suite *HealthTestSuite) TestRun_CriticalFailure() {
+	suite.checker.RegisterOptional("kafka", failing)
+	suite.checker.Register("postgres", failing)
+
+	report, ready := suite.checker.Run(context.Background())
+
+	suite.False(ready)
+	suite.Equal(StatusDown, report.Status)
+}
+
+// TestRun_Timeout - зависшая проверка прерывается по таймауту
+func (suite *HealthTestSuite) TestRun_Timeout() {
+	suite.checker = NewChecker(10 * time.Millisecond)
+	suite.checker.Register("redis", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report, ready := suite.checker.Run(context.Background())
+
+	suite.False(ready)
+	suite.Equal(context.DeadlineExceeded.Error(), report.Checks["redis"])
+}
+
+// TestReady_Unavailable - /health/ready отвечает 503, если критичная зависимость недоступна
+func (suite *HealthTestSuite) TestReady_Unavailable() {
+	suite.checker.Register("postgres", failing)
+
+	for _, path := range []string{"/health/ready", "/health"} {
+		rec, report := suite.get(path)
+
+		suite.Equal(http.StatusServiceUnavailable, rec.Code, path)
+		suite.Equal(StatusDown, report.Status, path)
+	}
+}
+
+// TestReady_Degraded - при отказе необязательной зависимости сервис готов
+func (suite *HealthTestSuite) TestReady_Degraded() {
+	suite.checker.RegisterOptional("kafka", failing)
+
+	rec, report := suite.get("/health/ready")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal(StatusDegraded, report.Status)
+}
+
+// TestLive_IgnoresDependencies - liveness не запускает проверки
+func (suite *HealthTestSuite) TestLive_IgnoresDependencies() {
+	suite.checker.Register("postgres", failing)
+
+	rec, report := suite.get("/health/live")
+
+	suite.Equal(http.StatusOK, rec.Code)
+	suite.Equal(StatusOK, report.Status)
+	suite.Empty(report.Checks)
+}
+
+// TestWatch - статус gRPC health следует за готовностью
+func (suite *HealthTestSuite) TestWatch() {
+	var down atomic.Bool
+	suite.checker.Register("postgres", func(ctx context.Context) error {
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	srv := grpchealth.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		suite.checker.Watch(ctx, srv, 5*time.Millisecond, "", "rent.RentService")
+		close(done)
+	}()
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return resp.Status
+	}
+	suite.Eventually(func() bool {
+		return status("rent.RentService") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	down.Store(true)
+	suite.Eventually(func() bool {
+		return status("") == healthpb.HealthCheckResponse_NOT_SERVING &&
+			status("rent.RentService") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.Fail("Watch did not stop after cancel")
+	}
+}
+
+// TestHealthTestSuite - запуск тестового набора
+func TestHealthTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// KafkaCheck succeeds when at least one of the brokers accepts a connection
+// and answers a metadata request.
+func KafkaCheck(brokers []string) Check {
+	return func(ctx context.Context) error {
+		var lastErr error
+		for _, broker := range brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			_, err = conn.Brokers()
+			conn.Close()
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			return nil
+		}
+		if lastErr == nil {
+			return fmt.Errorf("no kafka brokers configured")
+		}
+		return fmt.Errorf("kafka unreachable: %w", lastErr)
+	}
+}
+
+// Watch periodically runs the checks and mirrors readiness into a gRPC health
+// server for the given service names until ctx is cancelled.
+func (c *Checker) Watch(ctx context.Context, srv *grpchealth.Server, interval time.Duration, services ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		report, ready := c.Run(ctx)
+		status := healthpb.HealthCheckResponse_SERVING
+		if !ready {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status != last {
+			log.Printf("Health status changed to %s: %v", status, report.Checks)
+			last = status
+		}
+		for _, service := range services {
+			srv.SetServingStatus(service, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[string]Level{
+	"debug": LevelDebug,
+	"info":  LevelInfo,
+	"warn":  LevelWarn,
+	"error": LevelError,
+}
+
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(LevelInfo))
+}
+
+func ParseLevel(name string) (Level, error) {
+	level, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// SetLevel changes the minimum level at runtime. It is safe to call while
+// other goroutines are logging.
+func SetLevel(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	current.Store(int32(level))
+	return nil
+}
+
+func Enabled(level Level) bool {
+	return Level(current.Load()) <= level
+}
+
+// Debugf logs only when the level is set to debug; use it for per-request
+// and per-message chatter that is too noisy for production.
+func Debugf(format string, args ...interface{}) {
+	if Enabled(LevelDebug) {
+		log.Printf(format, args...)
+	}
+}
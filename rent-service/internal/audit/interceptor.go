@@ -0,0 +1,335 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/proto/rent"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Metadata keys callers set to identify themselves. ActorKey names the
+// person behind a caller with a verified client certificate, such as the
+// operator signed in to the gateway; from other callers it is ignored and
+// the actor is the peer address. Without RequestIDKey one is generated and
+// returned in the response header.
+const (
+	ActorKey     = "x-actor"
+	RequestIDKey = "x-request-id"
+)
+
+// Target types.
+const (
+	TargetBike   = "bike"
+	TargetRent   = "rent"
+	TargetWallet = "wallet"
+	TargetPromo  = "promo_code"
+	TargetUser   = "user"
+)
+
+// recordTimeout bounds writing an entry after the call has returned.
+const recordTimeout = 5 * time.Second
+
+// Lookup loads the state of a target before the call changes it.
+type Lookup interface {
+	GetBikeByID(ctx context.Context, bikeID uuid.UUID) (*models.Bike, error)
+	GetRentByID(ctx context.Context, rentID uuid.UUID) (*models.Rent, error)
+}
+
+// method describes an audited RPC: what it changes and where the target ID
+// is found. targetID gets the response too for calls that create the
+// target; resp is nil when the call failed.
+type method struct {
+	targetType string
+	targetID   func(req, resp interface{}) string
+}
+
+// methods lists every state-changing RPC. Reads and streams of events are
+// not audited, except GetReceipt, which issues the invoice number of a rent
+// charged before invoices were numbered.
+var methods = map[string]method{
+	rent.RentService_StartRent_FullMethodName: {TargetBike, func(req, _ interface{}) string {
+		return req.(*rent.StartRentRequest).BikeId
+	}},
+	rent.RentService_EndRent_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.EndRentRequest).RentId
+	}},
+	rent.RentService_PauseRent_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.PauseRentRequest).RentId
+	}},
+	rent.RentService_ResumeRent_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.ResumeRentRequest).RentId
+	}},
+	rent.RentService_AddBike_FullMethodName: {TargetBike, func(_, resp interface{}) string {
+		if resp == nil {
+			return ""
+		}
+		return resp.(*rent.BikeResponse).Id
+	}},
+	rent.RentService_DeleteBike_FullMethodName: {TargetBike, func(req, _ interface{}) string {
+		return req.(*rent.DeleteBikeRequest).BikeId
+	}},
+	rent.RentService_ForceEndRent_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.ForceEndRentRequest).RentId
+	}},
+	rent.RentService_DisputeRent_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.DisputeRentRequest).RentId
+	}},
+	rent.RentService_SetBikeStatus_FullMethodName: {TargetBike, func(req, _ interface{}) string {
+		return req.(*rent.SetBikeStatusRequest).BikeId
+	}},
+	rent.RentService_TopUpWallet_FullMethodName: {TargetWallet, func(req, _ interface{}) string {
+		return req.(*rent.TopUpWalletRequest).UserId
+	}},
+	rent.RentService_BuyPass_FullMethodName: {TargetWallet, func(req, _ interface{}) string {
+		return req.(*rent.BuyPassRequest).UserId
+	}},
+	rent.RentService_CancelPass_FullMethodName: {TargetWallet, func(req, _ interface{}) string {
+		return req.(*rent.CancelPassRequest).UserId
+	}},
+	rent.RentService_RefundRent_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.RefundRentRequest).RentId
+	}},
+	rent.RentService_GetReceipt_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.GetReceiptRequest).RentId
+	}},
+	rent.RentService_ReplayRentEvents_FullMethodName: {TargetRent, func(_, _ interface{}) string { return "" }},
+	rent.RentService_ImportBikes_FullMethodName:      {TargetBike, func(_, _ interface{}) string { return "" }},
+	rent.RentService_RelocateBikes_FullMethodName:    {TargetBike, func(_, _ interface{}) string { return "" }},
+	rent.RentService_RebuildRentProjections_FullMethodName: {TargetRent, func(req, _ interface{}) string {
+		return req.(*rent.RebuildRentProjectionsRequest).RentId
+	}},
+	rent.RentService_CreatePromoCode_FullMethodName: {TargetPromo, func(req, _ interface{}) string {
+		return req.(*rent.PromoCode).Code
+	}},
+	rent.RentService_UpdatePromoCode_FullMethodName: {TargetPromo, func(req, _ interface{}) string {
+		return req.(*rent.PromoCode).Code
+	}},
+	rent.RentService_DeletePromoCode_FullMethodName: {TargetPromo, func(req, _ interface{}) string {
+		return req.(*rent.DeletePromoCodeRequest).Code
+	}},
+	rent.RentService_RegisterUser_FullMethodName: {TargetUser, func(req, resp interface{}) string {
+		if resp == nil {
+			return req.(*rent.RegisterUserRequest).UserId
+		}
+		return resp.(*rent.User).UserId
+	}},
+	rent.RentService_UpdateUser_FullMethodName: {TargetUser, func(req, _ interface{}) string {
+		return req.(*rent.UpdateUserRequest).UserId
+	}},
+	rent.RentService_SetUserStatus_FullMethodName: {TargetUser, func(req, _ interface{}) string {
+		return req.(*rent.SetUserStatusRequest).UserId
+	}},
+}
+
+var marshalOptions = protojson.MarshalOptions{UseProtoNames: true}
+
+// Interceptor records every state-changing RPC in the audit log, whether
+// it succeeded or not. A failure to record is logged but does not fail the
+// call, which has already taken effect.
+type Interceptor struct {
+	store  Store
+	lookup Lookup
+}
+
+func NewInterceptor(store Store, lookup Lookup) *Interceptor {
+	return &Interceptor{store: store, lookup: lookup}
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		m, ok := methods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		entry := i.begin(ctx, info.FullMethod, m, req)
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, entry.RequestID))
+
+		resp, err := handler(ctx, req)
+
+		if err == nil && responseError(resp) == "" {
+			entry.TargetID = m.targetID(req, resp)
+		}
+		i.finish(ctx, entry, resp, err)
+		return resp, err
+	}
+}
+
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		m, ok := methods[info.FullMethod]
+		if !ok {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		entry := i.begin(ctx, info.FullMethod, m, nil)
+		ss.SetHeader(metadata.Pairs(RequestIDKey, entry.RequestID))
+
+		rs := &recordingStream{ServerStream: ss}
+		err := handler(srv, rs)
+
+		i.finish(ctx, entry, rs.last, err)
+		return err
+	}
+}
+
+// recordingStream keeps the last message sent, which is the response of a
+// client-streaming call.
+type recordingStream struct {
+	grpc.ServerStream
+	last interface{}
+}
+
+func (s *recordingStream) SendMsg(m interface{}) error {
+	s.last = m
+	return s.ServerStream.SendMsg(m)
+}
+
+// begin starts an entry with the caller identity and the target state
+// before the call. req is nil for streams, whose targets are not known
+// upfront.
+func (i *Interceptor) begin(ctx context.Context, fullMethod string, m method, req interface{}) Entry {
+	actor, peerName := identify(ctx)
+	entry := Entry{
+		Actor:      actor,
+		Peer:       peerName,
+		Action:     fullMethod[strings.LastIndex(fullMethod, "/")+1:],
+		TargetType: m.targetType,
+		RequestID:  incoming(ctx, RequestIDKey),
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = uuid.NewString()
+	}
+
+	if req != nil {
+		entry.TargetID = m.targetID(req, nil)
+		entry.Before = i.snapshot(ctx, entry.TargetType, entry.TargetID)
+	}
+	return entry
+}
+
+func (i *Interceptor) finish(ctx context.Context, entry Entry, resp interface{}, err error) {
+	if err != nil {
+		entry.Error = status.Convert(err).Message()
+	} else if msg := responseError(resp); msg != "" {
+		entry.Error = msg
+	} else if msg, ok := resp.(proto.Message); ok {
+		data, err := marshalOptions.Marshal(msg)
+		if err != nil {
+			log.Printf("Failed to marshal audit state for %s: %v", entry.Action, err)
+		} else {
+			entry.After = data
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	if err := i.store.Record(ctx, entry); err != nil {
+		log.Printf("Failed to record audit entry: action=%s, target=%s, request_id=%s: %v",
+			entry.Action, entry.TargetID, entry.RequestID, err)
+	}
+}
+
+// responseError returns the failure reported in the body of a response.
+// The rider and bike RPCs predate gRPC status codes and answer failures
+// with an error status or success false and the reason in the message.
+func responseError(resp interface{}) string {
+	switch r := resp.(type) {
+	case *rent.RentResponse:
+		if r.Status == "error" {
+			return r.Message
+		}
+	case *rent.BikeResponse:
+		if r.Status == "error" {
+			return r.Message
+		}
+	case *rent.DeleteBikeResponse:
+		if !r.Success {
+			return r.Message
+		}
+	}
+	return ""
+}
+
+// snapshot returns the target as JSON, or nil if it cannot be loaded.
+// Wallets are not snapshotted: their ledger already records every change.
+// Neither are promo codes and users: every change records the whole
+// target after it, so the entry before holds its previous state.
+func (i *Interceptor) snapshot(ctx context.Context, targetType, id string) json.RawMessage {
+	if targetType == TargetWallet || targetType == TargetPromo || targetType == TargetUser {
+		return nil
+	}
+	targetID, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+
+	var state interface{}
+	switch targetType {
+	case TargetBike:
+		state, err = i.lookup.GetBikeByID(ctx, targetID)
+	case TargetRent:
+		state, err = i.lookup.GetRentByID(ctx, targetID)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("Failed to load audit state of %s %s: %v", targetType, id, err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// identify returns the actor and the peer that made the call. The peer is
+// the verified client certificate's common name, or the address when the
+// caller has no certificate. Only a peer with a certificate may name a
+// different actor in x-actor metadata: anyone else could forge it.
+func identify(ctx context.Context) (actor, peerName string) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown", "unknown"
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+		if cn := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			if a := incoming(ctx, ActorKey); a != "" {
+				return a, cn
+			}
+			return cn, cn
+		}
+	}
+	if p.Addr != nil {
+		return p.Addr.String(), p.Addr.String()
+	}
+	return "unknown", "unknown"
+}
+
+func incoming(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/mocks"
+	"bike-rental/rent-service/proto/rent"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStore - запоминает записанные записи аудита
+type fakeStore struct {
+	entries []Entry
+}
+
+func (f *fakeStore) Record(ctx context.Context, entry Entry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeStore) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	return f.entries, nil
+}
+
+// fakeStream - клиентский поток ImportBikes, отправленные ответы запоминаются
+type fakeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []interface{}
+}
+
+func (s *fakeStream) Context() context.Context       { return s.ctx }
+func (s *fakeStream) SetHeader(md metadata.MD) error { return nil }
+func (s *fakeStream) SendMsg(m interface{}) error    { s.sent = append(s.sent, m); return nil }
+
+// InterceptorTestSuite - тестовый набор для записи аудита
+type InterceptorTestSuite struct {
+	suite.Suite
+	mockRepo    *mocks.Repository
+	store       *fakeStore
+	interceptor *Interceptor
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *InterceptorTestSuite) SetupTest() {
+	suite.mockRepo = mocks.NewRepository(suite.T())
+	suite.store = &fakeStore{}
+	suite.interceptor = NewInterceptor(suite.store, suite.mockRepo)
+}
+
+// withClientCert - вызов от клиента с проверенным сертификатом cn
+func withClientCert(ctx context.Context, cn string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	return peer.NewContext(ctx, &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.IPv4(10, 0, 0, 5), Port: 5000},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+}
+
+func (suite *InterceptorTestSuite) call(ctx context.Context, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	return suite.interceptor.Unary()(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+}
+
+// TestDeleteBike_RecordsBeforeAndAfter - удаление записывает актора, ID запроса и состояние до и после
+func (suite *InterceptorTestSuite) TestDeleteBike_RecordsBeforeAndAfter() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("GetBikeByID", mock.Anything, bikeID).
+		Return(&models.Bike{ID: bikeID, Name: "Bike 1", Status: "available", Location: "Center"}, nil)
+	ctx := metadata.NewIncomingContext(withClientCert(context.Background(), "api-gateway"), metadata.Pairs(ActorKey, "alice", RequestIDKey, "req-1"))
+
+	_, err := suite.call(ctx, rent.RentService_DeleteBike_FullMethodName, &rent.DeleteBikeRequest{BikeId: bikeID.String()},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.DeleteBikeResponse{Success: true, Message: "Bike deleted successfully"}, nil
+		})
+
+	suite.NoError(err)
+	suite.Require().Len(suite.store.entries, 1)
+	entry := suite.store.entries[0]
+	suite.Equal("alice", entry.Actor)
+	suite.Equal("api-gateway", entry.Peer)
+	suite.Equal("DeleteBike", entry.Action)
+	suite.Equal(TargetBike, entry.TargetType)
+	suite.Equal(bikeID.String(), entry.TargetID)
+	suite.Equal("req-1", entry.RequestID)
+	suite.Contains(string(entry.Before), `"name":"Bike 1"`)
+	suite.JSONEq(`{"success":true,"message":"Bike deleted successfully"}`, string(entry.After))
+	suite.Empty(entry.Error)
+}
+
+// TestAddBike_TargetFromResponse - ID созданного велосипеда берётся из ответа, состояния до нет
+func (suite *InterceptorTestSuite) TestAddBike_TargetFromResponse() {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 4242}})
+
+	_, err := suite.call(ctx, rent.RentService_AddBike_FullMethodName, &rent.AddBikeRequest{Name: "Bike 9", Location: "Park"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.BikeResponse{Id: "new-id", Name: "Bike 9"}, nil
+		})
+
+	suite.NoError(err)
+	entry := suite.store.entries[0]
+	suite.Equal("10.0.0.7:4242", entry.Actor)
+	suite.Equal("10.0.0.7:4242", entry.Peer)
+	suite.Equal("new-id", entry.TargetID)
+	suite.Nil(entry.Before)
+	suite.NotEmpty(entry.RequestID)
+}
+
+// TestActorWithoutCert_Ignored - x-actor от клиента без сертификата не принимается
+func (suite *InterceptorTestSuite) TestActorWithoutCert_Ignored() {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 9), Port: 4242}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ActorKey, "alice"))
+
+	_, err := suite.call(ctx, rent.RentService_TopUpWallet_FullMethodName, &rent.TopUpWalletRequest{UserId: "u1"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.Wallet{UserId: "u1"}, nil
+		})
+
+	suite.NoError(err)
+	entry := suite.store.entries[0]
+	suite.Equal("10.0.0.9:4242", entry.Actor)
+	suite.Equal("10.0.0.9:4242", entry.Peer)
+}
+
+// TestClientCert_WithoutActor - без x-actor актор - имя сертификата
+func (suite *InterceptorTestSuite) TestClientCert_WithoutActor() {
+	_, err := suite.call(withClientCert(context.Background(), "api-gateway"), rent.RentService_TopUpWallet_FullMethodName,
+		&rent.TopUpWalletRequest{UserId: "u1"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.Wallet{UserId: "u1"}, nil
+		})
+
+	suite.NoError(err)
+	entry := suite.store.entries[0]
+	suite.Equal("api-gateway", entry.Actor)
+	suite.Equal("api-gateway", entry.Peer)
+}
+
+// TestFailedCall_RecordsError - неудачный вызов тоже попадает в журнал
+func (suite *InterceptorTestSuite) TestFailedCall_RecordsError() {
+	rentID := uuid.New()
+	suite.mockRepo.On("GetRentByID", mock.Anything, rentID).Return(nil, fmt.Errorf("rent %w", repository.ErrNotFound))
+
+	_, err := suite.call(context.Background(), rent.RentService_ForceEndRent_FullMethodName, &rent.ForceEndRentRequest{RentId: rentID.String()},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "rent not found")
+		})
+
+	suite.Error(err)
+	entry := suite.store.entries[0]
+	suite.Equal(rentID.String(), entry.TargetID)
+	suite.Equal("rent not found", entry.Error)
+	suite.Nil(entry.Before)
+	suite.Nil(entry.After)
+}
+
+// TestErrorInResponse_RecordsError - ошибка, возвращённая в теле ответа, записывается как неудача
+func (suite *InterceptorTestSuite) TestErrorInResponse_RecordsError() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("GetBikeByID", mock.Anything, bikeID).Return(&models.Bike{ID: bikeID, Status: "rented"}, nil)
+
+	_, err := suite.call(context.Background(), rent.RentService_StartRent_FullMethodName,
+		&rent.StartRentRequest{UserId: "u1", BikeId: bikeID.String()},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.RentResponse{Status: "error", Message: "bike is not available"}, nil
+		})
+
+	suite.NoError(err)
+	entry := suite.store.entries[0]
+	suite.Equal(bikeID.String(), entry.TargetID)
+	suite.Equal("bike is not available", entry.Error)
+	suite.Nil(entry.After)
+}
+
+// TestDeleteBikeFailure_RecordsError - неудачное удаление с success=false записывается с причиной
+func (suite *InterceptorTestSuite) TestDeleteBikeFailure_RecordsError() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("GetBikeByID", mock.Anything, bikeID).Return(&models.Bike{ID: bikeID, Status: "available"}, nil)
+
+	_, err := suite.call(context.Background(), rent.RentService_DeleteBike_FullMethodName, &rent.DeleteBikeRequest{BikeId: bikeID.String()},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.DeleteBikeResponse{Success: false, Message: "bike has rents"}, nil
+		})
+
+	suite.NoError(err)
+	suite.Equal("bike has rents", suite.store.entries[0].Error)
+}
+
+// TestGetReceipt_Audited - чек может выдать номер счёта, поэтому запрос записывается
+func (suite *InterceptorTestSuite) TestGetReceipt_Audited() {
+	rentID := uuid.New()
+	suite.mockRepo.On("GetRentByID", mock.Anything, rentID).Return(&models.Rent{ID: rentID, Status: "completed"}, nil)
+
+	_, err := suite.call(context.Background(), rent.RentService_GetReceipt_FullMethodName, &rent.GetReceiptRequest{RentId: rentID.String()},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.Receipt{Invoice: &rent.Invoice{Number: "BR-2024-000042"}}, nil
+		})
+
+	suite.NoError(err)
+	suite.Require().Len(suite.store.entries, 1)
+	entry := suite.store.entries[0]
+	suite.Equal("GetReceipt", entry.Action)
+	suite.Equal(rentID.String(), entry.TargetID)
+	suite.Contains(string(entry.After), "BR-2024-000042")
+}
+
+// TestReadsAreNotAudited - запросы на чтение не записываются
+func (suite *InterceptorTestSuite) TestReadsAreNotAudited() {
+	_, err := suite.call(context.Background(), rent.RentService_GetAvailableBikes_FullMethodName, &rent.AvailableBikesRequest{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &rent.BikesList{}, nil
+		})
+
+	suite.NoError(err)
+	suite.Empty(suite.store.entries)
+}
+
+// TestImportBikes_RecordsResponse - для потока записывается итоговый ответ
+func (suite *InterceptorTestSuite) TestImportBikes_RecordsResponse() {
+	ss := &fakeStream{ctx: metadata.NewIncomingContext(withClientCert(context.Background(), "api-gateway"), metadata.Pairs(ActorKey, "bikectl"))}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.SendMsg(&rent.ImportBikesResponse{Total: 2})
+	}
+
+	err := suite.interceptor.Stream()(nil, ss, &grpc.StreamServerInfo{FullMethod: rent.RentService_ImportBikes_FullMethodName}, handler)
+
+	suite.NoError(err)
+	suite.Len(ss.sent, 1)
+	entry := suite.store.entries[0]
+	suite.Equal("ImportBikes", entry.Action)
+	suite.Equal("bikectl", entry.Actor)
+	suite.JSONEq(`{"total":2}`, string(entry.After))
+}
+
+// TestStreamError - ошибка потока записывается без ответа
+func (suite *InterceptorTestSuite) TestStreamError() {
+	ss := &fakeStream{ctx: context.Background()}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return errors.New("boom")
+	}
+
+	err := suite.interceptor.Stream()(nil, ss, &grpc.StreamServerInfo{FullMethod: rent.RentService_ImportBikes_FullMethodName}, handler)
+
+	suite.Error(err)
+	suite.Equal("boom", suite.store.entries[0].Error)
+	suite.Equal("unknown", suite.store.entries[0].Actor)
+}
+
+// TestInterceptorTestSuite - запуск тестового набора
+func TestInterceptorTestSuite(t *testing.T) {
+	suite.Run(t, new(InterceptorTestSuite))
+}
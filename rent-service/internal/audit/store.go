@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Entry is one state-changing call. Before and After are JSON; Before is
+// nil when the target did not exist or is not known before the call.
+// Actor is who acted and Peer the authenticated caller that vouched for
+// them; they are the same unless the peer named an actor.
+type Entry struct {
+	ID         int64
+	Time       time.Time
+	Actor      string
+	Peer       string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     json.RawMessage
+	After      json.RawMessage
+	RequestID  string
+	Error      string
+}
+
+// Filter selects entries; zero fields match everything. Since is inclusive
+// and Until exclusive.
+type Filter struct {
+	Actor     string
+	Action    string
+	TargetID  string
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// Store appends entries to the audit_log table and queries them. The
+// table rejects updates and deletes, see scripts/audit-log.sql.
+type Store interface {
+	Record(ctx context.Context, entry Entry) error
+	// List returns matching entries, newest first. Limit defaults to 100
+	// and is capped at 1000.
+	List(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+type pgStore struct {
+	db *pgxpool.Pool
+}
+
+func NewStore(db *pgxpool.Pool) Store {
+	return &pgStore{db: db}
+}
+
+func (s *pgStore) Record(ctx context.Context, e Entry) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO audit_log (actor, peer, action, target_type, target_id, before_state, after_state, request_id, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, e.Actor, e.Peer, e.Action, e.TargetType, e.TargetID, nullJSON(e.Before), nullJSON(e.After), e.RequestID, e.Error)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+func (s *pgStore) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	var conds []string
+	var args []interface{}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.TargetID != "" {
+		where("target_id = $%d", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		where("request_id = $%d", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < $%d", filter.Until)
+	}
+
+	limit := filter.Limit
+	switch {
+	case limit <= 0:
+		limit = defaultLimit
+	case limit > maxLimit:
+		limit = maxLimit
+	}
+
+	query := `
+		SELECT id, created_at, actor, peer, action, target_type, target_id,
+			COALESCE(before_state::text, ''), COALESCE(after_state::text, ''), request_id, error
+		FROM audit_log
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT %d", limit)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var before, after string
+		if err := rows.Scan(&e.ID, &e.Time, &e.Actor, &e.Peer, &e.Action, &e.TargetType, &e.TargetID, &before, &after, &e.RequestID, &e.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if before != "" {
+			e.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			e.After = json.RawMessage(after)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// nullJSON stores missing state as SQL NULL rather than an empty string,
+// which is not valid JSONB.
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/wallet"
+	"github.com/google/uuid"
+)
+
+// allLocations is the cache key suffix for GetAvailableBikes without a
+// location filter.
+const allLocations = "*"
+
+// Metrics counts cache hits, misses, store errors and invalidations. It is
+// published under "bike_cache" at /debug/vars.
+var Metrics = expvar.NewMap("bike_cache")
+
+// bikeCache is a read-through cache for available bikes in front of the
+// repository. Every write that can change availability invalidates the
+// bike's location and the unfiltered list. The TTL bounds staleness when a
+// read races with a write or an invalidation fails.
+type bikeCache struct {
+	repository.Repository
+	store Store
+	ttl   time.Duration
+}
+
+// NewBikeCache wraps repo so GetAvailableBikes is served from store. Store
+// errors are logged and the database is used instead.
+func NewBikeCache(repo repository.Repository, store Store, ttl time.Duration) repository.Repository {
+	return &bikeCache{
+		Repository: repo,
+		store:      store,
+		ttl:        ttl,
+	}
+}
+
+func availableKey(location string) string {
+	if location == "" {
+		location = allLocations
+	}
+	return "bikes:available:" + location
+}
+
+func (c *bikeCache) GetAvailableBikes(ctx context.Context, location string) ([]models.Bike, error) {
+	key := availableKey(location)
+
+	bikes, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		Metrics.Add("errors", 1)
+		log.Printf("Failed to read bike cache %s: %v", key, err)
+	} else if ok {
+		Metrics.Add("hits", 1)
+		return bikes, nil
+	}
+	Metrics.Add("misses", 1)
+
+	bikes, err = c.Repository.GetAvailableBikes(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store.Set(ctx, key, bikes, c.ttl); err != nil {
+		Metrics.Add("errors", 1)
+		log.Printf("Failed to write bike cache %s: %v", key, err)
+	}
+
+	return bikes, nil
+}
+
+func (c *bikeCache) StartRent(ctx context.Context, userID string, requireRegistration bool, bikeID uuid.UUID, tariff *wallet.Tariff, promoCode string) (*models.Rent, error) {
+	rent, err := c.Repository.StartRent(ctx, userID, requireRegistration, bikeID, tariff, promoCode)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, rent.Location)
+	return rent, nil
+}
+
+func (c *bikeCache) EndRent(ctx context.Context, rentID uuid.UUID, userID, promoCode string) (*models.Rent, error) {
+	rent, err := c.Repository.EndRent(ctx, rentID, userID, promoCode)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, rent.Location)
+	return rent, nil
+}
+
+func (c *bikeCache) ForceEndRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error) {
+	rent, err := c.Repository.ForceEndRent(ctx, rentID, reason)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, rent.Location)
+	return rent, nil
+}
+
+func (c *bikeCache) AddBike(ctx context.Context, name, location string) (*models.Bike, error) {
+	bike, err := c.Repository.AddBike(ctx, name, location)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, bike.Location)
+	return bike, nil
+}
+
+func (c *bikeCache) AddBikes(ctx context.Context, bikes []models.Bike) ([]models.Bike, error) {
+	added, err := c.Repository.AddBikes(ctx, bikes)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[string]bool)
+	for _, bike := range added {
+		if !locations[bike.Location] {
+			locations[bike.Location] = true
+			c.invalidate(ctx, bike.Location)
+		}
+	}
+	return added, nil
+}
+
+func (c *bikeCache) DeleteBike(ctx context.Context, bikeID uuid.UUID) error {
+	location := c.locationOf(ctx, bikeID)
+	if err := c.Repository.DeleteBike(ctx, bikeID); err != nil {
+		return err
+	}
+	c.invalidate(ctx, location)
+	return nil
+}
+
+func (c *bikeCache) UpdateBikeStatus(ctx context.Context, bikeID uuid.UUID, status string) error {
+	location := c.locationOf(ctx, bikeID)
+	if err := c.Repository.UpdateBikeStatus(ctx, bikeID, status); err != nil {
+		return err
+	}
+	c.invalidate(ctx, location)
+	return nil
+}
+
+func (c *bikeCache) RelocateBikes(ctx context.Context, from, to string, count int) ([]models.Bike, error) {
+	bikes, err := c.Repository.RelocateBikes(ctx, from, to, count)
+	if err != nil {
+		return nil, err
+	}
+	if len(bikes) > 0 {
+		c.invalidate(ctx, from)
+		c.invalidate(ctx, to)
+	}
+	return bikes, nil
+}
+
+// locationOf returns the bike's location, or "" when it cannot be read; the
+// unfiltered list is invalidated either way.
+func (c *bikeCache) locationOf(ctx context.Context, bikeID uuid.UUID) string {
+	bike, err := c.Repository.GetBikeByID(ctx, bikeID)
+	if err != nil {
+		return ""
+	}
+	return bike.Location
+}
+
+func (c *bikeCache) invalidate(ctx context.Context, location string) {
+	keys := []string{availableKey("")}
+	if location != "" {
+		keys = append(keys, availableKey(location))
+	}
+
+	Metrics.Add("invalidations", 1)
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		Metrics.Add("errors", 1)
+		log.Printf("Failed to invalidate bike cache %v: %v", keys, err)
+	}
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/wallet"
+	"bike-rental/rent-service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeStore - in-memory хранилище кэша
+type fakeStore struct {
+	data map[string][]models.Bike
+	err  error
+}
+
+func (f *fakeStore) Get(ctx context.Context, key string) ([]models.Bike, bool, error) {
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	bikes, ok := f.data[key]
+	return bikes, ok, nil
+}
+
+func (f *fakeStore) Set(ctx context.Context, key string, bikes []models.Bike, ttl time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.data[key] = bikes
+	return nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(f.data, key)
+	}
+	return nil
+}
+
+// BikeCacheTestSuite - тестовый набор для кэша доступных велосипедов
+type BikeCacheTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.Repository
+	store    *fakeStore
+	cache    repository.Repository
+	ctx      context.Context
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *BikeCacheTestSuite) SetupTest() {
+	suite.mockRepo = mocks.NewRepository(suite.T())
+	suite.store = &fakeStore{data: make(map[string][]models.Bike)}
+	suite.cache = NewBikeCache(suite.mockRepo, suite.store, time.Minute)
+	suite.ctx = context.Background()
+}
+
+// TestGetAvailableBikes_ReadThrough - второй запрос обслуживается из кэша
+func (suite *BikeCacheTestSuite) TestGetAvailableBikes_ReadThrough() {
+	bikes := []models.Bike{{ID: uuid.New(), Name: "Bike 1", Status: "available", Location: "Park"}}
+	suite.mockRepo.On("GetAvailableBikes", suite.ctx, "Park").Return(bikes, nil).Once()
+
+	first, err := suite.cache.GetAvailableBikes(suite.ctx, "Park")
+	suite.NoError(err)
+	second, err := suite.cache.GetAvailableBikes(suite.ctx, "Park")
+	suite.NoError(err)
+
+	suite.Equal(bikes, first)
+	suite.Equal(bikes, second)
+}
+
+// TestGetAvailableBikes_StoreErrorFallsBack - при недоступном Redis данные читаются из базы
+func (suite *BikeCacheTestSuite) TestGetAvailableBikes_StoreErrorFallsBack() {
+	suite.store.err = errors.New("connection refused")
+	suite.mockRepo.On("GetAvailableBikes", suite.ctx, "").Return([]models.Bike{}, nil).Twice()
+
+	_, err := suite.cache.GetAvailableBikes(suite.ctx, "")
+	suite.NoError(err)
+	_, err = suite.cache.GetAvailableBikes(suite.ctx, "")
+	suite.NoError(err)
+}
+
+// TestStartRent_InvalidatesLocation - старт аренды сбрасывает кэш локации и общий список
+func (suite *BikeCacheTestSuite) TestStartRent_InvalidatesLocation() {
+	suite.store.data[availableKey("Park")] = []models.Bike{}
+	suite.store.data[availableKey("")] = []models.Bike{}
+	suite.store.data[availableKey("Center")] = []models.Bike{}
+
+	bikeID := uuid.New()
+	suite.mockRepo.On("StartRent", suite.ctx, "user1", false, bikeID, (*wallet.Tariff)(nil), "").Return(&models.Rent{Location: "Park"}, nil)
+
+	_, err := suite.cache.StartRent(suite.ctx, "user1", false, bikeID, nil, "")
+	suite.NoError(err)
+
+	suite.NotContains(suite.store.data, availableKey("Park"))
+	suite.NotContains(suite.store.data, availableKey(""))
+	suite.Contains(suite.store.data, availableKey("Center"))
+}
+
+// TestDeleteBike_InvalidatesLocation - удаление велосипеда сбрасывает кэш его локации
+func (suite *BikeCacheTestSuite) TestDeleteBike_InvalidatesLocation() {
+	suite.store.data[availableKey("Park")] = []models.Bike{}
+
+	bikeID := uuid.New()
+	suite.mockRepo.On("GetBikeByID", suite.ctx, bikeID).Return(&models.Bike{ID: bikeID, Location: "Park"}, nil)
+	suite.mockRepo.On("DeleteBike", suite.ctx, bikeID).Return(nil)
+
+	suite.NoError(suite.cache.DeleteBike(suite.ctx, bikeID))
+
+	suite.NotContains(suite.store.data, availableKey("Park"))
+}
+
+// TestFailedWrite_KeepsCache - неудачная операция не сбрасывает кэш
+func (suite *BikeCacheTestSuite) TestFailedWrite_KeepsCache() {
+	suite.store.data[availableKey("")] = []models.Bike{}
+
+	suite.mockRepo.On("AddBike", suite.ctx, "Bike", "Park").Return(nil, errors.New("db down"))
+
+	_, err := suite.cache.AddBike(suite.ctx, "Bike", "Park")
+	suite.Error(err)
+
+	suite.Contains(suite.store.data, availableKey(""))
+}
+
+func TestBikeCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(BikeCacheTestSuite))
+}
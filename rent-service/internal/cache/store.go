@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps cached bike lists by key.
+type Store interface {
+	// Get returns the cached bikes and whether key was present.
+	Get(ctx context.Context, key string) ([]models.Bike, bool, error)
+	Set(ctx context.Context, key string, bikes []models.Bike, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) ([]models.Bike, bool, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var bikes []models.Bike
+	if err := json.Unmarshal(data, &bikes); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal cached bikes: %w", err)
+	}
+	return bikes, true, nil
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, bikes []models.Bike, ttl time.Duration) error {
+	data, err := json.Marshal(bikes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bikes: %w", err)
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *redisStore) Delete(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,251 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fleet event types.
+const (
+	RentStarted = "rent_started"
+	RentEnded   = "rent_ended"
+	// RentPaused and RentResumed keep the bike rented to the same user.
+	RentPaused  = "rent_paused"
+	RentResumed = "rent_resumed"
+	BikeAdded   = "bike_added"
+	BikeRemoved = "bike_removed"
+	// BikeStatusChanged is an operator changing a bike's status outside
+	// of a rent.
+	BikeStatusChanged = "bike_status_changed"
+	// BikeRelocated is an available bike moved from FromLocation to
+	// Location, e.g. when rebalancing the fleet.
+	BikeRelocated = "bike_relocated"
+)
+
+var (
+	// ErrInvalidToken is returned for resume tokens this broadcaster could
+	// not have issued.
+	ErrInvalidToken = errors.New("invalid resume token")
+	// ErrTokenExpired is returned when events after a resume token are no
+	// longer retained, either because they fell out of the history or
+	// because the process restarted. Subscribers have to resync from a
+	// snapshot.
+	ErrTokenExpired = errors.New("resume token expired")
+)
+
+// FleetEvent is a change to a bike or rent, published after it is
+// committed to the database.
+type FleetEvent struct {
+	// Seq is assigned by Publish and increases by one per event.
+	Seq      uint64
+	Type     string
+	BikeID   string
+	BikeName string
+	RentID   string
+	UserID   string
+	Location string
+	// FromLocation is only set for relocated bikes
+	FromLocation string
+	BikeStatus   string
+	Timestamp    time.Time
+}
+
+// Broadcaster fans fleet events out to in-process subscribers. Publish
+// never blocks: a subscriber whose buffer is full is dropped and has to
+// subscribe again. The last history events are retained so a subscriber
+// can resume from a token without missing changes.
+type Broadcaster struct {
+	buffer int
+	// epoch identifies this process so tokens from a previous run are
+	// rejected instead of silently skipping events.
+	epoch string
+
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	closed  bool
+	seq     uint64
+	history []FleetEvent
+	next    int
+	full    bool
+}
+
+func NewBroadcaster(buffer, history int) *Broadcaster {
+	return &Broadcaster{
+		buffer:  buffer,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:    make(map[*Subscription]struct{}),
+		history: make([]FleetEvent, history),
+	}
+}
+
+// Subscription receives events on C until it is closed, either by Close or
+// by the broadcaster when the subscriber falls behind.
+type Subscription struct {
+	C <-chan FleetEvent
+
+	ch       chan FleetEvent
+	b        *Broadcaster
+	overflow bool
+}
+
+// Subscribe receives events published from now on.
+func (b *Broadcaster) Subscribe() *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.subscribe(nil)
+}
+
+// SubscribeFrom receives the retained events after token followed by new
+// ones. An empty token behaves like Subscribe.
+func (b *Broadcaster) SubscribeFrom(token string) (*Subscription, error) {
+	if token == "" {
+		return b.Subscribe(), nil
+	}
+
+	epoch, after, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if epoch != b.epoch {
+		return nil, ErrTokenExpired
+	}
+	if after > b.seq {
+		return nil, ErrInvalidToken
+	}
+	replay := b.since(after)
+	if replay == nil {
+		return nil, ErrTokenExpired
+	}
+
+	return b.subscribe(replay), nil
+}
+
+// Token returns a resume token for the current position: subscribing from
+// it delivers every event published afterwards. Taking it before reading a
+// snapshot means no change made after the snapshot is missed.
+func (b *Broadcaster) Token() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ResumeToken(b.seq)
+}
+
+// ResumeToken returns the token that resumes after the event with seq.
+func (b *Broadcaster) ResumeToken(seq uint64) string {
+	return fmt.Sprintf("%s.%d", b.epoch, seq)
+}
+
+func parseToken(token string) (string, uint64, error) {
+	epoch, seq, ok := strings.Cut(token, ".")
+	if !ok || epoch == "" {
+		return "", 0, ErrInvalidToken
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidToken
+	}
+	return epoch, n, nil
+}
+
+func (b *Broadcaster) subscribe(replay []FleetEvent) *Subscription {
+	ch := make(chan FleetEvent, b.buffer+len(replay))
+	sub := &Subscription{C: ch, ch: ch, b: b}
+
+	if b.closed {
+		close(ch)
+		return sub
+	}
+	for _, event := range replay {
+		ch <- event
+	}
+	b.subs[sub] = struct{}{}
+
+	return sub
+}
+
+// since returns the retained events with Seq greater than after, or nil if
+// some of them were already evicted.
+func (b *Broadcaster) since(after uint64) []FleetEvent {
+	retained := uint64(b.next)
+	if b.full {
+		retained = uint64(len(b.history))
+	}
+	missing := b.seq - after
+	if missing > retained {
+		return nil
+	}
+
+	replay := make([]FleetEvent, 0, missing)
+	for i := missing; i > 0; i-- {
+		idx := (b.next - int(i) + len(b.history)) % len(b.history)
+		replay = append(replay, b.history[idx])
+	}
+	return replay
+}
+
+func (b *Broadcaster) Publish(event FleetEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event.Seq = b.seq
+	if len(b.history) > 0 {
+		b.history[b.next] = event
+		b.next = (b.next + 1) % len(b.history)
+		if b.next == 0 {
+			b.full = true
+		}
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			log.Printf("Fleet subscriber is too slow, dropping it")
+			sub.overflow = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Overflowed reports whether the subscription was dropped for falling
+// behind. It is only meaningful once C is closed.
+func (s *Subscription) Overflowed() bool {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.overflow
+}
+
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.remove(s)
+}
+
+// Close ends every subscription so streaming RPCs return and the gRPC
+// server can stop gracefully.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Broadcaster) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
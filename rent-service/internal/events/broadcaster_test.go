@@ -0,0 +1,119 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// BroadcasterTestSuite - тестовый набор для раздачи событий парка
+type BroadcasterTestSuite struct {
+	suite.Suite
+	broadcaster *Broadcaster
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *BroadcasterTestSuite) SetupTest() {
+	suite.broadcaster = NewBroadcaster(2, 3)
+}
+
+// TestPublish_DeliversToAllSubscribers - событие получают все подписчики
+func (suite *BroadcasterTestSuite) TestPublish_DeliversToAllSubscribers() {
+	first := suite.broadcaster.Subscribe()
+	second := suite.broadcaster.Subscribe()
+
+	suite.broadcaster.Publish(FleetEvent{Type: RentStarted, BikeID: "bike-1"})
+
+	suite.Equal("bike-1", (<-first.C).BikeID)
+	suite.Equal("bike-1", (<-second.C).BikeID)
+}
+
+// TestPublish_DropsSlowSubscriber - переполненный подписчик отключается, остальные продолжают получать события
+func (suite *BroadcasterTestSuite) TestPublish_DropsSlowSubscriber() {
+	slow := suite.broadcaster.Subscribe()
+	fast := suite.broadcaster.Subscribe()
+
+	for i := 0; i < 3; i++ {
+		suite.broadcaster.Publish(FleetEvent{Type: RentStarted})
+		if i < 2 {
+			<-fast.C
+		}
+	}
+
+	<-slow.C
+	<-slow.C
+	_, ok := <-slow.C
+	suite.False(ok)
+	suite.True(slow.Overflowed())
+
+	_, ok = <-fast.C
+	suite.True(ok)
+	suite.False(fast.Overflowed())
+}
+
+// TestClose_EndsSubscriptions - Close завершает все подписки
+func (suite *BroadcasterTestSuite) TestClose_EndsSubscriptions() {
+	sub := suite.broadcaster.Subscribe()
+
+	suite.broadcaster.Close()
+
+	_, ok := <-sub.C
+	suite.False(ok)
+	_, ok = <-suite.broadcaster.Subscribe().C
+	suite.False(ok)
+	suite.NotPanics(sub.Close)
+}
+
+// TestSubscribeFrom_ReplaysMissedEvents - подписка с токена получает пропущенные события без дублей
+func (suite *BroadcasterTestSuite) TestSubscribeFrom_ReplaysMissedEvents() {
+	suite.broadcaster.Publish(FleetEvent{BikeID: "bike-1"})
+	token := suite.broadcaster.Token()
+	suite.broadcaster.Publish(FleetEvent{BikeID: "bike-2"})
+	suite.broadcaster.Publish(FleetEvent{BikeID: "bike-3"})
+
+	sub, err := suite.broadcaster.SubscribeFrom(token)
+	suite.Require().NoError(err)
+	suite.broadcaster.Publish(FleetEvent{BikeID: "bike-4"})
+
+	for _, id := range []string{"bike-2", "bike-3", "bike-4"} {
+		event := <-sub.C
+		suite.Equal(id, event.BikeID)
+	}
+	suite.Equal(suite.broadcaster.Token(), suite.broadcaster.ResumeToken(4))
+}
+
+// TestSubscribeFrom_CurrentToken - токен текущей позиции ничего не воспроизводит
+func (suite *BroadcasterTestSuite) TestSubscribeFrom_CurrentToken() {
+	suite.broadcaster.Publish(FleetEvent{BikeID: "bike-1"})
+
+	sub, err := suite.broadcaster.SubscribeFrom(suite.broadcaster.Token())
+	suite.Require().NoError(err)
+
+	suite.Empty(sub.C)
+}
+
+// TestSubscribeFrom_Expired - токен старше истории или от другого процесса устарел
+func (suite *BroadcasterTestSuite) TestSubscribeFrom_Expired() {
+	token := suite.broadcaster.Token()
+	for i := 0; i < 4; i++ {
+		suite.broadcaster.Publish(FleetEvent{})
+	}
+
+	_, err := suite.broadcaster.SubscribeFrom(token)
+	suite.ErrorIs(err, ErrTokenExpired)
+
+	_, err = NewBroadcaster(2, 3).SubscribeFrom(suite.broadcaster.Token())
+	suite.ErrorIs(err, ErrTokenExpired)
+}
+
+// TestSubscribeFrom_Invalid - некорректный токен или токен из будущего отклоняются
+func (suite *BroadcasterTestSuite) TestSubscribeFrom_Invalid() {
+	for _, token := range []string{"garbage", ".1", "abc.x", suite.broadcaster.ResumeToken(10)} {
+		_, err := suite.broadcaster.SubscribeFrom(token)
+		suite.ErrorIs(err, ErrInvalidToken, token)
+	}
+}
+
+func TestBroadcasterTestSuite(t *testing.T) {
+	suite.Run(t, new(BroadcasterTestSuite))
+}
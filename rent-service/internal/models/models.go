@@ -7,30 +7,88 @@ import (
 )
 
 type Bike struct {
-	ID        uuid.UUID `db:"id"`
-	Name      string    `db:"name"`
-	Status    string    `db:"status"`
-	Location  string    `db:"location"`
-	CreatedAt time.Time `db:"created_at"`
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Status    string    `db:"status" json:"status"`
+	Location  string    `db:"location" json:"location"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
 type Rent struct {
-	ID        uuid.UUID  `db:"id"`
-	UserID    string     `db:"user_id"`
-	BikeID    uuid.UUID  `db:"bike_id"`
-	StartTime time.Time  `db:"start_time"`
-	EndTime   *time.Time `db:"end_time"`
-	Status    string     `db:"status"`
+	ID        uuid.UUID  `db:"id" json:"id"`
+	UserID    string     `db:"user_id" json:"user_id"`
+	BikeID    uuid.UUID  `db:"bike_id" json:"bike_id"`
+	StartTime time.Time  `db:"start_time" json:"start_time"`
+	EndTime   *time.Time `db:"end_time" json:"end_time"`
+	Status    string     `db:"status" json:"status"`
+	// PausedAt is when the current pause started, nil unless paused
+	PausedAt *time.Time `db:"paused_at" json:"paused_at,omitempty"`
+	// PausedSeconds is the length of the finished pauses. Paused time is
+	// not ridden and is priced and counted apart from it.
+	PausedSeconds int64 `db:"paused_seconds" json:"paused_seconds"`
+	// Location of the bike, filled by StartRent/EndRent; not stored in rents
+	Location string `db:"-" json:"location,omitempty"`
+	// Fare charged from the wallet, filled by EndRent for billed rents
+	Fare *int64 `db:"-" json:"fare,omitempty"`
+	// Pass is the code of the pass a billed rent is ridden on, filled by
+	// StartRent, EndRent and ListRents
+	Pass string `db:"-" json:"pass,omitempty"`
+	// Promo is the promo code applied to a billed rent, filled like Pass;
+	// Discount is what it took off the fare and Invoice the number of the
+	// rent's invoice, both filled with Fare
+	Promo    string `db:"-" json:"promo,omitempty"`
+	Discount int64  `db:"-" json:"discount,omitempty"`
+	Invoice  string `db:"-" json:"invoice,omitempty"`
 }
 
-type RentEvent struct {
-	RentID    string    `json:"rent_id"`
-	UserID    string    `json:"user_id"`
-	BikeID    string    `json:"bike_id"`
-	EventType string    `json:"event_type"` // "start" or "end"
-	Timestamp time.Time `json:"timestamp"`
+// PausedDuration is how long the rent has been paused by at, including a
+// pause still in progress.
+func (r Rent) PausedDuration(at time.Time) time.Duration {
+	paused := time.Duration(r.PausedSeconds) * time.Second
+	if r.PausedAt != nil && at.After(*r.PausedAt) {
+		paused += at.Sub(*r.PausedAt)
+	}
+	return paused
+}
+
+// Rent lifecycle event types. Each one is appended to the rent's history
+// and moves the rent to the status in RentStatuses.
+const (
+	RentStarted    = "started"
+	RentPaused     = "paused"
+	RentResumed    = "resumed"
+	RentEnded      = "ended"
+	RentForceEnded = "force_ended"
+	// RentDisputed follows the end of a rent whose rider contests it
+	RentDisputed = "disputed"
+)
+
+// RentStatuses maps each lifecycle event type to the rent status it leads to.
+var RentStatuses = map[string]string{
+	RentStarted:    "active",
+	RentPaused:     "paused",
+	RentResumed:    "active",
+	RentEnded:      "completed",
+	RentForceEnded: "force_ended",
+	RentDisputed:   "disputed",
+}
+
+// RentLifecycleEvent is one transition in a rent's history. The rents row
+// is a projection of these events.
+type RentLifecycleEvent struct {
+	Seq    int64     `json:"seq"`
+	RentID uuid.UUID `json:"rent_id"`
+	Type   string    `json:"type"`
+	UserID string    `json:"user_id"`
+	BikeID uuid.UUID `json:"bike_id"`
+	Time   time.Time `json:"time"`
+	// Reason is given for force-ends, disputes and pauses ended by the
+	// pause limit
+	Reason string `json:"reason,omitempty"`
 }
 
+// StatusEvent timestamps are always in UTC; consumers convert them to their
+// own time zone for bucketing. Rent events are defined in bike-rental/rentevents.
 type StatusEvent struct {
 	BikeID    string    `json:"bike_id"`
 	Status    string    `json:"status"`
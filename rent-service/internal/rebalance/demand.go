@@ -0,0 +1,133 @@
+package rebalance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// statsTimeout bounds reading the heatmap from stats-service.
+const statsTimeout = 10 * time.Second
+
+// Forecast is the rents expected at every location, hour by hour.
+type Forecast struct {
+	// From and To are the days of history it is based on, YYYY-MM-DD.
+	From      string
+	To        string
+	Locations map[string]LocationForecast
+}
+
+// LocationForecast holds the rents expected to start and end at a location
+// in each hour of the horizon.
+type LocationForecast struct {
+	Starts []float64
+	Ends   []float64
+}
+
+// Demand forecasts rents per location.
+type Demand interface {
+	// Forecast returns the rents expected in each of hours hours starting
+	// with the hour of now, averaged over the days of history before
+	// today.
+	Forecast(ctx context.Context, now time.Time, hours, days int) (*Forecast, error)
+}
+
+type statsDemand struct {
+	baseURL string
+	loc     *time.Location
+	client  *http.Client
+}
+
+// NewStatsDemand forecasts from the hour-of-week heatmap of stats-service
+// at baseURL. loc is the stats time zone, which the heatmap is bucketed
+// in.
+func NewStatsDemand(baseURL string, loc *time.Location) Demand {
+	return &statsDemand{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		loc:     loc,
+		client:  &http.Client{Timeout: statsTimeout},
+	}
+}
+
+// heatmap is the part of the stats-service heatmap used here. Grids are
+// indexed by weekday (Monday = 0) and hour.
+type heatmap struct {
+	Locations []struct {
+		Location string       `json:"location"`
+		Starts   [7][24]int64 `json:"starts"`
+		Ends     [7][24]int64 `json:"ends"`
+	} `json:"locations"`
+}
+
+func (d *statsDemand) Forecast(ctx context.Context, now time.Time, hours, days int) (*Forecast, error) {
+	now = now.In(d.loc)
+	// Today is not over, so history ends yesterday
+	to := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, d.loc)
+	from := to.AddDate(0, 0, 1-days)
+
+	query := url.Values{}
+	query.Set("from", from.Format(dateLayout))
+	query.Set("to", to.Format(dateLayout))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/internal/stats/heatmap?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get demand history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("stats service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var h heatmap
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return nil, fmt.Errorf("failed to decode demand history: %w", err)
+	}
+	return forecast(h, from, to, now, hours), nil
+}
+
+// forecast averages the heatmap cells of the coming hours over how often
+// their weekday occurs in [from, to].
+func forecast(h heatmap, from, to, now time.Time, hours int) *Forecast {
+	var occurrences [7]float64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		occurrences[weekday(day)]++
+	}
+
+	f := &Forecast{
+		From:      from.Format(dateLayout),
+		To:        to.Format(dateLayout),
+		Locations: make(map[string]LocationForecast, len(h.Locations)),
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	for _, l := range h.Locations {
+		lf := LocationForecast{Starts: make([]float64, hours), Ends: make([]float64, hours)}
+		for i := 0; i < hours; i++ {
+			t := start.Add(time.Duration(i) * time.Hour)
+			day, hour := weekday(t), t.Hour()
+			if n := occurrences[day]; n > 0 {
+				lf.Starts[i] = float64(l.Starts[day][hour]) / n
+				lf.Ends[i] = float64(l.Ends[day][hour]) / n
+			}
+		}
+		f.Locations[l.Location] = lf
+	}
+	return f
+}
+
+// weekday numbers days from Monday = 0 like the heatmap.
+func weekday(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
@@ -0,0 +1,128 @@
+// Package rebalance recommends moving available bikes between locations so
+// that each keeps enough for the rents expected in the next hours. Demand
+// is judged from the hour-of-week history kept by stats-service.
+package rebalance
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// Options bound a plan. A plan is one round of Trucks moves, each taking at
+// most TruckCapacity bikes from one location to another.
+type Options struct {
+	TruckCapacity int
+	Trucks        int
+	MinBikes      int
+}
+
+// Location is the stock of one location and the rents expected there over
+// the horizon of a plan.
+type Location struct {
+	Name      string
+	Available int
+	// ExpectedStarts and ExpectedEnds are the rents expected to start and
+	// end at the location over the horizon.
+	ExpectedStarts float64
+	ExpectedEnds   float64
+	// Target is the most bikes the location is expected to lose by any
+	// hour of the horizon, at least MinBikes.
+	Target int
+	// After is Available once the moves of the plan are made.
+	After int
+}
+
+// Move takes Count available bikes from one location to another.
+type Move struct {
+	From  string
+	To    string
+	Count int
+}
+
+// Plan is a recommendation; nothing changes until its moves are applied.
+type Plan struct {
+	GeneratedAt time.Time
+	Horizon     time.Duration
+	Options     Options
+	// HistoryFrom and HistoryTo are the days (YYYY-MM-DD) demand was
+	// judged from.
+	HistoryFrom string
+	HistoryTo   string
+	// DemandError says why demand history could not be read. Targets are
+	// then MinBikes.
+	DemandError string
+	Locations   []Location
+	Moves       []Move
+}
+
+// Build plans moves of the available bikes per location, given in
+// available. Locations without any bikes are left out. When demand cannot
+// be read, the plan only evens stock out to opts.MinBikes and says why.
+func Build(ctx context.Context, demand Demand, available map[string]int, now time.Time, horizon time.Duration, historyDays int, opts Options) *Plan {
+	plan := &Plan{GeneratedAt: now, Horizon: horizon, Options: opts}
+
+	forecast, err := demand.Forecast(ctx, now, int(math.Ceil(horizon.Hours())), historyDays)
+	if err != nil {
+		log.Printf("Planning rebalance without demand history: %v", err)
+		plan.DemandError = err.Error()
+		forecast = &Forecast{}
+	}
+	plan.HistoryFrom, plan.HistoryTo = forecast.From, forecast.To
+
+	for name, count := range available {
+		location := Location{Name: name, Available: count}
+		f := forecast.Locations[name]
+		var net, shortfall float64
+		for hour := range f.Starts {
+			location.ExpectedStarts += f.Starts[hour]
+			location.ExpectedEnds += f.Ends[hour]
+			net += f.Starts[hour] - f.Ends[hour]
+			shortfall = math.Max(shortfall, net)
+		}
+		// Averages are not exact; 2.0000001 bikes means 2
+		location.Target = max(opts.MinBikes, int(math.Ceil(shortfall-1e-6)))
+		plan.Locations = append(plan.Locations, location)
+	}
+	sort.Slice(plan.Locations, func(i, j int) bool { return plan.Locations[i].Name < plan.Locations[j].Name })
+
+	plan.Moves = moves(plan.Locations, opts)
+	return plan
+}
+
+// moves fills the largest shortage from the largest surplus until the
+// trucks run out or no location is short, and sets After of every
+// location. Ties go to the location first by name.
+func moves(locations []Location, opts Options) []Move {
+	balance := make([]int, len(locations))
+	for i := range locations {
+		balance[i] = locations[i].Available - locations[i].Target
+		locations[i].After = locations[i].Available
+	}
+
+	var moves []Move
+	for len(moves) < opts.Trucks {
+		from, to := -1, -1
+		for i, b := range balance {
+			if b > 0 && (from < 0 || b > balance[from]) {
+				from = i
+			}
+			if b < 0 && (to < 0 || b < balance[to]) {
+				to = i
+			}
+		}
+		if from < 0 || to < 0 {
+			break
+		}
+
+		count := min(balance[from], -balance[to], opts.TruckCapacity)
+		balance[from] -= count
+		balance[to] += count
+		locations[from].After -= count
+		locations[to].After += count
+		moves = append(moves, Move{From: locations[from].Name, To: locations[to].Name, Count: count})
+	}
+	return moves
+}
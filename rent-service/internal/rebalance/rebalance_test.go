@@ -0,0 +1,148 @@
+package rebalance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeDemand - заранее заданный прогноз
+type fakeDemand struct {
+	forecast *Forecast
+	err      error
+}
+
+func (f *fakeDemand) Forecast(ctx context.Context, now time.Time, hours, days int) (*Forecast, error) {
+	return f.forecast, f.err
+}
+
+// RebalanceTestSuite - тестовый набор для планов перераспределения
+type RebalanceTestSuite struct {
+	suite.Suite
+	ctx  context.Context
+	now  time.Time
+	opts Options
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *RebalanceTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	// Понедельник, 08:20
+	suite.now = time.Date(2024, 3, 4, 8, 20, 0, 0, time.UTC)
+	suite.opts = Options{TruckCapacity: 10, Trucks: 3, MinBikes: 2}
+}
+
+// TestBuild_TargetIsPeakShortfall - цель — наибольший ожидаемый отток за горизонт, а не итоговый
+func (suite *RebalanceTestSuite) TestBuild_TargetIsPeakShortfall() {
+	demand := &fakeDemand{forecast: &Forecast{From: "2024-02-05", To: "2024-03-03", Locations: map[string]LocationForecast{
+		// Утром уезжают 7, днём возвращаются 6: к концу горизонта не хватает 1, но в пике — 7
+		"Center": {Starts: []float64{4, 3, 0}, Ends: []float64{0, 0, 6}},
+	}}}
+
+	plan := Build(suite.ctx, demand, map[string]int{"Center": 0, "Park": 30}, suite.now, 3*time.Hour, 28, suite.opts)
+
+	suite.Equal("2024-02-05", plan.HistoryFrom)
+	suite.Require().Len(plan.Locations, 2)
+	center := plan.Locations[0]
+	suite.Equal("Center", center.Name)
+	suite.Equal(7.0, center.ExpectedStarts)
+	suite.Equal(6.0, center.ExpectedEnds)
+	suite.Equal(7, center.Target)
+	suite.Equal(7, center.After)
+	suite.Equal(2, plan.Locations[1].Target)
+	suite.Equal([]Move{{From: "Park", To: "Center", Count: 7}}, plan.Moves)
+}
+
+// TestBuild_TruckLimits - ходка не больше вместимости грузовика, ходок не больше, чем грузовиков
+func (suite *RebalanceTestSuite) TestBuild_TruckLimits() {
+	suite.opts.TruckCapacity = 4
+	suite.opts.Trucks = 2
+	available := map[string]int{"A": 0, "B": 0, "C": 20}
+
+	plan := Build(suite.ctx, &fakeDemand{forecast: &Forecast{}}, available, suite.now, time.Hour, 28, suite.opts)
+
+	suite.Equal([]Move{{From: "C", To: "A", Count: 2}, {From: "C", To: "B", Count: 2}}, plan.Moves)
+
+	suite.opts.MinBikes = 10
+	plan = Build(suite.ctx, &fakeDemand{forecast: &Forecast{}}, available, suite.now, time.Hour, 28, suite.opts)
+
+	suite.Equal([]Move{{From: "C", To: "A", Count: 4}, {From: "C", To: "B", Count: 4}}, plan.Moves)
+}
+
+// TestBuild_NothingToMove - без излишков перемещать нечего
+func (suite *RebalanceTestSuite) TestBuild_NothingToMove() {
+	plan := Build(suite.ctx, &fakeDemand{forecast: &Forecast{}}, map[string]int{"A": 1, "B": 2}, suite.now, time.Hour, 28, suite.opts)
+
+	suite.Empty(plan.Moves)
+}
+
+// TestBuild_DemandUnavailable - без истории спроса план выравнивает парк до min_bikes
+func (suite *RebalanceTestSuite) TestBuild_DemandUnavailable() {
+	demand := &fakeDemand{err: errors.New("stats service returned 503")}
+
+	plan := Build(suite.ctx, demand, map[string]int{"A": 0, "B": 5}, suite.now, time.Hour, 28, suite.opts)
+
+	suite.Contains(plan.DemandError, "503")
+	suite.Equal([]Move{{From: "B", To: "A", Count: 2}}, plan.Moves)
+}
+
+// TestForecast_AveragesOverWeekdays - ячейки делятся на число таких дней недели в истории
+func (suite *RebalanceTestSuite) TestForecast_AveragesOverWeekdays() {
+	var h heatmap
+	suite.Require().NoError(json.Unmarshal([]byte(`{"locations":[{"location":"Center"}]}`), &h))
+	h.Locations[0].Starts[0][8] = 8                     // понедельник, 08:00
+	h.Locations[0].Starts[0][9] = 4                     // понедельник, 09:00
+	h.Locations[0].Ends[1][0] = 12                      // вторник, 00:00
+	from := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC) // 4 недели, по 4 каждого дня
+	to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	f := forecast(h, from, to, suite.now, 2)
+
+	suite.Equal("2024-02-05", f.From)
+	suite.Equal("2024-03-03", f.To)
+	suite.Equal([]float64{2, 1}, f.Locations["Center"].Starts)
+
+	// Горизонт переходит через полночь
+	f = forecast(h, from, to, time.Date(2024, 3, 4, 23, 10, 0, 0, time.UTC), 2)
+	suite.Equal([]float64{0, 3}, f.Locations["Center"].Ends)
+}
+
+// TestStatsDemand - история берётся за полные дни до сегодняшнего
+func (suite *RebalanceTestSuite) TestStatsDemand() {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/internal/stats/heatmap", r.URL.Path)
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"locations":[{"location":"Center","starts":[[0,0,0,0,0,0,0,0,7]],"ends":[]}]}`))
+	}))
+	defer server.Close()
+
+	f, err := NewStatsDemand(server.URL+"/", time.UTC).Forecast(suite.ctx, suite.now, 1, 7)
+
+	suite.Require().NoError(err)
+	suite.Equal("from=2024-02-26&to=2024-03-03", query)
+	suite.Equal([]float64{7}, f.Locations["Center"].Starts)
+}
+
+// TestStatsDemand_Error - ответ stats-service с ошибкой возвращается как ошибка
+func (suite *RebalanceTestSuite) TestStatsDemand_Error() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "range must not exceed 366 days", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewStatsDemand(server.URL, time.UTC).Forecast(suite.ctx, suite.now, 1, 7)
+
+	suite.ErrorContains(err, "stats service returned 400: range must not exceed 366 days")
+}
+
+// TestRebalanceTestSuite - запуск тестового набора
+func TestRebalanceTestSuite(t *testing.T) {
+	suite.Run(t, new(RebalanceTestSuite))
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ApplyRentEvent folds one lifecycle event into rent, the projection of
+// the events before it. Events must be applied in seq order.
+func ApplyRentEvent(rent *models.Rent, event models.RentLifecycleEvent) error {
+	status, ok := models.RentStatuses[event.Type]
+	if !ok {
+		return fmt.Errorf("unknown rent event type %q", event.Type)
+	}
+
+	rent.ID = event.RentID
+	rent.UserID = event.UserID
+	rent.BikeID = event.BikeID
+	rent.Status = status
+
+	switch event.Type {
+	case models.RentStarted:
+		rent.StartTime = event.Time
+		rent.EndTime = nil
+		rent.PausedAt = nil
+		rent.PausedSeconds = 0
+	case models.RentPaused:
+		pausedAt := event.Time
+		rent.PausedAt = &pausedAt
+	case models.RentResumed:
+		endPause(rent, event.Time)
+	case models.RentEnded, models.RentForceEnded:
+		// A rent ended while paused was paused until the end
+		endPause(rent, event.Time)
+		end := event.Time
+		rent.EndTime = &end
+	}
+	return nil
+}
+
+// endPause adds the pause in progress, if any, to the rent's paused time.
+func endPause(rent *models.Rent, at time.Time) {
+	if rent.PausedAt == nil {
+		return
+	}
+	if at.After(*rent.PausedAt) {
+		rent.PausedSeconds += int64(at.Sub(*rent.PausedAt) / time.Second)
+	}
+	rent.PausedAt = nil
+}
+
+// appendRentEvent adds event to the rent's history and writes the updated
+// projection to rents, both within tx. rent is the state before the event
+// and is updated in place; the event time is the database clock.
+func appendRentEvent(ctx context.Context, tx pgx.Tx, rent *models.Rent, event models.RentLifecycleEvent) error {
+	event.RentID = rent.ID
+	if event.UserID == "" {
+		event.UserID = rent.UserID
+	}
+	if event.BikeID == uuid.Nil {
+		event.BikeID = rent.BikeID
+	}
+
+	err := tx.QueryRow(ctx,
+		`INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, reason)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING seq, occurred_at`,
+		event.RentID, event.Type, event.UserID, event.BikeID, event.Reason,
+	).Scan(&event.Seq, &event.Time)
+	if err != nil {
+		return fmt.Errorf("failed to append rent event: %w", err)
+	}
+
+	if err := ApplyRentEvent(rent, event); err != nil {
+		return err
+	}
+	if _, err := saveRent(ctx, tx, rent); err != nil {
+		return err
+	}
+	return nil
+}
+
+// lockRent reads a rent for update within tx. With a userID the rent
+// must belong to that user.
+func lockRent(ctx context.Context, tx pgx.Tx, rentID uuid.UUID, userID string) (*models.Rent, error) {
+	query := "SELECT " + rentColumns + " FROM rents WHERE id = $1"
+	args := []interface{}{rentID}
+	if userID != "" {
+		query += " AND user_id = $2"
+		args = append(args, userID)
+	}
+	query += " FOR UPDATE"
+
+	var rent models.Rent
+	err := tx.QueryRow(ctx, query, args...).Scan(
+		&rent.ID, &rent.UserID, &rent.BikeID, &rent.StartTime, &rent.EndTime, &rent.Status, &rent.PausedAt, &rent.PausedSeconds,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("rent %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rent: %w", err)
+	}
+	return &rent, nil
+}
+
+// saveRent writes a projected rent and reports whether the stored row
+// changed.
+func saveRent(ctx context.Context, tx pgx.Tx, rent *models.Rent) (bool, error) {
+	result, err := tx.Exec(ctx,
+		`INSERT INTO rents (id, user_id, bike_id, start_time, end_time, status, paused_at, paused_seconds)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (id) DO UPDATE SET
+		     user_id = EXCLUDED.user_id,
+		     bike_id = EXCLUDED.bike_id,
+		     start_time = EXCLUDED.start_time,
+		     end_time = EXCLUDED.end_time,
+		     status = EXCLUDED.status,
+		     paused_at = EXCLUDED.paused_at,
+		     paused_seconds = EXCLUDED.paused_seconds
+		 WHERE (rents.user_id, rents.bike_id, rents.start_time, rents.end_time, rents.status, rents.paused_at, rents.paused_seconds)
+		     IS DISTINCT FROM (EXCLUDED.user_id, EXCLUDED.bike_id, EXCLUDED.start_time, EXCLUDED.end_time, EXCLUDED.status, EXCLUDED.paused_at, EXCLUDED.paused_seconds)`,
+		rent.ID, rent.UserID, rent.BikeID, rent.StartTime, rent.EndTime, rent.Status, rent.PausedAt, rent.PausedSeconds,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save rent: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *repository) ListRentEvents(ctx context.Context, rentID uuid.UUID) ([]models.RentLifecycleEvent, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT seq, rent_id, type, user_id, bike_id, occurred_at, reason
+		 FROM rent_lifecycle_events
+		 WHERE rent_id = $1
+		 ORDER BY seq`,
+		rentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rent events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.RentLifecycleEvent
+	for rows.Next() {
+		var e models.RentLifecycleEvent
+		if err := rows.Scan(&e.Seq, &e.RentID, &e.Type, &e.UserID, &e.BikeID, &e.Time, &e.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan rent event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query rent events: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("rent %w", ErrNotFound)
+	}
+
+	return events, nil
+}
+
+func (r *repository) RebuildRents(ctx context.Context, rentID uuid.UUID) (int, int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Block rent changes, but not reads, until the rebuild commits so no
+	// event is appended between reading the log and writing the rows.
+	if _, err := tx.Exec(ctx, "LOCK TABLE rents IN EXCLUSIVE MODE"); err != nil {
+		return 0, 0, fmt.Errorf("failed to lock rents: %w", err)
+	}
+
+	// Bikes deleted before rented bikes were kept took their rents with
+	// them; that history stays but is not projected again.
+	query := `
+		SELECT e.seq, e.rent_id, e.type, e.user_id, e.bike_id, e.occurred_at, e.reason
+		FROM rent_lifecycle_events e
+		JOIN bikes b ON b.id = e.bike_id
+	`
+	args := []interface{}{}
+	if rentID != uuid.Nil {
+		query += " WHERE e.rent_id = $1"
+		args = append(args, rentID)
+	}
+	query += " ORDER BY e.rent_id, e.seq"
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query rent events: %w", err)
+	}
+
+	var rents []*models.Rent
+	var current *models.Rent
+	for rows.Next() {
+		var e models.RentLifecycleEvent
+		if err := rows.Scan(&e.Seq, &e.RentID, &e.Type, &e.UserID, &e.BikeID, &e.Time, &e.Reason); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan rent event: %w", err)
+		}
+		if current == nil || current.ID != e.RentID {
+			current = &models.Rent{}
+			rents = append(rents, current)
+		}
+		if err := ApplyRentEvent(current, e); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("rent %s event %d: %w", e.RentID, e.Seq, err)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to query rent events: %w", err)
+	}
+	if rentID != uuid.Nil && len(rents) == 0 {
+		return 0, 0, fmt.Errorf("rent %w", ErrNotFound)
+	}
+
+	changed := 0
+	for _, rent := range rents {
+		ok, err := saveRent(ctx, tx, rent)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ok {
+			changed++
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(rents), changed, nil
+}
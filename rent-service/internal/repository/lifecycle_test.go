@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// LifecycleTestSuite - тестовый набор для проекции аренды из событий
+type LifecycleTestSuite struct {
+	suite.Suite
+	rentID uuid.UUID
+	bikeID uuid.UUID
+	start  time.Time
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *LifecycleTestSuite) SetupTest() {
+	suite.rentID = uuid.New()
+	suite.bikeID = uuid.New()
+	suite.start = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+}
+
+func (suite *LifecycleTestSuite) event(eventType string, at time.Duration) models.RentLifecycleEvent {
+	return models.RentLifecycleEvent{
+		RentID: suite.rentID,
+		Type:   eventType,
+		UserID: "user123",
+		BikeID: suite.bikeID,
+		Time:   suite.start.Add(at),
+	}
+}
+
+func (suite *LifecycleTestSuite) project(events ...models.RentLifecycleEvent) models.Rent {
+	var rent models.Rent
+	for _, e := range events {
+		suite.Require().NoError(ApplyRentEvent(&rent, e))
+	}
+	return rent
+}
+
+// TestStarted - старт задаёт аренду и время начала
+func (suite *LifecycleTestSuite) TestStarted() {
+	rent := suite.project(suite.event(models.RentStarted, 0))
+
+	suite.Equal(suite.rentID, rent.ID)
+	suite.Equal("user123", rent.UserID)
+	suite.Equal(suite.bikeID, rent.BikeID)
+	suite.Equal("active", rent.Status)
+	suite.Equal(suite.start, rent.StartTime)
+	suite.Nil(rent.EndTime)
+}
+
+// TestEnded - завершение задаёт время окончания и статус completed
+func (suite *LifecycleTestSuite) TestEnded() {
+	rent := suite.project(suite.event(models.RentStarted, 0), suite.event(models.RentEnded, 25*time.Minute))
+
+	suite.Equal("completed", rent.Status)
+	suite.Equal(suite.start, rent.StartTime)
+	suite.Require().NotNil(rent.EndTime)
+	suite.Equal(suite.start.Add(25*time.Minute), *rent.EndTime)
+}
+
+// TestPauseAndResume - паузы копятся в paused_seconds, текущая пауза видна в paused_at
+func (suite *LifecycleTestSuite) TestPauseAndResume() {
+	rent := suite.project(
+		suite.event(models.RentStarted, 0),
+		suite.event(models.RentPaused, 10*time.Minute),
+		suite.event(models.RentResumed, 25*time.Minute),
+		suite.event(models.RentPaused, 40*time.Minute),
+	)
+
+	suite.Equal("paused", rent.Status)
+	suite.Equal(int64(15*60), rent.PausedSeconds)
+	suite.Require().NotNil(rent.PausedAt)
+	suite.Equal(suite.start.Add(40*time.Minute), *rent.PausedAt)
+	suite.Equal(20*time.Minute, rent.PausedDuration(suite.start.Add(45*time.Minute)))
+}
+
+// TestEndedWhilePaused - завершение на паузе засчитывает паузу до конца аренды
+func (suite *LifecycleTestSuite) TestEndedWhilePaused() {
+	rent := suite.project(
+		suite.event(models.RentStarted, 0),
+		suite.event(models.RentPaused, 10*time.Minute),
+		suite.event(models.RentForceEnded, 40*time.Minute),
+	)
+
+	suite.Equal("force_ended", rent.Status)
+	suite.Nil(rent.PausedAt)
+	suite.Equal(int64(30*60), rent.PausedSeconds)
+}
+
+// TestDisputedKeepsEndTime - спор по завершённой аренде не меняет её время
+func (suite *LifecycleTestSuite) TestDisputedKeepsEndTime() {
+	rent := suite.project(
+		suite.event(models.RentStarted, 0),
+		suite.event(models.RentForceEnded, time.Hour),
+		suite.event(models.RentDisputed, 2*time.Hour),
+	)
+
+	suite.Equal("disputed", rent.Status)
+	suite.Require().NotNil(rent.EndTime)
+	suite.Equal(suite.start.Add(time.Hour), *rent.EndTime)
+}
+
+// TestUnknownType - неизвестный тип события возвращает ошибку
+func (suite *LifecycleTestSuite) TestUnknownType() {
+	var rent models.Rent
+
+	err := ApplyRentEvent(&rent, suite.event("teleported", 0))
+
+	suite.Error(err)
+}
+
+// TestLifecycleTestSuite - запуск тестового набора
+func TestLifecycleTestSuite(t *testing.T) {
+	suite.Run(t, new(LifecycleTestSuite))
+}
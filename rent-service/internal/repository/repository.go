@@ -2,25 +2,116 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/users"
+	"bike-rental/rent-service/internal/wallet"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrNotFound is wrapped by errors for missing bikes and rents, e.g.
+// "rent not found".
+var ErrNotFound = errors.New("not found")
+
+// ErrRentNotActive is returned when ending a rent that already ended.
+var ErrRentNotActive = errors.New("rent is not active")
+
+// ErrRentNotPaused is returned when resuming a rent that is not paused.
+var ErrRentNotPaused = errors.New("rent is not paused")
+
+// ErrRentNotEnded is returned when disputing a rent that has not ended.
+var ErrRentNotEnded = errors.New("rent has not ended")
+
+// ErrRentDisputed is returned when disputing a rent a second time.
+var ErrRentDisputed = errors.New("rent is already disputed")
+
+// ErrBikeHasRents is returned when deleting a bike that has been rented.
+// Its rents, their history and charges refer to it.
+var ErrBikeHasRents = errors.New("bike has rents")
+
+// rentColumns are the stored columns of a rent in models.Rent field order.
+const rentColumns = "id, user_id, bike_id, start_time, end_time, status, paused_at, paused_seconds"
+
+// RentFilter selects rents for ListRents; zero fields do not filter.
+type RentFilter struct {
+	Status        string
+	UserID        string
+	BikeID        uuid.UUID
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	// PausedBefore matches rents whose current pause started before it
+	PausedBefore time.Time
+	// AfterStart and AfterID page through rents: only rents after that
+	// start time and ID, in the order rents are listed, are returned.
+	AfterStart time.Time
+	AfterID    uuid.UUID
+	// Limit of 0 returns every matching rent.
+	Limit int
+}
+
 type Repository interface {
 	GetAvailableBikes(ctx context.Context, location string) ([]models.Bike, error)
 	GetBikeByID(ctx context.Context, bikeID uuid.UUID) (*models.Bike, error)
-	StartRent(ctx context.Context, userID string, bikeID uuid.UUID) (*models.Rent, error)
-	EndRent(ctx context.Context, rentID uuid.UUID, userID string) (*models.Rent, error)
+	// StartRent rents the bike to userID, failing with the reason from
+	// users.CheckRider when the rider may not rent. With a tariff the rent is billed,
+	// with the benefits of the user's active pass: its hold is reserved
+	// from the user's wallet in the same transaction, failing with
+	// wallet.ErrInsufficientFunds before the bike is rented. A non-empty
+	// promoCode is redeemed in the transaction too, see wallet.Redeem.
+	StartRent(ctx context.Context, userID string, requireRegistration bool, bikeID uuid.UUID, tariff *wallet.Tariff, promoCode string) (*models.Rent, error)
+	// EndRent ends a rent of userID and, if it is billed, charges its fare,
+	// less promoCode when it is not empty. The rent is not ended if the
+	// code cannot be redeemed.
+	EndRent(ctx context.Context, rentID uuid.UUID, userID, promoCode string) (*models.Rent, error)
+	// PauseRent pauses an active rent of userID. The bike stays rented to
+	// the user until the rent ends.
+	PauseRent(ctx context.Context, rentID uuid.UUID, userID string) (*models.Rent, error)
+	// ResumeRent makes a paused rent active again. An empty userID resumes
+	// the rent of any user, e.g. when the pause limit is reached, and
+	// reason is kept in its history.
+	ResumeRent(ctx context.Context, rentID uuid.UUID, userID, reason string) (*models.Rent, error)
 	GetRentByID(ctx context.Context, rentID uuid.UUID) (*models.Rent, error)
 	UpdateBikeStatus(ctx context.Context, bikeID uuid.UUID, status string) error
 	AddBike(ctx context.Context, name, location string) (*models.Bike, error)
+	// DeleteBike deletes a bike that has never been rented, failing with
+	// ErrBikeHasRents otherwise.
 	DeleteBike(ctx context.Context, bikeID uuid.UUID) error
 	HasActiveRent(ctx context.Context, bikeID uuid.UUID) (bool, error)
+	// AddBikes inserts all bikes in one transaction; either every bike is
+	// added or none is.
+	AddBikes(ctx context.Context, bikes []models.Bike) ([]models.Bike, error)
+	// ListBikes calls fn for every bike, optionally at one location, in
+	// name order without loading the whole fleet into memory.
+	ListBikes(ctx context.Context, location string, fn func(models.Bike) error) error
+	// ListRents returns matching rents, oldest first, with the bike location
+	// and the pass and promo code of billed ones.
+	ListRents(ctx context.Context, filter RentFilter) ([]models.Rent, error)
+	// ForceEndRent ends an active or paused rent regardless of its user, marks it
+	// force_ended and frees the bike. The reason is kept in its history and
+	// a billed rent is charged as if the user had ended it.
+	ForceEndRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error)
+	// DisputeRent marks a completed or force-ended rent as disputed and
+	// keeps the reason in its history. The bike and charges are untouched.
+	DisputeRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error)
+	// ListRentEvents returns the lifecycle history of a rent, oldest first.
+	ListRentEvents(ctx context.Context, rentID uuid.UUID) ([]models.RentLifecycleEvent, error)
+	// RebuildRents recomputes rents from their lifecycle events, only
+	// rentID's unless it is uuid.Nil. It returns how many rents were
+	// rebuilt and how many of them differed from the stored row.
+	RebuildRents(ctx context.Context, rentID uuid.UUID) (int, int, error)
+	// CountAvailableBikes returns the available bikes per location, with
+	// 0 for locations whose bikes are all rented or in maintenance.
+	CountAvailableBikes(ctx context.Context) (map[string]int, error)
+	// RelocateBikes moves up to count available bikes from one location to
+	// another and returns them. Bikes locked by a rent starting at the same
+	// time are skipped, so fewer may be moved.
+	RelocateBikes(ctx context.Context, from, to string, count int) ([]models.Bike, error)
 }
 
 type repository struct {
@@ -63,6 +154,60 @@ func (r *repository) GetAvailableBikes(ctx context.Context, location string) ([]
 	return bikes, nil
 }
 
+func (r *repository) CountAvailableBikes(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT location, COUNT(*) FILTER (WHERE status = 'available')
+		 FROM bikes
+		 GROUP BY location`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count available bikes: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var location string
+		var count int
+		if err := rows.Scan(&location, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan bike count: %w", err)
+		}
+		counts[location] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *repository) RelocateBikes(ctx context.Context, from, to string, count int) ([]models.Bike, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE bikes SET location = $2
+		 WHERE id IN (
+		     SELECT id FROM bikes
+		     WHERE location = $1 AND status = 'available'
+		     ORDER BY name, id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED
+		 ) AND status = 'available'
+		 RETURNING id, name, status, location, created_at`,
+		from, to, count,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to relocate bikes: %w", err)
+	}
+	defer rows.Close()
+
+	var bikes []models.Bike
+	for rows.Next() {
+		var bike models.Bike
+		if err := rows.Scan(&bike.ID, &bike.Name, &bike.Status, &bike.Location, &bike.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bike: %w", err)
+		}
+		bikes = append(bikes, bike)
+	}
+
+	return bikes, rows.Err()
+}
+
 func (r *repository) GetBikeByID(ctx context.Context, bikeID uuid.UUID) (*models.Bike, error) {
 	var bike models.Bike
 	err := r.db.QueryRow(ctx,
@@ -71,7 +216,7 @@ func (r *repository) GetBikeByID(ctx context.Context, bikeID uuid.UUID) (*models
 	).Scan(&bike.ID, &bike.Name, &bike.Status, &bike.Location, &bike.CreatedAt)
 	
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("bike not found")
+		return nil, fmt.Errorf("bike %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bike: %w", err)
@@ -80,18 +225,22 @@ func (r *repository) GetBikeByID(ctx context.Context, bikeID uuid.UUID) (*models
 	return &bike, nil
 }
 
-func (r *repository) StartRent(ctx context.Context, userID string, bikeID uuid.UUID) (*models.Rent, error) {
+func (r *repository) StartRent(ctx context.Context, userID string, requireRegistration bool, bikeID uuid.UUID, tariff *wallet.Tariff, promoCode string) (*models.Rent, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	if err := users.CheckRider(ctx, tx, userID, requireRegistration); err != nil {
+		return nil, err
+	}
+
 	// Check if bike is available
-	var bikeStatus string
-	err = tx.QueryRow(ctx, "SELECT status FROM bikes WHERE id = $1", bikeID).Scan(&bikeStatus)
+	var bikeStatus, location string
+	err = tx.QueryRow(ctx, "SELECT status, location FROM bikes WHERE id = $1", bikeID).Scan(&bikeStatus, &location)
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("bike not found")
+		return nil, fmt.Errorf("bike %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to check bike status: %w", err)
@@ -107,18 +256,27 @@ func (r *repository) StartRent(ctx context.Context, userID string, bikeID uuid.U
 	}
 
 	// Create rent record
-	var rent models.Rent
-	err = tx.QueryRow(ctx,
-		`INSERT INTO rents (user_id, bike_id, start_time, status)
-		 VALUES ($1, $2, NOW(), 'active')
-		 RETURNING id, user_id, bike_id, start_time, end_time, status`,
-		userID, bikeID,
-	).Scan(&rent.ID, &rent.UserID, &rent.BikeID, &rent.StartTime, &rent.EndTime, &rent.Status)
-	
+	rent := models.Rent{ID: uuid.New(), UserID: userID, BikeID: bikeID, Location: location}
+	err = appendRentEvent(ctx, tx, &rent, models.RentLifecycleEvent{Type: models.RentStarted})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create rent: %w", err)
 	}
 
+	if tariff != nil {
+		charge, err := wallet.Hold(ctx, tx, userID, rent.ID, *tariff)
+		if err != nil {
+			return nil, err
+		}
+		rent.Pass = charge.Pass
+	}
+
+	if promoCode != "" {
+		if _, err := wallet.Redeem(ctx, tx, rent, promoCode); err != nil {
+			return nil, err
+		}
+		rent.Promo = promoCode
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -126,7 +284,7 @@ func (r *repository) StartRent(ctx context.Context, userID string, bikeID uuid.U
 	return &rent, nil
 }
 
-func (r *repository) EndRent(ctx context.Context, rentID uuid.UUID, userID string) (*models.Rent, error) {
+func (r *repository) EndRent(ctx context.Context, rentID uuid.UUID, userID, promoCode string) (*models.Rent, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -134,63 +292,104 @@ func (r *repository) EndRent(ctx context.Context, rentID uuid.UUID, userID strin
 	defer tx.Rollback(ctx)
 
 	// Get rent
-	var rent models.Rent
-	err = tx.QueryRow(ctx,
-		"SELECT id, user_id, bike_id, start_time, end_time, status FROM rents WHERE id = $1 AND user_id = $2",
-		rentID, userID,
-	).Scan(&rent.ID, &rent.UserID, &rent.BikeID, &rent.StartTime, &rent.EndTime, &rent.Status)
-	
-	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("rent not found")
-	}
+	rent, err := lockRent(ctx, tx, rentID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get rent: %w", err)
+		return nil, err
 	}
 	
-	if rent.Status != "active" {
-		return nil, fmt.Errorf("rent is not active")
+	if rent.Status != "active" && rent.Status != "paused" {
+		return nil, ErrRentNotActive
 	}
 
 	// Update rent
-	endTime := sql.NullTime{}
-	err = tx.QueryRow(ctx,
-		`UPDATE rents SET end_time = NOW(), status = 'completed'
-		 WHERE id = $1
-		 RETURNING end_time`,
-		rentID,
-	).Scan(&endTime)
-	
+	err = appendRentEvent(ctx, tx, rent, models.RentLifecycleEvent{Type: models.RentEnded})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update rent: %w", err)
 	}
-	
-	if endTime.Valid {
-		rent.EndTime = &endTime.Time
-	}
-	rent.Status = "completed"
 
 	// Update bike status
-	_, err = tx.Exec(ctx, "UPDATE bikes SET status = 'available' WHERE id = $1", rent.BikeID)
+	err = tx.QueryRow(ctx,
+		"UPDATE bikes SET status = 'available' WHERE id = $1 RETURNING location",
+		rent.BikeID,
+	).Scan(&rent.Location)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update bike status: %w", err)
 	}
 
+	// The code is checked against the location the bike was rented at
+	if promoCode != "" {
+		if _, err := wallet.Redeem(ctx, tx, *rent, promoCode); err != nil {
+			return nil, err
+		}
+	}
+
+	charge, err := wallet.Capture(ctx, tx, *rent)
+	if err != nil {
+		return nil, err
+	}
+	if charge != nil {
+		setCharge(rent, charge)
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &rent, nil
+	return rent, nil
+}
+
+func (r *repository) PauseRent(ctx context.Context, rentID uuid.UUID, userID string) (*models.Rent, error) {
+	return r.changeRent(ctx, rentID, userID, "active", ErrRentNotActive,
+		models.RentLifecycleEvent{Type: models.RentPaused})
+}
+
+func (r *repository) ResumeRent(ctx context.Context, rentID uuid.UUID, userID, reason string) (*models.Rent, error) {
+	return r.changeRent(ctx, rentID, userID, "paused", ErrRentNotPaused,
+		models.RentLifecycleEvent{Type: models.RentResumed, Reason: reason})
+}
+
+// changeRent appends event to a rent in status, failing with errStatus
+// otherwise. The bike is not touched: it stays rented to the user.
+func (r *repository) changeRent(ctx context.Context, rentID uuid.UUID, userID, status string, errStatus error, event models.RentLifecycleEvent) (*models.Rent, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rent, err := lockRent(ctx, tx, rentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rent.Status != status {
+		return nil, errStatus
+	}
+
+	if err := appendRentEvent(ctx, tx, rent, event); err != nil {
+		return nil, fmt.Errorf("failed to update rent: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, "SELECT location FROM bikes WHERE id = $1", rent.BikeID).Scan(&rent.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bike location: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rent, nil
 }
 
 func (r *repository) GetRentByID(ctx context.Context, rentID uuid.UUID) (*models.Rent, error) {
 	var rent models.Rent
 	err := r.db.QueryRow(ctx,
-		"SELECT id, user_id, bike_id, start_time, end_time, status FROM rents WHERE id = $1",
+		"SELECT "+rentColumns+" FROM rents WHERE id = $1",
 		rentID,
-	).Scan(&rent.ID, &rent.UserID, &rent.BikeID, &rent.StartTime, &rent.EndTime, &rent.Status)
+	).Scan(&rent.ID, &rent.UserID, &rent.BikeID, &rent.StartTime, &rent.EndTime, &rent.Status, &rent.PausedAt, &rent.PausedSeconds)
 	
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("rent not found")
+		return nil, fmt.Errorf("rent %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rent: %w", err)
@@ -226,7 +425,7 @@ func (r *repository) AddBike(ctx context.Context, name, location string) (*model
 func (r *repository) HasActiveRent(ctx context.Context, bikeID uuid.UUID) (bool, error) {
 	var count int
 	err := r.db.QueryRow(ctx,
-		`SELECT COUNT(*) FROM rents WHERE bike_id = $1 AND status = 'active'`,
+		`SELECT COUNT(*) FROM rents WHERE bike_id = $1 AND status IN ('active', 'paused')`,
 		bikeID,
 	).Scan(&count)
 	
@@ -245,23 +444,36 @@ func (r *repository) DeleteBike(ctx context.Context, bikeID uuid.UUID) error {
 		return fmt.Errorf("failed to check bike existence: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("bike not found")
+		return fmt.Errorf("bike %w", ErrNotFound)
 	}
 	
-	// Start transaction to delete bike and related rents
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 	
-	// First delete all rents for this bike (CASCADE manually)
-	_, err = tx.Exec(ctx, "DELETE FROM rents WHERE bike_id = $1", bikeID)
+	// Lock the bike so no rent starts before it is gone
+	_, err = tx.Exec(ctx, "SELECT 1 FROM bikes WHERE id = $1 FOR UPDATE", bikeID)
 	if err != nil {
-		return fmt.Errorf("failed to delete related rents: %w", err)
+		return fmt.Errorf("failed to lock bike: %w", err)
 	}
-	
-	// Then delete the bike
+
+	// Rents keep their bike, so a bike that has been rented stays; take it
+	// out of service with the maintenance status instead
+	var rented bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM rents WHERE bike_id = $1)
+		     OR EXISTS(SELECT 1 FROM rent_lifecycle_events WHERE bike_id = $1)`,
+		bikeID,
+	).Scan(&rented)
+	if err != nil {
+		return fmt.Errorf("failed to check bike rents: %w", err)
+	}
+	if rented {
+		return ErrBikeHasRents
+	}
+
 	result, err := tx.Exec(ctx, "DELETE FROM bikes WHERE id = $1", bikeID)
 	if err != nil {
 		return fmt.Errorf("failed to delete bike: %w", err)
@@ -269,7 +481,7 @@ func (r *repository) DeleteBike(ctx context.Context, bikeID uuid.UUID) error {
 	
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("bike not found")
+		return fmt.Errorf("bike %w", ErrNotFound)
 	}
 	
 	// Commit transaction
@@ -280,3 +492,206 @@ func (r *repository) DeleteBike(ctx context.Context, bikeID uuid.UUID) error {
 	return nil
 }
 
+func (r *repository) AddBikes(ctx context.Context, bikes []models.Bike) ([]models.Bike, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	added := make([]models.Bike, 0, len(bikes))
+	for _, b := range bikes {
+		var bike models.Bike
+		err := tx.QueryRow(ctx,
+			`INSERT INTO bikes (name, status, location, created_at)
+			 VALUES ($1, $2, $3, NOW())
+			 RETURNING id, name, status, location, created_at`,
+			b.Name, b.Status, b.Location,
+		).Scan(&bike.ID, &bike.Name, &bike.Status, &bike.Location, &bike.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add bike %q: %w", b.Name, err)
+		}
+		added = append(added, bike)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return added, nil
+}
+
+func (r *repository) ListBikes(ctx context.Context, location string, fn func(models.Bike) error) error {
+	query := "SELECT id, name, status, location, created_at FROM bikes"
+	args := []interface{}{}
+	if location != "" {
+		query += " WHERE location = $1"
+		args = append(args, location)
+	}
+	query += " ORDER BY location, name, id"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query bikes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bike models.Bike
+		if err := rows.Scan(&bike.ID, &bike.Name, &bike.Status, &bike.Location, &bike.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan bike: %w", err)
+		}
+		if err := fn(bike); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *repository) ListRents(ctx context.Context, filter RentFilter) ([]models.Rent, error) {
+	var conds []string
+	var args []interface{}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Status != "" {
+		where("r.status = $%d", filter.Status)
+	}
+	if filter.UserID != "" {
+		where("r.user_id = $%d", filter.UserID)
+	}
+	if filter.BikeID != uuid.Nil {
+		where("r.bike_id = $%d", filter.BikeID)
+	}
+	if !filter.StartedAfter.IsZero() {
+		where("r.start_time >= $%d", filter.StartedAfter)
+	}
+	if !filter.StartedBefore.IsZero() {
+		where("r.start_time < $%d", filter.StartedBefore)
+	}
+	if !filter.PausedBefore.IsZero() {
+		where("r.paused_at < $%d", filter.PausedBefore)
+	}
+	if !filter.AfterStart.IsZero() {
+		args = append(args, filter.AfterStart, filter.AfterID)
+		conds = append(conds, fmt.Sprintf("(r.start_time, r.id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT r.id, r.user_id, r.bike_id, r.start_time, r.end_time, r.status, r.paused_at, r.paused_seconds,
+		       COALESCE(b.location, ''), COALESCE(up.pass_code, ''), COALESCE(pr.promo_code, '')
+		FROM rents r
+		LEFT JOIN bikes b ON b.id = r.bike_id
+		LEFT JOIN rent_charges c ON c.rent_id = r.id
+		LEFT JOIN user_passes up ON up.id = c.user_pass_id
+		LEFT JOIN promo_redemptions pr ON pr.rent_id = r.id
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY r.start_time, r.id"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rents: %w", err)
+	}
+	defer rows.Close()
+
+	var rents []models.Rent
+	for rows.Next() {
+		var rent models.Rent
+		if err := rows.Scan(&rent.ID, &rent.UserID, &rent.BikeID, &rent.StartTime, &rent.EndTime, &rent.Status, &rent.PausedAt, &rent.PausedSeconds, &rent.Location, &rent.Pass, &rent.Promo); err != nil {
+			return nil, fmt.Errorf("failed to scan rent: %w", err)
+		}
+		rents = append(rents, rent)
+	}
+
+	return rents, rows.Err()
+}
+
+func (r *repository) ForceEndRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rent, err := lockRent(ctx, tx, rentID, "")
+	if err != nil {
+		return nil, err
+	}
+	if rent.Status != "active" && rent.Status != "paused" {
+		return nil, ErrRentNotActive
+	}
+
+	err = appendRentEvent(ctx, tx, rent, models.RentLifecycleEvent{Type: models.RentForceEnded, Reason: reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rent: %w", err)
+	}
+
+	charge, err := wallet.Capture(ctx, tx, *rent)
+	if err != nil {
+		return nil, err
+	}
+	if charge != nil {
+		setCharge(rent, charge)
+	}
+
+	err = tx.QueryRow(ctx,
+		"UPDATE bikes SET status = 'available' WHERE id = $1 RETURNING location",
+		rent.BikeID,
+	).Scan(&rent.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update bike status: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rent, nil
+}
+
+func (r *repository) DisputeRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rent, err := lockRent(ctx, tx, rentID, "")
+	if err != nil {
+		return nil, err
+	}
+	switch rent.Status {
+	case models.RentStatuses[models.RentEnded], models.RentStatuses[models.RentForceEnded]:
+	case models.RentStatuses[models.RentDisputed]:
+		return nil, ErrRentDisputed
+	default:
+		return nil, ErrRentNotEnded
+	}
+
+	err = appendRentEvent(ctx, tx, rent, models.RentLifecycleEvent{Type: models.RentDisputed, Reason: reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rent: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rent, nil
+}
+
+// setCharge fills the billing of an ended rent from its charge.
+func setCharge(rent *models.Rent, charge *wallet.Charge) {
+	rent.Fare, rent.Pass = charge.Fare, charge.Pass
+	rent.Promo, rent.Discount = charge.Promo, charge.Discount
+	rent.Invoice = charge.Invoice
+}
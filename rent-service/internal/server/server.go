@@ -2,24 +2,45 @@ package server
 
 import (
 	"context"
+	"errors"
+	"io"
+	"time"
 
+	"bike-rental/rent-service/internal/audit"
+	"bike-rental/rent-service/internal/events"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/rebalance"
+	"bike-rental/rent-service/internal/repository"
 	"bike-rental/rent-service/internal/service"
+	"bike-rental/rent-service/internal/users"
+	"bike-rental/rent-service/internal/wallet"
 	"bike-rental/rent-service/proto/rent"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type RentServer struct {
 	rent.UnimplementedRentServiceServer
-	service service.Service
+	service  service.Service
+	fleet    *events.Broadcaster
+	audit    audit.Store
+	currency string
 }
 
-func NewRentServer(svc service.Service) *RentServer {
+// NewRentServer serves svc over gRPC. Wallet amounts are reported in
+// currency.
+func NewRentServer(svc service.Service, fleet *events.Broadcaster, auditLog audit.Store, currency string) *RentServer {
 	return &RentServer{
-		service: svc,
+		service:  svc,
+		fleet:    fleet,
+		audit:    auditLog,
+		currency: currency,
 	}
 }
 
 func (s *RentServer) StartRent(ctx context.Context, req *rent.StartRentRequest) (*rent.RentResponse, error) {
-	rentModel, err := s.service.StartRent(ctx, req.UserId, req.BikeId)
+	rentModel, err := s.service.StartRent(ctx, req.UserId, req.BikeId, req.PromoCode)
 	if err != nil {
 		return &rent.RentResponse{
 			Status:  "error",
@@ -34,11 +55,13 @@ func (s *RentServer) StartRent(ctx context.Context, req *rent.StartRentRequest)
 		Status:    rentModel.Status,
 		Message:   "Rent started successfully",
 		StartTime: rentModel.StartTime.Unix(),
+		Pass:      rentModel.Pass,
+		PromoCode: rentModel.Promo,
 	}, nil
 }
 
 func (s *RentServer) EndRent(ctx context.Context, req *rent.EndRentRequest) (*rent.RentResponse, error) {
-	rentModel, err := s.service.EndRent(ctx, req.RentId, req.UserId)
+	rentModel, err := s.service.EndRent(ctx, req.RentId, req.UserId, req.PromoCode)
 	if err != nil {
 		return &rent.RentResponse{
 			Status:  "error",
@@ -51,25 +74,85 @@ func (s *RentServer) EndRent(ctx context.Context, req *rent.EndRentRequest) (*re
 		endTime = rentModel.EndTime.Unix()
 	}
 
-	return &rent.RentResponse{
-		RentId:    rentModel.ID.String(),
-		UserId:    rentModel.UserID,
-		BikeId:    rentModel.BikeID.String(),
-		Status:    rentModel.Status,
-		Message:   "Rent ended successfully",
-		StartTime: rentModel.StartTime.Unix(),
-		EndTime:   endTime,
-	}, nil
+	resp := &rent.RentResponse{
+		RentId:        rentModel.ID.String(),
+		UserId:        rentModel.UserID,
+		BikeId:        rentModel.BikeID.String(),
+		Status:        rentModel.Status,
+		Message:       "Rent ended successfully",
+		StartTime:     rentModel.StartTime.Unix(),
+		EndTime:       endTime,
+		PausedSeconds: rentModel.PausedSeconds,
+	}
+	s.setFare(resp, rentModel)
+	return resp, nil
+}
+
+// setFare adds what an ended rent was charged, the pass it was ridden on,
+// its promo code and its invoice number, if it is billed.
+func (s *RentServer) setFare(resp *rent.RentResponse, rentModel *models.Rent) {
+	if rentModel.Fare != nil {
+		resp.Fare = *rentModel.Fare
+		resp.Currency = s.currency
+		resp.Pass = rentModel.Pass
+		resp.PromoCode = rentModel.Promo
+		resp.Discount = rentModel.Discount
+		resp.InvoiceNumber = rentModel.Invoice
+	}
+}
+
+func (s *RentServer) PauseRent(ctx context.Context, req *rent.PauseRentRequest) (*rent.RentResponse, error) {
+	rentModel, err := s.service.PauseRent(ctx, req.RentId, req.UserId)
+	if err != nil {
+		return &rent.RentResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return pausedRentResponse(rentModel, "Rent paused successfully"), nil
+}
+
+func (s *RentServer) ResumeRent(ctx context.Context, req *rent.ResumeRentRequest) (*rent.RentResponse, error) {
+	rentModel, err := s.service.ResumeRent(ctx, req.RentId, req.UserId)
+	if err != nil {
+		return &rent.RentResponse{
+			Status:  "error",
+			Message: err.Error(),
+		}, nil
+	}
+
+	return pausedRentResponse(rentModel, "Rent resumed successfully"), nil
+}
+
+func pausedRentResponse(rentModel *models.Rent, message string) *rent.RentResponse {
+	resp := &rent.RentResponse{
+		RentId:        rentModel.ID.String(),
+		UserId:        rentModel.UserID,
+		BikeId:        rentModel.BikeID.String(),
+		Status:        rentModel.Status,
+		Message:       message,
+		StartTime:     rentModel.StartTime.Unix(),
+		PausedSeconds: rentModel.PausedSeconds,
+	}
+	if rentModel.PausedAt != nil {
+		resp.PausedAt = rentModel.PausedAt.Unix()
+	}
+	return resp
 }
 
 func (s *RentServer) GetAvailableBikes(ctx context.Context, req *rent.AvailableBikesRequest) (*rent.BikesList, error) {
+	// Take the token first so changes racing with the read are replayed
+	token := s.fleet.Token()
+
 	bikes, err := s.service.GetAvailableBikes(ctx, req.Location)
 	if err != nil {
 		return nil, err
 	}
 
 	result := &rent.BikesList{
-		Bikes: make([]*rent.Bike, 0, len(bikes)),
+		Bikes:       make([]*rent.Bike, 0, len(bikes)),
+		ResumeToken: token,
 	}
 
 	for _, b := range bikes {
@@ -128,3 +211,816 @@ func (s *RentServer) DeleteBike(ctx context.Context, req *rent.DeleteBikeRequest
 	}, nil
 }
 
+
+func (s *RentServer) WatchFleet(req *rent.WatchFleetRequest, stream rent.RentService_WatchFleetServer) error {
+	return s.watch(stream.Context(), req.ResumeToken, func(event events.FleetEvent) error {
+		if req.Location != "" && event.Location != req.Location && event.FromLocation != req.Location {
+			return nil
+		}
+		return stream.Send(&rent.FleetEvent{
+			Type:         event.Type,
+			BikeId:       event.BikeID,
+			RentId:       event.RentID,
+			UserId:       event.UserID,
+			Location:     event.Location,
+			FromLocation: event.FromLocation,
+			BikeStatus:   event.BikeStatus,
+			Timestamp:    event.Timestamp.Unix(),
+			ResumeToken:  s.fleet.ResumeToken(event.Seq),
+		})
+	})
+}
+
+func (s *RentServer) WatchBikes(req *rent.WatchBikesRequest, stream rent.RentService_WatchBikesServer) error {
+	return s.watch(stream.Context(), req.ResumeToken, func(event events.FleetEvent) error {
+		if req.Location != "" && event.Location != req.Location && event.FromLocation != req.Location {
+			return nil
+		}
+
+		changeType := "status_changed"
+		switch event.Type {
+		case events.BikeAdded:
+			changeType = "added"
+		case events.BikeRemoved:
+			changeType = "removed"
+		case events.BikeRelocated:
+			switch req.Location {
+			case "":
+				changeType = "relocated"
+			case event.FromLocation:
+				changeType = "removed"
+			default:
+				changeType = "added"
+			}
+		case events.RentPaused, events.RentResumed:
+			// The bike stays rented
+			return nil
+		}
+
+		return stream.Send(&rent.BikeChange{
+			ResumeToken: s.fleet.ResumeToken(event.Seq),
+			Type:        changeType,
+			Bike: &rent.Bike{
+				Id:       event.BikeID,
+				Name:     event.BikeName,
+				Status:   event.BikeStatus,
+				Location: event.Location,
+			},
+			Timestamp: event.Timestamp.Unix(),
+		})
+	})
+}
+
+func (s *RentServer) WatchRents(req *rent.WatchRentsRequest, stream rent.RentService_WatchRentsServer) error {
+	return s.watch(stream.Context(), req.ResumeToken, func(event events.FleetEvent) error {
+		var changeType string
+		switch event.Type {
+		case events.RentStarted:
+			changeType = "started"
+		case events.RentPaused:
+			changeType = "paused"
+		case events.RentResumed:
+			changeType = "resumed"
+		case events.RentEnded:
+			changeType = "ended"
+		default:
+			return nil
+		}
+		if req.UserId != "" && event.UserID != req.UserId {
+			return nil
+		}
+
+		return stream.Send(&rent.RentChange{
+			ResumeToken: s.fleet.ResumeToken(event.Seq),
+			Type:        changeType,
+			RentId:      event.RentID,
+			UserId:      event.UserID,
+			BikeId:      event.BikeID,
+			Location:    event.Location,
+			Timestamp:   event.Timestamp.Unix(),
+		})
+	})
+}
+
+// watch subscribes from resumeToken and passes every event to send until
+// the client goes away, send fails or the subscription ends.
+func (s *RentServer) watch(ctx context.Context, resumeToken string, send func(events.FleetEvent) error) error {
+	sub, err := s.fleet.SubscribeFrom(resumeToken)
+	if err != nil {
+		if errors.Is(err, events.ErrTokenExpired) {
+			return status.Error(codes.FailedPrecondition, "resume token expired, reload GetAvailableBikes and watch from its resume_token")
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.C:
+			if !ok {
+				if sub.Overflowed() {
+					return status.Error(codes.ResourceExhausted, "subscriber fell behind")
+				}
+				return status.Error(codes.Unavailable, "fleet stream closed")
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *RentServer) ImportBikes(stream rent.RentService_ImportBikesServer) error {
+	var opts service.ImportOptions
+	var rows []service.ImportRow
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch item := req.Item.(type) {
+		case *rent.ImportBikesRequest_Options:
+			opts.AllOrNothing = item.Options.AllOrNothing
+			opts.DryRun = item.Options.DryRun
+		case *rent.ImportBikesRequest_Row:
+			if len(rows) == service.MaxImportRows {
+				return status.Errorf(codes.InvalidArgument, "too many rows, at most %d per import", service.MaxImportRows)
+			}
+			rows = append(rows, service.ImportRow{
+				Line:     int(item.Row.Line),
+				Name:     item.Row.Name,
+				Location: item.Row.Location,
+				Status:   item.Row.Status,
+			})
+		}
+	}
+
+	result, err := s.service.ImportBikes(stream.Context(), rows, opts)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &rent.ImportBikesResponse{
+		Total:    int32(result.Total),
+		Imported: make([]*rent.Bike, 0, len(result.Imported)),
+		Errors:   make([]*rent.ImportRowError, 0, len(result.Errors)),
+		DryRun:   result.DryRun,
+	}
+	for _, b := range result.Imported {
+		resp.Imported = append(resp.Imported, &rent.Bike{
+			Id:       b.ID.String(),
+			Name:     b.Name,
+			Status:   b.Status,
+			Location: b.Location,
+		})
+	}
+	for _, e := range result.Errors {
+		resp.Errors = append(resp.Errors, &rent.ImportRowError{
+			Line:  int32(e.Line),
+			Error: e.Error,
+		})
+	}
+
+	return stream.SendAndClose(resp)
+}
+
+func (s *RentServer) ExportBikes(req *rent.ExportBikesRequest, stream rent.RentService_ExportBikesServer) error {
+	return s.service.ExportBikes(stream.Context(), req.Location, func(b models.Bike) error {
+		return stream.Send(&rent.Bike{
+			Id:       b.ID.String(),
+			Name:     b.Name,
+			Status:   b.Status,
+			Location: b.Location,
+		})
+	})
+}
+
+func (s *RentServer) ListRents(ctx context.Context, req *rent.ListRentsRequest) (*rent.RentsList, error) {
+	filter := repository.RentFilter{
+		Status: req.Status,
+		UserID: req.UserId,
+		Limit:  int(req.Limit),
+	}
+	if req.BikeId != "" {
+		bikeID, err := uuid.Parse(req.BikeId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid bike_id: %v", err)
+		}
+		filter.BikeID = bikeID
+	}
+	if req.StartedBefore > 0 {
+		filter.StartedBefore = time.Unix(req.StartedBefore, 0)
+	}
+
+	rents, err := s.service.ListRents(ctx, filter)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	result := &rent.RentsList{Rents: make([]*rent.RentInfo, 0, len(rents))}
+	for _, r := range rents {
+		info := &rent.RentInfo{
+			RentId:    r.ID.String(),
+			UserId:    r.UserID,
+			BikeId:    r.BikeID.String(),
+			Location:  r.Location,
+			Status:    r.Status,
+			StartTime: r.StartTime.Unix(),
+		}
+		if r.EndTime != nil {
+			info.EndTime = r.EndTime.Unix()
+		}
+		result.Rents = append(result.Rents, info)
+	}
+
+	return result, nil
+}
+
+func (s *RentServer) ForceEndRent(ctx context.Context, req *rent.ForceEndRentRequest) (*rent.RentResponse, error) {
+	rentModel, err := s.service.ForceEndRent(ctx, req.RentId, req.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.RentResponse{
+		RentId:        rentModel.ID.String(),
+		UserId:        rentModel.UserID,
+		BikeId:        rentModel.BikeID.String(),
+		Status:        rentModel.Status,
+		Message:       "Rent force-ended",
+		StartTime:     rentModel.StartTime.Unix(),
+		EndTime:       rentModel.EndTime.Unix(),
+		PausedSeconds: rentModel.PausedSeconds,
+	}
+	s.setFare(resp, rentModel)
+	return resp, nil
+}
+
+func (s *RentServer) DisputeRent(ctx context.Context, req *rent.DisputeRentRequest) (*rent.RentResponse, error) {
+	rentModel, err := s.service.DisputeRent(ctx, req.RentId, req.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	return &rent.RentResponse{
+		RentId:        rentModel.ID.String(),
+		UserId:        rentModel.UserID,
+		BikeId:        rentModel.BikeID.String(),
+		Status:        rentModel.Status,
+		Message:       "Rent disputed",
+		StartTime:     rentModel.StartTime.Unix(),
+		EndTime:       rentModel.EndTime.Unix(),
+		PausedSeconds: rentModel.PausedSeconds,
+	}, nil
+}
+
+func (s *RentServer) SetBikeStatus(ctx context.Context, req *rent.SetBikeStatusRequest) (*rent.Bike, error) {
+	bike, err := s.service.SetBikeStatus(ctx, req.BikeId, req.Status, req.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	return &rent.Bike{
+		Id:       bike.ID.String(),
+		Name:     bike.Name,
+		Status:   bike.Status,
+		Location: bike.Location,
+	}, nil
+}
+
+func (s *RentServer) ReplayRentEvents(ctx context.Context, req *rent.ReplayRentEventsRequest) (*rent.ReplayRentEventsResponse, error) {
+	n, err := s.service.ReplayRentEvents(ctx, time.Unix(req.From, 0), time.Unix(req.To, 0), req.DryRun)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &rent.ReplayRentEventsResponse{Events: int32(n)}, nil
+}
+
+func (s *RentServer) ListAuditLog(ctx context.Context, req *rent.ListAuditLogRequest) (*rent.AuditLog, error) {
+	filter := audit.Filter{
+		Actor:     req.Actor,
+		Action:    req.Action,
+		TargetID:  req.TargetId,
+		RequestID: req.RequestId,
+		Limit:     int(req.Limit),
+	}
+	if req.Since > 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until > 0 {
+		filter.Until = time.Unix(req.Until, 0)
+	}
+
+	entries, err := s.audit.List(ctx, filter)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	result := &rent.AuditLog{Entries: make([]*rent.AuditEntry, 0, len(entries))}
+	for _, e := range entries {
+		result.Entries = append(result.Entries, &rent.AuditEntry{
+			Id:         e.ID,
+			Timestamp:  e.Time.Unix(),
+			Actor:      e.Actor,
+			Peer:       e.Peer,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetId:   e.TargetID,
+			Before:     string(e.Before),
+			After:      string(e.After),
+			RequestId:  e.RequestID,
+			Error:      e.Error,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *RentServer) GetRentTimeline(ctx context.Context, req *rent.GetRentTimelineRequest) (*rent.RentTimeline, error) {
+	events, err := s.service.GetRentTimeline(ctx, req.RentId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	var projected models.Rent
+	result := &rent.RentTimeline{RentId: req.RentId, Events: make([]*rent.RentTimelineEvent, 0, len(events))}
+	for _, e := range events {
+		if err := repository.ApplyRentEvent(&projected, e); err != nil {
+			return nil, adminError(err)
+		}
+		result.Events = append(result.Events, &rent.RentTimelineEvent{
+			Seq:       e.Seq,
+			Type:      e.Type,
+			UserId:    e.UserID,
+			BikeId:    e.BikeID.String(),
+			Timestamp: e.Time.Unix(),
+			Reason:    e.Reason,
+		})
+	}
+	result.Status = projected.Status
+
+	return result, nil
+}
+
+func (s *RentServer) RebuildRentProjections(ctx context.Context, req *rent.RebuildRentProjectionsRequest) (*rent.RebuildRentProjectionsResponse, error) {
+	rebuilt, changed, err := s.service.RebuildRents(ctx, req.RentId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &rent.RebuildRentProjectionsResponse{Rents: int32(rebuilt), Changed: int32(changed)}, nil
+}
+
+func (s *RentServer) GetWallet(ctx context.Context, req *rent.GetWalletRequest) (*rent.Wallet, error) {
+	account, entries, err := s.service.GetWallet(ctx, req.UserId, int(req.Limit))
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := s.walletResponse(account)
+	resp.Entries = make([]*rent.WalletEntry, 0, len(entries))
+	for _, e := range entries {
+		entry := &rent.WalletEntry{
+			Id:        e.ID,
+			Timestamp: e.Time.Unix(),
+			Type:      e.Type,
+			Amount:    e.Amount,
+			Held:      e.Held,
+			Reference: e.Reference,
+			Reason:    e.Reason,
+		}
+		if e.RentID != uuid.Nil {
+			entry.RentId = e.RentID.String()
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+
+	pass, err := s.service.GetPass(ctx, req.UserId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	if pass != nil {
+		resp.Pass = s.subscriptionResponse(pass)
+	}
+
+	return resp, nil
+}
+
+func (s *RentServer) TopUpWallet(ctx context.Context, req *rent.TopUpWalletRequest) (*rent.Wallet, error) {
+	account, err := s.service.TopUpWallet(ctx, req.UserId, req.Amount, req.IdempotencyKey)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return s.walletResponse(account), nil
+}
+
+func (s *RentServer) RefundRent(ctx context.Context, req *rent.RefundRentRequest) (*rent.Wallet, error) {
+	account, err := s.service.RefundRent(ctx, req.RentId, req.Amount, req.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return s.walletResponse(account), nil
+}
+
+func (s *RentServer) walletResponse(account *wallet.Account) *rent.Wallet {
+	return &rent.Wallet{
+		UserId:    account.UserID,
+		Balance:   account.Balance,
+		Held:      account.Held,
+		Available: account.Available(),
+		Currency:  s.currency,
+	}
+}
+
+func (s *RentServer) ListPasses(ctx context.Context, req *rent.ListPassesRequest) (*rent.PassList, error) {
+	passes, err := s.service.ListPasses(ctx)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.PassList{Passes: make([]*rent.Pass, 0, len(passes))}
+	for _, p := range passes {
+		resp.Passes = append(resp.Passes, &rent.Pass{
+			Code:            p.Code,
+			Name:            p.Name,
+			Price:           p.Price,
+			Currency:        s.currency,
+			DurationSeconds: int64(p.Duration.Seconds()),
+			FreeMinutes:     p.FreeMinutes,
+			DiscountPercent: p.DiscountPercent,
+		})
+	}
+	return resp, nil
+}
+
+func (s *RentServer) BuyPass(ctx context.Context, req *rent.BuyPassRequest) (*rent.Subscription, error) {
+	sub, err := s.service.BuyPass(ctx, req.UserId, req.Pass)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return s.subscriptionResponse(sub), nil
+}
+
+func (s *RentServer) CancelPass(ctx context.Context, req *rent.CancelPassRequest) (*rent.Subscription, error) {
+	sub, err := s.service.CancelPass(ctx, req.UserId, req.SubscriptionId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return s.subscriptionResponse(sub), nil
+}
+
+func (s *RentServer) subscriptionResponse(sub *wallet.Subscription) *rent.Subscription {
+	resp := &rent.Subscription{
+		Id:              sub.ID.String(),
+		UserId:          sub.UserID,
+		Pass:            sub.Pass,
+		Name:            sub.Name,
+		Price:           sub.Price,
+		Currency:        s.currency,
+		FreeMinutes:     sub.FreeMinutes,
+		DiscountPercent: sub.DiscountPercent,
+		StartsAt:        sub.StartsAt.Unix(),
+		EndsAt:          sub.EndsAt.Unix(),
+		Refunded:        sub.Refunded,
+	}
+	if sub.CancelledAt != nil {
+		resp.CancelledAt = sub.CancelledAt.Unix()
+	}
+	return resp
+}
+
+func (s *RentServer) ListPromoCodes(ctx context.Context, req *rent.ListPromoCodesRequest) (*rent.PromoCodeList, error) {
+	promos, err := s.service.ListPromos(ctx)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.PromoCodeList{PromoCodes: make([]*rent.PromoCode, 0, len(promos))}
+	for _, p := range promos {
+		resp.PromoCodes = append(resp.PromoCodes, promoCodeResponse(&p))
+	}
+	return resp, nil
+}
+
+func (s *RentServer) CreatePromoCode(ctx context.Context, req *rent.PromoCode) (*rent.PromoCode, error) {
+	promo, err := s.service.CreatePromo(ctx, promoFromRequest(req))
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return promoCodeResponse(promo), nil
+}
+
+func (s *RentServer) UpdatePromoCode(ctx context.Context, req *rent.PromoCode) (*rent.PromoCode, error) {
+	promo, err := s.service.UpdatePromo(ctx, promoFromRequest(req))
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return promoCodeResponse(promo), nil
+}
+
+func (s *RentServer) DeletePromoCode(ctx context.Context, req *rent.DeletePromoCodeRequest) (*rent.DeletePromoCodeResponse, error) {
+	if err := s.service.DeletePromo(ctx, req.Code); err != nil {
+		return nil, adminError(err)
+	}
+	return &rent.DeletePromoCodeResponse{Success: true}, nil
+}
+
+// defaultPromoStatsPeriod is what GetPromoStats covers without from.
+const defaultPromoStatsPeriod = 30 * 24 * time.Hour
+
+func (s *RentServer) GetPromoStats(ctx context.Context, req *rent.PromoStatsRequest) (*rent.PromoStatsResponse, error) {
+	var from, to time.Time
+	if req.From > 0 {
+		from = time.Unix(req.From, 0)
+	}
+	if req.To > 0 {
+		to = time.Unix(req.To, 0)
+	} else {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultPromoStatsPeriod)
+	}
+
+	stats, err := s.service.GetPromoStats(ctx, from, to)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.PromoStatsResponse{
+		From:       from.Unix(),
+		To:         to.Unix(),
+		PromoCodes: make([]*rent.PromoStats, 0, len(stats)),
+	}
+	for _, st := range stats {
+		resp.PromoCodes = append(resp.PromoCodes, &rent.PromoStats{
+			Code:        st.Code,
+			Redemptions: st.Redemptions,
+			Users:       st.Users,
+			Charged:     st.Charged,
+			Discount:    st.Discount,
+			Currency:    s.currency,
+		})
+	}
+	return resp, nil
+}
+
+func (s *RentServer) GetReceipt(ctx context.Context, req *rent.GetReceiptRequest) (*rent.Receipt, error) {
+	receipt, err := s.service.GetReceipt(ctx, req.RentId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.Receipt{
+		Invoice:       s.invoiceResponse(receipt.Invoice),
+		StartTime:     receipt.StartTime.Unix(),
+		EndTime:       receipt.EndTime.Unix(),
+		RidingSeconds: int64(receipt.Riding.Seconds()),
+		PausedSeconds: int64(receipt.Paused.Seconds()),
+		Pass:          receipt.Pass,
+		PromoCode:     receipt.Promo,
+		Lines:         make([]*rent.ReceiptLine, 0, len(receipt.Lines)),
+	}
+	for _, line := range receipt.Lines {
+		resp.Lines = append(resp.Lines, &rent.ReceiptLine{
+			Kind:      line.Kind,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+			Amount:    line.Amount,
+		})
+	}
+	return resp, nil
+}
+
+func (s *RentServer) GetStatement(ctx context.Context, req *rent.GetStatementRequest) (*rent.Statement, error) {
+	statement, err := s.service.GetStatement(ctx, req.UserId, req.Month)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.Statement{
+		UserId:         statement.UserID,
+		Month:          statement.From.Format("2006-01"),
+		From:           statement.From.Unix(),
+		To:             statement.To.Unix(),
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		TopUps:         statement.TopUps,
+		Fares:          statement.Fares,
+		Refunds:        statement.Refunds,
+		Passes:         statement.Passes,
+		Tax:            statement.Tax,
+		Invoices:       make([]*rent.Invoice, 0, len(statement.Invoices)),
+		Currency:       s.currency,
+	}
+	for _, invoice := range statement.Invoices {
+		resp.Invoices = append(resp.Invoices, s.invoiceResponse(invoice))
+	}
+	return resp, nil
+}
+
+func (s *RentServer) RegisterUser(ctx context.Context, req *rent.RegisterUserRequest) (*rent.User, error) {
+	user, err := s.service.RegisterUser(ctx, users.User{ID: req.UserId, Name: req.Name, Email: req.Email, Phone: req.Phone})
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return userResponse(user), nil
+}
+
+func (s *RentServer) GetUser(ctx context.Context, req *rent.GetUserRequest) (*rent.User, error) {
+	user, err := s.service.GetUser(ctx, req.UserId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return userResponse(user), nil
+}
+
+func (s *RentServer) UpdateUser(ctx context.Context, req *rent.UpdateUserRequest) (*rent.User, error) {
+	user, err := s.service.UpdateUser(ctx, users.User{ID: req.UserId, Name: req.Name, Email: req.Email, Phone: req.Phone})
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return userResponse(user), nil
+}
+
+func (s *RentServer) SetUserStatus(ctx context.Context, req *rent.SetUserStatusRequest) (*rent.User, error) {
+	user, err := s.service.SetUserStatus(ctx, req.UserId, req.Status, req.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return userResponse(user), nil
+}
+
+func (s *RentServer) ListUsers(ctx context.Context, req *rent.ListUsersRequest) (*rent.UserList, error) {
+	list, err := s.service.ListUsers(ctx, req.Status, int(req.Limit))
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.UserList{Users: make([]*rent.User, 0, len(list))}
+	for i := range list {
+		resp.Users = append(resp.Users, userResponse(&list[i]))
+	}
+	return resp, nil
+}
+
+func userResponse(user *users.User) *rent.User {
+	return &rent.User{
+		UserId:       user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+		CreatedAt:    user.CreatedAt.Unix(),
+		UpdatedAt:    user.UpdatedAt.Unix(),
+	}
+}
+
+func (s *RentServer) invoiceResponse(invoice wallet.Invoice) *rent.Invoice {
+	return &rent.Invoice{
+		Number:     invoice.Number,
+		RentId:     invoice.RentID.String(),
+		UserId:     invoice.UserID,
+		IssuedAt:   invoice.IssuedAt.Unix(),
+		Total:      invoice.Total,
+		Tax:        invoice.Tax,
+		TaxPercent: invoice.TaxPercent,
+		Refunded:   invoice.Refunded,
+		Currency:   s.currency,
+	}
+}
+
+func promoFromRequest(req *rent.PromoCode) wallet.Promo {
+	promo := wallet.Promo{
+		Code:           req.Code,
+		Kind:           req.Kind,
+		Value:          req.Value,
+		Locations:      req.Locations,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		Active:         req.Active,
+	}
+	if req.ValidFrom > 0 {
+		from := time.Unix(req.ValidFrom, 0)
+		promo.ValidFrom = &from
+	}
+	if req.ValidUntil > 0 {
+		until := time.Unix(req.ValidUntil, 0)
+		promo.ValidUntil = &until
+	}
+	return promo
+}
+
+func promoCodeResponse(promo *wallet.Promo) *rent.PromoCode {
+	resp := &rent.PromoCode{
+		Code:           promo.Code,
+		Kind:           promo.Kind,
+		Value:          promo.Value,
+		Locations:      promo.Locations,
+		MaxRedemptions: promo.MaxRedemptions,
+		MaxPerUser:     promo.MaxPerUser,
+		Active:         promo.Active,
+		CreatedAt:      promo.CreatedAt.Unix(),
+		Redemptions:    promo.Redemptions,
+	}
+	if promo.ValidFrom != nil {
+		resp.ValidFrom = promo.ValidFrom.Unix()
+	}
+	if promo.ValidUntil != nil {
+		resp.ValidUntil = promo.ValidUntil.Unix()
+	}
+	return resp
+}
+
+func (s *RentServer) PlanRebalance(ctx context.Context, req *rent.PlanRebalanceRequest) (*rent.RebalancePlan, error) {
+	plan, err := s.service.PlanRebalance(ctx, service.RebalanceOptions{
+		TruckCapacity: int(req.TruckCapacity),
+		Trucks:        int(req.Trucks),
+		Horizon:       time.Duration(req.HorizonSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.RebalancePlan{
+		GeneratedAt:    plan.GeneratedAt.Unix(),
+		HorizonSeconds: int64(plan.Horizon.Seconds()),
+		TruckCapacity:  int32(plan.Options.TruckCapacity),
+		Trucks:         int32(plan.Options.Trucks),
+		HistoryFrom:    plan.HistoryFrom,
+		HistoryTo:      plan.HistoryTo,
+		DemandError:    plan.DemandError,
+		Locations:      make([]*rent.LocationBalance, 0, len(plan.Locations)),
+		Moves:          make([]*rent.BikeMove, 0, len(plan.Moves)),
+	}
+	for _, l := range plan.Locations {
+		resp.Locations = append(resp.Locations, &rent.LocationBalance{
+			Location:       l.Name,
+			Available:      int32(l.Available),
+			ExpectedStarts: l.ExpectedStarts,
+			ExpectedEnds:   l.ExpectedEnds,
+			Target:         int32(l.Target),
+			After:          int32(l.After),
+		})
+	}
+	for _, m := range plan.Moves {
+		resp.Moves = append(resp.Moves, &rent.BikeMove{From: m.From, To: m.To, Count: int32(m.Count)})
+	}
+	return resp, nil
+}
+
+func (s *RentServer) RelocateBikes(ctx context.Context, req *rent.RelocateBikesRequest) (*rent.RelocateBikesResponse, error) {
+	moves := make([]rebalance.Move, 0, len(req.Moves))
+	for _, m := range req.Moves {
+		moves = append(moves, rebalance.Move{From: m.GetFrom(), To: m.GetTo(), Count: int(m.GetCount())})
+	}
+
+	relocations, err := s.service.RelocateBikes(ctx, moves)
+	if err != nil {
+		return nil, adminError(err)
+	}
+
+	resp := &rent.RelocateBikesResponse{Relocations: make([]*rent.BikeRelocation, 0, len(relocations))}
+	for _, r := range relocations {
+		relocation := &rent.BikeRelocation{
+			Move:  &rent.BikeMove{From: r.Move.From, To: r.Move.To, Count: int32(r.Move.Count)},
+			Moved: int32(len(r.Bikes)),
+		}
+		for _, bike := range r.Bikes {
+			relocation.BikeIds = append(relocation.BikeIds, bike.ID.String())
+		}
+		resp.Relocations = append(resp.Relocations, relocation)
+		resp.Moved += relocation.Moved
+	}
+	return resp, nil
+}
+
+// adminError maps service errors to gRPC status codes for the operator
+// RPCs.
+func adminError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidArgument), errors.Is(err, wallet.ErrInvalidAmount),
+		errors.Is(err, wallet.ErrInvalidPromo), errors.Is(err, users.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, wallet.ErrPassNotFound),
+		errors.Is(err, wallet.ErrPromoNotFound), errors.Is(err, users.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, wallet.ErrPromoExists), errors.Is(err, users.ErrExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrRentNotActive), errors.Is(err, repository.ErrRentNotPaused),
+		errors.Is(err, repository.ErrRentNotEnded), errors.Is(err, repository.ErrRentDisputed),
+		errors.Is(err, service.ErrBikeRented), errors.Is(err, wallet.ErrNotCharged),
+		errors.Is(err, wallet.ErrInsufficientFunds), errors.Is(err, wallet.ErrPaymentDeclined),
+		errors.Is(err, wallet.ErrPassActive), errors.Is(err, wallet.ErrPassEnded), errors.Is(err, service.ErrWalletDisabled),
+		errors.Is(err, wallet.ErrPromoNotApplicable), errors.Is(err, wallet.ErrPromoRedeemed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
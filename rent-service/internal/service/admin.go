@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bike-rental/rent-service/internal/events"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rentevents"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidArgument is wrapped by errors caused by a bad request rather
+// than the state of the fleet.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// ErrBikeRented is returned for operations that need a bike without an
+// active rent.
+var ErrBikeRented = errors.New("bike has an active rent")
+
+const (
+	defaultRentsLimit = 100
+	maxRentsLimit     = 1000
+)
+
+// settableStatuses are the statuses an operator may put a bike in; rented
+// only comes from StartRent.
+var settableStatuses = map[string]bool{
+	"available":   true,
+	"maintenance": true,
+}
+
+func invalidArgument(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidArgument, fmt.Sprintf(format, args...))
+}
+
+// ListRents returns matching rents, at most maxRentsLimit.
+func (s *service) ListRents(ctx context.Context, filter repository.RentFilter) ([]models.Rent, error) {
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultRentsLimit
+	case filter.Limit > maxRentsLimit:
+		filter.Limit = maxRentsLimit
+	}
+	return s.repo.ListRents(ctx, filter)
+}
+
+// ForceEndRent ends an active rent on behalf of an operator, e.g. when the
+// user left the bike without ending it. It publishes the same events as
+// EndRent so stats stay consistent.
+func (s *service) ForceEndRent(ctx context.Context, rentID, reason string) (*models.Rent, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, invalidArgument("invalid rent_id: %v", err)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+
+	rent, err := s.repo.ForceEndRent(ctx, rentUUID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Force-ended rent: rent_id=%s, user_id=%s, bike_id=%s, reason=%q", rent.ID, rent.UserID, rent.BikeID, reason)
+	s.announceRentEnded(ctx, rent)
+
+	return rent, nil
+}
+
+// DisputeRent records that the rider contests an ended rent. Nothing is
+// published: the rent is over for stats and dashboards either way.
+func (s *service) DisputeRent(ctx context.Context, rentID, reason string) (*models.Rent, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, invalidArgument("invalid rent_id: %v", err)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+
+	rent, err := s.repo.DisputeRent(ctx, rentUUID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Disputed rent: rent_id=%s, user_id=%s, bike_id=%s, reason=%q", rent.ID, rent.UserID, rent.BikeID, reason)
+	return rent, nil
+}
+
+// SetBikeStatus puts a bike that is not rented into status, e.g. to take
+// it out for maintenance or to free a bike stuck in a wrong status.
+func (s *service) SetBikeStatus(ctx context.Context, bikeID, status, reason string) (*models.Bike, error) {
+	bikeUUID, err := uuid.Parse(bikeID)
+	if err != nil {
+		return nil, invalidArgument("invalid bike_id: %v", err)
+	}
+	if !settableStatuses[status] {
+		return nil, invalidArgument("status must be available or maintenance, got %q", status)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+
+	bike, err := s.repo.GetBikeByID(ctx, bikeUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	hasActiveRent, err := s.repo.HasActiveRent(ctx, bikeUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active rents: %w", err)
+	}
+	if hasActiveRent {
+		return nil, fmt.Errorf("%w, force-end it first", ErrBikeRented)
+	}
+
+	if err := s.repo.UpdateBikeStatus(ctx, bikeUUID, status); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Changed bike status: bike_id=%s, %s -> %s, reason=%q", bike.ID, bike.Status, status, reason)
+	bike.Status = status
+
+	s.fleet.Publish(events.FleetEvent{
+		Type:       events.BikeStatusChanged,
+		BikeID:     bike.ID.String(),
+		Location:   bike.Location,
+		BikeStatus: status,
+		Timestamp:  time.Now().UTC(),
+	})
+
+	return bike, nil
+}
+
+// ReplayRentEvents publishes the events of every rent started in
+// [from, to) again: its start, pauses, resumes and end, in the order of its
+// lifecycle history. It is meant for rebuilding stats after they were
+// cleared. stats-service skips events it processed in the last seven days,
+// so only older rents replayed into existing stats are counted twice.
+func (s *service) ReplayRentEvents(ctx context.Context, from, to time.Time, dryRun bool) (int, error) {
+	if !from.Before(to) {
+		return 0, invalidArgument("from must be before to")
+	}
+
+	rents, err := s.repo.ListRents(ctx, repository.RentFilter{StartedAfter: from, StartedBefore: to})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, rent := range rents {
+		history, err := s.repo.ListRentEvents(ctx, rent.ID)
+		if err != nil {
+			return published, fmt.Errorf("rent %s: %w", rent.ID, err)
+		}
+
+		for _, lifecycle := range history {
+			eventType, ok := replayedEventTypes[lifecycle.Type]
+			if !ok {
+				continue
+			}
+			event := rentevents.RentEvent{
+				RentID:    rent.ID.String(),
+				UserID:    rent.UserID,
+				BikeID:    rent.BikeID.String(),
+				EventType: eventType,
+				Location:  rent.Location,
+				Timestamp: lifecycle.Time.UTC(),
+			}
+			if eventType == rentevents.Start || eventType == rentevents.End {
+				event.Pass = rent.Pass
+			}
+
+			if !dryRun {
+				if err := s.publishRentEvent(ctx, event); err != nil {
+					return published, err
+				}
+			}
+			published++
+		}
+	}
+
+	log.Printf("Replayed %d rent events for %d rents from %s to %s (dry run: %t)", published, len(rents), from.Format(time.RFC3339), to.Format(time.RFC3339), dryRun)
+	return published, nil
+}
+
+// replayedEventTypes maps lifecycle events to the Kafka events published
+// for them; the others are not published.
+var replayedEventTypes = map[string]string{
+	models.RentStarted:    rentevents.Start,
+	models.RentPaused:     rentevents.Pause,
+	models.RentResumed:    rentevents.Resume,
+	models.RentEnded:      rentevents.End,
+	models.RentForceEnded: rentevents.End,
+}
+
+func (s *service) GetRentTimeline(ctx context.Context, rentID string) ([]models.RentLifecycleEvent, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, invalidArgument("invalid rent_id: %v", err)
+	}
+	return s.repo.ListRentEvents(ctx, rentUUID)
+}
+
+// RebuildRents repairs rents changed outside rent-service, e.g. by manual
+// SQL, by deriving them again from the events.
+func (s *service) RebuildRents(ctx context.Context, rentID string) (int, int, error) {
+	rentUUID := uuid.Nil
+	if rentID != "" {
+		var err error
+		if rentUUID, err = uuid.Parse(rentID); err != nil {
+			return 0, 0, invalidArgument("invalid rent_id: %v", err)
+		}
+	}
+
+	rebuilt, changed, err := s.repo.RebuildRents(ctx, rentUUID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	log.Printf("Rebuilt %d rent(s) from lifecycle events, %d changed", rebuilt, changed)
+	return rebuilt, changed, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bike-rental/rent-service/internal/events"
+	"bike-rental/rent-service/internal/models"
+)
+
+// MaxImportRows limits one ImportBikes call so a single upload cannot hold
+// a transaction open for long.
+const MaxImportRows = 10000
+
+// Column limits from the bikes table.
+const (
+	maxBikeNameLength     = 100
+	maxBikeLocationLength = 50
+)
+
+// importStatuses are the statuses a bike may be imported with; rented
+// bikes only come from StartRent.
+var importStatuses = map[string]bool{
+	"available":   true,
+	"maintenance": true,
+}
+
+// ImportRow is one bike of a bulk import. Line is its position in the
+// uploaded file and is echoed back in errors.
+type ImportRow struct {
+	Line     int
+	Name     string
+	Location string
+	Status   string
+}
+
+type ImportRowError struct {
+	Line  int
+	Error string
+}
+
+type ImportOptions struct {
+	// AllOrNothing imports nothing when any row is invalid.
+	AllOrNothing bool
+	// DryRun only validates the rows.
+	DryRun bool
+}
+
+type ImportResult struct {
+	Total    int
+	Imported []models.Bike
+	Errors   []ImportRowError
+	DryRun   bool
+}
+
+// ImportBikes validates every row and adds the valid ones in a single
+// transaction. Rows with errors are reported, not fatal, unless
+// AllOrNothing is set.
+func (s *service) ImportBikes(ctx context.Context, rows []ImportRow, opts ImportOptions) (*ImportResult, error) {
+	if len(rows) > MaxImportRows {
+		return nil, fmt.Errorf("too many rows: %d, at most %d per import", len(rows), MaxImportRows)
+	}
+
+	result := &ImportResult{Total: len(rows), DryRun: opts.DryRun}
+	valid := make([]models.Bike, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		bike, err := validateImportRow(row)
+		if err == nil {
+			key := bike.Location + "\x00" + bike.Name
+			if first, ok := seen[key]; ok {
+				err = fmt.Errorf("duplicate of line %d", first)
+			} else {
+				seen[key] = row.Line
+			}
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{Line: row.Line, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, bike)
+	}
+
+	if opts.DryRun || len(valid) == 0 || (opts.AllOrNothing && len(result.Errors) > 0) {
+		return result, nil
+	}
+
+	added, err := s.repo.AddBikes(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = added
+
+	log.Printf("Imported %d bikes (%d rows rejected)", len(added), len(result.Errors))
+
+	now := time.Now().UTC()
+	for _, bike := range added {
+		s.fleet.Publish(events.FleetEvent{
+			Type:       events.BikeAdded,
+			BikeID:     bike.ID.String(),
+			BikeName:   bike.Name,
+			Location:   bike.Location,
+			BikeStatus: bike.Status,
+			Timestamp:  now,
+		})
+	}
+
+	return result, nil
+}
+
+func validateImportRow(row ImportRow) (models.Bike, error) {
+	bike := models.Bike{
+		Name:     strings.TrimSpace(row.Name),
+		Location: strings.TrimSpace(row.Location),
+		Status:   strings.ToLower(strings.TrimSpace(row.Status)),
+	}
+	if bike.Status == "" {
+		bike.Status = "available"
+	}
+
+	switch {
+	case bike.Name == "":
+		return bike, fmt.Errorf("bike name is required")
+	case utf8.RuneCountInString(bike.Name) > maxBikeNameLength:
+		return bike, fmt.Errorf("bike name is longer than %d characters", maxBikeNameLength)
+	case bike.Location == "":
+		return bike, fmt.Errorf("bike location is required")
+	case utf8.RuneCountInString(bike.Location) > maxBikeLocationLength:
+		return bike, fmt.Errorf("bike location is longer than %d characters", maxBikeLocationLength)
+	case !importStatuses[bike.Status]:
+		return bike, fmt.Errorf("unknown status %q, expected available or maintenance", row.Status)
+	}
+
+	return bike, nil
+}
+
+func (s *service) ExportBikes(ctx context.Context, location string, fn func(models.Bike) error) error {
+	return s.repo.ListBikes(ctx, location, fn)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"bike-rental/rent-service/internal/wallet"
+)
+
+// normalizePromoCode makes codes case-insensitive: riders type them in any
+// case and they are stored in upper case.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *service) ListPromos(ctx context.Context) ([]wallet.Promo, error) {
+	return s.wallets.ListPromos(ctx)
+}
+
+func (s *service) CreatePromo(ctx context.Context, promo wallet.Promo) (*wallet.Promo, error) {
+	promo.Code = normalizePromoCode(promo.Code)
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := s.wallets.CreatePromo(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created promo code: code=%s, kind=%s, value=%d", created.Code, created.Kind, created.Value)
+	return created, nil
+}
+
+func (s *service) UpdatePromo(ctx context.Context, promo wallet.Promo) (*wallet.Promo, error) {
+	promo.Code = normalizePromoCode(promo.Code)
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.wallets.UpdatePromo(ctx, promo)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Updated promo code: code=%s, kind=%s, value=%d, active=%t", updated.Code, updated.Kind, updated.Value, updated.Active)
+	return updated, nil
+}
+
+func (s *service) DeletePromo(ctx context.Context, code string) error {
+	code = normalizePromoCode(code)
+	if code == "" {
+		return invalidArgument("code is required")
+	}
+
+	if err := s.wallets.DeletePromo(ctx, code); err != nil {
+		return err
+	}
+
+	log.Printf("Deleted promo code: code=%s", code)
+	return nil
+}
+
+func (s *service) GetPromoStats(ctx context.Context, from, to time.Time) ([]wallet.PromoStats, error) {
+	if !to.After(from) {
+		return nil, invalidArgument("to must be after from")
+	}
+	return s.wallets.PromoStats(ctx, from, to)
+}
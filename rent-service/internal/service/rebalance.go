@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/events"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/rebalance"
+)
+
+const (
+	// MaxRelocateMoves limits one RelocateBikes call.
+	MaxRelocateMoves = 100
+	// maxMoveCount limits the bikes of one move.
+	maxMoveCount = 1000
+	// maxRebalanceHorizon is as far ahead as a plan looks; the heatmap
+	// repeats weekly, but forecasts beyond a day are not worth a truck.
+	maxRebalanceHorizon = 24 * time.Hour
+)
+
+// RebalanceOptions override the configured truck capacity, number of
+// trucks and horizon of a plan when not zero.
+type RebalanceOptions struct {
+	TruckCapacity int
+	Trucks        int
+	Horizon       time.Duration
+}
+
+// Relocation is a move as applied: Bikes are the bikes actually moved,
+// which can be fewer than asked.
+type Relocation struct {
+	Move  rebalance.Move
+	Bikes []models.Bike
+}
+
+func (s *service) SetRebalanceConfig(cfg config.RebalanceConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rebalanceCfg = cfg
+}
+
+func (s *service) rebalanceConfig() config.RebalanceConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rebalanceCfg
+}
+
+func (s *service) PlanRebalance(ctx context.Context, opts RebalanceOptions) (*rebalance.Plan, error) {
+	switch {
+	case opts.TruckCapacity < 0:
+		return nil, invalidArgument("truck_capacity must not be negative")
+	case opts.Trucks < 0:
+		return nil, invalidArgument("trucks must not be negative")
+	case opts.Horizon < 0 || opts.Horizon > maxRebalanceHorizon:
+		return nil, invalidArgument("horizon must be between 0 and %s", maxRebalanceHorizon)
+	}
+
+	cfg := s.rebalanceConfig()
+	if opts.TruckCapacity == 0 {
+		opts.TruckCapacity = cfg.TruckCapacity
+	}
+	if opts.Trucks == 0 {
+		opts.Trucks = cfg.Trucks
+	}
+	if opts.Horizon == 0 {
+		opts.Horizon = cfg.Horizon
+	}
+
+	available, err := s.repo.CountAvailableBikes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rebalance.Build(ctx, s.demand, available, time.Now().UTC(), opts.Horizon, cfg.HistoryDays, rebalance.Options{
+		TruckCapacity: opts.TruckCapacity,
+		Trucks:        opts.Trucks,
+		MinBikes:      cfg.MinBikes,
+	}), nil
+}
+
+// RelocateBikes checks every move before applying any. Moves are applied in
+// order, each on its own, so after a failure the earlier ones stay made.
+func (s *service) RelocateBikes(ctx context.Context, moves []rebalance.Move) ([]Relocation, error) {
+	if len(moves) == 0 {
+		return nil, invalidArgument("at least one move is required")
+	}
+	if len(moves) > MaxRelocateMoves {
+		return nil, invalidArgument("too many moves, at most %d per call", MaxRelocateMoves)
+	}
+	for i := range moves {
+		m := &moves[i]
+		m.From = strings.TrimSpace(m.From)
+		m.To = strings.TrimSpace(m.To)
+		switch {
+		case m.From == "" || m.To == "":
+			return nil, invalidArgument("move %d: from and to are required", i+1)
+		case m.From == m.To:
+			return nil, invalidArgument("move %d: from and to are the same location %q", i+1, m.From)
+		case m.Count < 1 || m.Count > maxMoveCount:
+			return nil, invalidArgument("move %d: count must be between 1 and %d", i+1, maxMoveCount)
+		}
+	}
+
+	relocations := make([]Relocation, 0, len(moves))
+	for i, m := range moves {
+		bikes, err := s.repo.RelocateBikes(ctx, m.From, m.To, m.Count)
+		if err != nil {
+			return nil, fmt.Errorf("move %d of %d (%d applied before it): %w", i+1, len(moves), i, err)
+		}
+
+		log.Printf("Relocated bikes: %s -> %s, %d of %d", m.From, m.To, len(bikes), m.Count)
+		now := time.Now().UTC()
+		for _, bike := range bikes {
+			s.fleet.Publish(events.FleetEvent{
+				Type:         events.BikeRelocated,
+				BikeID:       bike.ID.String(),
+				BikeName:     bike.Name,
+				Location:     bike.Location,
+				FromLocation: m.From,
+				BikeStatus:   bike.Status,
+				Timestamp:    now,
+			})
+		}
+		relocations = append(relocations, Relocation{Move: m, Bikes: bikes})
+	}
+	return relocations, nil
+}
@@ -2,58 +2,192 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"bike-rental/config"
+	"bike-rental/logging"
+	"bike-rental/rent-service/internal/events"
 	"bike-rental/rent-service/internal/kafka"
 	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/rebalance"
 	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/users"
+	"bike-rental/rent-service/internal/wallet"
+	"bike-rental/rentevents"
 	"github.com/google/uuid"
-	kafkago "github.com/segmentio/kafka-go"
 )
 
 type Service interface {
-	StartRent(ctx context.Context, userID string, bikeID string) (*models.Rent, error)
-	EndRent(ctx context.Context, rentID string, userID string) (*models.Rent, error)
+	// StartRent rents a bike to userID. Blocked and unverified accounts are
+	// refused, as are riders without one when registration is required.
+	// With the wallet enabled, riders who cannot cover the hold are refused
+	// with wallet.ErrInsufficientFunds.
+	// A promo code, if given, is redeemed with the rent and needs the
+	// wallet enabled.
+	StartRent(ctx context.Context, userID, bikeID, promoCode string) (*models.Rent, error)
+	// EndRent ends a rent of userID and charges its fare if it is billed,
+	// less the promo code given at the start or now.
+	EndRent(ctx context.Context, rentID, userID, promoCode string) (*models.Rent, error)
+	// PauseRent pauses an active rent of userID; the bike stays locked to
+	// the user. ResumeRent makes it active again.
+	PauseRent(ctx context.Context, rentID string, userID string) (*models.Rent, error)
+	ResumeRent(ctx context.Context, rentID string, userID string) (*models.Rent, error)
+	// ForceResumeRent resumes a paused rent of any user, e.g. when it has
+	// been paused longer than allowed. The reason is kept in its history.
+	ForceResumeRent(ctx context.Context, rentID, reason string) (*models.Rent, error)
 	GetAvailableBikes(ctx context.Context, location string) ([]models.Bike, error)
 	AddBike(ctx context.Context, name, location string) (*models.Bike, error)
 	DeleteBike(ctx context.Context, bikeID string) error
+	ImportBikes(ctx context.Context, rows []ImportRow, opts ImportOptions) (*ImportResult, error)
+	// ExportBikes calls fn for every bike, optionally at one location.
+	ExportBikes(ctx context.Context, location string, fn func(models.Bike) error) error
+	ListRents(ctx context.Context, filter repository.RentFilter) ([]models.Rent, error)
+	ForceEndRent(ctx context.Context, rentID, reason string) (*models.Rent, error)
+	// DisputeRent marks an ended rent as disputed, e.g. when its rider
+	// contests the fare.
+	DisputeRent(ctx context.Context, rentID, reason string) (*models.Rent, error)
+	SetBikeStatus(ctx context.Context, bikeID, status, reason string) (*models.Bike, error)
+	// ReplayRentEvents republishes the Kafka events of rents started in
+	// [from, to) and returns how many were (or, on a dry run, would be) sent.
+	ReplayRentEvents(ctx context.Context, from, to time.Time, dryRun bool) (int, error)
+	// GetRentTimeline returns the lifecycle history of a rent, oldest first.
+	GetRentTimeline(ctx context.Context, rentID string) ([]models.RentLifecycleEvent, error)
+	// RebuildRents recomputes rents from their lifecycle events, all of
+	// them when rentID is empty. It returns how many rents were rebuilt and
+	// how many had drifted from their history.
+	RebuildRents(ctx context.Context, rentID string) (int, int, error)
+	// SetTopic switches the Kafka topic rent events are published to.
+	SetTopic(topic string)
+	// SetEventEncoding switches how rent events are encoded.
+	SetEventEncoding(encoding rentevents.Encoding)
+	// GetWallet returns the user's wallet and its latest ledger entries,
+	// newest first.
+	GetWallet(ctx context.Context, userID string, limit int) (*wallet.Account, []wallet.Entry, error)
+	// TopUpWallet charges the payment provider and credits the wallet.
+	// Calls with the same idempotency key are charged and credited once.
+	TopUpWallet(ctx context.Context, userID string, amount int64, idempotencyKey string) (*wallet.Account, error)
+	// RefundRent credits amount of a rent's fare back to the rider, all of
+	// what is left when amount is 0.
+	RefundRent(ctx context.Context, rentID string, amount int64, reason string) (*wallet.Account, error)
+	// SetWalletConfig switches whether new rents are billed and at what
+	// prices; rents in progress keep the tariff they started with.
+	SetWalletConfig(cfg config.WalletConfig)
+	// ListPasses returns the pass catalog.
+	ListPasses(ctx context.Context) ([]wallet.Pass, error)
+	// GetPass returns the user's active pass, nil if there is none.
+	GetPass(ctx context.Context, userID string) (*wallet.Subscription, error)
+	// BuyPass pays a pass from the user's wallet; rents started while it
+	// lasts are billed with its benefits. It fails with ErrWalletDisabled
+	// when rents are not billed.
+	BuyPass(ctx context.Context, userID, code string) (*wallet.Subscription, error)
+	// CancelPass ends the user's pass now, refunding it if it is unused.
+	CancelPass(ctx context.Context, userID, subscriptionID string) (*wallet.Subscription, error)
+	// ListPromos returns every promo code, newest first.
+	ListPromos(ctx context.Context) ([]wallet.Promo, error)
+	// CreatePromo adds a promo code and UpdatePromo replaces its terms.
+	CreatePromo(ctx context.Context, promo wallet.Promo) (*wallet.Promo, error)
+	UpdatePromo(ctx context.Context, promo wallet.Promo) (*wallet.Promo, error)
+	// DeletePromo removes a promo code that was never redeemed.
+	DeletePromo(ctx context.Context, code string) error
+	// GetPromoStats returns how promo codes redeemed in [from, to) were
+	// used.
+	GetPromoStats(ctx context.Context, from, to time.Time) ([]wallet.PromoStats, error)
+	// GetReceipt returns the itemized invoice of an ended, billed rent.
+	GetReceipt(ctx context.Context, rentID string) (*wallet.Receipt, error)
+	// GetStatement sums up the user's wallet for a month given as
+	// YYYY-MM in UTC, the current one when empty.
+	GetStatement(ctx context.Context, userID, month string) (*wallet.Statement, error)
+	// RegisterUser creates an account pending verification, with a
+	// generated ID when user.ID is empty.
+	RegisterUser(ctx context.Context, user users.User) (*users.User, error)
+	GetUser(ctx context.Context, userID string) (*users.User, error)
+	// UpdateUser replaces the name, email and phone of an account.
+	UpdateUser(ctx context.Context, user users.User) (*users.User, error)
+	// SetUserStatus verifies, blocks or unblocks an account; reason is
+	// required to block.
+	SetUserStatus(ctx context.Context, userID, status, reason string) (*users.User, error)
+	// ListUsers returns accounts, optionally in one status, newest first.
+	ListUsers(ctx context.Context, status string, limit int) ([]users.User, error)
+	// SetUsersConfig switches whether riders need an account to rent.
+	SetUsersConfig(cfg config.UsersConfig)
+	// PlanRebalance recommends moves of available bikes from locations
+	// with more than they need to locations short of them.
+	PlanRebalance(ctx context.Context, opts RebalanceOptions) (*rebalance.Plan, error)
+	// RelocateBikes moves available bikes between locations, e.g. to apply
+	// a plan, and returns the bikes each move took.
+	RelocateBikes(ctx context.Context, moves []rebalance.Move) ([]Relocation, error)
+	// SetRebalanceConfig switches the defaults of rebalancing plans.
+	SetRebalanceConfig(cfg config.RebalanceConfig)
 }
 
 type service struct {
-	repo   repository.Repository
-	writer kafka.Writer
-	topic  string
+	repo     repository.Repository
+	writer   kafka.Writer
+	fleet    *events.Broadcaster
+	wallets  wallet.Store
+	payments wallet.PaymentProvider
+	users    users.Store
+	demand   rebalance.Demand
+
+	mu           sync.RWMutex
+	topic        string
+	encoding     rentevents.Encoding
+	walletCfg    config.WalletConfig
+	usersCfg     config.UsersConfig
+	rebalanceCfg config.RebalanceConfig
 }
 
-func NewService(repo repository.Repository, writer kafka.Writer, topic string) Service {
+// NewService creates the rent service. Committed changes are announced on
+// fleet for streaming subscribers; top-ups are charged through payments.
+// Rebalancing plans judge demand from demand.
+func NewService(repo repository.Repository, writer kafka.Writer, topic string, encoding rentevents.Encoding, fleet *events.Broadcaster,
+	wallets wallet.Store, payments wallet.PaymentProvider, walletCfg config.WalletConfig,
+	accounts users.Store, usersCfg config.UsersConfig, demand rebalance.Demand, rebalanceCfg config.RebalanceConfig) Service {
 	return &service{
-		repo:   repo,
-		writer: writer,
-		topic:  topic,
+		repo:         repo,
+		writer:       writer,
+		fleet:        fleet,
+		wallets:      wallets,
+		payments:     payments,
+		users:        accounts,
+		demand:       demand,
+		topic:        topic,
+		encoding:     encoding,
+		walletCfg:    walletCfg,
+		usersCfg:     usersCfg,
+		rebalanceCfg: rebalanceCfg,
 	}
 }
 
-func (s *service) StartRent(ctx context.Context, userID string, bikeID string) (*models.Rent, error) {
+func (s *service) StartRent(ctx context.Context, userID, bikeID, promoCode string) (*models.Rent, error) {
 	bikeUUID, err := uuid.Parse(bikeID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid bike_id: %w", err)
 	}
 
-	rent, err := s.repo.StartRent(ctx, userID, bikeUUID)
+	tariff := s.tariff()
+	promoCode = normalizePromoCode(promoCode)
+	if promoCode != "" && tariff == nil {
+		return nil, ErrWalletDisabled
+	}
+
+	rent, err := s.repo.StartRent(ctx, userID, s.usersConfig().RequireRegistration, bikeUUID, tariff, promoCode)
 	if err != nil {
 		return nil, err
 	}
 
 	// Send event to Kafka
-	event := models.RentEvent{
+	event := rentevents.RentEvent{
 		RentID:    rent.ID.String(),
 		UserID:    userID,
 		BikeID:    bikeID,
-		EventType: "start",
-		Timestamp: time.Now(),
+		EventType: rentevents.Start,
+		Location:  rent.Location,
+		Timestamp: eventTime(&rent.StartTime),
+		Pass:      rent.Pass,
 	}
 
 	if err := s.publishRentEvent(ctx, event); err != nil {
@@ -63,29 +197,129 @@ func (s *service) StartRent(ctx context.Context, userID string, bikeID string) (
 		log.Printf("Successfully published rent event: rent_id=%s, event_type=%s", event.RentID, event.EventType)
 	}
 
+	s.fleet.Publish(events.FleetEvent{
+		Type:       events.RentStarted,
+		BikeID:     bikeID,
+		RentID:     event.RentID,
+		UserID:     userID,
+		Location:   rent.Location,
+		BikeStatus: "rented",
+		Timestamp:  event.Timestamp,
+	})
+
 	return rent, nil
 }
 
-func (s *service) EndRent(ctx context.Context, rentID string, userID string) (*models.Rent, error) {
+func (s *service) EndRent(ctx context.Context, rentID, userID, promoCode string) (*models.Rent, error) {
 	rentUUID, err := uuid.Parse(rentID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid rent_id: %w", err)
 	}
 
-	rent, err := s.repo.EndRent(ctx, rentUUID, userID)
+	rent, err := s.repo.EndRent(ctx, rentUUID, userID, normalizePromoCode(promoCode))
 	if err != nil {
 		return nil, err
 	}
 
-	// Send event to Kafka
-	event := models.RentEvent{
+	s.announceRentEnded(ctx, rent)
+
+	return rent, nil
+}
+
+func (s *service) PauseRent(ctx context.Context, rentID string, userID string) (*models.Rent, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rent_id: %w", err)
+	}
+
+	rent, err := s.repo.PauseRent(ctx, rentUUID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.announcePause(ctx, rent, rentevents.Pause, events.RentPaused, rent.PausedAt)
+
+	return rent, nil
+}
+
+func (s *service) ResumeRent(ctx context.Context, rentID string, userID string) (*models.Rent, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rent_id: %w", err)
+	}
+
+	return s.resumeRent(ctx, rentUUID, userID, "")
+}
+
+func (s *service) ForceResumeRent(ctx context.Context, rentID, reason string) (*models.Rent, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, invalidArgument("invalid rent_id: %v", err)
+	}
+
+	rent, err := s.resumeRent(ctx, rentUUID, "", reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Force-resumed rent: rent_id=%s, user_id=%s, bike_id=%s, reason=%q", rent.ID, rent.UserID, rent.BikeID, reason)
+	return rent, nil
+}
+
+func (s *service) resumeRent(ctx context.Context, rentID uuid.UUID, userID, reason string) (*models.Rent, error) {
+	rent, err := s.repo.ResumeRent(ctx, rentID, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rent keeps no resume time, so the event is stamped now, right
+	// after the commit
+	s.announcePause(ctx, rent, rentevents.Resume, events.RentResumed, nil)
+
+	return rent, nil
+}
+
+// announcePause publishes the Kafka and fleet events for a rent that has
+// just paused or resumed at, or now if at is nil.
+func (s *service) announcePause(ctx context.Context, rent *models.Rent, eventType, fleetType string, at *time.Time) {
+	event := rentevents.RentEvent{
 		RentID:    rent.ID.String(),
-		UserID:    userID,
+		UserID:    rent.UserID,
 		BikeID:    rent.BikeID.String(),
-		EventType: "end",
-		Timestamp: time.Now(),
+		EventType: eventType,
+		Location:  rent.Location,
+		Timestamp: eventTime(at),
 	}
+	if err := s.publishRentEvent(ctx, event); err != nil {
+		// Log error but don't fail the operation
+		log.Printf("Failed to publish rent event: %v", err)
+	} else {
+		log.Printf("Successfully published rent event: rent_id=%s, event_type=%s", event.RentID, event.EventType)
+	}
+
+	s.fleet.Publish(events.FleetEvent{
+		Type:       fleetType,
+		BikeID:     event.BikeID,
+		RentID:     event.RentID,
+		UserID:     rent.UserID,
+		Location:   rent.Location,
+		BikeStatus: "rented",
+		Timestamp:  event.Timestamp,
+	})
+}
 
+// announceRentEnded publishes the Kafka and fleet events for a rent that
+// has just ended.
+func (s *service) announceRentEnded(ctx context.Context, rent *models.Rent) {
+	event := rentevents.RentEvent{
+		RentID:    rent.ID.String(),
+		UserID:    rent.UserID,
+		BikeID:    rent.BikeID.String(),
+		EventType: rentevents.End,
+		Location:  rent.Location,
+		Timestamp: eventTime(rent.EndTime),
+		Pass:      rent.Pass,
+	}
 	if err := s.publishRentEvent(ctx, event); err != nil {
 		// Log error but don't fail the operation
 		log.Printf("Failed to publish rent event: %v", err)
@@ -93,7 +327,24 @@ func (s *service) EndRent(ctx context.Context, rentID string, userID string) (*m
 		log.Printf("Successfully published rent event: rent_id=%s, event_type=%s", event.RentID, event.EventType)
 	}
 
-	return rent, nil
+	s.fleet.Publish(events.FleetEvent{
+		Type:       events.RentEnded,
+		BikeID:     event.BikeID,
+		RentID:     event.RentID,
+		UserID:     rent.UserID,
+		Location:   rent.Location,
+		BikeStatus: "available",
+		Timestamp:  event.Timestamp,
+	})
+}
+
+// eventTime returns the time recorded by the database for an event in UTC,
+// falling back to the current time when it is unknown.
+func eventTime(t *time.Time) time.Time {
+	if t == nil || t.IsZero() {
+		return time.Now().UTC()
+	}
+	return t.UTC()
 }
 
 func (s *service) GetAvailableBikes(ctx context.Context, location string) ([]models.Bike, error) {
@@ -114,6 +365,16 @@ func (s *service) AddBike(ctx context.Context, name, location string) (*models.B
 	}
 	
 	log.Printf("Successfully added new bike: id=%s, name=%s, location=%s", bike.ID, bike.Name, bike.Location)
+
+	s.fleet.Publish(events.FleetEvent{
+		Type:       events.BikeAdded,
+		BikeID:     bike.ID.String(),
+		BikeName:   bike.Name,
+		Location:   bike.Location,
+		BikeStatus: bike.Status,
+		Timestamp:  time.Now().UTC(),
+	})
+
 	return bike, nil
 }
 
@@ -146,26 +407,50 @@ func (s *service) DeleteBike(ctx context.Context, bikeID string) error {
 	}
 	
 	log.Printf("Successfully deleted bike: id=%s, name=%s, location=%s", bike.ID, bike.Name, bike.Location)
+
+	s.fleet.Publish(events.FleetEvent{
+		Type:      events.BikeRemoved,
+		BikeID:    bike.ID.String(),
+		Location:  bike.Location,
+		Timestamp: time.Now().UTC(),
+	})
+
 	return nil
 }
 
-func (s *service) publishRentEvent(ctx context.Context, event models.RentEvent) error {
-	eventJSON, err := json.Marshal(event)
+func (s *service) SetTopic(topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topic = topic
+}
+
+func (s *service) SetEventEncoding(encoding rentevents.Encoding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoding = encoding
+}
+
+func (s *service) eventSettings() (string, rentevents.Encoding) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.topic, s.encoding
+}
+
+func (s *service) publishRentEvent(ctx context.Context, event rentevents.RentEvent) error {
+	topic, encoding := s.eventSettings()
+	msg, err := rentevents.NewMessage(topic, event, encoding)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return err
 	}
 
-	log.Printf("Publishing event to Kafka: topic=%s, rent_id=%s, event_type=%s", s.topic, event.RentID, event.EventType)
-	
-	err = s.writer.WriteMessages(ctx, kafkago.Message{
-		Key:   []byte(event.RentID),
-		Value: eventJSON,
-	})
+	logging.Debugf("Publishing event to Kafka: topic=%s, rent_id=%s, event_type=%s, encoding=%s", topic, event.RentID, event.EventType, encoding)
+
+	err = s.writer.WriteMessages(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to write message to Kafka: %w", err)
 	}
 
-	log.Printf("Event published successfully to Kafka: rent_id=%s", event.RentID)
+	logging.Debugf("Event published successfully to Kafka: rent_id=%s", event.RentID)
 	return nil
 }
 
@@ -3,12 +3,22 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/events"
 	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/rebalance"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/users"
+	"bike-rental/rent-service/internal/wallet"
 	"bike-rental/rent-service/mocks"
+	"bike-rental/rentevents"
 	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -18,15 +28,76 @@ type ServiceTestSuite struct {
 	suite.Suite
 	mockRepo   *mocks.Repository
 	mockWriter *mocks.Writer
+	fleet      *events.Broadcaster
+	wallets    *fakeWallets
+	users      *fakeUsers
+	demand     *fakeDemand
 	service    Service
 	ctx        context.Context
 }
 
+// fakeWallets - запоминает зачисления и возвраты вместо записи в Postgres
+type fakeWallets struct {
+	wallet.Store
+	payments []string
+	refunds  []int64
+}
+
+func (f *fakeWallets) TopUp(ctx context.Context, userID string, amount int64, paymentID string) (*wallet.Account, error) {
+	f.payments = append(f.payments, paymentID)
+	return &wallet.Account{UserID: userID, Balance: amount}, nil
+}
+
+func (f *fakeWallets) Refund(ctx context.Context, rentID uuid.UUID, amount int64, reason string) (*wallet.Account, error) {
+	f.refunds = append(f.refunds, amount)
+	return &wallet.Account{UserID: "user123", Balance: amount}, nil
+}
+
+// fakeUsers - учётные записи в памяти вместо Postgres
+type fakeUsers struct {
+	users.Store
+	accounts map[string]users.User
+}
+
+func (f *fakeUsers) Get(ctx context.Context, id string) (*users.User, error) {
+	user, ok := f.accounts[id]
+	if !ok {
+		return nil, users.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (f *fakeUsers) Create(ctx context.Context, user users.User) (*users.User, error) {
+	user.Status = users.StatusPending
+	f.accounts[user.ID] = user
+	return &user, nil
+}
+
+// fakeDemand - прогноз спроса вместо запроса к stats-service
+type fakeDemand struct {
+	forecast *rebalance.Forecast
+	err      error
+	hours    int
+}
+
+func (f *fakeDemand) Forecast(ctx context.Context, now time.Time, hours, days int) (*rebalance.Forecast, error) {
+	f.hours = hours
+	return f.forecast, f.err
+}
+
 // SetupTest - вызывается перед каждым тестом
 func (suite *ServiceTestSuite) SetupTest() {
 	suite.mockRepo = mocks.NewRepository(suite.T())
 	suite.mockWriter = mocks.NewWriter(suite.T())
-	suite.service = NewService(suite.mockRepo, suite.mockWriter, "test-topic")
+	suite.fleet = events.NewBroadcaster(16, 16)
+	suite.wallets = &fakeWallets{}
+	suite.users = &fakeUsers{accounts: map[string]users.User{
+		"user123": {ID: "user123", Status: users.StatusActive},
+	}}
+	suite.demand = &fakeDemand{forecast: &rebalance.Forecast{}}
+	suite.service = NewService(suite.mockRepo, suite.mockWriter, "test-topic", rentevents.Protobuf, suite.fleet,
+		suite.wallets, wallet.NewFakeProvider(), config.Default().Rent.Wallet, suite.users, config.Default().Rent.Users,
+		suite.demand, config.Default().Rent.Rebalance)
 	suite.ctx = context.Background()
 }
 
@@ -45,11 +116,11 @@ func (suite *ServiceTestSuite) TestStartRent_Success() {
 		Status:    "active",
 	}
 
-	suite.mockRepo.On("StartRent", suite.ctx, userID, bikeID).Return(expectedRent, nil)
+	suite.mockRepo.On("StartRent", suite.ctx, userID, true, bikeID, (*wallet.Tariff)(nil), "").Return(expectedRent, nil)
 	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil)
 
 	// Act
-	result, err := suite.service.StartRent(suite.ctx, userID, bikeIDStr)
+	result, err := suite.service.StartRent(suite.ctx, userID, bikeIDStr, "")
 
 	// Assert
 	suite.NoError(err)
@@ -60,6 +131,36 @@ func (suite *ServiceTestSuite) TestStartRent_Success() {
 	suite.Equal("active", result.Status)
 }
 
+// TestStartRent_PublishesFleetEvent - старт аренды публикуется подписчикам потока
+func (suite *ServiceTestSuite) TestStartRent_PublishesFleetEvent() {
+	// Arrange
+	sub := suite.fleet.Subscribe()
+	defer sub.Close()
+
+	bikeID := uuid.New()
+	expectedRent := &models.Rent{
+		ID:        uuid.New(),
+		UserID:    "user123",
+		BikeID:    bikeID,
+		StartTime: time.Now(),
+		Status:    "active",
+		Location:  "Park",
+	}
+	suite.mockRepo.On("StartRent", suite.ctx, "user123", true, bikeID, (*wallet.Tariff)(nil), "").Return(expectedRent, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil)
+
+	// Act
+	_, err := suite.service.StartRent(suite.ctx, "user123", bikeID.String(), "")
+
+	// Assert
+	suite.NoError(err)
+	event := <-sub.C
+	suite.Equal(events.RentStarted, event.Type)
+	suite.Equal(bikeID.String(), event.BikeID)
+	suite.Equal("Park", event.Location)
+	suite.Equal("rented", event.BikeStatus)
+}
+
 // TestStartRent_InvalidBikeID - тест с невалидным bike_id
 func (suite *ServiceTestSuite) TestStartRent_InvalidBikeID() {
 	// Arrange
@@ -67,7 +168,7 @@ func (suite *ServiceTestSuite) TestStartRent_InvalidBikeID() {
 	invalidBikeID := "invalid-uuid"
 
 	// Act
-	result, err := suite.service.StartRent(suite.ctx, userID, invalidBikeID)
+	result, err := suite.service.StartRent(suite.ctx, userID, invalidBikeID, "")
 
 	// Assert
 	suite.Error(err)
@@ -83,10 +184,10 @@ func (suite *ServiceTestSuite) TestStartRent_RepositoryError() {
 	bikeIDStr := bikeID.String()
 	
 	expectedError := errors.New("bike is not available")
-	suite.mockRepo.On("StartRent", suite.ctx, userID, bikeID).Return(nil, expectedError)
+	suite.mockRepo.On("StartRent", suite.ctx, userID, true, bikeID, (*wallet.Tariff)(nil), "").Return(nil, expectedError)
 
 	// Act
-	result, err := suite.service.StartRent(suite.ctx, userID, bikeIDStr)
+	result, err := suite.service.StartRent(suite.ctx, userID, bikeIDStr, "")
 
 	// Assert
 	suite.Error(err)
@@ -109,11 +210,11 @@ func (suite *ServiceTestSuite) TestStartRent_KafkaError() {
 		Status:    "active",
 	}
 
-	suite.mockRepo.On("StartRent", suite.ctx, userID, bikeID).Return(expectedRent, nil)
+	suite.mockRepo.On("StartRent", suite.ctx, userID, true, bikeID, (*wallet.Tariff)(nil), "").Return(expectedRent, nil)
 	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(errors.New("kafka connection failed"))
 
 	// Act
-	result, err := suite.service.StartRent(suite.ctx, userID, bikeIDStr)
+	result, err := suite.service.StartRent(suite.ctx, userID, bikeIDStr, "")
 
 	// Assert - операция должна завершиться успешно несмотря на ошибку Kafka
 	suite.NoError(err)
@@ -121,6 +222,66 @@ func (suite *ServiceTestSuite) TestStartRent_KafkaError() {
 	suite.Equal(expectedRent.ID, result.ID)
 }
 
+// TestStartRent_PublishesToCurrentTopic - событие уходит в топик, заданный через SetTopic
+func (suite *ServiceTestSuite) TestStartRent_PublishesToCurrentTopic() {
+	// Arrange
+	userID := "user123"
+	bikeID := uuid.New()
+
+	expectedRent := &models.Rent{
+		ID:        uuid.New(),
+		UserID:    userID,
+		BikeID:    bikeID,
+		StartTime: time.Now(),
+		Status:    "active",
+	}
+
+	suite.service.SetTopic("rent-events-v2")
+	suite.mockRepo.On("StartRent", suite.ctx, userID, true, bikeID, (*wallet.Tariff)(nil), "").Return(expectedRent, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.MatchedBy(func(msg kafkago.Message) bool {
+		return msg.Topic == "rent-events-v2"
+	})).Return(nil)
+
+	// Act
+	_, err := suite.service.StartRent(suite.ctx, userID, bikeID.String(), "")
+
+	// Assert
+	suite.NoError(err)
+}
+
+// TestStartRent_EventEncoding - событие кодируется в формате, заданном через SetEventEncoding
+func (suite *ServiceTestSuite) TestStartRent_EventEncoding() {
+	// Arrange
+	bikeID := uuid.New()
+	expectedRent := &models.Rent{
+		ID:        uuid.New(),
+		UserID:    "user123",
+		BikeID:    bikeID,
+		StartTime: time.Now(),
+		Status:    "active",
+		Location:  "Park",
+	}
+
+	var sent kafkago.Message
+	suite.service.SetEventEncoding(rentevents.JSON)
+	suite.mockRepo.On("StartRent", suite.ctx, "user123", true, bikeID, (*wallet.Tariff)(nil), "").Return(expectedRent, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(kafkago.Message)
+	}).Return(nil)
+
+	// Act
+	_, err := suite.service.StartRent(suite.ctx, "user123", bikeID.String(), "")
+
+	// Assert
+	suite.NoError(err)
+	suite.Contains(sent.Headers, kafkago.Header{Key: rentevents.HeaderContentType, Value: []byte(rentevents.ContentTypeJSON)})
+	event, err := rentevents.Decode(sent)
+	suite.Require().NoError(err)
+	suite.Equal(rentevents.EventID(expectedRent.ID.String(), rentevents.Start), event.ID)
+	suite.Equal(rentevents.Start, event.EventType)
+	suite.Equal("Park", event.Location)
+}
+
 // TestEndRent_Success - тест успешного завершения аренды
 func (suite *ServiceTestSuite) TestEndRent_Success() {
 	// Arrange
@@ -139,11 +300,11 @@ func (suite *ServiceTestSuite) TestEndRent_Success() {
 		Status:    "completed",
 	}
 
-	suite.mockRepo.On("EndRent", suite.ctx, rentID, userID).Return(expectedRent, nil)
+	suite.mockRepo.On("EndRent", suite.ctx, rentID, userID, "").Return(expectedRent, nil)
 	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil)
 
 	// Act
-	result, err := suite.service.EndRent(suite.ctx, rentIDStr, userID)
+	result, err := suite.service.EndRent(suite.ctx, rentIDStr, userID, "")
 
 	// Assert
 	suite.NoError(err)
@@ -160,7 +321,7 @@ func (suite *ServiceTestSuite) TestEndRent_InvalidRentID() {
 	invalidRentID := "invalid-uuid"
 
 	// Act
-	result, err := suite.service.EndRent(suite.ctx, invalidRentID, userID)
+	result, err := suite.service.EndRent(suite.ctx, invalidRentID, userID, "")
 
 	// Assert
 	suite.Error(err)
@@ -176,10 +337,10 @@ func (suite *ServiceTestSuite) TestEndRent_RepositoryError() {
 	rentIDStr := rentID.String()
 	
 	expectedError := errors.New("rent not found")
-	suite.mockRepo.On("EndRent", suite.ctx, rentID, userID).Return(nil, expectedError)
+	suite.mockRepo.On("EndRent", suite.ctx, rentID, userID, "").Return(nil, expectedError)
 
 	// Act
-	result, err := suite.service.EndRent(suite.ctx, rentIDStr, userID)
+	result, err := suite.service.EndRent(suite.ctx, rentIDStr, userID, "")
 
 	// Assert
 	suite.Error(err)
@@ -187,6 +348,85 @@ func (suite *ServiceTestSuite) TestEndRent_RepositoryError() {
 	suite.Equal(expectedError, err)
 }
 
+// TestPauseRent_PublishesPauseEvent - пауза публикуется в Kafka временем начала паузы, велосипед остаётся арендованным
+func (suite *ServiceTestSuite) TestPauseRent_PublishesPauseEvent() {
+	// Arrange
+	sub := suite.fleet.Subscribe()
+	defer sub.Close()
+
+	rentID := uuid.New()
+	pausedAt := time.Date(2024, 1, 15, 10, 10, 0, 0, time.UTC)
+	paused := &models.Rent{
+		ID:       rentID,
+		UserID:   "user123",
+		BikeID:   uuid.New(),
+		Status:   "paused",
+		PausedAt: &pausedAt,
+		Location: "Park",
+	}
+
+	var sent kafkago.Message
+	suite.mockRepo.On("PauseRent", suite.ctx, rentID, "user123").Return(paused, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(kafkago.Message)
+	}).Return(nil)
+
+	// Act
+	result, err := suite.service.PauseRent(suite.ctx, rentID.String(), "user123")
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("paused", result.Status)
+	event, err := rentevents.Decode(sent)
+	suite.Require().NoError(err)
+	suite.Equal(rentevents.Pause, event.EventType)
+	suite.Equal(pausedAt, event.Timestamp)
+	suite.Equal(rentevents.PauseEventID(rentID.String(), rentevents.Pause, pausedAt), event.ID)
+
+	fleetEvent := <-sub.C
+	suite.Equal(events.RentPaused, fleetEvent.Type)
+	suite.Equal("rented", fleetEvent.BikeStatus)
+}
+
+// TestResumeRent_NotPaused - продолжить можно только аренду на паузе, событие не публикуется
+func (suite *ServiceTestSuite) TestResumeRent_NotPaused() {
+	// Arrange
+	rentID := uuid.New()
+	suite.mockRepo.On("ResumeRent", suite.ctx, rentID, "user123", "").Return(nil, repository.ErrRentNotPaused)
+
+	// Act
+	result, err := suite.service.ResumeRent(suite.ctx, rentID.String(), "user123")
+
+	// Assert
+	suite.ErrorIs(err, repository.ErrRentNotPaused)
+	suite.Nil(result)
+	suite.mockWriter.AssertNotCalled(suite.T(), "WriteMessages", mock.Anything, mock.Anything)
+}
+
+// TestForceResumeRent_PublishesResumeEvent - продолжение по лимиту паузы идёт без пользователя, с причиной
+func (suite *ServiceTestSuite) TestForceResumeRent_PublishesResumeEvent() {
+	// Arrange
+	rentID := uuid.New()
+	resumed := &models.Rent{ID: rentID, UserID: "user123", BikeID: uuid.New(), Status: "active", PausedSeconds: 1800}
+
+	var sent kafkago.Message
+	suite.mockRepo.On("ResumeRent", suite.ctx, rentID, "", "paused longer than 30m0s").Return(resumed, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(kafkago.Message)
+	}).Return(nil)
+
+	// Act
+	result, err := suite.service.ForceResumeRent(suite.ctx, rentID.String(), "paused longer than 30m0s")
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(int64(1800), result.PausedSeconds)
+	event, err := rentevents.Decode(sent)
+	suite.Require().NoError(err)
+	suite.Equal(rentevents.Resume, event.EventType)
+	suite.Equal("user123", event.UserID)
+}
+
 // TestGetAvailableBikes_Success - тест успешного получения доступных велосипедов
 func (suite *ServiceTestSuite) TestGetAvailableBikes_Success() {
 	// Arrange
@@ -366,6 +606,18 @@ func (suite *ServiceTestSuite) TestDeleteBike_HasActiveRent() {
 	suite.Contains(err.Error(), "cannot delete bike: bike has active rent")
 }
 
+// TestDeleteBike_HasRents - велосипед с историей аренд не удаляется и не снимается с карты
+func (suite *ServiceTestSuite) TestDeleteBike_HasRents() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("HasActiveRent", suite.ctx, bikeID).Return(false, nil)
+	suite.mockRepo.On("GetBikeByID", suite.ctx, bikeID).Return(&models.Bike{ID: bikeID, Status: "available"}, nil)
+	suite.mockRepo.On("DeleteBike", suite.ctx, bikeID).Return(repository.ErrBikeHasRents)
+
+	err := suite.service.DeleteBike(suite.ctx, bikeID.String())
+
+	suite.ErrorIs(err, repository.ErrBikeHasRents)
+}
+
 // TestDeleteBike_BikeNotFound - тест удаления несуществующего велосипеда
 func (suite *ServiceTestSuite) TestDeleteBike_BikeNotFound() {
 	// Arrange
@@ -384,6 +636,486 @@ func (suite *ServiceTestSuite) TestDeleteBike_BikeNotFound() {
 }
 
 // TestServiceTestSuite - запуск всего набора тестов
+// TestImportBikes_ReportsRowErrors - невалидные строки попадают в отчёт, валидные импортируются
+func (suite *ServiceTestSuite) TestImportBikes_ReportsRowErrors() {
+	rows := []ImportRow{
+		{Line: 2, Name: "Bike 10", Location: "Park"},
+		{Line: 3, Name: "", Location: "Park"},
+		{Line: 4, Name: "Bike 11", Location: "Park", Status: "broken"},
+		{Line: 5, Name: " Bike 10 ", Location: "Park"},
+		{Line: 6, Name: "Bike 12", Location: "Park", Status: "Maintenance"},
+	}
+	valid := []models.Bike{
+		{Name: "Bike 10", Location: "Park", Status: "available"},
+		{Name: "Bike 12", Location: "Park", Status: "maintenance"},
+	}
+	added := []models.Bike{
+		{ID: uuid.New(), Name: "Bike 10", Location: "Park", Status: "available"},
+		{ID: uuid.New(), Name: "Bike 12", Location: "Park", Status: "maintenance"},
+	}
+	suite.mockRepo.On("AddBikes", suite.ctx, valid).Return(added, nil)
+	sub := suite.fleet.Subscribe()
+
+	result, err := suite.service.ImportBikes(suite.ctx, rows, ImportOptions{})
+
+	suite.NoError(err)
+	suite.Equal(5, result.Total)
+	suite.Equal(added, result.Imported)
+	suite.Equal([]ImportRowError{
+		{Line: 3, Error: "bike name is required"},
+		{Line: 4, Error: `unknown status "broken", expected available or maintenance`},
+		{Line: 5, Error: "duplicate of line 2"},
+	}, result.Errors)
+	suite.Len(sub.C, 2)
+}
+
+// TestImportBikes_AllOrNothing - при ошибке в любой строке ничего не импортируется
+func (suite *ServiceTestSuite) TestImportBikes_AllOrNothing() {
+	rows := []ImportRow{
+		{Line: 1, Name: "Bike 10", Location: "Park"},
+		{Line: 2, Name: "Bike 11"},
+	}
+
+	result, err := suite.service.ImportBikes(suite.ctx, rows, ImportOptions{AllOrNothing: true})
+
+	suite.NoError(err)
+	suite.Empty(result.Imported)
+	suite.Len(result.Errors, 1)
+	suite.mockRepo.AssertNotCalled(suite.T(), "AddBikes", mock.Anything, mock.Anything)
+}
+
+// TestImportBikes_DryRun - пробный импорт только проверяет строки
+func (suite *ServiceTestSuite) TestImportBikes_DryRun() {
+	result, err := suite.service.ImportBikes(suite.ctx, []ImportRow{{Line: 1, Name: "Bike 10", Location: "Park"}}, ImportOptions{DryRun: true})
+
+	suite.NoError(err)
+	suite.True(result.DryRun)
+	suite.Empty(result.Errors)
+	suite.Empty(result.Imported)
+}
+
+// TestImportBikes_CyrillicLimits - длина названия и локации считается в символах, а не в байтах
+func (suite *ServiceTestSuite) TestImportBikes_CyrillicLimits() {
+	location := strings.Repeat("Ж", maxBikeLocationLength)
+	rows := []ImportRow{
+		{Line: 1, Name: "Велосипед 1", Location: location},
+		{Line: 2, Name: "Велосипед 2", Location: location + "Ж"},
+	}
+
+	result, err := suite.service.ImportBikes(suite.ctx, rows, ImportOptions{DryRun: true})
+
+	suite.NoError(err)
+	suite.Equal([]ImportRowError{
+		{Line: 2, Error: "bike location is longer than 50 characters"},
+	}, result.Errors)
+}
+
+// TestImportBikes_TooManyRows - слишком большой импорт отклоняется целиком
+func (suite *ServiceTestSuite) TestImportBikes_TooManyRows() {
+	_, err := suite.service.ImportBikes(suite.ctx, make([]ImportRow, MaxImportRows+1), ImportOptions{})
+
+	suite.Error(err)
+}
+
+// TestForceEndRent_PublishesEndEvent - принудительное завершение публикует событие end
+func (suite *ServiceTestSuite) TestForceEndRent_PublishesEndEvent() {
+	rentID := uuid.New()
+	endTime := time.Now()
+	suite.mockRepo.On("ForceEndRent", suite.ctx, rentID, "bike found at depot").Return(&models.Rent{
+		ID:        rentID,
+		UserID:    "user1",
+		BikeID:    uuid.New(),
+		StartTime: endTime.Add(-48 * time.Hour),
+		EndTime:   &endTime,
+		Status:    "force_ended",
+		Location:  "Park",
+	}, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.MatchedBy(func(msg kafkago.Message) bool {
+		event, err := rentevents.Decode(msg)
+		return err == nil && event.EventType == rentevents.End
+	})).Return(nil)
+
+	rent, err := suite.service.ForceEndRent(suite.ctx, rentID.String(), "bike found at depot")
+
+	suite.NoError(err)
+	suite.Equal("force_ended", rent.Status)
+}
+
+// TestForceEndRent_RequiresReason - без причины аренда не завершается
+func (suite *ServiceTestSuite) TestForceEndRent_RequiresReason() {
+	_, err := suite.service.ForceEndRent(suite.ctx, uuid.New().String(), "  ")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+// TestDisputeRent_RecordsReason - спор записывается с причиной и не публикуется в Kafka
+func (suite *ServiceTestSuite) TestDisputeRent_RecordsReason() {
+	rentID := uuid.New()
+	suite.mockRepo.On("DisputeRent", suite.ctx, rentID, "charged twice").Return(&models.Rent{
+		ID:     rentID,
+		UserID: "user1",
+		BikeID: uuid.New(),
+		Status: "disputed",
+	}, nil)
+
+	rent, err := suite.service.DisputeRent(suite.ctx, rentID.String(), " charged twice ")
+
+	suite.NoError(err)
+	suite.Equal("disputed", rent.Status)
+	suite.mockWriter.AssertNotCalled(suite.T(), "WriteMessages", mock.Anything, mock.Anything)
+}
+
+// TestDisputeRent_RequiresReason - без причины спор не записывается
+func (suite *ServiceTestSuite) TestDisputeRent_RequiresReason() {
+	_, err := suite.service.DisputeRent(suite.ctx, uuid.New().String(), "  ")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.mockRepo.AssertNotCalled(suite.T(), "DisputeRent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGetRentTimeline_InvalidID - некорректный rent_id не доходит до репозитория
+func (suite *ServiceTestSuite) TestGetRentTimeline_InvalidID() {
+	_, err := suite.service.GetRentTimeline(suite.ctx, "not-a-uuid")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.mockRepo.AssertNotCalled(suite.T(), "ListRentEvents", mock.Anything, mock.Anything)
+}
+
+// TestRebuildRents_All - пустой rent_id пересобирает все аренды
+func (suite *ServiceTestSuite) TestRebuildRents_All() {
+	suite.mockRepo.On("RebuildRents", suite.ctx, uuid.Nil).Return(10, 2, nil)
+
+	rebuilt, changed, err := suite.service.RebuildRents(suite.ctx, "")
+
+	suite.NoError(err)
+	suite.Equal(10, rebuilt)
+	suite.Equal(2, changed)
+}
+
+// TestSetBikeStatus_RejectsRentedBike - статус арендованного велосипеда не меняется
+func (suite *ServiceTestSuite) TestSetBikeStatus_RejectsRentedBike() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("GetBikeByID", suite.ctx, bikeID).Return(&models.Bike{ID: bikeID, Status: "rented"}, nil)
+	suite.mockRepo.On("HasActiveRent", suite.ctx, bikeID).Return(true, nil)
+
+	_, err := suite.service.SetBikeStatus(suite.ctx, bikeID.String(), "available", "stuck")
+
+	suite.ErrorIs(err, ErrBikeRented)
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateBikeStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestSetBikeStatus_Success - смена статуса публикует событие парка
+func (suite *ServiceTestSuite) TestSetBikeStatus_Success() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("GetBikeByID", suite.ctx, bikeID).Return(&models.Bike{ID: bikeID, Status: "rented", Location: "Park"}, nil)
+	suite.mockRepo.On("HasActiveRent", suite.ctx, bikeID).Return(false, nil)
+	suite.mockRepo.On("UpdateBikeStatus", suite.ctx, bikeID, "maintenance").Return(nil)
+	sub := suite.fleet.Subscribe()
+
+	bike, err := suite.service.SetBikeStatus(suite.ctx, bikeID.String(), "maintenance", "flat tyre")
+
+	suite.NoError(err)
+	suite.Equal("maintenance", bike.Status)
+	suite.Equal(events.BikeStatusChanged, (<-sub.C).Type)
+}
+
+// TestReplayRentEvents - публикуется вся история аренды по порядку, для активных - без end
+func (suite *ServiceTestSuite) TestReplayRentEvents() {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	end := from.Add(time.Hour)
+	completed, active := uuid.New(), uuid.New()
+	suite.mockRepo.On("ListRents", suite.ctx, repository.RentFilter{StartedAfter: from, StartedBefore: to}).Return([]models.Rent{
+		{ID: completed, StartTime: from, EndTime: &end},
+		{ID: active, StartTime: from.Add(time.Minute)},
+	}, nil)
+	suite.mockRepo.On("ListRentEvents", suite.ctx, completed).Return([]models.RentLifecycleEvent{
+		{Type: models.RentStarted, Time: from},
+		{Type: models.RentPaused, Time: from.Add(10 * time.Minute)},
+		{Type: models.RentResumed, Time: from.Add(20 * time.Minute)},
+		{Type: models.RentEnded, Time: end},
+	}, nil)
+	suite.mockRepo.On("ListRentEvents", suite.ctx, active).Return([]models.RentLifecycleEvent{
+		{Type: models.RentStarted, Time: from.Add(time.Minute)},
+		{Type: models.RentPaused, Time: from.Add(5 * time.Minute)},
+	}, nil)
+	var published []string
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		event, err := rentevents.Decode(args.Get(1).(kafkago.Message))
+		suite.Require().NoError(err)
+		published = append(published, event.RentID+"/"+event.EventType+"/"+event.Timestamp.Format("15:04"))
+	})
+
+	n, err := suite.service.ReplayRentEvents(suite.ctx, from, to, false)
+
+	suite.NoError(err)
+	suite.Equal(6, n)
+	suite.Equal([]string{
+		completed.String() + "/start/00:00",
+		completed.String() + "/pause/00:10",
+		completed.String() + "/resume/00:20",
+		completed.String() + "/end/01:00",
+		active.String() + "/start/00:01",
+		active.String() + "/pause/00:05",
+	}, published)
+}
+
+// TestStartRent_HoldsWithWalletEnabled - при включённом кошельке аренда начинается по текущему тарифу
+func (suite *ServiceTestSuite) TestStartRent_HoldsWithWalletEnabled() {
+	cfg := config.Default().Rent.Wallet
+	cfg.Enabled = true
+	cfg.Pricing.Hold = 50000
+	suite.service.SetWalletConfig(cfg)
+	bikeID := uuid.New()
+	tariff := wallet.NewTariff(cfg.Pricing)
+	suite.mockRepo.On("StartRent", suite.ctx, "user123", true, bikeID, &tariff, "").Return(&models.Rent{ID: uuid.New(), UserID: "user123", BikeID: bikeID}, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil)
+
+	_, err := suite.service.StartRent(suite.ctx, "user123", bikeID.String(), "")
+
+	suite.NoError(err)
+}
+
+// TestStartRent_InsufficientFunds - без денег на холд аренда не начинается и событие не публикуется
+func (suite *ServiceTestSuite) TestStartRent_InsufficientFunds() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("StartRent", suite.ctx, "user123", true, bikeID, (*wallet.Tariff)(nil), "").Return(nil, wallet.ErrInsufficientFunds)
+
+	rent, err := suite.service.StartRent(suite.ctx, "user123", bikeID.String(), "")
+
+	suite.ErrorIs(err, wallet.ErrInsufficientFunds)
+	suite.Nil(rent)
+	suite.mockWriter.AssertNotCalled(suite.T(), "WriteMessages", mock.Anything, mock.Anything)
+}
+
+// TestTopUpWallet_SameKeyPaysOnce - повтор пополнения с тем же ключом не создаёт новый платёж
+func (suite *ServiceTestSuite) TestTopUpWallet_SameKeyPaysOnce() {
+	_, err := suite.service.TopUpWallet(suite.ctx, "user123", 10000, "key-1")
+	suite.Require().NoError(err)
+	_, err = suite.service.TopUpWallet(suite.ctx, "user123", 10000, "key-1")
+	suite.Require().NoError(err)
+	_, err = suite.service.TopUpWallet(suite.ctx, "user456", 10000, "key-1")
+	suite.Require().NoError(err)
+
+	suite.Require().Len(suite.wallets.payments, 3)
+	suite.Equal(suite.wallets.payments[0], suite.wallets.payments[1])
+	suite.NotEqual(suite.wallets.payments[0], suite.wallets.payments[2])
+}
+
+// TestTopUpWallet_InvalidAmount - сумма вне допустимого диапазона отклоняется до платежа
+func (suite *ServiceTestSuite) TestTopUpWallet_InvalidAmount() {
+	_, err := suite.service.TopUpWallet(suite.ctx, "user123", 0, "")
+	suite.ErrorIs(err, ErrInvalidArgument)
+
+	_, err = suite.service.TopUpWallet(suite.ctx, "user123", config.Default().Rent.Wallet.MaxTopUp+1, "")
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.Empty(suite.wallets.payments)
+}
+
+// TestRefundRent_RequiresReason - возврат без причины не выполняется
+func (suite *ServiceTestSuite) TestRefundRent_RequiresReason() {
+	_, err := suite.service.RefundRent(suite.ctx, uuid.New().String(), 100, " ")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.Empty(suite.wallets.refunds)
+}
+
+// TestEndRent_PublishesPass - событие завершения аренды по абонементу содержит его код
+func (suite *ServiceTestSuite) TestEndRent_PublishesPass() {
+	rentID := uuid.New()
+	endTime := time.Now()
+	fare := int64(2500)
+	ended := &models.Rent{
+		ID: rentID, UserID: "user123", BikeID: uuid.New(), StartTime: endTime.Add(-time.Hour), EndTime: &endTime,
+		Status: "completed", Fare: &fare, Pass: "month",
+	}
+	var sent kafkago.Message
+	suite.mockRepo.On("EndRent", suite.ctx, rentID, "user123", "").Return(ended, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(kafkago.Message)
+	}).Return(nil)
+
+	_, err := suite.service.EndRent(suite.ctx, rentID.String(), "user123", "")
+
+	suite.Require().NoError(err)
+	event, err := rentevents.Decode(sent)
+	suite.Require().NoError(err)
+	suite.Equal(rentevents.End, event.EventType)
+	suite.Equal("month", event.Pass)
+}
+
+// TestStartRent_PromoNeedsWallet - промокод не принимается, пока аренды бесплатны
+func (suite *ServiceTestSuite) TestStartRent_PromoNeedsWallet() {
+	rent, err := suite.service.StartRent(suite.ctx, "user123", uuid.New().String(), "SUMMER10")
+
+	suite.ErrorIs(err, ErrWalletDisabled)
+	suite.Nil(rent)
+	suite.mockRepo.AssertNotCalled(suite.T(), "StartRent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestEndRent_NormalizesPromo - промокод не зависит от регистра и пробелов
+func (suite *ServiceTestSuite) TestEndRent_NormalizesPromo() {
+	rentID := uuid.New()
+	endTime := time.Now()
+	ended := &models.Rent{ID: rentID, UserID: "user123", BikeID: uuid.New(), StartTime: endTime.Add(-time.Hour), EndTime: &endTime, Status: "completed"}
+	suite.mockRepo.On("EndRent", suite.ctx, rentID, "user123", "SUMMER10").Return(ended, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil)
+
+	_, err := suite.service.EndRent(suite.ctx, rentID.String(), "user123", " summer10 ")
+
+	suite.NoError(err)
+}
+
+// TestCreatePromo_Invalid - промокод с некорректными условиями не создаётся
+func (suite *ServiceTestSuite) TestCreatePromo_Invalid() {
+	promo, err := suite.service.CreatePromo(suite.ctx, wallet.Promo{Code: "half", Kind: wallet.PromoKindPercent, Value: 150})
+
+	suite.ErrorIs(err, wallet.ErrInvalidPromo)
+	suite.Nil(promo)
+}
+
+// TestGetPromoStats_InvalidRange - конец периода должен быть позже начала
+func (suite *ServiceTestSuite) TestGetPromoStats_InvalidRange() {
+	now := time.Now()
+	_, err := suite.service.GetPromoStats(suite.ctx, now, now.Add(-time.Hour))
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+// TestGetStatement_InvalidMonth - месяц выписки задаётся как YYYY-MM
+func (suite *ServiceTestSuite) TestGetStatement_InvalidMonth() {
+	statement, err := suite.service.GetStatement(suite.ctx, "user123", "2024-13")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.Nil(statement)
+}
+
+// TestGetReceipt_InvalidID - чек запрашивается по корректному ID аренды
+func (suite *ServiceTestSuite) TestGetReceipt_InvalidID() {
+	receipt, err := suite.service.GetReceipt(suite.ctx, "not-a-uuid")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.Nil(receipt)
+}
+
+// TestStartRent_BlockedUser - заблокированный пользователь не может начать аренду, событие не публикуется
+func (suite *ServiceTestSuite) TestStartRent_BlockedUser() {
+	bikeID := uuid.New()
+	suite.mockRepo.On("StartRent", suite.ctx, "user123", true, bikeID, (*wallet.Tariff)(nil), "").
+		Return(nil, fmt.Errorf("%w: fraud", users.ErrBlocked))
+
+	rent, err := suite.service.StartRent(suite.ctx, "user123", bikeID.String(), "")
+
+	suite.ErrorIs(err, users.ErrBlocked)
+	suite.Contains(err.Error(), "fraud")
+	suite.Nil(rent)
+	suite.mockWriter.AssertNotCalled(suite.T(), "WriteMessages", mock.Anything, mock.Anything)
+}
+
+// TestStartRent_RegistrationOptional - при выключенном require_registration аренда доступна без учётной записи
+func (suite *ServiceTestSuite) TestStartRent_RegistrationOptional() {
+	suite.service.SetUsersConfig(config.UsersConfig{RequireRegistration: false})
+	bikeID := uuid.New()
+	suite.mockRepo.On("StartRent", suite.ctx, "stranger", false, bikeID, (*wallet.Tariff)(nil), "").
+		Return(&models.Rent{ID: uuid.New(), UserID: "stranger", BikeID: bikeID}, nil)
+	suite.mockWriter.On("WriteMessages", suite.ctx, mock.Anything).Return(nil)
+
+	_, err := suite.service.StartRent(suite.ctx, "stranger", bikeID.String(), "")
+
+	suite.NoError(err)
+}
+
+// TestRegisterUser_GeneratesID - без user_id генерируется ID, email приводится к нижнему регистру
+func (suite *ServiceTestSuite) TestRegisterUser_GeneratesID() {
+	user, err := suite.service.RegisterUser(suite.ctx, users.User{Name: " Anna ", Email: "Anna@Example.COM"})
+
+	suite.Require().NoError(err)
+	suite.NotEmpty(user.ID)
+	suite.Equal("Anna", user.Name)
+	suite.Equal("anna@example.com", user.Email)
+	suite.Equal(users.StatusPending, user.Status)
+}
+
+// TestSetUserStatus_BlockNeedsReason - блокировка без причины отклоняется
+func (suite *ServiceTestSuite) TestSetUserStatus_BlockNeedsReason() {
+	_, err := suite.service.SetUserStatus(suite.ctx, "user123", users.StatusBlocked, " ")
+	suite.ErrorIs(err, ErrInvalidArgument)
+
+	_, err = suite.service.SetUserStatus(suite.ctx, "user123", "banned", "fraud")
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+// TestPlanRebalance - велосипеды везут туда, где их ждёт спрос, не больше вместимости грузовика
+func (suite *ServiceTestSuite) TestPlanRebalance() {
+	suite.mockRepo.On("CountAvailableBikes", suite.ctx).Return(map[string]int{"Center": 2, "Park": 20, "Station": 1}, nil)
+	suite.demand.forecast = &rebalance.Forecast{Locations: map[string]rebalance.LocationForecast{
+		"Center": {Starts: []float64{6, 6}, Ends: []float64{1, 1}},
+	}}
+
+	plan, err := suite.service.PlanRebalance(suite.ctx, RebalanceOptions{TruckCapacity: 5, Horizon: 90 * time.Minute})
+
+	suite.Require().NoError(err)
+	suite.Equal(2, suite.demand.hours)
+	suite.Equal(3, plan.Options.Trucks)
+	suite.Equal([]rebalance.Move{
+		{From: "Park", To: "Center", Count: 5},
+		{From: "Park", To: "Center", Count: 3},
+		{From: "Park", To: "Station", Count: 1},
+	}, plan.Moves)
+}
+
+// TestPlanRebalance_InvalidOptions - отрицательные параметры и горизонт больше суток отклоняются
+func (suite *ServiceTestSuite) TestPlanRebalance_InvalidOptions() {
+	_, err := suite.service.PlanRebalance(suite.ctx, RebalanceOptions{Trucks: -1})
+	suite.ErrorIs(err, ErrInvalidArgument)
+
+	_, err = suite.service.PlanRebalance(suite.ctx, RebalanceOptions{Horizon: 48 * time.Hour})
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+// TestRelocateBikes - перемещение публикует событие для каждого велосипеда
+func (suite *ServiceTestSuite) TestRelocateBikes() {
+	bike := models.Bike{ID: uuid.New(), Name: "Bike 1", Status: "available", Location: "Center"}
+	suite.mockRepo.On("RelocateBikes", suite.ctx, "Park", "Center", 3).Return([]models.Bike{bike}, nil)
+	sub := suite.fleet.Subscribe()
+
+	relocations, err := suite.service.RelocateBikes(suite.ctx, []rebalance.Move{{From: " Park ", To: "Center", Count: 3}})
+
+	suite.Require().NoError(err)
+	suite.Require().Len(relocations, 1)
+	suite.Equal([]models.Bike{bike}, relocations[0].Bikes)
+	event := <-sub.C
+	suite.Equal(events.BikeRelocated, event.Type)
+	suite.Equal("Park", event.FromLocation)
+	suite.Equal("Center", event.Location)
+}
+
+// TestRelocateBikes_InvalidMove - некорректное перемещение отклоняется до применения остальных
+func (suite *ServiceTestSuite) TestRelocateBikes_InvalidMove() {
+	_, err := suite.service.RelocateBikes(suite.ctx, []rebalance.Move{
+		{From: "Park", To: "Center", Count: 3},
+		{From: "Park", To: "Park", Count: 1},
+	})
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+	suite.mockRepo.AssertNotCalled(suite.T(), "RelocateBikes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestBuyPass_WalletDisabled - абонемент не продаётся, пока аренды бесплатны
+func (suite *ServiceTestSuite) TestBuyPass_WalletDisabled() {
+	sub, err := suite.service.BuyPass(suite.ctx, "user123", "day")
+
+	suite.ErrorIs(err, ErrWalletDisabled)
+	suite.Nil(sub)
+}
+
+// TestCancelPass_InvalidID - отмена с некорректным ID абонемента отклоняется
+func (suite *ServiceTestSuite) TestCancelPass_InvalidID() {
+	_, err := suite.service.CancelPass(suite.ctx, "user123", "not-a-uuid")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
 func TestServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(ServiceTestSuite))
 }
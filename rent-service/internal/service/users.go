@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/users"
+	"github.com/google/uuid"
+)
+
+func (s *service) SetUsersConfig(cfg config.UsersConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usersCfg = cfg
+}
+
+func (s *service) usersConfig() config.UsersConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.usersCfg
+}
+
+func (s *service) RegisterUser(ctx context.Context, user users.User) (*users.User, error) {
+	user.Normalize()
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := s.users.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Registered user: user_id=%s", created.ID)
+	return created, nil
+}
+
+func (s *service) GetUser(ctx context.Context, userID string) (*users.User, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	return s.users.Get(ctx, userID)
+}
+
+func (s *service) UpdateUser(ctx context.Context, user users.User) (*users.User, error) {
+	user.Normalize()
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+	return s.users.Update(ctx, user)
+}
+
+func (s *service) SetUserStatus(ctx context.Context, userID, status, reason string) (*users.User, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	if !users.ValidStatus(status) {
+		return nil, invalidArgument("status must be %s, %s or %s, got %q", users.StatusPending, users.StatusActive, users.StatusBlocked, status)
+	}
+	reason = strings.TrimSpace(reason)
+	if status == users.StatusBlocked && reason == "" {
+		return nil, invalidArgument("reason is required to block a user")
+	}
+
+	user, err := s.users.SetStatus(ctx, userID, status, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Changed user status: user_id=%s, status=%s, reason=%q", userID, status, reason)
+	return user, nil
+}
+
+func (s *service) ListUsers(ctx context.Context, status string, limit int) ([]users.User, error) {
+	if status != "" && !users.ValidStatus(status) {
+		return nil, invalidArgument("unknown status %q", status)
+	}
+	return s.users.List(ctx, status, limit)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/wallet"
+	"github.com/google/uuid"
+)
+
+// ErrWalletDisabled is returned when buying a pass or starting a rent with
+// a promo code while rents are free.
+var ErrWalletDisabled = errors.New("wallet is disabled")
+
+func (s *service) SetWalletConfig(cfg config.WalletConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.walletCfg = cfg
+}
+
+func (s *service) walletConfig() config.WalletConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.walletCfg
+}
+
+// tariff returns the prices new rents are billed by, or nil when the
+// wallet is disabled and rents are free.
+func (s *service) tariff() *wallet.Tariff {
+	cfg := s.walletConfig()
+	if !cfg.Enabled {
+		return nil
+	}
+	tariff := wallet.NewTariff(cfg.Pricing)
+	return &tariff
+}
+
+func (s *service) GetWallet(ctx context.Context, userID string, limit int) (*wallet.Account, []wallet.Entry, error) {
+	if userID == "" {
+		return nil, nil, invalidArgument("user_id is required")
+	}
+
+	account, err := s.wallets.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := s.wallets.ListEntries(ctx, userID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, entries, nil
+}
+
+func (s *service) TopUpWallet(ctx context.Context, userID string, amount int64, idempotencyKey string) (*wallet.Account, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	cfg := s.walletConfig()
+	if amount <= 0 || amount > cfg.MaxTopUp {
+		return nil, invalidArgument("amount must be between 1 and %d, got %d", cfg.MaxTopUp, amount)
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.NewString()
+	}
+
+	// Keys are chosen by clients, so they are only unique per user
+	paymentID, err := s.payments.Charge(ctx, userID, amount, cfg.Currency, userID+"/"+idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.wallets.TopUp(ctx, userID, amount, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Topped up wallet: user_id=%s, amount=%d %s, payment_id=%s", userID, amount, cfg.Currency, paymentID)
+	return account, nil
+}
+
+func (s *service) RefundRent(ctx context.Context, rentID string, amount int64, reason string) (*wallet.Account, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, invalidArgument("invalid rent_id: %v", err)
+	}
+	if amount < 0 {
+		return nil, invalidArgument("amount must not be negative")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, invalidArgument("reason is required")
+	}
+
+	account, err := s.wallets.Refund(ctx, rentUUID, amount, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Refunded rent: rent_id=%s, user_id=%s, reason=%q", rentID, account.UserID, reason)
+	return account, nil
+}
+
+func (s *service) ListPasses(ctx context.Context) ([]wallet.Pass, error) {
+	return s.wallets.ListPasses(ctx)
+}
+
+func (s *service) GetPass(ctx context.Context, userID string) (*wallet.Subscription, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	return s.wallets.ActivePass(ctx, userID)
+}
+
+func (s *service) BuyPass(ctx context.Context, userID, code string) (*wallet.Subscription, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	if code == "" {
+		return nil, invalidArgument("pass is required")
+	}
+	if !s.walletConfig().Enabled {
+		return nil, ErrWalletDisabled
+	}
+
+	sub, err := s.wallets.BuyPass(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Bought pass: user_id=%s, pass=%s, id=%s, ends_at=%s", userID, sub.Pass, sub.ID, sub.EndsAt.Format(time.RFC3339))
+	return sub, nil
+}
+
+func (s *service) CancelPass(ctx context.Context, userID, subscriptionID string) (*wallet.Subscription, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return nil, invalidArgument("invalid subscription_id: %v", err)
+	}
+
+	sub, err := s.wallets.CancelPass(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Cancelled pass: user_id=%s, pass=%s, id=%s, refunded=%d", userID, sub.Pass, sub.ID, sub.Refunded)
+	return sub, nil
+}
+
+func (s *service) GetReceipt(ctx context.Context, rentID string) (*wallet.Receipt, error) {
+	rentUUID, err := uuid.Parse(rentID)
+	if err != nil {
+		return nil, invalidArgument("invalid rent_id: %v", err)
+	}
+
+	rent, err := s.repo.GetRentByID(ctx, rentUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.wallets.Receipt(ctx, *rent)
+}
+
+func (s *service) GetStatement(ctx context.Context, userID, month string) (*wallet.Statement, error) {
+	if userID == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	from := time.Now().UTC()
+	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month != "" {
+		var err error
+		if from, err = time.Parse("2006-01", month); err != nil {
+			return nil, invalidArgument("month must be YYYY-MM, got %q", month)
+		}
+	}
+	return s.wallets.Statement(ctx, userID, from, from.AddDate(0, 1, 0))
+}
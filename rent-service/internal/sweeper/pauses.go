@@ -0,0 +1,147 @@
+package sweeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/audit"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/service"
+	"github.com/google/uuid"
+)
+
+// pauseActor is recorded in the audit log for rents the limiter resumes.
+const pauseActor = "pause-limiter"
+
+// PauseMetrics reports the total of pauses ended by the limit. It is
+// published under "rent_pauses" at /debug/vars.
+var PauseMetrics = expvar.NewMap("rent_pauses")
+
+// PauseLimiter periodically resumes rents paused longer than the
+// configured maximum, so a forgotten pause does not hold the bike for free.
+type PauseLimiter struct {
+	svc      service.Service
+	auditLog audit.Store
+
+	mu  sync.Mutex
+	cfg config.PauseConfig
+}
+
+func NewPauseLimiter(svc service.Service, auditLog audit.Store, cfg config.PauseConfig) *PauseLimiter {
+	return &PauseLimiter{
+		svc:      svc,
+		auditLog: auditLog,
+		cfg:      cfg,
+	}
+}
+
+// SetConfig applies a reloaded max duration from the next check on.
+func (l *PauseLimiter) SetConfig(cfg config.PauseConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+// Run checks every interval until ctx is cancelled.
+func (l *PauseLimiter) Run(ctx context.Context) {
+	l.mu.Lock()
+	interval := l.cfg.Interval
+	l.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.Check(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Rent pause check failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check resumes every rent paused more than the max duration ago and
+// returns how many it resumed.
+func (l *PauseLimiter) Check(ctx context.Context) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	filter := repository.RentFilter{
+		Status:       "paused",
+		PausedBefore: time.Now().Add(-l.cfg.MaxDuration),
+		Limit:        pageSize,
+	}
+	reason := fmt.Sprintf("paused longer than %s", l.cfg.MaxDuration)
+
+	resumed := 0
+	for {
+		rents, err := l.svc.ListRents(ctx, filter)
+		if err != nil {
+			return resumed, fmt.Errorf("failed to list paused rents: %w", err)
+		}
+
+		progress := 0
+		for _, rent := range rents {
+			id := rent.ID.String()
+			after, err := l.svc.ForceResumeRent(ctx, id, reason)
+			if errors.Is(err, repository.ErrRentNotPaused) {
+				// Resumed or ended by the user or another replica meanwhile
+				continue
+			}
+			l.record(ctx, rent, after, err)
+			if err != nil {
+				log.Printf("Failed to resume paused rent %s: %v", id, err)
+				continue
+			}
+			progress++
+		}
+		resumed += progress
+
+		// Resumed rents drop out of the filter, so the next query returns
+		// the following page. Without progress another query would return
+		// the same rents.
+		if len(rents) < pageSize || progress == 0 {
+			break
+		}
+	}
+
+	PauseMetrics.Add("resumed", int64(resumed))
+	if resumed > 0 {
+		log.Printf("Rent pause check: resumed %d rent(s) paused longer than %s", resumed, l.cfg.MaxDuration)
+	}
+	return resumed, nil
+}
+
+// record adds a resumed rent to the audit log like the interceptor does
+// for ResumeRent calls over gRPC.
+func (l *PauseLimiter) record(ctx context.Context, before models.Rent, after *models.Rent, err error) {
+	entry := audit.Entry{
+		Actor:      pauseActor,
+		Action:     "ResumeRent",
+		TargetType: audit.TargetRent,
+		TargetID:   before.ID.String(),
+		RequestID:  uuid.NewString(),
+	}
+	entry.Before, _ = json.Marshal(before)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.After, _ = json.Marshal(after)
+	}
+
+	if err := l.auditLog.Record(ctx, entry); err != nil {
+		log.Printf("Failed to record audit entry for paused rent %s: %v", before.ID, err)
+	}
+}
@@ -0,0 +1,117 @@
+package sweeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakePauseService - хранит аренды на паузе и продолжает их по ForceResumeRent
+type fakePauseService struct {
+	service.Service
+	paused    []models.Rent
+	filters   []repository.RentFilter
+	resumed   map[string]string
+	resumeErr error
+}
+
+func (f *fakePauseService) ListRents(ctx context.Context, filter repository.RentFilter) ([]models.Rent, error) {
+	f.filters = append(f.filters, filter)
+	var rents []models.Rent
+	for _, r := range f.paused {
+		if _, ok := f.resumed[r.ID.String()]; ok {
+			continue
+		}
+		if r.PausedAt.Before(filter.PausedBefore) && len(rents) < filter.Limit {
+			rents = append(rents, r)
+		}
+	}
+	return rents, nil
+}
+
+func (f *fakePauseService) ForceResumeRent(ctx context.Context, rentID, reason string) (*models.Rent, error) {
+	if f.resumeErr != nil {
+		return nil, f.resumeErr
+	}
+	f.resumed[rentID] = reason
+	return &models.Rent{Status: "active"}, nil
+}
+
+// PauseLimiterTestSuite - тестовый набор для ограничения длительности паузы
+type PauseLimiterTestSuite struct {
+	suite.Suite
+	svc      *fakePauseService
+	auditLog *fakeAuditLog
+	cfg      config.PauseConfig
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *PauseLimiterTestSuite) SetupTest() {
+	suite.svc = &fakePauseService{resumed: make(map[string]string)}
+	suite.auditLog = &fakeAuditLog{}
+	suite.cfg = config.PauseConfig{MaxDuration: 30 * time.Minute, Interval: time.Minute}
+}
+
+func (suite *PauseLimiterTestSuite) addRent(pausedFor time.Duration) models.Rent {
+	pausedAt := time.Now().Add(-pausedFor)
+	rent := models.Rent{ID: uuid.New(), UserID: "user123", BikeID: uuid.New(), StartTime: pausedAt.Add(-time.Hour), Status: "paused", PausedAt: &pausedAt}
+	suite.svc.paused = append(suite.svc.paused, rent)
+	return rent
+}
+
+// TestCheck_ResumesLongPauses - аренды на паузе дольше лимита продолжаются с причиной и записью в аудит
+func (suite *PauseLimiterTestSuite) TestCheck_ResumesLongPauses() {
+	long := suite.addRent(time.Hour)
+	short := suite.addRent(5 * time.Minute)
+
+	resumed, err := NewPauseLimiter(suite.svc, suite.auditLog, suite.cfg).Check(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(1, resumed)
+	suite.Equal("paused longer than 30m0s", suite.svc.resumed[long.ID.String()])
+	suite.NotContains(suite.svc.resumed, short.ID.String())
+	suite.Equal("paused", suite.svc.filters[0].Status)
+	suite.WithinDuration(time.Now().Add(-30*time.Minute), suite.svc.filters[0].PausedBefore, time.Second)
+	suite.Require().Len(suite.auditLog.entries, 1)
+	suite.Equal("pause-limiter", suite.auditLog.entries[0].Actor)
+	suite.Equal("ResumeRent", suite.auditLog.entries[0].Action)
+}
+
+// TestCheck_AlreadyResumed - аренда, продолженная параллельно, пропускается без записи в аудит
+func (suite *PauseLimiterTestSuite) TestCheck_AlreadyResumed() {
+	suite.addRent(time.Hour)
+	suite.svc.resumeErr = repository.ErrRentNotPaused
+
+	resumed, err := NewPauseLimiter(suite.svc, suite.auditLog, suite.cfg).Check(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(0, resumed)
+	suite.Len(suite.svc.filters, 1)
+	suite.Empty(suite.auditLog.entries)
+}
+
+// TestSetConfig - новый лимит паузы применяется со следующей проверки
+func (suite *PauseLimiterTestSuite) TestSetConfig() {
+	suite.addRent(20 * time.Minute)
+	limiter := NewPauseLimiter(suite.svc, suite.auditLog, suite.cfg)
+
+	resumed, _ := limiter.Check(context.Background())
+	suite.Equal(0, resumed)
+
+	suite.cfg.MaxDuration = 15 * time.Minute
+	limiter.SetConfig(suite.cfg)
+	resumed, _ = limiter.Check(context.Background())
+	suite.Equal(1, resumed)
+}
+
+// TestPauseLimiterTestSuite - запуск тестового набора
+func TestPauseLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(PauseLimiterTestSuite))
+}
@@ -0,0 +1,174 @@
+package sweeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/audit"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/service"
+	"github.com/google/uuid"
+)
+
+// pageSize is how many stale rents are fetched per query.
+const pageSize = 1000
+
+// actor is recorded in the audit log for rents the sweeper closes.
+const actor = "stale-rent-sweeper"
+
+// Metrics reports the stale rents found by the last sweep and the total
+// force-ended so far. It is published under "stale_rents" at /debug/vars.
+var Metrics = expvar.NewMap("stale_rents")
+
+// Sweeper periodically looks for rents that stayed active longer than the
+// configured maximum, which usually means the user left without ending
+// them.
+type Sweeper struct {
+	svc      service.Service
+	auditLog audit.Store
+
+	mu  sync.Mutex
+	cfg config.StaleRentsConfig
+	// flagged holds the stale rents already logged so each is reported
+	// once rather than on every sweep.
+	flagged map[string]bool
+}
+
+func NewSweeper(svc service.Service, auditLog audit.Store, cfg config.StaleRentsConfig) *Sweeper {
+	return &Sweeper{
+		svc:      svc,
+		auditLog: auditLog,
+		cfg:      cfg,
+		flagged:  make(map[string]bool),
+	}
+}
+
+// SetConfig applies a reloaded max duration and auto close setting from
+// the next sweep on.
+func (s *Sweeper) SetConfig(cfg config.StaleRentsConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// Run sweeps every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	s.mu.Lock()
+	interval := s.cfg.Interval
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Stale rent sweep failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep handles every rent started more than the max duration ago and
+// returns how many it found. With auto close they are force-ended and
+// freed; otherwise newly found ones are logged.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filter := repository.RentFilter{
+		Status:        "active",
+		StartedBefore: time.Now().Add(-s.cfg.MaxDuration),
+		Limit:         pageSize,
+	}
+	reason := fmt.Sprintf("active longer than %s", s.cfg.MaxDuration)
+
+	found := 0
+	flagged := make(map[string]bool)
+	for {
+		rents, err := s.svc.ListRents(ctx, filter)
+		if err != nil {
+			return found, fmt.Errorf("failed to list stale rents: %w", err)
+		}
+		found += len(rents)
+
+		closed := 0
+		for _, rent := range rents {
+			filter.AfterStart, filter.AfterID = rent.StartTime, rent.ID
+
+			id := rent.ID.String()
+			if !s.cfg.AutoClose {
+				if !s.flagged[id] {
+					log.Printf("Stale rent: rent_id=%s, user_id=%s, bike_id=%s, started=%s",
+						id, rent.UserID, rent.BikeID, rent.StartTime.Format(time.RFC3339))
+				}
+				flagged[id] = true
+				continue
+			}
+
+			ended, err := s.svc.ForceEndRent(ctx, id, reason)
+			if !errors.Is(err, repository.ErrRentNotActive) {
+				s.record(ctx, rent, ended, err)
+			}
+			switch {
+			case err == nil:
+				closed++
+			case errors.Is(err, repository.ErrRentNotActive):
+				// Ended by the user or another replica meanwhile
+			default:
+				log.Printf("Failed to close stale rent %s: %v", id, err)
+			}
+		}
+		Metrics.Add("closed", int64(closed))
+
+		// Page on from the last rent seen, whether it was closed or
+		// only flagged.
+		if len(rents) < pageSize {
+			break
+		}
+	}
+
+	s.flagged = flagged
+	found64 := new(expvar.Int)
+	found64.Set(int64(found))
+	Metrics.Set("found", found64)
+
+	if found > 0 {
+		log.Printf("Stale rent sweep: %d rent(s) active longer than %s, auto_close=%t", found, s.cfg.MaxDuration, s.cfg.AutoClose)
+	}
+	return found, nil
+}
+
+// record adds a closed rent to the audit log like the interceptor does for
+// ForceEndRent calls over gRPC.
+func (s *Sweeper) record(ctx context.Context, before models.Rent, after *models.Rent, err error) {
+	entry := audit.Entry{
+		Actor:      actor,
+		Action:     "ForceEndRent",
+		TargetType: audit.TargetRent,
+		TargetID:   before.ID.String(),
+		RequestID:  uuid.NewString(),
+	}
+	entry.Before, _ = json.Marshal(before)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.After, _ = json.Marshal(after)
+	}
+
+	if err := s.auditLog.Record(ctx, entry); err != nil {
+		log.Printf("Failed to record audit entry for stale rent %s: %v", before.ID, err)
+	}
+}
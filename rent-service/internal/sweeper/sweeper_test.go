@@ -0,0 +1,188 @@
+package sweeper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/audit"
+	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeService - хранит активные аренды и завершает их по ForceEndRent
+type fakeService struct {
+	service.Service
+	active  []models.Rent
+	filters []repository.RentFilter
+	ended   map[string]string
+	endErr  error
+}
+
+func (f *fakeService) ListRents(ctx context.Context, filter repository.RentFilter) ([]models.Rent, error) {
+	f.filters = append(f.filters, filter)
+	active := slices.Clone(f.active)
+	slices.SortFunc(active, func(a, b models.Rent) int {
+		if c := a.StartTime.Compare(b.StartTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
+	var rents []models.Rent
+	for _, r := range active {
+		if _, ok := f.ended[r.ID.String()]; ok {
+			continue
+		}
+		if !filter.AfterStart.IsZero() {
+			if c := r.StartTime.Compare(filter.AfterStart); c < 0 || c == 0 && r.ID.String() <= filter.AfterID.String() {
+				continue
+			}
+		}
+		if r.StartTime.Before(filter.StartedBefore) && len(rents) < filter.Limit {
+			rents = append(rents, r)
+		}
+	}
+	return rents, nil
+}
+
+func (f *fakeService) ForceEndRent(ctx context.Context, rentID, reason string) (*models.Rent, error) {
+	if f.endErr != nil {
+		return nil, f.endErr
+	}
+	f.ended[rentID] = reason
+	return &models.Rent{}, nil
+}
+
+// fakeAuditLog - запоминает записи аудита
+type fakeAuditLog struct {
+	audit.Store
+	entries []audit.Entry
+}
+
+func (f *fakeAuditLog) Record(ctx context.Context, entry audit.Entry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+// SweeperTestSuite - тестовый набор для поиска зависших аренд
+type SweeperTestSuite struct {
+	suite.Suite
+	svc      *fakeService
+	auditLog *fakeAuditLog
+	cfg      config.StaleRentsConfig
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *SweeperTestSuite) SetupTest() {
+	suite.svc = &fakeService{ended: make(map[string]string)}
+	suite.auditLog = &fakeAuditLog{}
+	suite.cfg = config.StaleRentsConfig{Enabled: true, Interval: time.Minute, MaxDuration: 24 * time.Hour}
+}
+
+func (suite *SweeperTestSuite) addRent(age time.Duration) models.Rent {
+	rent := models.Rent{ID: uuid.New(), UserID: "user123", BikeID: uuid.New(), StartTime: time.Now().Add(-age), Status: "active"}
+	suite.svc.active = append(suite.svc.active, rent)
+	return rent
+}
+
+// TestSweep_FlagOnly - без auto_close аренды только находятся, но не завершаются
+func (suite *SweeperTestSuite) TestSweep_FlagOnly() {
+	suite.addRent(48 * time.Hour)
+	suite.addRent(time.Hour)
+
+	found, err := NewSweeper(suite.svc, suite.auditLog, suite.cfg).Sweep(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(1, found)
+	suite.Empty(suite.svc.ended)
+	suite.Equal("active", suite.svc.filters[0].Status)
+	suite.WithinDuration(time.Now().Add(-24*time.Hour), suite.svc.filters[0].StartedBefore, time.Second)
+}
+
+// TestSweep_AutoClose - с auto_close старые аренды завершаются с причиной
+func (suite *SweeperTestSuite) TestSweep_AutoClose() {
+	suite.cfg.AutoClose = true
+	stale := suite.addRent(48 * time.Hour)
+	fresh := suite.addRent(time.Hour)
+
+	found, err := NewSweeper(suite.svc, suite.auditLog, suite.cfg).Sweep(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(1, found)
+	suite.Equal("active longer than 24h0m0s", suite.svc.ended[stale.ID.String()])
+	suite.NotContains(suite.svc.ended, fresh.ID.String())
+	suite.Require().Len(suite.auditLog.entries, 1)
+	suite.Equal("stale-rent-sweeper", suite.auditLog.entries[0].Actor)
+	suite.Equal(stale.ID.String(), suite.auditLog.entries[0].TargetID)
+}
+
+// TestSweep_AutoClosePages - больше одной страницы зависших аренд закрываются за один проход
+func (suite *SweeperTestSuite) TestSweep_AutoClosePages() {
+	suite.cfg.AutoClose = true
+	for i := 0; i < pageSize+5; i++ {
+		suite.addRent(48 * time.Hour)
+	}
+
+	found, err := NewSweeper(suite.svc, suite.auditLog, suite.cfg).Sweep(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(pageSize+5, found)
+	suite.Len(suite.svc.ended, pageSize+5)
+	suite.Len(suite.svc.filters, 2)
+}
+
+// TestSweep_FlagOnlyPages - без auto_close все страницы тоже просматриваются и считаются
+func (suite *SweeperTestSuite) TestSweep_FlagOnlyPages() {
+	for i := 0; i < pageSize+5; i++ {
+		suite.addRent(48 * time.Hour)
+	}
+
+	found, err := NewSweeper(suite.svc, suite.auditLog, suite.cfg).Sweep(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(pageSize+5, found)
+	suite.Empty(suite.svc.ended)
+	suite.Len(suite.svc.filters, 2)
+	suite.False(suite.svc.filters[1].AfterStart.IsZero())
+}
+
+// TestSweep_AlreadyEnded - аренда, завершённая параллельно, не приводит к бесконечному циклу
+func (suite *SweeperTestSuite) TestSweep_AlreadyEnded() {
+	suite.cfg.AutoClose = true
+	suite.addRent(48 * time.Hour)
+	suite.svc.endErr = fmt.Errorf("rent %w", repository.ErrRentNotActive)
+
+	found, err := NewSweeper(suite.svc, suite.auditLog, suite.cfg).Sweep(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(1, found)
+	suite.Len(suite.svc.filters, 1)
+	suite.Empty(suite.auditLog.entries)
+}
+
+// TestSetConfig - новая максимальная длительность применяется со следующего прохода
+func (suite *SweeperTestSuite) TestSetConfig() {
+	suite.addRent(3 * time.Hour)
+	sw := NewSweeper(suite.svc, suite.auditLog, suite.cfg)
+
+	found, _ := sw.Sweep(context.Background())
+	suite.Equal(0, found)
+
+	suite.cfg.MaxDuration = 2 * time.Hour
+	sw.SetConfig(suite.cfg)
+	found, _ = sw.Sweep(context.Background())
+	suite.Equal(1, found)
+}
+
+// TestSweeperTestSuite - запуск тестового набора
+func TestSweeperTestSuite(t *testing.T) {
+	suite.Run(t, new(SweeperTestSuite))
+}
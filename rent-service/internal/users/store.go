@@ -0,0 +1,184 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Store keeps accounts in Postgres.
+type Store interface {
+	// Get returns a user, failing with ErrNotFound.
+	Get(ctx context.Context, id string) (*User, error)
+	// List returns users, optionally in one status, newest first. Limit
+	// defaults to 100 and is capped at 1000.
+	List(ctx context.Context, status string, limit int) ([]User, error)
+	// Create registers a user pending verification, failing with
+	// ErrExists for a taken ID or email.
+	Create(ctx context.Context, user User) (*User, error)
+	// Update replaces the name, email and phone of a user.
+	Update(ctx context.Context, user User) (*User, error)
+	// SetStatus changes the status of a user, keeping why.
+	SetStatus(ctx context.Context, id, status, reason string) (*User, error)
+}
+
+const userColumns = "id, name, email, phone, status, status_reason, created_at, updated_at"
+
+type pgStore struct {
+	db *pgxpool.Pool
+}
+
+func NewStore(db *pgxpool.Pool) Store {
+	return &pgStore{db: db}
+}
+
+func scanUser(row pgx.Row) (*User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Phone, &u.Status, &u.StatusReason, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CheckRider returns why userID may not start a rent, nil if it may. It
+// reads the account within tx and holds it until tx ends, so a block
+// committed in between waits for the rent instead of missing it. Without
+// an account the rider may rent unless requireRegistration is set.
+func CheckRider(ctx context.Context, tx pgx.Tx, userID string, requireRegistration bool) error {
+	var user User
+	err := tx.QueryRow(ctx, "SELECT status, status_reason FROM users WHERE id = $1 FOR SHARE", userID).
+		Scan(&user.Status, &user.StatusReason)
+	if err == pgx.ErrNoRows {
+		if requireRegistration {
+			return ErrNotRegistered
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	return user.CanRent()
+}
+
+func (s *pgStore) Get(ctx context.Context, id string) (*User, error) {
+	user, err := scanUser(s.db.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *pgStore) List(ctx context.Context, status string, limit int) ([]User, error) {
+	switch {
+	case limit <= 0:
+		limit = defaultLimit
+	case limit > maxLimit:
+		limit = maxLimit
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT `+userColumns+`
+		 FROM users
+		 WHERE $1 = '' OR status = $1
+		 ORDER BY created_at DESC, id
+		 LIMIT $2`,
+		status, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *pgStore) Create(ctx context.Context, user User) (*User, error) {
+	created, err := scanUser(s.db.QueryRow(ctx,
+		`INSERT INTO users (id, name, email, phone, status)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT DO NOTHING
+		 RETURNING `+userColumns,
+		user.ID, user.Name, user.Email, user.Phone, StatusPending,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: user_id %q or email %q is taken", ErrExists, user.ID, user.Email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return created, nil
+}
+
+func (s *pgStore) Update(ctx context.Context, user User) (*User, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var taken bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND id <> $2)",
+		user.Email, user.ID,
+	).Scan(&taken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if taken {
+		return nil, fmt.Errorf("%w: email %q is taken", ErrExists, user.Email)
+	}
+
+	updated, err := scanUser(tx.QueryRow(ctx,
+		`UPDATE users SET name = $2, email = $3, phone = $4, updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING `+userColumns,
+		user.ID, user.Name, user.Email, user.Phone,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *pgStore) SetStatus(ctx context.Context, id, status, reason string) (*User, error) {
+	user, err := scanUser(s.db.QueryRow(ctx,
+		`UPDATE users SET status = $2, status_reason = $3, updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING `+userColumns,
+		id, status, reason,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set user status: %w", err)
+	}
+	return user, nil
+}
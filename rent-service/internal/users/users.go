@@ -0,0 +1,110 @@
+// Package users keeps rider accounts: contact details and whether the
+// rider may rent. The user_id of rents and wallets is the ID of an
+// account, although riders without one are allowed unless registration is
+// required.
+package users
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Account statuses. Registered riders wait for verification and cannot
+// rent until an operator activates them.
+const (
+	StatusPending = "pending_verification"
+	StatusActive  = "active"
+	StatusBlocked = "blocked"
+)
+
+var (
+	// ErrNotFound is returned for an unknown user ID.
+	ErrNotFound = errors.New("user not found")
+	// ErrExists is returned when registering a taken user ID or email.
+	ErrExists = errors.New("user already exists")
+	// ErrInvalid is returned for a bad profile or status.
+	ErrInvalid = errors.New("invalid user")
+	// ErrBlocked, ErrNotVerified and ErrNotRegistered are why a rider may
+	// not start a rent.
+	ErrBlocked       = errors.New("user is blocked")
+	ErrNotVerified   = errors.New("user is not verified")
+	ErrNotRegistered = errors.New("user is not registered")
+)
+
+const (
+	maxIDLength   = 100
+	maxNameLength = 200
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// User is a rider account. Email is unique and stored in lower case; Phone
+// is optional. StatusReason says why an operator last changed Status.
+type User struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Phone        string    `json:"phone,omitempty"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Normalize trims the profile and lower-cases the email so that the same
+// address is not registered twice.
+func (u *User) Normalize() {
+	u.ID = strings.TrimSpace(u.ID)
+	u.Name = strings.TrimSpace(u.Name)
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	u.Phone = strings.Join(strings.Fields(u.Phone), "")
+}
+
+// Validate checks the profile of a normalized user.
+func (u User) Validate() error {
+	switch {
+	case u.ID == "":
+		return fmt.Errorf("%w: user_id is required", ErrInvalid)
+	case utf8.RuneCountInString(u.ID) > maxIDLength:
+		return fmt.Errorf("%w: user_id is longer than %d characters", ErrInvalid, maxIDLength)
+	case u.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	case utf8.RuneCountInString(u.Name) > maxNameLength:
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalid, maxNameLength)
+	case u.Phone != "" && !phonePattern.MatchString(u.Phone):
+		return fmt.Errorf("%w: phone must be 7 to 15 digits with an optional +, got %q", ErrInvalid, u.Phone)
+	}
+	if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email {
+		return fmt.Errorf("%w: invalid email %q", ErrInvalid, u.Email)
+	}
+	return nil
+}
+
+// ValidStatus reports whether status is one of the account statuses.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusActive, StatusBlocked:
+		return true
+	}
+	return false
+}
+
+// CanRent returns why u may not start a rent, nil if it may.
+func (u User) CanRent() error {
+	switch u.Status {
+	case StatusActive:
+		return nil
+	case StatusBlocked:
+		if u.StatusReason != "" {
+			return fmt.Errorf("%w: %s", ErrBlocked, u.StatusReason)
+		}
+		return ErrBlocked
+	default:
+		return ErrNotVerified
+	}
+}
@@ -0,0 +1,57 @@
+package users
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// UsersTestSuite - тестовый набор для учётных записей
+type UsersTestSuite struct {
+	suite.Suite
+}
+
+// TestNormalize - пробелы убираются, email приводится к нижнему регистру
+func (suite *UsersTestSuite) TestNormalize() {
+	user := User{ID: " u1 ", Name: " Anna ", Email: " Anna@Example.com ", Phone: "+7 999 123 45 67"}
+	user.Normalize()
+
+	suite.Equal(User{ID: "u1", Name: "Anna", Email: "anna@example.com", Phone: "+79991234567"}, user)
+}
+
+// TestValidate - некорректный профиль отклоняется
+func (suite *UsersTestSuite) TestValidate() {
+	valid := User{ID: "u1", Name: "Anna", Email: "anna@example.com", Phone: "+79991234567"}
+	suite.NoError(valid.Validate())
+	// Длина считается в символах, как у VARCHAR
+	cyrillic := User{ID: strings.Repeat("ю", 100), Name: strings.Repeat("Я", 200), Email: "anna@example.com"}
+	suite.NoError(cyrillic.Validate())
+
+	for _, user := range []User{
+		{Name: "Anna", Email: "anna@example.com"},
+		{ID: strings.Repeat("u", 101), Name: "Anna", Email: "anna@example.com"},
+		{ID: "u1", Name: strings.Repeat("Я", 201), Email: "anna@example.com"},
+		{ID: "u1", Email: "anna@example.com"},
+		{ID: "u1", Name: "Anna", Email: "anna"},
+		{ID: "u1", Name: "Anna", Email: "Anna <anna@example.com>"},
+		{ID: "u1", Name: "Anna", Email: "anna@example.com", Phone: "12-34"},
+	} {
+		suite.ErrorIs(user.Validate(), ErrInvalid, "%+v", user)
+	}
+}
+
+// TestCanRent - арендовать могут только активные пользователи
+func (suite *UsersTestSuite) TestCanRent() {
+	suite.NoError(User{Status: StatusActive}.CanRent())
+	suite.ErrorIs(User{Status: StatusPending}.CanRent(), ErrNotVerified)
+
+	err := User{Status: StatusBlocked, StatusReason: "unpaid damage"}.CanRent()
+	suite.ErrorIs(err, ErrBlocked)
+	suite.Contains(err.Error(), "unpaid damage")
+}
+
+// TestUsersTestSuite - запуск тестового набора
+func TestUsersTestSuite(t *testing.T) {
+	suite.Run(t, new(UsersTestSuite))
+}
@@ -0,0 +1,175 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Hold reserves tariff.Hold of the user's balance for a starting rent and
+// records the tariff the rent is billed by, with the benefits of the
+// user's active pass, both within tx, so the hold commits or rolls back
+// with the rent. It fails with ErrInsufficientFunds when the available
+// balance does not cover the hold.
+func Hold(ctx context.Context, tx pgx.Tx, userID string, rentID uuid.UUID, tariff Tariff) (*Charge, error) {
+	account, err := lockAccount(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Available() < tariff.Hold {
+		return nil, fmt.Errorf("%w: %d available, %d required", ErrInsufficientFunds, account.Available(), tariff.Hold)
+	}
+
+	charge := Charge{Tariff: tariff}
+	var passID *uuid.UUID
+	err = tx.QueryRow(ctx,
+		`SELECT id, pass_code, free_minutes, discount_percent
+		 FROM user_passes
+		 WHERE user_id = $1 AND cancelled_at IS NULL AND starts_at <= NOW() AND ends_at > NOW()`,
+		userID,
+	).Scan(&passID, &charge.Pass, &charge.Tariff.FreeMinutes, &charge.Tariff.DiscountPercent)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+
+	t := charge.Tariff
+	_, err = tx.Exec(ctx,
+		`INSERT INTO rent_charges (rent_id, user_id, held, unlock_fee, per_minute, paused_per_minute,
+		                           user_pass_id, free_minutes, discount_percent, tax_percent)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rentID, userID, t.Hold, t.UnlockFee, t.PerMinute, t.PausedPerMinute, passID, t.FreeMinutes, t.DiscountPercent,
+		t.TaxPercent,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save rent charge: %w", err)
+	}
+
+	if t.Hold > 0 {
+		_, err = addEntry(ctx, tx, Entry{UserID: userID, Type: EntryHold, Held: t.Hold, RentID: rentID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &charge, nil
+}
+
+// Capture charges an ended rent its fare, less its promo code, releases
+// its hold and issues its invoice within tx. It returns nil for rents
+// started without billing, which are free. The fare is taken even if it
+// exceeds the balance.
+func Capture(ctx context.Context, tx pgx.Tx, rent models.Rent) (*Charge, error) {
+	var charge Charge
+	var kind string
+	var value int64
+	var discount *int64
+	var invoice *string
+	t := &charge.Tariff
+	err := tx.QueryRow(ctx,
+		`SELECT c.held, c.unlock_fee, c.per_minute, c.paused_per_minute, c.free_minutes, c.discount_percent,
+		        c.tax_percent, c.fare, COALESCE(p.pass_code, ''), COALESCE(r.promo_code, ''), COALESCE(r.kind, ''),
+		        COALESCE(r.value, 0), r.discount, i.number
+		 FROM rent_charges c
+		 LEFT JOIN user_passes p ON p.id = c.user_pass_id
+		 LEFT JOIN promo_redemptions r ON r.rent_id = c.rent_id
+		 LEFT JOIN invoices i ON i.rent_id = c.rent_id
+		 WHERE c.rent_id = $1
+		 FOR UPDATE OF c`,
+		rent.ID,
+	).Scan(&t.Hold, &t.UnlockFee, &t.PerMinute, &t.PausedPerMinute, &t.FreeMinutes, &t.DiscountPercent,
+		&t.TaxPercent, &charge.Fare, &charge.Pass, &charge.Promo, &kind, &value, &discount, &invoice)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rent charge: %w", err)
+	}
+	if charge.Fare != nil {
+		if discount != nil {
+			charge.Discount = *discount
+		}
+		if invoice != nil {
+			charge.Invoice = *invoice
+		}
+		return &charge, nil
+	}
+
+	full := t.Fare(rent)
+	applyPromo(t, kind, value)
+	amount := t.Fare(rent)
+	if _, err := tx.Exec(ctx, "UPDATE rent_charges SET fare = $2 WHERE rent_id = $1", rent.ID, amount); err != nil {
+		return nil, fmt.Errorf("failed to save fare: %w", err)
+	}
+	if charge.Promo != "" {
+		charge.Discount = full - amount
+		_, err = tx.Exec(ctx, "UPDATE promo_redemptions SET discount = $2 WHERE rent_id = $1", rent.ID, charge.Discount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save promo discount: %w", err)
+		}
+	}
+
+	if _, err := lockAccount(ctx, tx, rent.UserID); err != nil {
+		return nil, err
+	}
+	_, err = addEntry(ctx, tx, Entry{UserID: rent.UserID, Type: EntryCapture, Amount: -amount, Held: -t.Hold, RentID: rent.ID})
+	if err != nil {
+		return nil, err
+	}
+	issued, err := issueInvoice(ctx, tx, rent.ID, rent.UserID, amount, *t)
+	if err != nil {
+		return nil, err
+	}
+	charge.Fare = &amount
+	charge.Invoice = issued.Number
+	return &charge, nil
+}
+
+// lockAccount reads a wallet for update within tx, creating an empty one
+// for a rider seen for the first time.
+func lockAccount(ctx context.Context, tx pgx.Tx, userID string) (*Account, error) {
+	_, err := tx.Exec(ctx, "INSERT INTO wallets (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+
+	account := Account{UserID: userID}
+	err = tx.QueryRow(ctx,
+		"SELECT balance, held FROM wallets WHERE user_id = $1 FOR UPDATE",
+		userID,
+	).Scan(&account.Balance, &account.Held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return &account, nil
+}
+
+// addEntry appends entry to the ledger and applies it to the wallet,
+// which must be locked by tx. It returns the updated wallet.
+func addEntry(ctx context.Context, tx pgx.Tx, entry Entry) (*Account, error) {
+	var rentID *uuid.UUID
+	if entry.RentID != uuid.Nil {
+		rentID = &entry.RentID
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO wallet_entries (user_id, type, amount, held, rent_id, reference, reason)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.UserID, entry.Type, entry.Amount, entry.Held, rentID, entry.Reference, entry.Reason,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append wallet entry: %w", err)
+	}
+
+	account := Account{UserID: entry.UserID}
+	err = tx.QueryRow(ctx,
+		`UPDATE wallets SET balance = balance + $2, held = held + $3, updated_at = NOW()
+		 WHERE user_id = $1
+		 RETURNING balance, held`,
+		entry.UserID, entry.Amount, entry.Held,
+	).Scan(&account.Balance, &account.Held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update wallet: %w", err)
+	}
+	return &account, nil
+}
@@ -0,0 +1,248 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrPassNotFound is returned for an unknown or retired pass and for a
+// subscription the user does not have.
+var ErrPassNotFound = errors.New("pass not found")
+
+// ErrPassActive is returned when buying a pass while another one lasts.
+var ErrPassActive = errors.New("rider already has an active pass")
+
+// ErrPassEnded is returned when cancelling a pass that is over or was
+// already cancelled.
+var ErrPassEnded = errors.New("pass has already ended")
+
+// Pass is an offer of the catalog: for Duration after purchase, rents get
+// FreeMinutes of riding free and DiscountPercent off their fare.
+type Pass struct {
+	Code            string        `json:"code"`
+	Name            string        `json:"name"`
+	Price           int64         `json:"price"`
+	Duration        time.Duration `json:"duration"`
+	FreeMinutes     int64         `json:"free_minutes"`
+	DiscountPercent int64         `json:"discount_percent"`
+}
+
+// Subscription is a pass bought by a rider, with the terms of the pass at
+// purchase. A cancelled pass ends at once.
+type Subscription struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          string     `json:"user_id"`
+	Pass            string     `json:"pass"`
+	Name            string     `json:"name"`
+	Price           int64      `json:"price"`
+	FreeMinutes     int64      `json:"free_minutes"`
+	DiscountPercent int64      `json:"discount_percent"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+	// Refunded is what was credited back on cancellation
+	Refunded int64 `json:"refunded"`
+}
+
+// Active reports whether rents started at are billed with the pass.
+func (s Subscription) Active(at time.Time) bool {
+	return s.CancelledAt == nil && !at.Before(s.StartsAt) && at.Before(s.EndsAt)
+}
+
+const subscriptionColumns = `up.id, up.user_id, up.pass_code, p.name, up.price, up.free_minutes, up.discount_percent,
+	up.starts_at, up.ends_at, up.cancelled_at, up.refunded`
+
+func scanSubscription(row pgx.Row) (*Subscription, error) {
+	var sub Subscription
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.Pass, &sub.Name, &sub.Price, &sub.FreeMinutes, &sub.DiscountPercent,
+		&sub.StartsAt, &sub.EndsAt, &sub.CancelledAt, &sub.Refunded)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *pgStore) ListPasses(ctx context.Context) ([]Pass, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT code, name, price, EXTRACT(EPOCH FROM duration)::BIGINT, free_minutes, discount_percent
+		 FROM passes
+		 WHERE available
+		 ORDER BY price, code`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query passes: %w", err)
+	}
+	defer rows.Close()
+
+	var passes []Pass
+	for rows.Next() {
+		var p Pass
+		var seconds int64
+		if err := rows.Scan(&p.Code, &p.Name, &p.Price, &seconds, &p.FreeMinutes, &p.DiscountPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan pass: %w", err)
+		}
+		p.Duration = time.Duration(seconds) * time.Second
+		passes = append(passes, p)
+	}
+
+	return passes, rows.Err()
+}
+
+func (s *pgStore) ActivePass(ctx context.Context, userID string) (*Subscription, error) {
+	sub, err := scanSubscription(s.db.QueryRow(ctx,
+		`SELECT `+subscriptionColumns+`
+		 FROM user_passes up
+		 JOIN passes p ON p.code = up.pass_code
+		 WHERE up.user_id = $1 AND up.cancelled_at IS NULL AND up.starts_at <= NOW() AND up.ends_at > NOW()`,
+		userID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+	return sub, nil
+}
+
+func (s *pgStore) BuyPass(ctx context.Context, userID, code string) (*Subscription, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The locked wallet serializes purchases, cancellations and rent
+	// starts of the user
+	account, err := lockAccount(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var active bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM user_passes WHERE user_id = $1 AND cancelled_at IS NULL AND ends_at > NOW())",
+		userID,
+	).Scan(&active)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check passes: %w", err)
+	}
+	if active {
+		return nil, ErrPassActive
+	}
+
+	var price int64
+	err = tx.QueryRow(ctx, "SELECT price FROM passes WHERE code = $1 AND available", code).Scan(&price)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %q", ErrPassNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+	if account.Available() < price {
+		return nil, fmt.Errorf("%w: %d available, %d required", ErrInsufficientFunds, account.Available(), price)
+	}
+
+	id := uuid.New()
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_passes (id, user_id, pass_code, price, free_minutes, discount_percent, starts_at, ends_at)
+		 SELECT $1, $2, code, price, free_minutes, discount_percent, NOW(), NOW() + duration
+		 FROM passes WHERE code = $3`,
+		id, userID, code,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save pass: %w", err)
+	}
+	if price > 0 {
+		_, err = addEntry(ctx, tx, Entry{UserID: userID, Type: EntryPass, Amount: -price, Reference: id.String()})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sub, err := getSubscription(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *pgStore) CancelPass(ctx context.Context, userID string, id uuid.UUID) (*Subscription, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockAccount(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	sub, err := getSubscription(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.CancelledAt != nil || !time.Now().Before(sub.EndsAt) {
+		return nil, ErrPassEnded
+	}
+
+	var used bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM rent_charges WHERE user_pass_id = $1)", id).Scan(&used)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pass rents: %w", err)
+	}
+
+	// Only a pass no rent was ridden on is refunded
+	var refund int64
+	if !used {
+		refund = sub.Price
+	}
+	_, err = tx.Exec(ctx, "UPDATE user_passes SET cancelled_at = NOW(), refunded = $2 WHERE id = $1", id, refund)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel pass: %w", err)
+	}
+	if refund > 0 {
+		_, err = addEntry(ctx, tx, Entry{UserID: userID, Type: EntryRefund, Amount: refund, Reference: id.String(), Reason: "pass cancelled"})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sub, err = getSubscription(ctx, tx, id, userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sub, nil
+}
+
+// getSubscription reads a subscription of userID for update within tx.
+func getSubscription(ctx context.Context, tx pgx.Tx, id uuid.UUID, userID string) (*Subscription, error) {
+	sub, err := scanSubscription(tx.QueryRow(ctx,
+		`SELECT `+subscriptionColumns+`
+		 FROM user_passes up
+		 JOIN passes p ON p.code = up.pass_code
+		 WHERE up.id = $1 AND up.user_id = $2
+		 FOR UPDATE OF up`,
+		id, userID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrPassNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass: %w", err)
+	}
+	return sub, nil
+}
@@ -0,0 +1,318 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Promo code kinds.
+const (
+	// PromoKindPercent takes Value percent off the fare
+	PromoKindPercent = "percent"
+	// PromoKindFixed takes Value minor units off the fare
+	PromoKindFixed = "fixed"
+)
+
+// ErrPromoNotFound is returned for an unknown promo code.
+var ErrPromoNotFound = errors.New("promo code not found")
+
+// ErrPromoNotApplicable is wrapped by errors for promo codes a rent cannot
+// get: inactive, out of their validity window or location, used up, or
+// applied to an unbilled rent or one that already has a code.
+var ErrPromoNotApplicable = errors.New("promo code cannot be applied")
+
+// ErrInvalidPromo is returned when creating or updating a promo code with
+// bad terms.
+var ErrInvalidPromo = errors.New("invalid promo code")
+
+// ErrPromoExists is returned when creating a promo code that exists.
+var ErrPromoExists = errors.New("promo code already exists")
+
+// ErrPromoRedeemed is returned when deleting a promo code that was applied
+// to rents; deactivate it instead.
+var ErrPromoRedeemed = errors.New("promo code has been redeemed")
+
+// Promo is a promo code. Nil ValidFrom and ValidUntil do not bound it,
+// limits of 0 are unlimited and empty Locations allow every location.
+type Promo struct {
+	Code       string     `json:"code"`
+	Kind       string     `json:"kind"`
+	Value      int64      `json:"value"`
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// Locations the rented bike must be at
+	Locations []string `json:"locations,omitempty"`
+	// MaxRedemptions limits rents the code is applied to, MaxPerUser the
+	// rents of one rider
+	MaxRedemptions int64     `json:"max_redemptions"`
+	MaxPerUser     int64     `json:"max_per_user"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Redemptions counts rents the code was applied to
+	Redemptions int64 `json:"redemptions"`
+}
+
+// Validate checks the terms of a promo code being created or updated.
+func (p Promo) Validate() error {
+	switch {
+	case p.Code == "" || len(p.Code) > 50:
+		return fmt.Errorf("%w: code must be 1 to 50 characters", ErrInvalidPromo)
+	case p.Kind != PromoKindPercent && p.Kind != PromoKindFixed:
+		return fmt.Errorf("%w: kind must be %s or %s", ErrInvalidPromo, PromoKindPercent, PromoKindFixed)
+	case p.Value <= 0 || (p.Kind == PromoKindPercent && p.Value > 100):
+		return fmt.Errorf("%w: value %d is out of range", ErrInvalidPromo, p.Value)
+	case p.ValidFrom != nil && p.ValidUntil != nil && !p.ValidUntil.After(*p.ValidFrom):
+		return fmt.Errorf("%w: valid_until must be after valid_from", ErrInvalidPromo)
+	case p.MaxRedemptions < 0 || p.MaxPerUser < 0:
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidPromo)
+	}
+	return nil
+}
+
+// check reports why a rent at location cannot get the code at, nil if it
+// can. Usage limits are checked by Redeem.
+func (p Promo) check(at time.Time, location string) error {
+	switch {
+	case !p.Active:
+		return fmt.Errorf("%w: %s is not active", ErrPromoNotApplicable, p.Code)
+	case p.ValidFrom != nil && at.Before(*p.ValidFrom):
+		return fmt.Errorf("%w: %s is not valid yet", ErrPromoNotApplicable, p.Code)
+	case p.ValidUntil != nil && !at.Before(*p.ValidUntil):
+		return fmt.Errorf("%w: %s has expired", ErrPromoNotApplicable, p.Code)
+	case len(p.Locations) > 0 && !slices.Contains(p.Locations, location):
+		return fmt.Errorf("%w: %s is not valid at %s", ErrPromoNotApplicable, p.Code, location)
+	}
+	return nil
+}
+
+// applyPromo adds the discount of a promo code of kind and value to t.
+func applyPromo(t *Tariff, kind string, value int64) {
+	switch kind {
+	case PromoKindPercent:
+		t.PromoPercent = value
+	case PromoKindFixed:
+		t.PromoAmount = value
+	}
+}
+
+// PromoStats is how a promo code was used by rents it was applied to in a
+// period. Discount sums the charged rents; the others are still running.
+type PromoStats struct {
+	Code        string `json:"code"`
+	Redemptions int64  `json:"redemptions"`
+	Users       int64  `json:"users"`
+	Charged     int64  `json:"charged"`
+	Discount    int64  `json:"discount"`
+}
+
+// Redeem applies a promo code to a billed rent within tx, so it commits or
+// rolls back with the rent. The code is locked, so concurrent rents cannot
+// exceed its limits. The discount is taken when Capture charges the fare.
+func Redeem(ctx context.Context, tx pgx.Tx, rent models.Rent, code string) (*Promo, error) {
+	promo, err := scanPromo(tx.QueryRow(ctx,
+		`SELECT `+promoColumns+`, 0 FROM promo_codes WHERE code = $1 FOR UPDATE`,
+		code,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %q", ErrPromoNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	if err := promo.check(time.Now(), rent.Location); err != nil {
+		return nil, err
+	}
+
+	var userRedemptions int64
+	err = tx.QueryRow(ctx,
+		"SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2) FROM promo_redemptions WHERE promo_code = $1",
+		code, rent.UserID,
+	).Scan(&promo.Redemptions, &userRedemptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count promo redemptions: %w", err)
+	}
+	if promo.MaxRedemptions > 0 && promo.Redemptions >= promo.MaxRedemptions {
+		return nil, fmt.Errorf("%w: %s is used up", ErrPromoNotApplicable, code)
+	}
+	if promo.MaxPerUser > 0 && userRedemptions >= promo.MaxPerUser {
+		return nil, fmt.Errorf("%w: %s was already used %d times by the rider", ErrPromoNotApplicable, code, userRedemptions)
+	}
+
+	var billed, redeemed bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM rent_charges WHERE rent_id = $1),
+		        EXISTS(SELECT 1 FROM promo_redemptions WHERE rent_id = $1)`,
+		rent.ID,
+	).Scan(&billed, &redeemed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rent charge: %w", err)
+	}
+	if !billed {
+		return nil, fmt.Errorf("%w: rent is not billed", ErrPromoNotApplicable)
+	}
+	if redeemed {
+		return nil, fmt.Errorf("%w: rent already has a promo code", ErrPromoNotApplicable)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO promo_redemptions (rent_id, promo_code, user_id, kind, value)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		rent.ID, code, rent.UserID, promo.Kind, promo.Value,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save promo redemption: %w", err)
+	}
+	promo.Redemptions++
+	return promo, nil
+}
+
+const promoColumns = `code, kind, value, valid_from, valid_until, locations, max_redemptions, max_per_user,
+	active, created_at`
+
+// scanPromo scans promoColumns followed by the redemption count.
+func scanPromo(row pgx.Row) (*Promo, error) {
+	var p Promo
+	err := row.Scan(&p.Code, &p.Kind, &p.Value, &p.ValidFrom, &p.ValidUntil, &p.Locations, &p.MaxRedemptions,
+		&p.MaxPerUser, &p.Active, &p.CreatedAt, &p.Redemptions)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *pgStore) ListPromos(ctx context.Context) ([]Promo, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT `+promoColumns+`,
+		        (SELECT COUNT(*) FROM promo_redemptions r WHERE r.promo_code = p.code)
+		 FROM promo_codes p
+		 ORDER BY created_at DESC, code`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	var promos []Promo
+	for rows.Next() {
+		p, err := scanPromo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promo code: %w", err)
+		}
+		promos = append(promos, *p)
+	}
+
+	return promos, rows.Err()
+}
+
+func (s *pgStore) CreatePromo(ctx context.Context, promo Promo) (*Promo, error) {
+	created, err := scanPromo(s.db.QueryRow(ctx,
+		`INSERT INTO promo_codes (code, kind, value, valid_from, valid_until, locations, max_redemptions,
+		                          max_per_user, active)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (code) DO NOTHING
+		 RETURNING `+promoColumns+`, 0`,
+		promo.Code, promo.Kind, promo.Value, promo.ValidFrom, promo.ValidUntil, locations(promo),
+		promo.MaxRedemptions, promo.MaxPerUser, promo.Active,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %q", ErrPromoExists, promo.Code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+	return created, nil
+}
+
+func (s *pgStore) UpdatePromo(ctx context.Context, promo Promo) (*Promo, error) {
+	updated, err := scanPromo(s.db.QueryRow(ctx,
+		`UPDATE promo_codes p
+		 SET kind = $2, value = $3, valid_from = $4, valid_until = $5, locations = $6, max_redemptions = $7,
+		     max_per_user = $8, active = $9
+		 WHERE code = $1
+		 RETURNING `+promoColumns+`,
+		           (SELECT COUNT(*) FROM promo_redemptions r WHERE r.promo_code = p.code)`,
+		promo.Code, promo.Kind, promo.Value, promo.ValidFrom, promo.ValidUntil, locations(promo),
+		promo.MaxRedemptions, promo.MaxPerUser, promo.Active,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("%w: %q", ErrPromoNotFound, promo.Code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update promo code: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *pgStore) DeletePromo(ctx context.Context, code string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the code keeps rents from redeeming it meanwhile
+	var redeemed bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM promo_redemptions WHERE promo_code = p.code)
+		 FROM promo_codes p WHERE code = $1 FOR UPDATE`,
+		code,
+	).Scan(&redeemed)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("%w: %q", ErrPromoNotFound, code)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get promo code: %w", err)
+	}
+	if redeemed {
+		return fmt.Errorf("%w: %q", ErrPromoRedeemed, code)
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM promo_codes WHERE code = $1", code); err != nil {
+		return fmt.Errorf("failed to delete promo code: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *pgStore) PromoStats(ctx context.Context, from, to time.Time) ([]PromoStats, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT promo_code, COUNT(*), COUNT(DISTINCT user_id), COUNT(discount), COALESCE(SUM(discount), 0)
+		 FROM promo_redemptions
+		 WHERE redeemed_at >= $1 AND redeemed_at < $2
+		 GROUP BY promo_code
+		 ORDER BY COUNT(*) DESC, promo_code`,
+		from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query promo redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []PromoStats
+	for rows.Next() {
+		var st PromoStats
+		if err := rows.Scan(&st.Code, &st.Redemptions, &st.Users, &st.Charged, &st.Discount); err != nil {
+			return nil, fmt.Errorf("failed to scan promo stats: %w", err)
+		}
+		stats = append(stats, st)
+	}
+
+	return stats, rows.Err()
+}
+
+// locations returns the locations of promo as stored, never NULL.
+func locations(promo Promo) []string {
+	if promo.Locations == nil {
+		return []string{}
+	}
+	return promo.Locations
+}
@@ -0,0 +1,45 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrPaymentDeclined is returned when the payment provider refuses a
+// charge.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// PaymentProvider charges riders' payment methods for top-ups. A charge
+// is identified by key: charging the same key again returns the first
+// payment rather than paying twice, so a retried top-up is safe.
+type PaymentProvider interface {
+	// Charge takes amount from the user's payment method and returns the
+	// provider's payment ID.
+	Charge(ctx context.Context, userID string, amount int64, currency, key string) (string, error)
+}
+
+// FakeProvider approves every charge without moving money. It is meant
+// for local development and tests.
+type FakeProvider struct {
+	mu       sync.Mutex
+	payments map[string]string
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{payments: make(map[string]string)}
+}
+
+func (p *FakeProvider) Charge(ctx context.Context, userID string, amount int64, currency, key string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.payments[key]; ok {
+		return id, nil
+	}
+	id := "fake_" + uuid.NewString()
+	p.payments[key] = id
+	return id, nil
+}
@@ -0,0 +1,248 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// InvoicePrefix starts every invoice number, e.g. BR-2024-000042.
+const InvoicePrefix = "BR"
+
+// Receipt line kinds. Free minutes and discounts have negative amounts.
+const (
+	LineUnlock        = "unlock"
+	LineRiding        = "riding"
+	LineFreeMinutes   = "free_minutes"
+	LinePaused        = "paused"
+	LinePassDiscount  = "pass_discount"
+	LinePromoDiscount = "promo_discount"
+)
+
+// Line is one item of a receipt: Quantity minutes or units at UnitPrice.
+type Line struct {
+	Kind      string `json:"kind"`
+	Quantity  int64  `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"`
+	Amount    int64  `json:"amount"`
+}
+
+// Invoice is the numbered record of a charged rent. Numbers run without
+// gaps within a calendar year and never change once issued.
+type Invoice struct {
+	Number   string    `json:"number"`
+	RentID   uuid.UUID `json:"rent_id"`
+	UserID   string    `json:"user_id"`
+	IssuedAt time.Time `json:"issued_at"`
+	// Total is the fare charged; Tax is the VAT included in it
+	Total      int64 `json:"total"`
+	Tax        int64 `json:"tax"`
+	TaxPercent int64 `json:"tax_percent"`
+	// Refunded is what was credited back after the invoice was issued
+	Refunded int64 `json:"refunded"`
+}
+
+// Receipt is the invoice of a rent with what it was charged for.
+type Receipt struct {
+	Invoice
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Riding    time.Duration `json:"riding"`
+	Paused    time.Duration `json:"paused"`
+	Pass      string        `json:"pass,omitempty"`
+	Promo     string        `json:"promo,omitempty"`
+	Lines     []Line        `json:"lines"`
+}
+
+// Statement sums up a rider's wallet over [From, To). Amounts are
+// positive; ClosingBalance is OpeningBalance plus TopUps and Refunds less
+// Fares and Passes.
+type Statement struct {
+	UserID         string    `json:"user_id"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	TopUps         int64     `json:"top_ups"`
+	Fares          int64     `json:"fares"`
+	Refunds        int64     `json:"refunds"`
+	Passes         int64     `json:"passes"`
+	// Tax is the VAT included in the invoices issued in the period
+	Tax      int64     `json:"tax"`
+	Invoices []Invoice `json:"invoices"`
+}
+
+// issueInvoice numbers the invoice of a rent charged total within tx. The
+// number is taken from a per-year counter row locked by tx rather than a
+// sequence, so a rolled back rent leaves no gap.
+func issueInvoice(ctx context.Context, tx pgx.Tx, rentID uuid.UUID, userID string, total int64, t Tariff) (*Invoice, error) {
+	var year, n int64
+	err := tx.QueryRow(ctx,
+		`INSERT INTO invoice_counters (year, last) VALUES (EXTRACT(YEAR FROM NOW())::INTEGER, 1)
+		 ON CONFLICT (year) DO UPDATE SET last = invoice_counters.last + 1
+		 RETURNING year, last`,
+	).Scan(&year, &n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to number invoice: %w", err)
+	}
+
+	invoice := Invoice{
+		Number:     fmt.Sprintf("%s-%d-%06d", InvoicePrefix, year, n),
+		RentID:     rentID,
+		UserID:     userID,
+		Total:      total,
+		Tax:        t.Tax(total),
+		TaxPercent: t.TaxPercent,
+	}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO invoices (number, rent_id, user_id, total, tax, tax_percent)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING issued_at`,
+		invoice.Number, rentID, userID, total, invoice.Tax, invoice.TaxPercent,
+	).Scan(&invoice.IssuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save invoice: %w", err)
+	}
+	return &invoice, nil
+}
+
+func (s *pgStore) Receipt(ctx context.Context, rent models.Rent) (*Receipt, error) {
+	if rent.EndTime == nil {
+		return nil, ErrNotCharged
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	receipt := Receipt{StartTime: rent.StartTime, EndTime: *rent.EndTime}
+	var t Tariff
+	var fare *int64
+	var kind string
+	var value int64
+	var number *string
+	var issuedAt *time.Time
+	var tax *int64
+	err = tx.QueryRow(ctx,
+		`SELECT c.unlock_fee, c.per_minute, c.paused_per_minute, c.free_minutes, c.discount_percent, c.tax_percent,
+		        c.fare, c.refunded, COALESCE(p.pass_code, ''), COALESCE(r.promo_code, ''), COALESCE(r.kind, ''),
+		        COALESCE(r.value, 0), i.number, i.issued_at, i.tax
+		 FROM rent_charges c
+		 LEFT JOIN user_passes p ON p.id = c.user_pass_id
+		 LEFT JOIN promo_redemptions r ON r.rent_id = c.rent_id
+		 LEFT JOIN invoices i ON i.rent_id = c.rent_id
+		 WHERE c.rent_id = $1
+		 FOR UPDATE OF c`,
+		rent.ID,
+	).Scan(&t.UnlockFee, &t.PerMinute, &t.PausedPerMinute, &t.FreeMinutes, &t.DiscountPercent, &t.TaxPercent,
+		&fare, &receipt.Refunded, &receipt.Pass, &receipt.Promo, &kind, &value,
+		&number, &issuedAt, &tax)
+	if err == pgx.ErrNoRows || (err == nil && fare == nil) {
+		return nil, ErrNotCharged
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rent charge: %w", err)
+	}
+
+	// Rents charged before invoices were introduced get theirs on first
+	// request
+	if number == nil {
+		issued, err := issueInvoice(ctx, tx, rent.ID, rent.UserID, *fare, t)
+		if err != nil {
+			return nil, err
+		}
+		number, issuedAt, tax = &issued.Number, &issued.IssuedAt, &issued.Tax
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	applyPromo(&t, kind, value)
+	receipt.Number, receipt.IssuedAt, receipt.Tax = *number, *issuedAt, *tax
+	receipt.RentID = rent.ID
+	receipt.UserID = rent.UserID
+	receipt.Total = *fare
+	receipt.TaxPercent = t.TaxPercent
+	receipt.Paused = rent.PausedDuration(*rent.EndTime)
+	receipt.Riding = rent.EndTime.Sub(rent.StartTime) - receipt.Paused
+	receipt.Lines = t.Lines(rent)
+	return &receipt, nil
+}
+
+func (s *pgStore) Statement(ctx context.Context, userID string, from, to time.Time) (*Statement, error) {
+	statement := Statement{UserID: userID, From: from, To: to, Invoices: []Invoice{}}
+	err := s.db.QueryRow(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM wallet_entries WHERE user_id = $1 AND created_at < $2",
+		userID, from,
+	).Scan(&statement.OpeningBalance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT type, SUM(amount)
+		 FROM wallet_entries
+		 WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		 GROUP BY type`,
+		userID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallet entries: %w", err)
+	}
+	defer rows.Close()
+
+	statement.ClosingBalance = statement.OpeningBalance
+	for rows.Next() {
+		var entryType string
+		var amount int64
+		if err := rows.Scan(&entryType, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet entries: %w", err)
+		}
+		statement.ClosingBalance += amount
+		switch entryType {
+		case EntryTopUp:
+			statement.TopUps += amount
+		case EntryCapture:
+			statement.Fares -= amount
+		case EntryRefund:
+			statement.Refunds += amount
+		case EntryPass:
+			statement.Passes -= amount
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(ctx,
+		`SELECT i.number, i.rent_id, i.issued_at, i.total, i.tax, i.tax_percent, c.refunded
+		 FROM invoices i
+		 JOIN rent_charges c ON c.rent_id = i.rent_id
+		 WHERE i.user_id = $1 AND i.issued_at >= $2 AND i.issued_at < $3
+		 ORDER BY i.issued_at, i.number`,
+		userID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invoices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		invoice := Invoice{UserID: userID}
+		err := rows.Scan(&invoice.Number, &invoice.RentID, &invoice.IssuedAt, &invoice.Total, &invoice.Tax,
+			&invoice.TaxPercent, &invoice.Refunded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		statement.Tax += invoice.Tax
+		statement.Invoices = append(statement.Invoices, invoice)
+	}
+
+	return &statement, rows.Err()
+}
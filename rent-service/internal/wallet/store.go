@@ -0,0 +1,205 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 1000
+)
+
+// Store reads wallets and changes them outside of rents. Holds and
+// captures are made by the repository within the rent's transaction, see
+// Hold and Capture.
+type Store interface {
+	// GetAccount returns the user's wallet, empty if the user never had one.
+	GetAccount(ctx context.Context, userID string) (*Account, error)
+	// ListEntries returns the user's ledger, newest first. Limit defaults
+	// to 20 and is capped at 1000.
+	ListEntries(ctx context.Context, userID string, limit int) ([]Entry, error)
+	// TopUp credits a payment. A payment already credited is not credited
+	// again, so retried top-ups are safe.
+	TopUp(ctx context.Context, userID string, amount int64, paymentID string) (*Account, error)
+	// Refund credits amount of a rent's fare back to the rider, everything
+	// not refunded yet when amount is 0.
+	Refund(ctx context.Context, rentID uuid.UUID, amount int64, reason string) (*Account, error)
+	// ListPasses returns the passes that can be bought, cheapest first.
+	ListPasses(ctx context.Context) ([]Pass, error)
+	// ActivePass returns the user's pass rents started now are billed
+	// with, nil if there is none.
+	ActivePass(ctx context.Context, userID string) (*Subscription, error)
+	// BuyPass pays a pass from the available balance. It starts at once
+	// and fails with ErrPassActive while another pass lasts.
+	BuyPass(ctx context.Context, userID, code string) (*Subscription, error)
+	// CancelPass ends the user's pass now. Its price is refunded only if
+	// no rent was started with it.
+	CancelPass(ctx context.Context, userID string, id uuid.UUID) (*Subscription, error)
+	// ListPromos returns every promo code with its redemptions, newest
+	// first.
+	ListPromos(ctx context.Context) ([]Promo, error)
+	// CreatePromo adds a promo code, failing with ErrPromoExists for a
+	// taken code.
+	CreatePromo(ctx context.Context, promo Promo) (*Promo, error)
+	// UpdatePromo replaces the terms of a promo code. Rents it was applied
+	// to keep the terms they were redeemed with.
+	UpdatePromo(ctx context.Context, promo Promo) (*Promo, error)
+	// DeletePromo removes a promo code no rent was given.
+	DeletePromo(ctx context.Context, code string) error
+	// PromoStats returns the use of promo codes applied in [from, to),
+	// most redeemed first.
+	PromoStats(ctx context.Context, from, to time.Time) ([]PromoStats, error)
+	// Receipt returns the itemized invoice of an ended rent, failing with
+	// ErrNotCharged for rents that were not billed.
+	Receipt(ctx context.Context, rent models.Rent) (*Receipt, error)
+	// Statement sums up the user's wallet and lists the invoices issued
+	// in [from, to).
+	Statement(ctx context.Context, userID string, from, to time.Time) (*Statement, error)
+}
+
+type pgStore struct {
+	db *pgxpool.Pool
+}
+
+func NewStore(db *pgxpool.Pool) Store {
+	return &pgStore{db: db}
+}
+
+func (s *pgStore) GetAccount(ctx context.Context, userID string) (*Account, error) {
+	account := Account{UserID: userID}
+	err := s.db.QueryRow(ctx,
+		"SELECT balance, held FROM wallets WHERE user_id = $1",
+		userID,
+	).Scan(&account.Balance, &account.Held)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return &account, nil
+}
+
+func (s *pgStore) ListEntries(ctx context.Context, userID string, limit int) ([]Entry, error) {
+	switch {
+	case limit <= 0:
+		limit = defaultLimit
+	case limit > maxLimit:
+		limit = maxLimit
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT id, created_at, user_id, type, amount, held, rent_id, reference, reason
+		 FROM wallet_entries
+		 WHERE user_id = $1
+		 ORDER BY id DESC
+		 LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallet entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var rentID *uuid.UUID
+		if err := rows.Scan(&e.ID, &e.Time, &e.UserID, &e.Type, &e.Amount, &e.Held, &rentID, &e.Reference, &e.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet entry: %w", err)
+		}
+		if rentID != nil {
+			e.RentID = *rentID
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *pgStore) TopUp(ctx context.Context, userID string, amount int64, paymentID string) (*Account, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	account, err := lockAccount(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var credited bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM wallet_entries WHERE type = $1 AND reference = $2)",
+		EntryTopUp, paymentID,
+	).Scan(&credited)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check payment: %w", err)
+	}
+	if !credited {
+		account, err = addEntry(ctx, tx, Entry{UserID: userID, Type: EntryTopUp, Amount: amount, Reference: paymentID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
+
+func (s *pgStore) Refund(ctx context.Context, rentID uuid.UUID, amount int64, reason string) (*Account, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	var fare *int64
+	var refunded int64
+	err = tx.QueryRow(ctx,
+		"SELECT user_id, fare, refunded FROM rent_charges WHERE rent_id = $1 FOR UPDATE",
+		rentID,
+	).Scan(&userID, &fare, &refunded)
+	if err == pgx.ErrNoRows || (err == nil && fare == nil) {
+		return nil, ErrNotCharged
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rent charge: %w", err)
+	}
+
+	left := *fare - refunded
+	if amount == 0 {
+		amount = left
+	}
+	if amount <= 0 || amount > left {
+		return nil, fmt.Errorf("%w: %d of the fare can be refunded, got %d", ErrInvalidAmount, left, amount)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE rent_charges SET refunded = refunded + $2 WHERE rent_id = $1", rentID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save refund: %w", err)
+	}
+
+	if _, err := lockAccount(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	account, err := addEntry(ctx, tx, Entry{UserID: userID, Type: EntryRefund, Amount: amount, RentID: rentID, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return account, nil
+}
@@ -0,0 +1,168 @@
+// Package wallet keeps rider balances: a ledger of top-ups, holds placed
+// when rents start, fares captured when they end, passes, promo codes,
+// refunds and the receipts of charged rents.
+// Amounts are in minor units of the configured currency, e.g. kopecks.
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"bike-rental/config"
+	"bike-rental/rent-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrInsufficientFunds is returned when a rider's available balance does
+// not cover the hold of a new rent.
+var ErrInsufficientFunds = errors.New("insufficient balance")
+
+// ErrInvalidAmount is returned for top-ups and refunds of a bad amount.
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// ErrNotCharged is returned when refunding a rent that has no fare yet,
+// because it is still running or was never billed.
+var ErrNotCharged = errors.New("rent has not been charged")
+
+// Ledger entry types.
+const (
+	EntryTopUp   = "top_up"
+	EntryHold    = "hold"
+	EntryCapture = "capture"
+	EntryRefund  = "refund"
+	// EntryPass is a pass bought from the balance; its Reference is the
+	// subscription ID, as is that of the refund when it is cancelled.
+	EntryPass = "pass"
+)
+
+// Account is a rider's wallet. Held is the part of Balance reserved for
+// rents in progress. Balance goes negative when a fare exceeds it; the
+// rider cannot start another rent until it is topped up.
+type Account struct {
+	UserID  string `json:"user_id"`
+	Balance int64  `json:"balance"`
+	Held    int64  `json:"held"`
+}
+
+// Available is what the rider can spend on new rents.
+func (a Account) Available() int64 {
+	return a.Balance - a.Held
+}
+
+// Entry is one change of a wallet. Amount changes the balance and Held
+// the reserved part of it.
+type Entry struct {
+	ID     int64     `json:"id"`
+	Time   time.Time `json:"time"`
+	UserID string    `json:"user_id"`
+	Type   string    `json:"type"`
+	Amount int64     `json:"amount"`
+	Held   int64     `json:"held"`
+	// RentID is set for holds, captures and refunds of fares
+	RentID uuid.UUID `json:"rent_id"`
+	// Reference is the payment provider's ID of a top-up or the
+	// subscription ID of a pass
+	Reference string `json:"reference,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Tariff is what a rent costs. A rent is billed by the tariff in effect
+// when it started, with the benefits of the rider's pass at that time and
+// of the promo code applied to it.
+type Tariff struct {
+	// Hold is reserved from the balance while the rent is in progress
+	Hold            int64
+	UnlockFee       int64
+	PerMinute       int64
+	PausedPerMinute int64
+	// FreeMinutes of riding are not charged
+	FreeMinutes int64
+	// DiscountPercent is taken off the whole fare
+	DiscountPercent int64
+	// PromoPercent and then PromoAmount are taken off what is left
+	PromoPercent int64
+	PromoAmount  int64
+	// TaxPercent is the VAT rate included in the prices
+	TaxPercent int64
+}
+
+func NewTariff(cfg config.PricingConfig) Tariff {
+	return Tariff{
+		Hold:            cfg.Hold,
+		UnlockFee:       cfg.UnlockFee,
+		PerMinute:       cfg.PerMinute,
+		PausedPerMinute: cfg.PausedPerMinute,
+		TaxPercent:      cfg.TaxPercent,
+	}
+}
+
+// Fare prices an ended rent: the unlock fee plus every started minute of
+// riding past the free ones and of pause at their own rates, less the pass
+// and promo discounts rounded in the rider's favour. It is never negative.
+func (t Tariff) Fare(rent models.Rent) int64 {
+	var fare int64
+	for _, line := range t.Lines(rent) {
+		fare += line.Amount
+	}
+	return fare
+}
+
+// Lines itemizes the fare of an ended rent; their amounts add up to Fare.
+// Discounts that take nothing off are left out.
+func (t Tariff) Lines(rent models.Rent) []Line {
+	if rent.EndTime == nil {
+		return nil
+	}
+	paused := rent.PausedDuration(*rent.EndTime)
+	riding := startedMinutes(rent.EndTime.Sub(rent.StartTime) - paused)
+	free := min(riding, t.FreeMinutes)
+	pausedMinutes := startedMinutes(paused)
+
+	lines := []Line{
+		{Kind: LineUnlock, Quantity: 1, UnitPrice: t.UnlockFee, Amount: t.UnlockFee},
+		{Kind: LineRiding, Quantity: riding, UnitPrice: t.PerMinute, Amount: riding * t.PerMinute},
+	}
+	if free > 0 {
+		lines = append(lines, Line{Kind: LineFreeMinutes, Quantity: free, UnitPrice: -t.PerMinute, Amount: -free * t.PerMinute})
+	}
+	if pausedMinutes > 0 {
+		lines = append(lines, Line{Kind: LinePaused, Quantity: pausedMinutes, UnitPrice: t.PausedPerMinute, Amount: pausedMinutes * t.PausedPerMinute})
+	}
+
+	fare := t.UnlockFee + (riding-free)*t.PerMinute + pausedMinutes*t.PausedPerMinute
+	discounted := fare * (100 - t.DiscountPercent) / 100
+	if discounted < fare {
+		lines = append(lines, Line{Kind: LinePassDiscount, Quantity: 1, UnitPrice: discounted - fare, Amount: discounted - fare})
+	}
+	promoted := max(discounted*(100-t.PromoPercent)/100-t.PromoAmount, 0)
+	if promoted < discounted {
+		lines = append(lines, Line{Kind: LinePromoDiscount, Quantity: 1, UnitPrice: promoted - discounted, Amount: promoted - discounted})
+	}
+	return lines
+}
+
+// Tax is the VAT included in amount, rounded half up.
+func (t Tariff) Tax(amount int64) int64 {
+	return (amount*t.TaxPercent*2 + 100 + t.TaxPercent) / (2 * (100 + t.TaxPercent))
+}
+
+// Charge is how a rent is billed.
+type Charge struct {
+	Tariff Tariff
+	// Pass is the code of the pass the rent is ridden on, empty if none
+	Pass string
+	// Promo is the promo code applied to the rent, empty if none
+	Promo string
+	// Fare is set once the rent has ended, with Discount, the part of it
+	// the promo code took off, and the number of its invoice
+	Fare     *int64
+	Discount int64
+	Invoice  string
+}
+
+func startedMinutes(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Minute - 1) / time.Minute)
+}
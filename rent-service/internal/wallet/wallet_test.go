@@ -0,0 +1,202 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bike-rental/rent-service/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// WalletTestSuite - тестовый набор для тарифа и тестового платёжного провайдера
+type WalletTestSuite struct {
+	suite.Suite
+	tariff Tariff
+	start  time.Time
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *WalletTestSuite) SetupTest() {
+	suite.tariff = Tariff{Hold: 30000, UnlockFee: 5000, PerMinute: 800, PausedPerMinute: 300}
+	suite.start = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+}
+
+func (suite *WalletTestSuite) rent(ride time.Duration, pausedSeconds int64) models.Rent {
+	end := suite.start.Add(ride)
+	return models.Rent{StartTime: suite.start, EndTime: &end, PausedSeconds: pausedSeconds}
+}
+
+// TestFare_StartedMinutes - каждая начатая минута поездки оплачивается целиком
+func (suite *WalletTestSuite) TestFare_StartedMinutes() {
+	suite.Equal(int64(5000+800), suite.tariff.Fare(suite.rent(10*time.Second, 0)))
+	suite.Equal(int64(5000+10*800), suite.tariff.Fare(suite.rent(10*time.Minute, 0)))
+	suite.Equal(int64(5000+11*800), suite.tariff.Fare(suite.rent(10*time.Minute+time.Second, 0)))
+}
+
+// TestFare_PausedTime - время на паузе оплачивается по своей цене и не входит в поездку
+func (suite *WalletTestSuite) TestFare_PausedTime() {
+	fare := suite.tariff.Fare(suite.rent(30*time.Minute, 20*60))
+
+	suite.Equal(int64(5000+10*800+20*300), fare)
+}
+
+// TestFare_EndedWhilePaused - пауза, не закончившаяся до завершения, длится до конца аренды
+func (suite *WalletTestSuite) TestFare_EndedWhilePaused() {
+	rent := suite.rent(30*time.Minute, 0)
+	pausedAt := suite.start.Add(25 * time.Minute)
+	rent.PausedAt = &pausedAt
+
+	suite.Equal(int64(5000+25*800+5*300), suite.tariff.Fare(rent))
+}
+
+// TestFare_ActiveRent - у незавершённой аренды стоимости ещё нет
+func (suite *WalletTestSuite) TestFare_ActiveRent() {
+	suite.Zero(suite.tariff.Fare(models.Rent{StartTime: suite.start}))
+}
+
+// TestFare_PassFreeMinutes - бесплатные минуты абонемента вычитаются только из поездки
+func (suite *WalletTestSuite) TestFare_PassFreeMinutes() {
+	suite.tariff.FreeMinutes = 30
+
+	suite.Equal(int64(5000), suite.tariff.Fare(suite.rent(25*time.Minute, 0)))
+	suite.Equal(int64(5000+5*800+10*300), suite.tariff.Fare(suite.rent(45*time.Minute, 10*60)))
+}
+
+// TestFare_PassDiscount - скидка абонемента применяется ко всей стоимости и округляется вниз
+func (suite *WalletTestSuite) TestFare_PassDiscount() {
+	suite.tariff.DiscountPercent = 50
+
+	suite.Equal(int64((5000+3*800)/2), suite.tariff.Fare(suite.rent(3*time.Minute, 0)))
+
+	suite.tariff = Tariff{UnlockFee: 1, DiscountPercent: 50}
+	suite.Equal(int64(0), suite.tariff.Fare(suite.rent(0, 0)))
+}
+
+// TestSubscription_Active - абонемент действует с начала до конца, если не отменён
+func (suite *WalletTestSuite) TestSubscription_Active() {
+	sub := Subscription{StartsAt: suite.start, EndsAt: suite.start.Add(24 * time.Hour)}
+
+	suite.True(sub.Active(suite.start))
+	suite.False(sub.Active(suite.start.Add(-time.Second)))
+	suite.False(sub.Active(sub.EndsAt))
+
+	cancelled := suite.start.Add(time.Hour)
+	sub.CancelledAt = &cancelled
+	suite.False(sub.Active(suite.start.Add(time.Minute)))
+}
+
+// TestFare_PromoPercent - процент промокода берётся от стоимости после скидки абонемента
+func (suite *WalletTestSuite) TestFare_PromoPercent() {
+	suite.tariff.DiscountPercent = 50
+	applyPromo(&suite.tariff, PromoKindPercent, 10)
+
+	// (5000 + 10*800) / 2 = 6500, минус 10% = 5850
+	suite.Equal(int64(5850), suite.tariff.Fare(suite.rent(10*time.Minute, 0)))
+}
+
+// TestFare_PromoFixed - фиксированная скидка не делает стоимость отрицательной
+func (suite *WalletTestSuite) TestFare_PromoFixed() {
+	applyPromo(&suite.tariff, PromoKindFixed, 3000)
+	suite.Equal(int64(5000+10*800-3000), suite.tariff.Fare(suite.rent(10*time.Minute, 0)))
+
+	applyPromo(&suite.tariff, PromoKindFixed, 100000)
+	suite.Equal(int64(0), suite.tariff.Fare(suite.rent(10*time.Minute, 0)))
+}
+
+// TestPromo_Validate - некорректные условия промокода отклоняются
+func (suite *WalletTestSuite) TestPromo_Validate() {
+	valid := Promo{Code: "SUMMER10", Kind: PromoKindPercent, Value: 10}
+	suite.NoError(valid.Validate())
+
+	for _, promo := range []Promo{
+		{Kind: PromoKindPercent, Value: 10},
+		{Code: "X", Kind: "free", Value: 10},
+		{Code: "X", Kind: PromoKindPercent, Value: 101},
+		{Code: "X", Kind: PromoKindFixed, Value: 0},
+		{Code: "X", Kind: PromoKindFixed, Value: 100, ValidFrom: &suite.start, ValidUntil: &suite.start},
+		{Code: "X", Kind: PromoKindFixed, Value: 100, MaxPerUser: -1},
+	} {
+		suite.ErrorIs(promo.Validate(), ErrInvalidPromo, "%+v", promo)
+	}
+}
+
+// TestPromo_Check - промокод действует только активным, в своём окне и на своих локациях
+func (suite *WalletTestSuite) TestPromo_Check() {
+	until := suite.start.Add(24 * time.Hour)
+	promo := Promo{Code: "PARK", Active: true, ValidFrom: &suite.start, ValidUntil: &until, Locations: []string{"Park"}}
+
+	suite.NoError(promo.check(suite.start, "Park"))
+	suite.ErrorIs(promo.check(suite.start.Add(-time.Second), "Park"), ErrPromoNotApplicable)
+	suite.ErrorIs(promo.check(until, "Park"), ErrPromoNotApplicable)
+	suite.ErrorIs(promo.check(suite.start, "Station"), ErrPromoNotApplicable)
+
+	promo.Active = false
+	suite.ErrorIs(promo.check(suite.start, "Park"), ErrPromoNotApplicable)
+}
+
+// TestLines_AddUpToFare - строки чека в сумме дают стоимость аренды
+func (suite *WalletTestSuite) TestLines_AddUpToFare() {
+	suite.tariff.FreeMinutes = 5
+	suite.tariff.DiscountPercent = 50
+	applyPromo(&suite.tariff, PromoKindPercent, 10)
+	rent := suite.rent(33*time.Minute, 7*60)
+
+	lines := suite.tariff.Lines(rent)
+
+	var kinds []string
+	var total int64
+	for _, line := range lines {
+		kinds = append(kinds, line.Kind)
+		total += line.Amount
+	}
+	suite.Equal([]string{LineUnlock, LineRiding, LineFreeMinutes, LinePaused, LinePassDiscount, LinePromoDiscount}, kinds)
+	suite.Equal(Line{Kind: LineRiding, Quantity: 26, UnitPrice: 800, Amount: 26 * 800}, lines[1])
+	suite.Equal(Line{Kind: LineFreeMinutes, Quantity: 5, UnitPrice: -800, Amount: -5 * 800}, lines[2])
+	suite.Equal(suite.tariff.Fare(rent), total)
+}
+
+// TestLines_NoDiscounts - без абонемента и промокода скидок в чеке нет
+func (suite *WalletTestSuite) TestLines_NoDiscounts() {
+	lines := suite.tariff.Lines(suite.rent(10*time.Minute, 0))
+
+	suite.Equal([]Line{
+		{Kind: LineUnlock, Quantity: 1, UnitPrice: 5000, Amount: 5000},
+		{Kind: LineRiding, Quantity: 10, UnitPrice: 800, Amount: 8000},
+	}, lines)
+	suite.Nil(suite.tariff.Lines(models.Rent{StartTime: suite.start}))
+}
+
+// TestTax_Included - НДС выделяется из цены и округляется до копейки
+func (suite *WalletTestSuite) TestTax_Included() {
+	suite.tariff.TaxPercent = 20
+
+	suite.Equal(int64(2167), suite.tariff.Tax(13000))
+	suite.Equal(int64(1000), suite.tariff.Tax(6000))
+	suite.Equal(int64(0), Tariff{}.Tax(13000))
+}
+
+// TestAccount_Available - доступно всё, что не заблокировано под аренды
+func (suite *WalletTestSuite) TestAccount_Available() {
+	suite.Equal(int64(-500), Account{Balance: 29500, Held: 30000}.Available())
+}
+
+// TestFakeProvider_SameKey - повторный платёж с тем же ключом возвращает первый
+func (suite *WalletTestSuite) TestFakeProvider_SameKey() {
+	provider := NewFakeProvider()
+
+	first, err := provider.Charge(context.Background(), "user1", 1000, "RUB", "key")
+	suite.Require().NoError(err)
+	again, err := provider.Charge(context.Background(), "user1", 1000, "RUB", "key")
+	suite.Require().NoError(err)
+	other, err := provider.Charge(context.Background(), "user1", 1000, "RUB", "other")
+	suite.Require().NoError(err)
+
+	suite.Equal(first, again)
+	suite.NotEqual(first, other)
+}
+
+// TestWalletTestSuite - запуск тестового набора
+func TestWalletTestSuite(t *testing.T) {
+	suite.Run(t, new(WalletTestSuite))
+}
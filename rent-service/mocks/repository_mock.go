@@ -6,6 +6,8 @@ import (
 	"context"
 
 	"bike-rental/rent-service/internal/models"
+	"bike-rental/rent-service/internal/repository"
+	"bike-rental/rent-service/internal/wallet"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -76,9 +78,9 @@ func (_m *Repository) GetBikeByID(ctx context.Context, bikeID uuid.UUID) (*model
 	return r0, r1
 }
 
-// StartRent provides a mock function with given fields: ctx, userID, bikeID
-func (_m *Repository) StartRent(ctx context.Context, userID string, bikeID uuid.UUID) (*models.Rent, error) {
-	ret := _m.Called(ctx, userID, bikeID)
+// StartRent provides a mock function with given fields: ctx, userID, requireRegistration, bikeID, tariff, promoCode
+func (_m *Repository) StartRent(ctx context.Context, userID string, requireRegistration bool, bikeID uuid.UUID, tariff *wallet.Tariff, promoCode string) (*models.Rent, error) {
+	ret := _m.Called(ctx, userID, requireRegistration, bikeID, tariff, promoCode)
 
 	if len(ret) == 0 {
 		panic("no return value specified for StartRent")
@@ -86,19 +88,19 @@ func (_m *Repository) StartRent(ctx context.Context, userID string, bikeID uuid.
 
 	var r0 *models.Rent
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) (*models.Rent, error)); ok {
-		return rf(ctx, userID, bikeID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, uuid.UUID, *wallet.Tariff, string) (*models.Rent, error)); ok {
+		return rf(ctx, userID, requireRegistration, bikeID, tariff, promoCode)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) *models.Rent); ok {
-		r0 = rf(ctx, userID, bikeID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, uuid.UUID, *wallet.Tariff, string) *models.Rent); ok {
+		r0 = rf(ctx, userID, requireRegistration, bikeID, tariff, promoCode)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Rent)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, uuid.UUID) error); ok {
-		r1 = rf(ctx, userID, bikeID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool, uuid.UUID, *wallet.Tariff, string) error); ok {
+		r1 = rf(ctx, userID, requireRegistration, bikeID, tariff, promoCode)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -106,14 +108,44 @@ func (_m *Repository) StartRent(ctx context.Context, userID string, bikeID uuid.
 	return r0, r1
 }
 
-// EndRent provides a mock function with given fields: ctx, rentID, userID
-func (_m *Repository) EndRent(ctx context.Context, rentID uuid.UUID, userID string) (*models.Rent, error) {
-	ret := _m.Called(ctx, rentID, userID)
+// EndRent provides a mock function with given fields: ctx, rentID, userID, promoCode
+func (_m *Repository) EndRent(ctx context.Context, rentID uuid.UUID, userID string, promoCode string) (*models.Rent, error) {
+	ret := _m.Called(ctx, rentID, userID, promoCode)
 
 	if len(ret) == 0 {
 		panic("no return value specified for EndRent")
 	}
 
+	var r0 *models.Rent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) (*models.Rent, error)); ok {
+		return rf(ctx, rentID, userID, promoCode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) *models.Rent); ok {
+		r0 = rf(ctx, rentID, userID, promoCode)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Rent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r1 = rf(ctx, rentID, userID, promoCode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PauseRent provides a mock function with given fields: ctx, rentID, userID
+func (_m *Repository) PauseRent(ctx context.Context, rentID uuid.UUID, userID string) (*models.Rent, error) {
+	ret := _m.Called(ctx, rentID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PauseRent")
+	}
+
 	var r0 *models.Rent
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (*models.Rent, error)); ok {
@@ -136,6 +168,36 @@ func (_m *Repository) EndRent(ctx context.Context, rentID uuid.UUID, userID stri
 	return r0, r1
 }
 
+// ResumeRent provides a mock function with given fields: ctx, rentID, userID, reason
+func (_m *Repository) ResumeRent(ctx context.Context, rentID uuid.UUID, userID string, reason string) (*models.Rent, error) {
+	ret := _m.Called(ctx, rentID, userID, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeRent")
+	}
+
+	var r0 *models.Rent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) (*models.Rent, error)); ok {
+		return rf(ctx, rentID, userID, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) *models.Rent); ok {
+		r0 = rf(ctx, rentID, userID, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Rent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r1 = rf(ctx, rentID, userID, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRentByID provides a mock function with given fields: ctx, rentID
 func (_m *Repository) GetRentByID(ctx context.Context, rentID uuid.UUID) (*models.Rent, error) {
 	ret := _m.Called(ctx, rentID)
@@ -260,6 +322,269 @@ func (_m *Repository) HasActiveRent(ctx context.Context, bikeID uuid.UUID) (bool
 	return r0, r1
 }
 
+// AddBikes provides a mock function with given fields: ctx, bikes
+func (_m *Repository) AddBikes(ctx context.Context, bikes []models.Bike) ([]models.Bike, error) {
+	ret := _m.Called(ctx, bikes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddBikes")
+	}
+
+	var r0 []models.Bike
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.Bike) ([]models.Bike, error)); ok {
+		return rf(ctx, bikes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []models.Bike) []models.Bike); ok {
+		r0 = rf(ctx, bikes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Bike)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []models.Bike) error); ok {
+		r1 = rf(ctx, bikes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBikes provides a mock function with given fields: ctx, location, fn
+func (_m *Repository) ListBikes(ctx context.Context, location string, fn func(models.Bike) error) error {
+	ret := _m.Called(ctx, location, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBikes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(models.Bike) error) error); ok {
+		r0 = rf(ctx, location, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListRents provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListRents(ctx context.Context, filter repository.RentFilter) ([]models.Rent, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRents")
+	}
+
+	var r0 []models.Rent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, repository.RentFilter) ([]models.Rent, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, repository.RentFilter) []models.Rent); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Rent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, repository.RentFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForceEndRent provides a mock function with given fields: ctx, rentID, reason
+func (_m *Repository) ForceEndRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error) {
+	ret := _m.Called(ctx, rentID, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForceEndRent")
+	}
+
+	var r0 *models.Rent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (*models.Rent, error)); ok {
+		return rf(ctx, rentID, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) *models.Rent); ok {
+		r0 = rf(ctx, rentID, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Rent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, rentID, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DisputeRent provides a mock function with given fields: ctx, rentID, reason
+func (_m *Repository) DisputeRent(ctx context.Context, rentID uuid.UUID, reason string) (*models.Rent, error) {
+	ret := _m.Called(ctx, rentID, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisputeRent")
+	}
+
+	var r0 *models.Rent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (*models.Rent, error)); ok {
+		return rf(ctx, rentID, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) *models.Rent); ok {
+		r0 = rf(ctx, rentID, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Rent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, rentID, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRentEvents provides a mock function with given fields: ctx, rentID
+func (_m *Repository) ListRentEvents(ctx context.Context, rentID uuid.UUID) ([]models.RentLifecycleEvent, error) {
+	ret := _m.Called(ctx, rentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRentEvents")
+	}
+
+	var r0 []models.RentLifecycleEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.RentLifecycleEvent, error)); ok {
+		return rf(ctx, rentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.RentLifecycleEvent); ok {
+		r0 = rf(ctx, rentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.RentLifecycleEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, rentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RebuildRents provides a mock function with given fields: ctx, rentID
+func (_m *Repository) RebuildRents(ctx context.Context, rentID uuid.UUID) (int, int, error) {
+	ret := _m.Called(ctx, rentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RebuildRents")
+	}
+
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (int, int, error)); ok {
+		return rf(ctx, rentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) int); ok {
+		r0 = rf(ctx, rentID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) int); ok {
+		r1 = rf(ctx, rentID)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID) error); ok {
+		r2 = rf(ctx, rentID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CountAvailableBikes provides a mock function with given fields: ctx
+func (_m *Repository) CountAvailableBikes(ctx context.Context) (map[string]int, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountAvailableBikes")
+	}
+
+	var r0 map[string]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[string]int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RelocateBikes provides a mock function with given fields: ctx, from, to, count
+func (_m *Repository) RelocateBikes(ctx context.Context, from string, to string, count int) ([]models.Bike, error) {
+	ret := _m.Called(ctx, from, to, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RelocateBikes")
+	}
+
+	var r0 []models.Bike
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) ([]models.Bike, error)); ok {
+		return rf(ctx, from, to, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) []models.Bike); ok {
+		r0 = rf(ctx, from, to, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Bike)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, from, to, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepository(t interface {
@@ -0,0 +1,218 @@
+// Package rentevents is the contract for rent events on Kafka, shared by
+// rent-service and stats-service. The payload is defined in
+// proto/rent_event.proto; every message carries headers naming its encoding,
+// schema version and event ID.
+//
+// Messages without a content-type header are the legacy JSON events
+// published before the contract existed and are still decoded.
+package rentevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"time"
+
+	"bike-rental/rentevents/eventpb"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SchemaVersion is the contract version this package writes. Decode accepts
+// this version and older ones.
+const SchemaVersion = 1
+
+// Kafka header keys.
+const (
+	HeaderContentType   = "content-type"
+	HeaderSchemaVersion = "schema-version"
+	HeaderEventID       = "event-id"
+)
+
+// Content types of the message value.
+const (
+	ContentTypeProtobuf = "application/x-protobuf; proto=rentevents.v1.RentEvent"
+	ContentTypeJSON     = "application/json"
+)
+
+// Encoding selects how events are written.
+type Encoding string
+
+const (
+	Protobuf Encoding = "protobuf"
+	JSON     Encoding = "json"
+)
+
+// Event types.
+const (
+	Start = "start"
+	End   = "end"
+	// Pause and Resume happen between Start and End, any number of times.
+	// A rent ended while paused has no Resume.
+	Pause  = "pause"
+	Resume = "resume"
+)
+
+// RentEvent is a rent starting, pausing, resuming or ending. Timestamps are sent in UTC;
+// consumers convert them to their own time zone for bucketing. The JSON
+// tags are the legacy wire format.
+type RentEvent struct {
+	ID            string    `json:"event_id,omitempty"`
+	SchemaVersion int       `json:"schema_version,omitempty"`
+	RentID        string    `json:"rent_id"`
+	UserID        string    `json:"user_id"`
+	BikeID        string    `json:"bike_id"`
+	EventType     string    `json:"event_type"`
+	Location      string    `json:"location,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	// Pass is set in start and end events of rents ridden on a pass
+	Pass string `json:"pass,omitempty"`
+}
+
+// idNamespace derives event IDs from the rent ID and event type.
+var idNamespace = uuid.MustParse("6f1c0a52-3f0e-4d8b-9a57-2b1e8c4d7a10")
+
+var eventTypes = map[string]eventpb.RentEvent_Type{
+	Start:  eventpb.RentEvent_START,
+	End:    eventpb.RentEvent_END,
+	Pause:  eventpb.RentEvent_PAUSE,
+	Resume: eventpb.RentEvent_RESUME,
+}
+
+// EventID is the ID of a rent's start or end event. It is the same every
+// time the event is published, so consumers can drop replayed duplicates.
+func EventID(rentID, eventType string) string {
+	return uuid.NewSHA1(idNamespace, []byte(rentID+"/"+eventType)).String()
+}
+
+// PauseEventID is the ID of a pause or resume event at. A rent can pause
+// more than once, so the time tells its pauses apart.
+func PauseEventID(rentID, eventType string, at time.Time) string {
+	return EventID(rentID, eventType+"/"+at.UTC().Format(time.RFC3339Nano))
+}
+
+// NewMessage encodes event for topic, keyed by rent ID so events of one
+// rent stay ordered. The schema version is always set to SchemaVersion and
+// a missing ID to EventID, or PauseEventID for pauses and resumes.
+func NewMessage(topic string, event RentEvent, encoding Encoding) (kafka.Message, error) {
+	event.SchemaVersion = SchemaVersion
+	if event.ID == "" {
+		switch event.EventType {
+		case Pause, Resume:
+			event.ID = PauseEventID(event.RentID, event.EventType, event.Timestamp)
+		default:
+			event.ID = EventID(event.RentID, event.EventType)
+		}
+	}
+
+	var value []byte
+	var contentType string
+	var err error
+	switch encoding {
+	case Protobuf:
+		contentType = ContentTypeProtobuf
+		value, err = proto.Marshal(toProto(event))
+	case JSON:
+		contentType = ContentTypeJSON
+		value, err = json.Marshal(event)
+	default:
+		return kafka.Message{}, fmt.Errorf("unknown event encoding %q", encoding)
+	}
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return kafka.Message{
+		Topic: topic,
+		Key:   []byte(event.RentID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(contentType)},
+			{Key: HeaderSchemaVersion, Value: []byte(strconv.Itoa(SchemaVersion))},
+			{Key: HeaderEventID, Value: []byte(event.ID)},
+		},
+	}, nil
+}
+
+// Decode reads an event in any supported encoding. Legacy JSON events have
+// no ID and schema version 0.
+func Decode(msg kafka.Message) (RentEvent, error) {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	if v, ok := headers[HeaderSchemaVersion]; ok {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return RentEvent{}, fmt.Errorf("invalid schema version %q", v)
+		}
+		if version > SchemaVersion {
+			return RentEvent{}, fmt.Errorf("unsupported schema version %d, this build reads up to %d", version, SchemaVersion)
+		}
+	}
+
+	contentType := headers[HeaderContentType]
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return RentEvent{}, fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	switch {
+	case mediaType == "application/x-protobuf" && params["proto"] == "rentevents.v1.RentEvent":
+		var pb eventpb.RentEvent
+		if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+			return RentEvent{}, fmt.Errorf("failed to unmarshal protobuf event: %w", err)
+		}
+		return fromProto(&pb), nil
+
+	case mediaType == "application/json":
+		var event RentEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return RentEvent{}, fmt.Errorf("failed to unmarshal JSON event: %w", err)
+		}
+		return event, nil
+
+	default:
+		return RentEvent{}, fmt.Errorf("unsupported content type %q", contentType)
+	}
+}
+
+func toProto(e RentEvent) *eventpb.RentEvent {
+	return &eventpb.RentEvent{
+		EventId:       e.ID,
+		SchemaVersion: int32(e.SchemaVersion),
+		Type:          eventTypes[e.EventType],
+		RentId:        e.RentID,
+		UserId:        e.UserID,
+		BikeId:        e.BikeID,
+		Location:      e.Location,
+		Timestamp:     timestamppb.New(e.Timestamp),
+		Pass:          e.Pass,
+	}
+}
+
+func fromProto(pb *eventpb.RentEvent) RentEvent {
+	event := RentEvent{
+		ID:            pb.EventId,
+		SchemaVersion: int(pb.SchemaVersion),
+		RentID:        pb.RentId,
+		UserID:        pb.UserId,
+		BikeID:        pb.BikeId,
+		Location:      pb.Location,
+		Timestamp:     pb.Timestamp.AsTime(),
+		Pass:          pb.Pass,
+	}
+	for name, t := range eventTypes {
+		if t == pb.Type {
+			event.EventType = name
+		}
+	}
+	return event
+}
@@ -0,0 +1,150 @@
+package rentevents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/suite"
+)
+
+// RentEventsTestSuite - тестовый набор для контракта событий аренды
+type RentEventsTestSuite struct {
+	suite.Suite
+	event RentEvent
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *RentEventsTestSuite) SetupTest() {
+	suite.event = RentEvent{
+		RentID:    "rent-1",
+		UserID:    "user-1",
+		BikeID:    "bike-1",
+		EventType: End,
+		Location:  "Park",
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Pass:      "month",
+	}
+}
+
+func (suite *RentEventsTestSuite) header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// TestRoundTrip - событие одинаково читается в обоих форматах
+func (suite *RentEventsTestSuite) TestRoundTrip() {
+	for _, encoding := range []Encoding{Protobuf, JSON} {
+		msg, err := NewMessage("bike-rent-events", suite.event, encoding)
+		suite.Require().NoError(err)
+
+		decoded, err := Decode(msg)
+		suite.Require().NoError(err, encoding)
+
+		expected := suite.event
+		expected.ID = EventID("rent-1", End)
+		expected.SchemaVersion = SchemaVersion
+		suite.Equal(expected, decoded, encoding)
+		suite.Equal("bike-rent-events", msg.Topic)
+		suite.Equal([]byte("rent-1"), msg.Key)
+		suite.Equal("1", suite.header(msg, HeaderSchemaVersion))
+		suite.Equal(expected.ID, suite.header(msg, HeaderEventID))
+	}
+}
+
+// TestContentTypeHeader - заголовок content-type соответствует формату
+func (suite *RentEventsTestSuite) TestContentTypeHeader() {
+	msg, err := NewMessage("t", suite.event, Protobuf)
+	suite.Require().NoError(err)
+	suite.Equal(ContentTypeProtobuf, suite.header(msg, HeaderContentType))
+
+	msg, err = NewMessage("t", suite.event, JSON)
+	suite.Require().NoError(err)
+	suite.Equal(ContentTypeJSON, suite.header(msg, HeaderContentType))
+}
+
+// TestEventID - повторная публикация события получает тот же ID, start и end - разные
+func (suite *RentEventsTestSuite) TestEventID() {
+	suite.Equal(EventID("rent-1", Start), EventID("rent-1", Start))
+	suite.NotEqual(EventID("rent-1", Start), EventID("rent-1", End))
+	suite.NotEqual(EventID("rent-1", Start), EventID("rent-2", Start))
+
+	suite.event.ID = "custom"
+	msg, err := NewMessage("t", suite.event, Protobuf)
+	suite.Require().NoError(err)
+	suite.Equal("custom", suite.header(msg, HeaderEventID))
+}
+
+// TestPauseEventID - каждая пауза аренды получает свой ID, повтор той же паузы - тот же
+func (suite *RentEventsTestSuite) TestPauseEventID() {
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	pause := RentEvent{RentID: "rent-1", EventType: Pause, Timestamp: at}
+
+	first, err := NewMessage("t", pause, Protobuf)
+	suite.Require().NoError(err)
+	again, err := NewMessage("t", pause, JSON)
+	suite.Require().NoError(err)
+	pause.Timestamp = at.Add(time.Hour)
+	second, err := NewMessage("t", pause, Protobuf)
+	suite.Require().NoError(err)
+
+	suite.Equal(suite.header(first, HeaderEventID), suite.header(again, HeaderEventID))
+	suite.NotEqual(suite.header(first, HeaderEventID), suite.header(second, HeaderEventID))
+
+	decoded, err := Decode(second)
+	suite.Require().NoError(err)
+	suite.Equal(Pause, decoded.EventType)
+}
+
+// TestDecode_Legacy - сообщение без заголовков читается как старый JSON
+func (suite *RentEventsTestSuite) TestDecode_Legacy() {
+	msg := kafka.Message{Value: []byte(`{"rent_id":"rent-1","user_id":"user-1","bike_id":"bike-1","event_type":"start","timestamp":"2024-01-15T10:00:00Z"}`)}
+
+	event, err := Decode(msg)
+
+	suite.Require().NoError(err)
+	suite.Equal(Start, event.EventType)
+	suite.Equal("rent-1", event.RentID)
+	suite.Empty(event.ID)
+	suite.Zero(event.SchemaVersion)
+}
+
+// TestDecode_NewerSchema - событие более новой версии схемы отклоняется
+func (suite *RentEventsTestSuite) TestDecode_NewerSchema() {
+	msg, err := NewMessage("t", suite.event, Protobuf)
+	suite.Require().NoError(err)
+	msg.Headers = append(msg.Headers[:1], kafka.Header{Key: HeaderSchemaVersion, Value: []byte("2")})
+
+	_, err = Decode(msg)
+
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "unsupported schema version 2")
+}
+
+// TestDecode_UnknownContentType - неизвестный формат возвращает ошибку
+func (suite *RentEventsTestSuite) TestDecode_UnknownContentType() {
+	msg := kafka.Message{
+		Value:   []byte("{}"),
+		Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte("application/avro")}},
+	}
+
+	_, err := Decode(msg)
+
+	suite.Error(err)
+}
+
+// TestNewMessage_UnknownEncoding - неизвестная кодировка не публикуется
+func (suite *RentEventsTestSuite) TestNewMessage_UnknownEncoding() {
+	_, err := NewMessage("t", suite.event, Encoding("avro"))
+
+	suite.Error(err)
+}
+
+// TestRentEventsTestSuite - запуск тестового набора
+func TestRentEventsTestSuite(t *testing.T) {
+	suite.Run(t, new(RentEventsTestSuite))
+}
@@ -0,0 +1,39 @@
+-- Append-only log of every state-changing rent-service RPC. Safe to run
+-- again on an existing database.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    actor VARCHAR(200) NOT NULL,
+    peer VARCHAR(200) NOT NULL DEFAULT '',
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(100) NOT NULL DEFAULT '',
+    before_state JSONB,
+    after_state JSONB,
+    request_id VARCHAR(100) NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+-- The authenticated caller that vouched for the actor. Logs created before
+-- have no such column; their rows keep an empty peer.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS peer VARCHAR(200) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (target_id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_no_change ON audit_log;
+CREATE TRIGGER audit_log_no_change
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -20,7 +20,7 @@ SELECT
     b.status as bike_status
 FROM rents r
 JOIN bikes b ON r.bike_id = b.id
-WHERE r.status = 'active'
+WHERE r.status IN ('active', 'paused')
 ORDER BY r.start_time DESC;
 
 -- 2. ПОСМОТРЕТЬ СТАТУС ВСЕХ ВЕЛОСИПЕДОВ
@@ -34,12 +34,26 @@ SELECT
 FROM bikes
 ORDER BY name;
 
--- 3. ЗАВЕРШИТЬ ВСЕ АКТИВНЫЕ АРЕНДЫ
+-- 3. ЗАВЕРШИТЬ ВСЕ АКТИВНЫЕ И ПРИОСТАНОВЛЕННЫЕ АРЕНДЫ
 -- --------------------------------------------
+-- ВНИМАНИЕ: событие end не публикуется в Kafka, счётчик активных аренд
+-- в stats-service останется завышенным. Для рабочих данных используйте
+-- `bikectl rents end` или rent.stale_rents.auto_close в config.yaml.
+-- Событие ended добавляется в историю аренды, иначе `bikectl rents rebuild`
+-- вернёт аренды в статус active.
+-- Холды в кошельках при этом не снимаются и плата не списывается.
+-- Пауза приостановленной аренды длится до её завершения, как при EndRent.
+INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, reason)
+SELECT id, 'ended', user_id, bike_id, 'cleanup-db'
+FROM rents
+WHERE status IN ('active', 'paused');
+
 UPDATE rents 
 SET status = 'completed', 
-    end_time = NOW() 
-WHERE status = 'active';
+    end_time = NOW(),
+    paused_seconds = paused_seconds + COALESCE(FLOOR(EXTRACT(EPOCH FROM NOW()::timestamp - paused_at)), 0)::bigint,
+    paused_at = NULL
+WHERE status IN ('active', 'paused');
 
 -- 4. СБРОСИТЬ ВСЕ ВЕЛОСИПЕДЫ В СТАТУС "AVAILABLE"
 -- --------------------------------------------
@@ -48,7 +62,7 @@ SET status = 'available';
 
 -- 5. ПОЛНАЯ ОЧИСТКА (осторожно! удаляет все данные)
 -- --------------------------------------------
--- TRUNCATE TABLE rents CASCADE;
+-- TRUNCATE TABLE rents, rent_lifecycle_events CASCADE;
 -- DELETE FROM bikes;
 -- INSERT INTO bikes (name, status, location) VALUES
 --     ('Bike 1', 'available', 'Location A'),
@@ -59,6 +73,9 @@ SET status = 'available';
 
 -- 6. ЗАВЕРШИТЬ КОНКРЕТНУЮ АРЕНДУ ПО ID
 -- --------------------------------------------
+-- Лучше `bikectl rents end -reason R <rent-id>`: он пишет историю и событие в Kafka.
+-- INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, reason)
+-- SELECT id, 'ended', user_id, bike_id, 'cleanup-db' FROM rents WHERE id = 'YOUR_RENT_ID_HERE';
 -- UPDATE rents 
 -- SET status = 'completed', end_time = NOW() 
 -- WHERE id = 'YOUR_RENT_ID_HERE';
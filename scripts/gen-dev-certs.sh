@@ -0,0 +1,10 @@
+#!/bin/bash
+
+# Генерация локального CA и сертификатов для TLS/mTLS (только для разработки)
+# Использование: ./scripts/gen-dev-certs.sh [каталог]
+
+cd "$(dirname "$0")/.." || exit
+
+OUT=${1:-certs}
+
+go run ./tlsutil/cmd -out "$OUT"
@@ -8,5 +8,10 @@ protoc -I ./rent-service/proto \
   --go-grpc_out=./rent-service/proto/rent --go-grpc_opt=paths=source_relative \
   ./rent-service/proto/rent.proto
 
+# Генерация контракта событий аренды в Kafka
+protoc -I ./rentevents/proto \
+  --go_out=./rentevents/eventpb --go_opt=paths=source_relative \
+  ./rentevents/proto/rent_event.proto
+
 echo "Proto files generated successfully"
 
@@ -12,7 +12,9 @@ CREATE TABLE IF NOT EXISTS rents (
     bike_id UUID REFERENCES bikes(id),
     start_time TIMESTAMP NOT NULL,
     end_time TIMESTAMP,
-    status VARCHAR(20) NOT NULL DEFAULT 'active'
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    paused_at TIMESTAMP,
+    paused_seconds BIGINT NOT NULL DEFAULT 0
 );
 
 -- Insert sample bikes
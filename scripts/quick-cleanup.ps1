@@ -17,7 +17,7 @@ SELECT
     r.status as rent_status
 FROM rents r
 JOIN bikes b ON r.bike_id = b.id
-WHERE r.status = 'active';
+WHERE r.status IN ('active', 'paused');
 "@
         Write-Host ""
         Write-Host "🚲 Статус всех велосипедов:" -ForegroundColor Cyan
@@ -31,9 +31,10 @@ WHERE r.status = 'active';
     }
     
     "reset" {
-        Write-Host "🔄 Завершаю все активные аренды и освобождаю велосипеды..." -ForegroundColor Yellow
+        Write-Host "🔄 Завершаю все активные и приостановленные аренды и освобождаю велосипеды..." -ForegroundColor Yellow
         docker exec postgres psql -U user -d bikerent -c @"
-UPDATE rents SET status = 'completed', end_time = NOW() WHERE status = 'active';
+INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, reason) SELECT id, 'ended', user_id, bike_id, 'quick-cleanup reset' FROM rents WHERE status IN ('active', 'paused');
+UPDATE rents SET status = 'completed', end_time = NOW(), paused_seconds = paused_seconds + COALESCE(FLOOR(EXTRACT(EPOCH FROM NOW()::timestamp - paused_at)), 0)::bigint, paused_at = NULL WHERE status IN ('active', 'paused');
 UPDATE bikes SET status = 'available';
 "@
         Write-Host "✅ Готово! Все велосипеды освобождены." -ForegroundColor Green
@@ -42,7 +43,7 @@ UPDATE bikes SET status = 'available';
     "full" {
         Write-Host "🗑️  ПОЛНАЯ ОЧИСТКА: удаляю все аренды и сбрасываю велосипеды..." -ForegroundColor Red
         docker exec postgres psql -U user -d bikerent -c @"
-TRUNCATE TABLE rents CASCADE;
+TRUNCATE TABLE rents, rent_lifecycle_events CASCADE;
 UPDATE bikes SET status = 'available';
 "@
         Write-Host "✅ База данных полностью очищена!" -ForegroundColor Green
@@ -71,7 +72,7 @@ UPDATE bikes SET status = 'available';
         Write-Host ""
         Write-Host "1. Очистка PostgreSQL..." -ForegroundColor Yellow
         docker exec postgres psql -U user -d bikerent -c @"
-TRUNCATE TABLE rents CASCADE;
+TRUNCATE TABLE rents, rent_lifecycle_events CASCADE;
 UPDATE bikes SET status = 'available';
 "@
         Write-Host "✅ PostgreSQL очищен" -ForegroundColor Green
@@ -17,7 +17,7 @@ case $COMMAND in
         r.status as rent_status
       FROM rents r
       JOIN bikes b ON r.bike_id = b.id
-      WHERE r.status = 'active';
+      WHERE r.status IN ('active', 'paused');
     "
     echo ""
     echo "🚲 Статус всех велосипедов:"
@@ -33,9 +33,10 @@ case $COMMAND in
     ;;
     
   reset)
-    echo "🔄 Завершаю все активные аренды и освобождаю велосипеды..."
+    echo "🔄 Завершаю все активные и приостановленные аренды и освобождаю велосипеды..."
     docker exec postgres psql -U user -d bikerent -c "
-      UPDATE rents SET status = 'completed', end_time = NOW() WHERE status = 'active';
+      INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, reason) SELECT id, 'ended', user_id, bike_id, 'quick-cleanup reset' FROM rents WHERE status IN ('active', 'paused');
+      UPDATE rents SET status = 'completed', end_time = NOW(), paused_seconds = paused_seconds + COALESCE(FLOOR(EXTRACT(EPOCH FROM NOW()::timestamp - paused_at)), 0)::bigint, paused_at = NULL WHERE status IN ('active', 'paused');
       UPDATE bikes SET status = 'available';
     "
     echo "✅ Готово! Все велосипеды освобождены."
@@ -44,7 +45,7 @@ case $COMMAND in
   full)
     echo "🗑️  ПОЛНАЯ ОЧИСТКА: удаляю все аренды и сбрасываю велосипеды..."
     docker exec postgres psql -U user -d bikerent -c "
-      TRUNCATE TABLE rents CASCADE;
+      TRUNCATE TABLE rents, rent_lifecycle_events CASCADE;
       UPDATE bikes SET status = 'available';
     "
     echo "✅ База данных полностью очищена!"
@@ -73,7 +74,7 @@ case $COMMAND in
     echo ""
     echo "1. Очистка PostgreSQL..."
     docker exec postgres psql -U user -d bikerent -c "
-      TRUNCATE TABLE rents CASCADE;
+      TRUNCATE TABLE rents, rent_lifecycle_events CASCADE;
       UPDATE bikes SET status = 'available';
     "
     echo "✅ PostgreSQL очищен"
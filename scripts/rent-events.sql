@@ -0,0 +1,48 @@
+-- Lifecycle history of every rent. The rents table is a projection of
+-- these events and can be rebuilt from them with bikectl rents rebuild.
+-- Safe to run again on an existing database.
+CREATE TABLE IF NOT EXISTS rent_lifecycle_events (
+    seq BIGSERIAL PRIMARY KEY,
+    rent_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    user_id VARCHAR(100) NOT NULL,
+    bike_id UUID NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS rent_lifecycle_events_rent_idx ON rent_lifecycle_events (rent_id, seq);
+
+-- Pause state of the projection, added with pause/resume. Databases
+-- created before have rents without these columns.
+ALTER TABLE rents ADD COLUMN IF NOT EXISTS paused_at TIMESTAMP;
+ALTER TABLE rents ADD COLUMN IF NOT EXISTS paused_seconds BIGINT NOT NULL DEFAULT 0;
+
+-- Rows are never changed or deleted one by one. TRUNCATE stays allowed so
+-- development resets can clear rents together with their history.
+CREATE OR REPLACE FUNCTION rent_lifecycle_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'rent_lifecycle_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS rent_lifecycle_events_no_change ON rent_lifecycle_events;
+CREATE TRIGGER rent_lifecycle_events_no_change
+    BEFORE UPDATE OR DELETE ON rent_lifecycle_events
+    FOR EACH ROW EXECUTE FUNCTION rent_lifecycle_events_append_only();
+
+-- Backfill rents created before the event log existed: a started event and,
+-- for rents that are over, an ended or force_ended one.
+INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, occurred_at)
+SELECT r.id, 'started', r.user_id, r.bike_id, r.start_time
+FROM rents r
+WHERE r.bike_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM rent_lifecycle_events e WHERE e.rent_id = r.id)
+ORDER BY r.start_time;
+
+INSERT INTO rent_lifecycle_events (rent_id, type, user_id, bike_id, occurred_at)
+SELECT r.id, CASE r.status WHEN 'force_ended' THEN 'force_ended' ELSE 'ended' END, r.user_id, r.bike_id, r.end_time
+FROM rents r
+WHERE r.end_time IS NOT NULL AND r.bike_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM rent_lifecycle_events e WHERE e.rent_id = r.id AND e.type <> 'started')
+ORDER BY r.end_time;
@@ -0,0 +1,26 @@
+-- Rider accounts. id is the user_id of rents and wallets; riders without
+-- an account cannot rent while rent.users.require_registration is set.
+-- Safe to run again on an existing database.
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    -- Lower case, so the same address is registered once
+    email VARCHAR(254) NOT NULL UNIQUE,
+    phone VARCHAR(16) NOT NULL DEFAULT '',
+    status VARCHAR(30) NOT NULL DEFAULT 'pending_verification'
+        CHECK (status IN ('pending_verification', 'active', 'blocked')),
+    -- Why an operator last changed the status
+    status_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS users_status_idx ON users (status, created_at);
+
+-- Backfill riders who rented before accounts existed as active accounts, so
+-- they keep renting once registration is required. Their email is a
+-- placeholder on the reserved .invalid domain until they update the profile.
+INSERT INTO users (id, name, email, status, status_reason)
+SELECT r.user_id, r.user_id, 'rider-' || md5(r.user_id) || '@migrated.invalid', 'active', 'existing rider'
+FROM (SELECT DISTINCT user_id FROM rents) r
+ON CONFLICT DO NOTHING;
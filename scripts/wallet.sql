@@ -0,0 +1,153 @@
+-- Rider wallets. Amounts are in minor currency units (rent.wallet.currency).
+-- Safe to run again on an existing database.
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id VARCHAR(100) PRIMARY KEY,
+    balance BIGINT NOT NULL DEFAULT 0,
+    held BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Ledger of every wallet change: balance and held of a wallet are the sums
+-- of amount and held of its entries.
+CREATE TABLE IF NOT EXISTS wallet_entries (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL,
+    held BIGINT NOT NULL DEFAULT 0,
+    rent_id UUID,
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS wallet_entries_user_idx ON wallet_entries (user_id, id);
+-- A provider payment is credited once
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_entries_reference_idx ON wallet_entries (type, reference) WHERE reference <> '';
+
+-- Billing of each rent started with the wallet enabled: the hold and the
+-- tariff at the start, the fare once ended and how much was refunded.
+CREATE TABLE IF NOT EXISTS rent_charges (
+    rent_id UUID PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL,
+    held BIGINT NOT NULL,
+    unlock_fee BIGINT NOT NULL,
+    per_minute BIGINT NOT NULL,
+    paused_per_minute BIGINT NOT NULL,
+    fare BIGINT,
+    refunded BIGINT NOT NULL DEFAULT 0
+);
+
+-- Pass catalog. A pass is bought from the wallet and lasts duration from
+-- the purchase; rents started while it lasts get the first free_minutes of
+-- riding free and discount_percent off the rest of the fare.
+CREATE TABLE IF NOT EXISTS passes (
+    code VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    price BIGINT NOT NULL CHECK (price >= 0),
+    duration INTERVAL NOT NULL,
+    free_minutes INTEGER NOT NULL DEFAULT 0 CHECK (free_minutes >= 0),
+    discount_percent INTEGER NOT NULL DEFAULT 0 CHECK (discount_percent BETWEEN 0 AND 100),
+    -- Retired passes cannot be bought; subscriptions to them still apply
+    available BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO passes (code, name, price, duration, free_minutes, discount_percent) VALUES
+    ('day', 'Day pass', 29900, INTERVAL '24 hours', 30, 0),
+    ('month', 'Monthly plan', 99900, INTERVAL '30 days', 45, 50)
+ON CONFLICT (code) DO NOTHING;
+
+-- Passes bought by riders, with the terms of the pass at purchase. A rider
+-- has at most one pass that is not cancelled and not over.
+CREATE TABLE IF NOT EXISTS user_passes (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL,
+    pass_code VARCHAR(50) NOT NULL REFERENCES passes (code),
+    price BIGINT NOT NULL,
+    free_minutes INTEGER NOT NULL,
+    discount_percent INTEGER NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    cancelled_at TIMESTAMP,
+    refunded BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS user_passes_user_idx ON user_passes (user_id, ends_at);
+
+-- The pass a rent is billed with and its benefits
+ALTER TABLE rent_charges ADD COLUMN IF NOT EXISTS user_pass_id UUID REFERENCES user_passes (id);
+ALTER TABLE rent_charges ADD COLUMN IF NOT EXISTS free_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rent_charges ADD COLUMN IF NOT EXISTS discount_percent INTEGER NOT NULL DEFAULT 0;
+
+-- Promo codes riders apply to a billed rent at its start or end: kind
+-- percent takes value percent off the fare, fixed takes value minor units
+-- off it. Limits of 0 are unlimited and an empty locations list allows
+-- every location.
+CREATE TABLE IF NOT EXISTS promo_codes (
+    code VARCHAR(50) PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value BIGINT NOT NULL CHECK (value > 0),
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    locations TEXT[] NOT NULL DEFAULT '{}',
+    max_redemptions INTEGER NOT NULL DEFAULT 0 CHECK (max_redemptions >= 0),
+    max_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_per_user >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One promo code per rent, with its terms at redemption; discount is set
+-- when the fare is charged.
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    rent_id UUID PRIMARY KEY REFERENCES rent_charges (rent_id),
+    promo_code VARCHAR(50) NOT NULL REFERENCES promo_codes (code),
+    user_id VARCHAR(100) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    value BIGINT NOT NULL,
+    redeemed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    discount BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS promo_redemptions_code_idx ON promo_redemptions (promo_code, user_id);
+CREATE INDEX IF NOT EXISTS promo_redemptions_time_idx ON promo_redemptions (redeemed_at);
+
+-- VAT rate included in the prices a rent is billed by
+ALTER TABLE rent_charges ADD COLUMN IF NOT EXISTS tax_percent INTEGER NOT NULL DEFAULT 0;
+
+-- Last invoice number issued in each year. Invoices are numbered from this
+-- row within the charging transaction, so numbers have no gaps.
+CREATE TABLE IF NOT EXISTS invoice_counters (
+    year INTEGER PRIMARY KEY,
+    last BIGINT NOT NULL
+);
+
+-- Invoice of each charged rent: its number, the fare and the VAT included
+-- in it. Issued when the fare is captured, or on the first receipt request
+-- for rents charged before this table existed.
+CREATE TABLE IF NOT EXISTS invoices (
+    number VARCHAR(30) PRIMARY KEY,
+    rent_id UUID NOT NULL UNIQUE REFERENCES rent_charges (rent_id),
+    user_id VARCHAR(100) NOT NULL,
+    issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    total BIGINT NOT NULL,
+    tax BIGINT NOT NULL,
+    tax_percent INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS invoices_user_idx ON invoices (user_id, issued_at);
+
+CREATE OR REPLACE FUNCTION wallet_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallet_entries_no_change ON wallet_entries;
+CREATE TRIGGER wallet_entries_no_change
+    BEFORE UPDATE OR DELETE ON wallet_entries
+    FOR EACH ROW EXECUTE FUNCTION wallet_entries_append_only();
+
+DROP TRIGGER IF EXISTS wallet_entries_no_truncate ON wallet_entries;
+CREATE TRIGGER wallet_entries_no_truncate
+    BEFORE TRUNCATE ON wallet_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION wallet_entries_append_only();
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"bike-rental/config"
+	"bike-rental/health"
+	"bike-rental/logging"
 	"bike-rental/stats-service/internal/consumer"
 	"bike-rental/stats-service/internal/handlers"
 	"bike-rental/stats-service/internal/repository"
@@ -20,10 +22,17 @@ import (
 )
 
 func main() {
+	if code, ok := config.RunCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
 	cfg, err := config.LoadConfig(os.Getenv("CONFIG_PATH"))
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := logging.SetLevel(cfg.Log.Level); err != nil {
+		log.Fatalf("Failed to set log level: %v", err)
+	}
 
 	// Connect to Redis
 	rdb := redis.NewClient(&redis.Options{
@@ -40,30 +49,53 @@ func main() {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 
+	// Validated by LoadConfig
+	loc, err := cfg.Stats.Location()
+	if err != nil {
+		log.Fatalf("Failed to load stats time zone: %v", err)
+	}
+
 	// Initialize repository and service
 	repo := repository.NewRepository(rdb)
-	svc := service.NewService(repo)
+	svc := service.NewService(repo, loc)
 
 	// Start Kafka consumer
 	kafkaConsumer := consumer.NewConsumer(
 		cfg.Kafka.Brokers,
 		cfg.Kafka.Topics.RentEvents,
 		repo,
+		loc,
 	)
 
 	go kafkaConsumer.Start(context.Background())
 	defer kafkaConsumer.Stop()
 
+	// Apply live config changes
+	watcher := config.NewWatcher(os.Getenv("CONFIG_PATH"), cfg)
+	watcher.Subscribe(func(old, updated *config.Config) {
+		if updated.Log.Level != old.Log.Level {
+			logging.SetLevel(updated.Log.Level)
+		}
+		if updated.Kafka.Topics.RentEvents != old.Kafka.Topics.RentEvents {
+			kafkaConsumer.SetTopic(updated.Kafka.Topics.RentEvents)
+		}
+	})
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go watcher.Run(watchCtx)
+
 	// Setup HTTP server
 	r := chi.NewRouter()
 	handlers := handlers.NewHandlers(svc)
 	handlers.RegisterRoutes(r)
 
-	// Health check
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+	// Health checks
+	checker := health.NewChecker(2 * time.Second)
+	checker.Register("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
 	})
+	checker.Register("kafka", health.KafkaCheck(cfg.Kafka.Brokers))
+	checker.RegisterRoutes(r)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.StatsServicePort),
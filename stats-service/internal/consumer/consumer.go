@@ -2,45 +2,76 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"bike-rental/rentevents"
 	"bike-rental/stats-service/internal/repository"
 	"github.com/segmentio/kafka-go"
 )
 
 type Consumer struct {
-	reader  *kafka.Reader
+	brokers []string
 	repo    repository.Repository
+	loc     *time.Location
 	stopCh  chan struct{}
+
+	mu     sync.Mutex
+	reader *kafka.Reader
+	topic  string
 }
 
-type RentEvent struct {
-	RentID    string    `json:"rent_id"`
-	UserID    string    `json:"user_id"`
-	BikeID    string    `json:"bike_id"`
-	EventType string    `json:"event_type"`
-	Timestamp time.Time `json:"timestamp"`
+// NewConsumer creates a consumer that buckets statistics by day and hour in
+// loc, the business time zone, regardless of the zone events were sent in.
+func NewConsumer(brokers []string, topic string, repo repository.Repository, loc *time.Location) *Consumer {
+	return &Consumer{
+		brokers: brokers,
+		reader:  newReader(brokers, topic),
+		topic:   topic,
+		repo:    repo,
+		loc:     loc,
+		stopCh:  make(chan struct{}),
+	}
 }
 
-func NewConsumer(brokers []string, topic string, repo repository.Repository) *Consumer {
-	reader := kafka.NewReader(kafka.ReaderConfig{
+func newReader(brokers []string, topic string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  brokers,
 		Topic:    topic,
 		GroupID:  "stats-service-group",
 		MinBytes: 10e3,
 		MaxBytes: 10e6,
 	})
+}
 
-	return &Consumer{
-		reader: reader,
-		repo:   repo,
-		stopCh: make(chan struct{}),
+// SetTopic switches the consumer to another topic. The current reader is
+// closed, which makes the pending ReadMessage return, and the loop picks up
+// the new reader on its next iteration.
+func (c *Consumer) SetTopic(topic string) {
+	c.mu.Lock()
+	if topic == c.topic {
+		c.mu.Unlock()
+		return
+	}
+	old := c.reader
+	c.reader = newReader(c.brokers, topic)
+	c.topic = topic
+	c.mu.Unlock()
+
+	log.Printf("Switching Kafka consumer to topic %s", topic)
+	if err := old.Close(); err != nil {
+		log.Printf("Error closing reader: %v", err)
 	}
 }
 
+func (c *Consumer) currentReader() *kafka.Reader {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reader
+}
+
 func (c *Consumer) Start(ctx context.Context) {
 	log.Println("Starting Kafka consumer...")
 	
@@ -53,14 +84,14 @@ func (c *Consumer) Start(ctx context.Context) {
 			log.Println("Stop signal received, stopping consumer")
 			return
 		default:
-			msg, err := c.reader.ReadMessage(ctx)
+			msg, err := c.currentReader().ReadMessage(ctx)
 			if err != nil {
 				log.Printf("Error reading message: %v", err)
 				time.Sleep(time.Second)
 				continue
 			}
 
-			if err := c.processMessage(ctx, msg.Value); err != nil {
+			if err := c.processMessage(ctx, msg); err != nil {
 				log.Printf("Error processing message: %v", err)
 			}
 		}
@@ -69,36 +100,196 @@ func (c *Consumer) Start(ctx context.Context) {
 
 func (c *Consumer) Stop() {
 	close(c.stopCh)
-	if err := c.reader.Close(); err != nil {
+	if err := c.currentReader().Close(); err != nil {
 		log.Printf("Error closing reader: %v", err)
 	}
 }
 
-func (c *Consumer) processMessage(ctx context.Context, data []byte) error {
-	var event RentEvent
-	if err := json.Unmarshal(data, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal event: %w", err)
+// processMessage accepts both protobuf events and the legacy JSON ones,
+// so rent-service can switch kafka.event_encoding at any time. An event
+// whose ID was already processed, e.g. one published again by a replay, is
+// skipped; legacy events have no ID and are always applied.
+func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message) error {
+	event, err := rentevents.Decode(msg)
+	if err != nil {
+		return fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	if event.ID == "" {
+		return c.applyEvent(ctx, event)
+	}
+	claimed, err := c.repo.ClaimEvent(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to claim event: %w", err)
+	}
+	if !claimed {
+		log.Printf("Event %s (%s of rent %s) was already processed, skipping", event.ID, event.EventType, event.RentID)
+		return nil
+	}
+	if err := c.applyEvent(ctx, event); err != nil {
+		if releaseErr := c.repo.ReleaseEvent(ctx, event.ID); releaseErr != nil {
+			log.Printf("Error releasing event %s: %v", event.ID, releaseErr)
+		}
+		return err
 	}
+	return nil
+}
 
-	date := event.Timestamp.Format("2006-01-02")
+func (c *Consumer) applyEvent(ctx context.Context, event rentevents.RentEvent) error {
+	local := event.Timestamp.In(c.loc)
+	date := local.Format("2006-01-02")
+
+	if event.Location != "" && (event.EventType == rentevents.Start || event.EventType == rentevents.End) {
+		if err := c.repo.IncrementHeatmap(ctx, date, event.EventType, local.Hour(), event.Location); err != nil {
+			return fmt.Errorf("failed to increment heatmap: %w", err)
+		}
+	}
 
 	switch event.EventType {
-	case "start":
+	case rentevents.Start:
 		if err := c.repo.IncrementDailyRent(ctx, date); err != nil {
 			return fmt.Errorf("failed to increment daily rent: %w", err)
 		}
 		if err := c.repo.IncrementActiveRents(ctx); err != nil {
 			return fmt.Errorf("failed to increment active rents: %w", err)
 		}
-		// Note: location would need to come from bike data, for now we'll skip it
-		// In a real implementation, you'd fetch bike location from rent service
-		
-	case "end":
+		if err := c.repo.IncrementHourlyRent(ctx, date, local.Hour()); err != nil {
+			return fmt.Errorf("failed to increment hourly rent: %w", err)
+		}
+		// Events published before rent-service started sending the bike
+		// location have no location
+		if event.Location != "" {
+			if err := c.repo.IncrementLocationRent(ctx, date, event.Location); err != nil {
+				return fmt.Errorf("failed to increment location rent: %w", err)
+			}
+		}
+		if event.Pass != "" {
+			if err := c.repo.IncrementPassRent(ctx, date, event.Pass); err != nil {
+				return fmt.Errorf("failed to increment pass rent: %w", err)
+			}
+		}
+		start := repository.RentStart{
+			BikeID:    event.BikeID,
+			Location:  event.Location,
+			StartTime: event.Timestamp,
+		}
+		if err := c.repo.SaveRentStart(ctx, event.RentID, start); err != nil {
+			return fmt.Errorf("failed to save rent start: %w", err)
+		}
+
+	case rentevents.Pause:
+		paused, err := c.repo.SaveRentPause(ctx, event.RentID, event.Timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to save rent pause: %w", err)
+		}
+		if !paused {
+			log.Printf("Rent %s is already paused, skipping pause", event.RentID)
+		}
+
+	case rentevents.Resume:
+		if err := c.recordPause(ctx, event); err != nil {
+			return err
+		}
+
+	case rentevents.End:
 		if err := c.repo.DecrementActiveRents(ctx); err != nil {
 			return fmt.Errorf("failed to decrement active rents: %w", err)
 		}
+		// A rent ended while paused has no resume event
+		if err := c.recordPause(ctx, event); err != nil {
+			return err
+		}
+		if err := c.recordCompletedRent(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordPause ends the pause of the rent, if any, at the event and adds
+// its length to paused time, split across days like rented time.
+func (c *Consumer) recordPause(ctx context.Context, event rentevents.RentEvent) error {
+	pausedAt, err := c.repo.TakeRentPause(ctx, event.RentID)
+	if err != nil {
+		return fmt.Errorf("failed to load rent pause: %w", err)
+	}
+	if pausedAt == nil {
+		if event.EventType == rentevents.Resume {
+			log.Printf("No pause event for rent %s, skipping resume", event.RentID)
+		}
+		return nil
+	}
+
+	for _, day := range splitByDay(pausedAt.In(c.loc), event.Timestamp.In(c.loc)) {
+		if err := c.repo.AddPausedTime(ctx, day.date, day.duration); err != nil {
+			return fmt.Errorf("failed to record paused time: %w", err)
+		}
+	}
+	return nil
+}
+
+
+// recordCompletedRent pairs an end event with its start. The duration
+// belongs to the day the rent started; rented time is split across every
+// day the rent covers so utilization stays correct past midnight.
+func (c *Consumer) recordCompletedRent(ctx context.Context, event rentevents.RentEvent) error {
+	start, err := c.repo.GetRentStart(ctx, event.RentID)
+	if err != nil {
+		return fmt.Errorf("failed to load rent start: %w", err)
+	}
+	if start == nil {
+		log.Printf("No start event for rent %s, skipping duration", event.RentID)
+		return nil
+	}
+
+	from := start.StartTime.In(c.loc)
+	to := event.Timestamp.In(c.loc)
+	if to.Before(from) {
+		log.Printf("Rent %s ends before it starts, skipping duration", event.RentID)
+		return nil
+	}
+
+	location := start.Location
+	if location == "" {
+		location = event.Location
+	}
+	rent := repository.CompletedRent{
+		RentID:   event.RentID,
+		BikeID:   start.BikeID,
+		Location: location,
+		Duration: to.Sub(from),
+	}
+	var days []repository.RentedDay
+	for _, day := range splitByDay(from, to) {
+		days = append(days, repository.RentedDay{Date: day.date, Duration: day.duration})
+	}
+	// Like the duration, time ridden on a pass belongs to the start day.
+	// The start is only removed once everything is recorded.
+	if err := c.repo.CompleteRent(ctx, from.Format("2006-01-02"), rent, event.Pass, days); err != nil {
+		return fmt.Errorf("failed to record completed rent: %w", err)
 	}
 
 	return nil
 }
 
+type dayPart struct {
+	date     string
+	duration time.Duration
+}
+
+// splitByDay splits [from, to) at midnights in from's location.
+func splitByDay(from, to time.Time) []dayPart {
+	var parts []dayPart
+	for from.Before(to) {
+		year, month, day := from.Date()
+		midnight := time.Date(year, month, day+1, 0, 0, 0, 0, from.Location())
+		end := to
+		if midnight.Before(to) {
+			end = midnight
+		}
+		parts = append(parts, dayPart{date: from.Format("2006-01-02"), duration: end.Sub(from)})
+		from = end
+	}
+	return parts
+}
@@ -0,0 +1,303 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"bike-rental/rentevents"
+	"bike-rental/stats-service/internal/repository"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeRepository - in-memory хранилище для проверки обработки событий
+type fakeRepository struct {
+	repository.Repository
+	pending   map[string]repository.RentStart
+	completed map[string][]repository.CompletedRent
+	rented    map[string]time.Duration
+	daily     map[string]int64
+	heatmap   map[string]int64
+	pauses    map[string]time.Time
+	pausedFor map[string]time.Duration
+	passes    map[string]int64
+	passTime  map[string]time.Duration
+	processed map[string]bool
+
+	completeErr error
+}
+
+func (f *fakeRepository) IncrementDailyRent(ctx context.Context, date string) error {
+	f.daily[date]++
+	return nil
+}
+func (f *fakeRepository) IncrementActiveRents(ctx context.Context) error { return nil }
+func (f *fakeRepository) DecrementActiveRents(ctx context.Context) error { return nil }
+func (f *fakeRepository) IncrementHourlyRent(ctx context.Context, date string, hour int) error {
+	return nil
+}
+func (f *fakeRepository) IncrementLocationRent(ctx context.Context, date, location string) error {
+	return nil
+}
+
+func (f *fakeRepository) IncrementHeatmap(ctx context.Context, date, event string, hour int, location string) error {
+	f.heatmap[fmt.Sprintf("%s/%s/%d/%s", date, event, hour, location)]++
+	return nil
+}
+
+func (f *fakeRepository) SaveRentStart(ctx context.Context, rentID string, start repository.RentStart) error {
+	f.pending[rentID] = start
+	return nil
+}
+
+func (f *fakeRepository) GetRentStart(ctx context.Context, rentID string) (*repository.RentStart, error) {
+	start, ok := f.pending[rentID]
+	if !ok {
+		return nil, nil
+	}
+	return &start, nil
+}
+
+func (f *fakeRepository) SaveRentPause(ctx context.Context, rentID string, at time.Time) (bool, error) {
+	if _, ok := f.pauses[rentID]; ok {
+		return false, nil
+	}
+	f.pauses[rentID] = at
+	return true, nil
+}
+
+func (f *fakeRepository) TakeRentPause(ctx context.Context, rentID string) (*time.Time, error) {
+	at, ok := f.pauses[rentID]
+	if !ok {
+		return nil, nil
+	}
+	delete(f.pauses, rentID)
+	return &at, nil
+}
+
+func (f *fakeRepository) AddPausedTime(ctx context.Context, date string, d time.Duration) error {
+	f.pausedFor[date] += d
+	return nil
+}
+
+func (f *fakeRepository) CompleteRent(ctx context.Context, date string, rent repository.CompletedRent, pass string, days []repository.RentedDay) error {
+	if f.completeErr != nil {
+		return f.completeErr
+	}
+	f.completed[date] = append(f.completed[date], rent)
+	if pass != "" {
+		f.passTime[date+"/"+pass] += rent.Duration
+	}
+	for _, day := range days {
+		f.rented[day.Date] += day.Duration
+	}
+	delete(f.pending, rent.RentID)
+	return nil
+}
+
+func (f *fakeRepository) IncrementPassRent(ctx context.Context, date, pass string) error {
+	f.passes[date+"/"+pass]++
+	return nil
+}
+
+func (f *fakeRepository) ClaimEvent(ctx context.Context, eventID string) (bool, error) {
+	if f.processed[eventID] {
+		return false, nil
+	}
+	f.processed[eventID] = true
+	return true, nil
+}
+
+func (f *fakeRepository) ReleaseEvent(ctx context.Context, eventID string) error {
+	delete(f.processed, eventID)
+	return nil
+}
+
+// ConsumerTestSuite - тестовый набор для обработки событий аренды
+type ConsumerTestSuite struct {
+	suite.Suite
+	repo     *fakeRepository
+	consumer *Consumer
+	ctx      context.Context
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *ConsumerTestSuite) SetupTest() {
+	suite.repo = &fakeRepository{
+		pending:   make(map[string]repository.RentStart),
+		completed: make(map[string][]repository.CompletedRent),
+		rented:    make(map[string]time.Duration),
+		daily:     make(map[string]int64),
+		heatmap:   make(map[string]int64),
+		pauses:    make(map[string]time.Time),
+		pausedFor: make(map[string]time.Duration),
+		passes:    make(map[string]int64),
+		passTime:  make(map[string]time.Duration),
+		processed: make(map[string]bool),
+	}
+	suite.consumer = &Consumer{repo: suite.repo, loc: time.UTC}
+	suite.ctx = context.Background()
+}
+
+func (suite *ConsumerTestSuite) process(event rentevents.RentEvent) {
+	msg, err := rentevents.NewMessage("bike-rent-events", event, rentevents.Protobuf)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.consumer.processMessage(suite.ctx, msg))
+}
+
+// TestPairsStartAndEnd - длительность аренды считается по паре start/end
+func (suite *ConsumerTestSuite) TestPairsStartAndEnd() {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	suite.process(rentevents.RentEvent{RentID: "rent-1", BikeID: "bike-1", EventType: "start", Location: "Park", Timestamp: start})
+	suite.process(rentevents.RentEvent{RentID: "rent-1", BikeID: "bike-1", EventType: "end", Location: "Park", Timestamp: start.Add(25 * time.Minute)})
+
+	suite.Equal([]repository.CompletedRent{
+		{RentID: "rent-1", BikeID: "bike-1", Location: "Park", Duration: 25 * time.Minute},
+	}, suite.repo.completed["2024-01-15"])
+	suite.Empty(suite.repo.pending)
+}
+
+// TestPassUsage - аренды по абонементу и их время считаются по коду абонемента, без абонемента - нет
+func (suite *ConsumerTestSuite) TestPassUsage() {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	suite.process(rentevents.RentEvent{RentID: "rent-10", BikeID: "bike-1", EventType: "start", Timestamp: start, Pass: "month"})
+	suite.process(rentevents.RentEvent{RentID: "rent-10", BikeID: "bike-1", EventType: "end", Timestamp: start.Add(20 * time.Minute), Pass: "month"})
+	suite.process(rentevents.RentEvent{RentID: "rent-11", BikeID: "bike-2", EventType: "start", Timestamp: start})
+	suite.process(rentevents.RentEvent{RentID: "rent-11", BikeID: "bike-2", EventType: "end", Timestamp: start.Add(5 * time.Minute)})
+
+	suite.Equal(map[string]int64{"2024-01-15/month": 1}, suite.repo.passes)
+	suite.Equal(map[string]time.Duration{"2024-01-15/month": 20 * time.Minute}, suite.repo.passTime)
+}
+
+// TestPauseAndResume - время на паузе считается по паре pause/resume, повторная пауза не сбивает начало
+func (suite *ConsumerTestSuite) TestPauseAndResume() {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	suite.process(rentevents.RentEvent{RentID: "rent-8", BikeID: "bike-1", EventType: "start", Location: "Park", Timestamp: start})
+	suite.process(rentevents.RentEvent{RentID: "rent-8", BikeID: "bike-1", EventType: "pause", Location: "Park", Timestamp: start.Add(10 * time.Minute)})
+	suite.process(rentevents.RentEvent{RentID: "rent-8", BikeID: "bike-1", EventType: "pause", Location: "Park", Timestamp: start.Add(12 * time.Minute)})
+	suite.process(rentevents.RentEvent{RentID: "rent-8", BikeID: "bike-1", EventType: "resume", Location: "Park", Timestamp: start.Add(25 * time.Minute)})
+	suite.process(rentevents.RentEvent{RentID: "rent-8", BikeID: "bike-1", EventType: "end", Location: "Park", Timestamp: start.Add(40 * time.Minute)})
+
+	suite.Equal(map[string]time.Duration{"2024-01-15": 15 * time.Minute}, suite.repo.pausedFor)
+	suite.Empty(suite.repo.pauses)
+	suite.Equal(40*time.Minute, suite.repo.completed["2024-01-15"][0].Duration)
+	suite.NotContains(suite.repo.heatmap, "2024-01-15/pause/10/Park")
+}
+
+// TestEndWhilePaused - аренда, завершённая на паузе, закрывает паузу временем завершения
+func (suite *ConsumerTestSuite) TestEndWhilePaused() {
+	start := time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)
+	suite.process(rentevents.RentEvent{RentID: "rent-9", BikeID: "bike-1", EventType: "start", Timestamp: start})
+	suite.process(rentevents.RentEvent{RentID: "rent-9", BikeID: "bike-1", EventType: "pause", Timestamp: start.Add(30 * time.Minute)})
+	suite.process(rentevents.RentEvent{RentID: "rent-9", BikeID: "bike-1", EventType: "end", Timestamp: start.Add(90 * time.Minute)})
+
+	suite.Equal(map[string]time.Duration{
+		"2024-01-15": 30 * time.Minute,
+		"2024-01-16": 30 * time.Minute,
+	}, suite.repo.pausedFor)
+	suite.Empty(suite.repo.pauses)
+}
+
+// TestCompleteRentFailure - при ошибке Redis начало аренды сохраняется для повторного события
+func (suite *ConsumerTestSuite) TestCompleteRentFailure() {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	end := rentevents.RentEvent{RentID: "rent-12", BikeID: "bike-1", EventType: "end", Location: "Park", Timestamp: start.Add(30 * time.Minute)}
+	suite.process(rentevents.RentEvent{RentID: "rent-12", BikeID: "bike-1", EventType: "start", Location: "Park", Timestamp: start})
+
+	suite.repo.completeErr = fmt.Errorf("connection reset")
+	msg, err := rentevents.NewMessage("bike-rent-events", end, rentevents.Protobuf)
+	suite.Require().NoError(err)
+	suite.ErrorContains(suite.consumer.processMessage(suite.ctx, msg), "connection reset")
+	suite.Contains(suite.repo.pending, "rent-12")
+
+	suite.repo.completeErr = nil
+	suite.process(end)
+	suite.Equal(30*time.Minute, suite.repo.completed["2024-01-15"][0].Duration)
+	suite.Empty(suite.repo.pending)
+}
+
+// TestDuplicateEvent - повторно доставленное событие с тем же ID не учитывается дважды
+func (suite *ConsumerTestSuite) TestDuplicateEvent() {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	event := rentevents.RentEvent{RentID: "rent-13", BikeID: "bike-1", EventType: "start", Location: "Park", Timestamp: start}
+	suite.process(event)
+	suite.process(event)
+	suite.process(rentevents.RentEvent{RentID: "rent-13", BikeID: "bike-1", EventType: "end", Location: "Park", Timestamp: start.Add(10 * time.Minute)})
+	suite.process(rentevents.RentEvent{RentID: "rent-13", BikeID: "bike-1", EventType: "end", Location: "Park", Timestamp: start.Add(10 * time.Minute)})
+
+	suite.Equal(map[string]int64{"2024-01-15": 1}, suite.repo.daily)
+	suite.Equal(map[string]int64{"2024-01-15/start/10/Park": 1, "2024-01-15/end/10/Park": 1}, suite.repo.heatmap)
+	suite.Len(suite.repo.completed["2024-01-15"], 1)
+}
+
+// TestEndWithoutStart - повторный или потерянный start не ломает обработку
+func (suite *ConsumerTestSuite) TestEndWithoutStart() {
+	suite.process(rentevents.RentEvent{RentID: "rent-2", BikeID: "bike-1", EventType: "end", Timestamp: time.Now()})
+
+	suite.Empty(suite.repo.completed)
+}
+
+// TestHeatmapUsesConfiguredTimeZone - часы тепловой карты считаются в заданном часовом поясе
+func (suite *ConsumerTestSuite) TestHeatmapUsesConfiguredTimeZone() {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	suite.Require().NoError(err)
+	suite.consumer.loc = loc
+
+	start := time.Date(2024, 1, 15, 22, 30, 0, 0, time.UTC)
+	suite.process(rentevents.RentEvent{RentID: "rent-3", BikeID: "bike-1", EventType: "start", Location: "Park", Timestamp: start})
+
+	suite.Equal(map[string]int64{"2024-01-16/start/1/Park": 1}, suite.repo.heatmap)
+}
+
+// TestRentSpanningMidnight - длительность относится ко дню начала, время в аренде делится по дням
+func (suite *ConsumerTestSuite) TestRentSpanningMidnight() {
+	start := time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC)
+	suite.process(rentevents.RentEvent{RentID: "rent-4", BikeID: "bike-1", EventType: "start", Location: "Park", Timestamp: start})
+	suite.process(rentevents.RentEvent{RentID: "rent-4", BikeID: "bike-1", EventType: "end", Location: "Park", Timestamp: start.Add(2 * time.Hour)})
+
+	suite.Len(suite.repo.completed["2024-01-15"], 1)
+	suite.Equal(2*time.Hour, suite.repo.completed["2024-01-15"][0].Duration)
+	suite.Equal(map[string]time.Duration{
+		"2024-01-15": 30 * time.Minute,
+		"2024-01-16": 90 * time.Minute,
+	}, suite.repo.rented)
+}
+
+// TestDailyBucketingUsesBusinessTimeZone - день определяется часовым поясом бизнеса, а не временем события
+func (suite *ConsumerTestSuite) TestDailyBucketingUsesBusinessTimeZone() {
+	loc, err := time.LoadLocation("America/New_York")
+	suite.Require().NoError(err)
+	suite.consumer.loc = loc
+
+	// 02:00 UTC is still the previous evening in New York
+	suite.process(rentevents.RentEvent{RentID: "rent-5", BikeID: "bike-1", EventType: "start", Timestamp: time.Date(2024, 1, 16, 2, 0, 0, 0, time.UTC)})
+
+	suite.Equal(map[string]int64{"2024-01-15": 1}, suite.repo.daily)
+}
+
+// TestLegacyJSONEvent - события без заголовков от старых версий rent-service по-прежнему обрабатываются
+func (suite *ConsumerTestSuite) TestLegacyJSONEvent() {
+	msg := kafka.Message{Value: []byte(`{"rent_id":"rent-6","user_id":"user1","bike_id":"bike-1","event_type":"start","location":"Park","timestamp":"2024-01-15T10:00:00Z"}`)}
+
+	suite.Require().NoError(suite.consumer.processMessage(suite.ctx, msg))
+
+	suite.Equal(map[string]int64{"2024-01-15": 1}, suite.repo.daily)
+	suite.Equal("Park", suite.repo.pending["rent-6"].Location)
+}
+
+// TestUnknownContentType - сообщение в неизвестном формате возвращает ошибку и не меняет статистику
+func (suite *ConsumerTestSuite) TestUnknownContentType() {
+	msg := kafka.Message{
+		Value:   []byte("rent-7"),
+		Headers: []kafka.Header{{Key: rentevents.HeaderContentType, Value: []byte("text/plain")}},
+	}
+
+	suite.Error(suite.consumer.processMessage(suite.ctx, msg))
+	suite.Empty(suite.repo.daily)
+}
+
+func TestConsumerTestSuite(t *testing.T) {
+	suite.Run(t, new(ConsumerTestSuite))
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"bike-rental/stats-service/internal/service"
 	"github.com/go-chi/chi/v5"
@@ -18,39 +21,190 @@ func NewHandlers(svc service.Service) *Handlers {
 }
 
 func (h *Handlers) GetDailyStats(w http.ResponseWriter, r *http.Request) {
-	date := r.URL.Query().Get("date")
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+	stats, err := h.service.GetDailyStats(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		writeError(w, err)
+		return
 	}
 
-	count, err := h.service.GetDailyStats(r.Context(), date)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetActiveRents(w http.ResponseWriter, r *http.Request) {
+	count, err := h.service.GetActiveRents(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	paused, err := h.service.GetPausedRents(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Paused rents are still active and counted in active_rents
 	response := map[string]interface{}{
-		"date": date,
-		"count": count,
+		"active_rents": count,
+		"paused_rents": paused,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *Handlers) GetActiveRents(w http.ResponseWriter, r *http.Request) {
-	count, err := h.service.GetActiveRents(r.Context())
+func (h *Handlers) GetRangeStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.service.GetRangeStats(r.Context(), q.Get("from"), q.Get("to"), q.Get("granularity"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	response := map[string]interface{}{
-		"active_rents": count,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetHourlyStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetHourlyStats(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		writeError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetLocationBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.service.GetLocationBreakdown(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetPassStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.service.GetPassStats(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetDurationStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.service.GetDurationStats(r.Context(), q.Get("from"), q.Get("to"), q.Get("location"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetUtilization(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	stats, err := h.service.GetUtilization(r.Context(), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handlers) GetTopStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	stats, err := h.service.GetTopStats(r.Context(), q.Get("from"), q.Get("to"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GetHeatmap returns JSON by default and CSV with format=csv or an
+// Accept: text/csv header.
+func (h *Handlers) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	heatmap, err := h.service.GetHeatmap(r.Context(), q.Get("from"), q.Get("to"), q.Get("location"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="heatmap.csv"`)
+		writeHeatmapCSV(w, heatmap)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(heatmap)
+}
+
+// writeHeatmapCSV writes one row per location, weekday and hour.
+func writeHeatmapCSV(w http.ResponseWriter, heatmap *service.Heatmap) {
+	out := csv.NewWriter(w)
+	out.Write([]string{"location", "weekday", "hour", "starts", "ends", "net"})
+	for _, l := range heatmap.Locations {
+		for day, name := range heatmap.Weekdays {
+			for hour := 0; hour < 24; hour++ {
+				out.Write([]string{
+					l.Location,
+					name,
+					strconv.Itoa(hour),
+					strconv.FormatInt(l.Starts[day][hour], 10),
+					strconv.FormatInt(l.Ends[day][hour], 10),
+					strconv.FormatInt(l.Net[day][hour], 10),
+				})
+			}
+		}
+	}
+	out.Flush()
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if errors.Is(err, service.ErrInvalidArgument) {
+		code = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), code)
 }
 
 func (h *Handlers) RefreshStats(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +222,14 @@ func (h *Handlers) RefreshStats(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) RegisterRoutes(r *chi.Mux) {
 	r.Get("/internal/stats/daily", h.GetDailyStats)
 	r.Get("/internal/stats/active", h.GetActiveRents)
+	r.Get("/internal/stats/range", h.GetRangeStats)
+	r.Get("/internal/stats/hourly", h.GetHourlyStats)
+	r.Get("/internal/stats/locations", h.GetLocationBreakdown)
+	r.Get("/internal/stats/durations", h.GetDurationStats)
+	r.Get("/internal/stats/utilization", h.GetUtilization)
+	r.Get("/internal/stats/top", h.GetTopStats)
+	r.Get("/internal/stats/heatmap", h.GetHeatmap)
+	r.Get("/internal/stats/passes", h.GetPassStats)
 	r.Post("/admin/refresh-stats", h.RefreshStats)
 }
 
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pendingRentTTL bounds how long an unmatched start event is kept. Rents
+// that end later than this are not included in duration analytics.
+const pendingRentTTL = 7 * 24 * time.Hour
+
+// RentStart is what the consumer remembers about a started rent until the
+// matching end event arrives.
+type RentStart struct {
+	BikeID    string
+	Location  string
+	StartTime time.Time
+}
+
+// CompletedRent is a rent with both start and end events.
+type CompletedRent struct {
+	RentID   string
+	BikeID   string
+	Location string
+	Duration time.Duration
+}
+
+func (r *repository) SaveRentStart(ctx context.Context, rentID string, start RentStart) error {
+	key := pendingKey(rentID)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key,
+		"bike_id", start.BikeID,
+		"location", start.Location,
+		"start_time", start.StartTime.Format(time.RFC3339Nano),
+	)
+	pipe.Expire(ctx, key, pendingRentTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RentedDay is the part of a completed rent that falls on one day.
+type RentedDay struct {
+	Date     string
+	Duration time.Duration
+}
+
+// GetRentStart returns the start of rentID. It returns nil if the start is
+// unknown, e.g. when the end event is delivered twice.
+func (r *repository) GetRentStart(ctx context.Context, rentID string) (*RentStart, error) {
+	vals, err := r.client.HGetAll(ctx, pendingKey(rentID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vals) == 0 {
+		return nil, nil
+	}
+
+	startTime, err := time.Parse(time.RFC3339Nano, vals["start_time"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time for rent %s: %w", rentID, err)
+	}
+
+	return &RentStart{
+		BikeID:    vals["bike_id"],
+		Location:  vals["location"],
+		StartTime: startTime,
+	}, nil
+}
+
+// CompleteRent records a rent that started on date and removes its start
+// in one transaction: on failure nothing is recorded and the start is kept
+// for a replayed end event.
+//
+// The duration belongs to date and is kept in sorted sets keyed by rent
+// ID, so the same rent recorded twice is counted once for percentiles.
+// Rented time is added to the bike and location usage of every day in
+// days, and to the usage of pass, if any, on date.
+func (r *repository) CompleteRent(ctx context.Context, date string, rent CompletedRent, pass string, days []RentedDay) error {
+	seconds := rent.Duration.Seconds()
+
+	pipe := r.client.TxPipeline()
+	pipe.ZAdd(ctx, durationKey(date, ""), redis.Z{Score: seconds, Member: rent.RentID})
+	if rent.Location != "" {
+		pipe.ZAdd(ctx, durationKey(date, rent.Location), redis.Z{Score: seconds, Member: rent.RentID})
+	}
+	if rent.BikeID != "" {
+		pipe.ZIncrBy(ctx, fmt.Sprintf("stats:bike_rents:%s", date), 1, rent.BikeID)
+	}
+	if pass != "" {
+		pipe.ZIncrBy(ctx, fmt.Sprintf("stats:pass_usage:%s", date), seconds, pass)
+	}
+	for _, day := range days {
+		if rent.Location != "" {
+			pipe.ZIncrBy(ctx, fmt.Sprintf("stats:location_usage:%s", day.Date), day.Duration.Seconds(), rent.Location)
+		}
+		if rent.BikeID != "" {
+			pipe.ZIncrBy(ctx, fmt.Sprintf("stats:bike_usage:%s", day.Date), day.Duration.Seconds(), rent.BikeID)
+		}
+	}
+	pipe.Del(ctx, pendingKey(rent.RentID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetDurations returns the sorted rent durations in seconds for each date,
+// optionally limited to one location.
+func (r *repository) GetDurations(ctx context.Context, dates []string, location string) ([][]float64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, len(dates))
+	for i, date := range dates {
+		cmds[i] = pipe.ZRangeWithScores(ctx, durationKey(date, location), 0, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make([][]float64, len(dates))
+	for i, cmd := range cmds {
+		for _, z := range cmd.Val() {
+			result[i] = append(result[i], z.Score)
+		}
+	}
+
+	return result, nil
+}
+
+// GetBikeUsageRange returns rented seconds per bike for each date.
+func (r *repository) GetBikeUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return r.scoresRange(ctx, "stats:bike_usage", dates)
+}
+
+// GetBikeRentsRange returns completed rents per bike for each date.
+func (r *repository) GetBikeRentsRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return r.scoresRange(ctx, "stats:bike_rents", dates)
+}
+
+// GetLocationUsageRange returns rented seconds per location for each date.
+func (r *repository) GetLocationUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return r.scoresRange(ctx, "stats:location_usage", dates)
+}
+
+func (r *repository) scoresRange(ctx context.Context, prefix string, dates []string) ([]map[string]float64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, len(dates))
+	for i, date := range dates {
+		cmds[i] = pipe.ZRangeWithScores(ctx, fmt.Sprintf("%s:%s", prefix, date), 0, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make([]map[string]float64, len(dates))
+	for i, cmd := range cmds {
+		result[i] = make(map[string]float64)
+		for _, z := range cmd.Val() {
+			member, ok := z.Member.(string)
+			if !ok {
+				continue
+			}
+			result[i][member] = z.Score
+		}
+	}
+
+	return result, nil
+}
+
+func pendingKey(rentID string) string {
+	return fmt.Sprintf("stats:pending:%s", rentID)
+}
+
+func durationKey(date, location string) string {
+	if location == "" {
+		return fmt.Sprintf("stats:durations:%s", date)
+	}
+	return fmt.Sprintf("stats:durations:%s:location:%s", date, location)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// processedEventTTL is how long a processed event ID is remembered. It
+// covers Kafka's default retention of seven days, so any event still on
+// the topic is recognized when it is delivered or replayed again.
+const processedEventTTL = 7 * 24 * time.Hour
+
+func processedEventKey(eventID string) string {
+	return fmt.Sprintf("stats:events:%s", eventID)
+}
+
+// ClaimEvent marks eventID as processed. It reports false if the event
+// was already claimed, e.g. when a replay publishes it again.
+func (r *repository) ClaimEvent(ctx context.Context, eventID string) (bool, error) {
+	return r.client.SetNX(ctx, processedEventKey(eventID), 1, processedEventTTL).Result()
+}
+
+// ReleaseEvent forgets eventID, so an event that failed to apply is
+// processed when it is delivered again.
+func (r *repository) ReleaseEvent(ctx context.Context, eventID string) error {
+	return r.client.Del(ctx, processedEventKey(eventID)).Err()
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IncrementPassRent counts a rent started on date with pass.
+func (r *repository) IncrementPassRent(ctx context.Context, date, pass string) error {
+	return r.client.HIncrBy(ctx, fmt.Sprintf("stats:passes:%s", date), pass, 1).Err()
+}
+
+// GetPassRentsRange returns rents started per pass for each date.
+func (r *repository) GetPassRentsRange(ctx context.Context, dates []string) ([]map[string]int64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(dates))
+	for i, date := range dates {
+		cmds[i] = pipe.HGetAll(ctx, fmt.Sprintf("stats:passes:%s", date))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make([]map[string]int64, len(dates))
+	for i, cmd := range cmds {
+		result[i] = parseCounts(cmd.Val())
+	}
+
+	return result, nil
+}
+
+// GetPassUsageRange returns rented seconds per pass for each date.
+func (r *repository) GetPassUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return r.scoresRange(ctx, "stats:pass_usage", dates)
+}
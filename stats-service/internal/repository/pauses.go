@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pausedRentsKey maps the ID of every paused rent to when its pause
+// started, so its length is the number of paused rents.
+const pausedRentsKey = "stats:paused_rents"
+
+func pausedTimeKey(date string) string {
+	return fmt.Sprintf("stats:paused_time:%s", date)
+}
+
+// SaveRentPause remembers that rentID paused at. It reports false if the
+// rent is already paused, e.g. when the pause event is delivered twice.
+func (r *repository) SaveRentPause(ctx context.Context, rentID string, at time.Time) (bool, error) {
+	return r.client.HSetNX(ctx, pausedRentsKey, rentID, at.Format(time.RFC3339Nano)).Result()
+}
+
+// TakeRentPause returns and removes when rentID paused. It returns nil if
+// the rent is not paused.
+func (r *repository) TakeRentPause(ctx context.Context, rentID string) (*time.Time, error) {
+	pipe := r.client.TxPipeline()
+	get := pipe.HGet(ctx, pausedRentsKey, rentID)
+	pipe.HDel(ctx, pausedRentsKey, rentID)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	val, err := get.Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pause time for rent %s: %w", rentID, err)
+	}
+	return &at, nil
+}
+
+func (r *repository) GetPausedRents(ctx context.Context) (int64, error) {
+	return r.client.HLen(ctx, pausedRentsKey).Result()
+}
+
+// AddPausedTime adds d to the time rents spent paused on date.
+func (r *repository) AddPausedTime(ctx context.Context, date string, d time.Duration) error {
+	return r.client.IncrByFloat(ctx, pausedTimeKey(date), d.Seconds()).Err()
+}
+
+func (r *repository) GetPausedTime(ctx context.Context, date string) (time.Duration, error) {
+	val, err := r.client.Get(ctx, pausedTimeKey(date)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
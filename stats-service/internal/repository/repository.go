@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -16,6 +17,29 @@ type Repository interface {
 	GetDailyStats(ctx context.Context, date string) (int64, error)
 	GetActiveRents(ctx context.Context) (int64, error)
 	GetLocationStats(ctx context.Context, date string) (map[string]int64, error)
+	IncrementHourlyRent(ctx context.Context, date string, hour int) error
+	GetDailyStatsRange(ctx context.Context, dates []string) ([]int64, error)
+	GetLocationStatsRange(ctx context.Context, dates []string) ([]map[string]int64, error)
+	GetHourlyStats(ctx context.Context, date string) (map[int]int64, error)
+	SaveRentStart(ctx context.Context, rentID string, start RentStart) error
+	GetRentStart(ctx context.Context, rentID string) (*RentStart, error)
+	CompleteRent(ctx context.Context, date string, rent CompletedRent, pass string, days []RentedDay) error
+	GetDurations(ctx context.Context, dates []string, location string) ([][]float64, error)
+	GetBikeUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error)
+	GetBikeRentsRange(ctx context.Context, dates []string) ([]map[string]float64, error)
+	GetLocationUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error)
+	IncrementHeatmap(ctx context.Context, date string, event string, hour int, location string) error
+	GetHeatmapRange(ctx context.Context, dates []string) ([]map[string]int64, error)
+	SaveRentPause(ctx context.Context, rentID string, at time.Time) (bool, error)
+	TakeRentPause(ctx context.Context, rentID string) (*time.Time, error)
+	GetPausedRents(ctx context.Context) (int64, error)
+	AddPausedTime(ctx context.Context, date string, d time.Duration) error
+	GetPausedTime(ctx context.Context, date string) (time.Duration, error)
+	IncrementPassRent(ctx context.Context, date, pass string) error
+	GetPassRentsRange(ctx context.Context, dates []string) ([]map[string]int64, error)
+	GetPassUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error)
+	ClaimEvent(ctx context.Context, eventID string) (bool, error)
+	ReleaseEvent(ctx context.Context, eventID string) error
 }
 
 type repository struct {
@@ -87,3 +111,116 @@ func (r *repository) GetLocationStats(ctx context.Context, date string) (map[str
 	return result, nil
 }
 
+
+func (r *repository) IncrementHourlyRent(ctx context.Context, date string, hour int) error {
+	key := fmt.Sprintf("stats:hourly:%s", date)
+	return r.client.HIncrBy(ctx, key, strconv.Itoa(hour), 1).Err()
+}
+
+// GetDailyStatsRange returns the daily counters for dates in one round trip;
+// missing days count as zero.
+func (r *repository) GetDailyStatsRange(ctx context.Context, dates []string) ([]int64, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(dates))
+	for i, date := range dates {
+		keys[i] = fmt.Sprintf("stats:daily:%s", date)
+	}
+
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(dates))
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		count, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[i] = count
+	}
+
+	return counts, nil
+}
+
+func (r *repository) GetLocationStatsRange(ctx context.Context, dates []string) ([]map[string]int64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(dates))
+	for i, date := range dates {
+		cmds[i] = pipe.HGetAll(ctx, fmt.Sprintf("stats:locations:%s", date))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make([]map[string]int64, len(dates))
+	for i, cmd := range cmds {
+		result[i] = parseCounts(cmd.Val())
+	}
+
+	return result, nil
+}
+
+func (r *repository) GetHourlyStats(ctx context.Context, date string) (map[int]int64, error) {
+	key := fmt.Sprintf("stats:hourly:%s", date)
+	vals, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]int64, len(vals))
+	for k, v := range parseCounts(vals) {
+		hour, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		result[hour] = v
+	}
+
+	return result, nil
+}
+
+// IncrementHeatmap counts a rent event at a location in one hour of date.
+// Fields are "<event>:<hour>:<location>" so one hash holds a whole day.
+func (r *repository) IncrementHeatmap(ctx context.Context, date string, event string, hour int, location string) error {
+	key := fmt.Sprintf("stats:heatmap:%s", date)
+	field := fmt.Sprintf("%s:%d:%s", event, hour, location)
+	return r.client.HIncrBy(ctx, key, field, 1).Err()
+}
+
+func (r *repository) GetHeatmapRange(ctx context.Context, dates []string) ([]map[string]int64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(dates))
+	for i, date := range dates {
+		cmds[i] = pipe.HGetAll(ctx, fmt.Sprintf("stats:heatmap:%s", date))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make([]map[string]int64, len(dates))
+	for i, cmd := range cmds {
+		result[i] = parseCounts(cmd.Val())
+	}
+
+	return result, nil
+}
+
+func parseCounts(vals map[string]string) map[string]int64 {
+	result := make(map[string]int64, len(vals))
+	for k, v := range vals {
+		count, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		result[k] = count
+	}
+	return result
+}
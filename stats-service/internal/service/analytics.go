@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 100
+)
+
+// DurationSummary describes completed rent durations in seconds.
+type DurationSummary struct {
+	Count         int     `json:"count"`
+	AvgSeconds    float64 `json:"avg_seconds"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P95Seconds    float64 `json:"p95_seconds"`
+}
+
+type DayDurations struct {
+	Date string `json:"date"`
+	DurationSummary
+}
+
+type LocationDurations struct {
+	Location string `json:"location"`
+	DurationSummary
+}
+
+type DurationStats struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Location  string              `json:"location,omitempty"`
+	Overall   DurationSummary     `json:"overall"`
+	Days      []DayDurations      `json:"days"`
+	Locations []LocationDurations `json:"locations,omitempty"`
+}
+
+type BikeUsage struct {
+	BikeID        string  `json:"bike_id"`
+	Rents         int64   `json:"rents"`
+	RentedMinutes float64 `json:"rented_minutes"`
+	Utilization   float64 `json:"utilization"`
+}
+
+type UtilizationStats struct {
+	From             string      `json:"from"`
+	To               string      `json:"to"`
+	AvailableMinutes float64     `json:"available_minutes"`
+	FleetUtilization float64     `json:"fleet_utilization"`
+	Bikes            []BikeUsage `json:"bikes"`
+}
+
+type LocationUsage struct {
+	Location      string  `json:"location"`
+	Rents         int64   `json:"rents"`
+	RentedMinutes float64 `json:"rented_minutes"`
+}
+
+type TopStats struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Bikes     []BikeUsage     `json:"bikes"`
+	Locations []LocationUsage `json:"locations"`
+}
+
+// GetDurationStats summarizes durations of rents started in the range, per
+// day and, unless location is set, per location.
+func (s *service) GetDurationStats(ctx context.Context, from, to, location string) (*DurationStats, error) {
+	days, err := s.parseRange(from, to, defaultRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	dates := formatDates(days)
+
+	perDay, err := s.repo.GetDurations(ctx, dates, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get durations: %w", err)
+	}
+
+	result := &DurationStats{
+		From:     dates[0],
+		To:       dates[len(dates)-1],
+		Location: location,
+		Days:     make([]DayDurations, len(dates)),
+	}
+	var all []float64
+	for i, durations := range perDay {
+		result.Days[i] = DayDurations{Date: dates[i], DurationSummary: summarize(durations)}
+		all = append(all, durations...)
+	}
+	result.Overall = summarize(all)
+
+	if location != "" {
+		return result, nil
+	}
+
+	locations, err := s.repo.GetLocationStatsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location stats: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, day := range locations {
+		for name := range day {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			perDay, err := s.repo.GetDurations(ctx, dates, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get durations for %s: %w", name, err)
+			}
+			var durations []float64
+			for _, d := range perDay {
+				durations = append(durations, d...)
+			}
+			if len(durations) == 0 {
+				continue
+			}
+			result.Locations = append(result.Locations, LocationDurations{Location: name, DurationSummary: summarize(durations)})
+		}
+	}
+	sort.Slice(result.Locations, func(i, j int) bool {
+		if result.Locations[i].Count != result.Locations[j].Count {
+			return result.Locations[i].Count > result.Locations[j].Count
+		}
+		return result.Locations[i].Location < result.Locations[j].Location
+	})
+
+	return result, nil
+}
+
+// GetUtilization returns rented minutes divided by available minutes for
+// every bike rented in the range, most used first. Available time runs
+// from the start of from to the end of to, or to now if that is earlier.
+// The fleet figure averages over those bikes only, since stats-service does
+// not know about bikes that were never rented.
+func (s *service) GetUtilization(ctx context.Context, from, to string) (*UtilizationStats, error) {
+	days, err := s.parseRange(from, to, defaultRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	dates := formatDates(days)
+
+	bikes, err := s.bikeUsage(ctx, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	start := s.startOfDay(days[0])
+	end := s.startOfDay(days[len(days)-1].AddDate(0, 0, 1))
+	if now := s.now(); now.Before(end) {
+		end = now
+	}
+	available := end.Sub(start).Minutes()
+
+	result := &UtilizationStats{
+		From:             dates[0],
+		To:               dates[len(dates)-1],
+		AvailableMinutes: math.Max(available, 0),
+		Bikes:            bikes,
+	}
+
+	var rented float64
+	for i := range result.Bikes {
+		rented += result.Bikes[i].RentedMinutes
+		result.Bikes[i].Utilization = ratio(result.Bikes[i].RentedMinutes, result.AvailableMinutes)
+	}
+	result.FleetUtilization = ratio(rented, result.AvailableMinutes*float64(len(result.Bikes)))
+
+	return result, nil
+}
+
+// GetTopStats returns the most used bikes (by rented minutes) and the
+// busiest locations (by rents started) in the range.
+func (s *service) GetTopStats(ctx context.Context, from, to string, limit int) (*TopStats, error) {
+	if limit == 0 {
+		limit = defaultTopLimit
+	}
+	if limit < 0 || limit > maxTopLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidArgument, maxTopLimit)
+	}
+
+	days, err := s.parseRange(from, to, defaultRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	dates := formatDates(days)
+
+	bikes, err := s.bikeUsage(ctx, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	rents, err := s.repo.GetLocationStatsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location stats: %w", err)
+	}
+	usage, err := s.repo.GetLocationUsageRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location usage: %w", err)
+	}
+
+	byLocation := make(map[string]*LocationUsage)
+	get := func(name string) *LocationUsage {
+		if byLocation[name] == nil {
+			byLocation[name] = &LocationUsage{Location: name}
+		}
+		return byLocation[name]
+	}
+	for _, day := range rents {
+		for name, count := range day {
+			get(name).Rents += count
+		}
+	}
+	for _, day := range usage {
+		for name, seconds := range day {
+			get(name).RentedMinutes += seconds / 60
+		}
+	}
+
+	locations := make([]LocationUsage, 0, len(byLocation))
+	for _, l := range byLocation {
+		locations = append(locations, *l)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Rents != locations[j].Rents {
+			return locations[i].Rents > locations[j].Rents
+		}
+		return locations[i].Location < locations[j].Location
+	})
+
+	return &TopStats{
+		From:      dates[0],
+		To:        dates[len(dates)-1],
+		Bikes:     bikes[:min(limit, len(bikes))],
+		Locations: locations[:min(limit, len(locations))],
+	}, nil
+}
+
+// bikeUsage sums rents and rented minutes per bike over dates, most used
+// bike first.
+func (s *service) bikeUsage(ctx context.Context, dates []string) ([]BikeUsage, error) {
+	seconds, err := s.repo.GetBikeUsageRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bike usage: %w", err)
+	}
+	rents, err := s.repo.GetBikeRentsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bike rents: %w", err)
+	}
+
+	byBike := make(map[string]*BikeUsage)
+	for i := range dates {
+		for bikeID, value := range seconds[i] {
+			if byBike[bikeID] == nil {
+				byBike[bikeID] = &BikeUsage{BikeID: bikeID}
+			}
+			byBike[bikeID].RentedMinutes += value / 60
+			byBike[bikeID].Rents += int64(rents[i][bikeID])
+		}
+	}
+
+	bikes := make([]BikeUsage, 0, len(byBike))
+	for _, b := range byBike {
+		bikes = append(bikes, *b)
+	}
+	sort.Slice(bikes, func(i, j int) bool {
+		if bikes[i].RentedMinutes != bikes[j].RentedMinutes {
+			return bikes[i].RentedMinutes > bikes[j].RentedMinutes
+		}
+		return bikes[i].BikeID < bikes[j].BikeID
+	})
+
+	return bikes, nil
+}
+
+// summarize computes count, mean, median and nearest-rank p95.
+func summarize(durations []float64) DurationSummary {
+	n := len(durations)
+	if n == 0 {
+		return DurationSummary{}
+	}
+
+	sorted := append([]float64(nil), durations...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, d := range sorted {
+		sum += d
+	}
+
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	return DurationSummary{
+		Count:         n,
+		AvgSeconds:    sum / float64(n),
+		MedianSeconds: median,
+		P95Seconds:    sorted[int(math.Ceil(0.95*float64(n)))-1],
+	}
+}
+
+func ratio(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return part / whole
+}
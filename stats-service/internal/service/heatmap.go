@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Weekdays labels the rows of a heatmap, Monday first.
+var Weekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// HeatmapGrid is indexed by weekday (Monday = 0) and hour of day.
+type HeatmapGrid [7][24]int64
+
+// LocationHeatmap counts rent starts and ends at a location per hour of
+// week. Net is ends minus starts: negative cells are hours when the
+// location loses bikes.
+type LocationHeatmap struct {
+	Location string      `json:"location"`
+	Starts   HeatmapGrid `json:"starts"`
+	Ends     HeatmapGrid `json:"ends"`
+	Net      HeatmapGrid `json:"net"`
+}
+
+type Heatmap struct {
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	TimeZone  string            `json:"time_zone"`
+	Weekdays  []string          `json:"weekdays"`
+	Locations []LocationHeatmap `json:"locations"`
+}
+
+// GetHeatmap builds hour-of-week histograms of rent starts and ends per
+// location over the range, in the stats time zone. An empty location
+// returns every location.
+func (s *service) GetHeatmap(ctx context.Context, from, to, location string) (*Heatmap, error) {
+	days, err := s.parseRange(from, to, defaultHeatmapDays)
+	if err != nil {
+		return nil, err
+	}
+	dates := formatDates(days)
+
+	perDay, err := s.repo.GetHeatmapRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get heatmap: %w", err)
+	}
+
+	byLocation := make(map[string]*LocationHeatmap)
+	for i, fields := range perDay {
+		weekday := (int(days[i].Weekday()) + 6) % 7
+		for field, count := range fields {
+			parts := strings.SplitN(field, ":", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			event, name := parts[0], parts[2]
+			hour, err := strconv.Atoi(parts[1])
+			if err != nil || hour < 0 || hour > 23 {
+				continue
+			}
+			if location != "" && name != location {
+				continue
+			}
+
+			h := byLocation[name]
+			if h == nil {
+				h = &LocationHeatmap{Location: name}
+				byLocation[name] = h
+			}
+			switch event {
+			case "start":
+				h.Starts[weekday][hour] += count
+				h.Net[weekday][hour] -= count
+			case "end":
+				h.Ends[weekday][hour] += count
+				h.Net[weekday][hour] += count
+			}
+		}
+	}
+
+	result := &Heatmap{
+		From:      dates[0],
+		To:        dates[len(dates)-1],
+		TimeZone:  s.loc.String(),
+		Weekdays:  Weekdays,
+		Locations: make([]LocationHeatmap, 0, len(byLocation)),
+	}
+	for _, h := range byLocation {
+		result.Locations = append(result.Locations, *h)
+	}
+	sort.Slice(result.Locations, func(i, j int) bool {
+		return result.Locations[i].Location < result.Locations[j].Location
+	})
+
+	return result, nil
+}
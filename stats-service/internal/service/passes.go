@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// PassUsage is how much one pass was ridden on.
+type PassUsage struct {
+	Pass  string `json:"pass"`
+	Rents int64  `json:"rents"`
+	// RentedMinutes counts rents of the range that have ended
+	RentedMinutes float64 `json:"rented_minutes"`
+}
+
+type PassStats struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Rents started in the range, with a pass or without
+	Rents     int64 `json:"rents"`
+	PassRents int64 `json:"pass_rents"`
+	// PassShare is the part of the rents ridden on a pass, 0 to 1
+	PassShare float64     `json:"pass_share"`
+	Passes    []PassUsage `json:"passes"`
+}
+
+// GetPassStats sums rents and rented time per pass over the range, most
+// used pass first.
+func (s *service) GetPassStats(ctx context.Context, from, to string) (*PassStats, error) {
+	days, err := s.parseRange(from, to, defaultRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	dates := formatDates(days)
+
+	rents, err := s.repo.GetPassRentsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass rents: %w", err)
+	}
+	usage, err := s.repo.GetPassUsageRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pass usage: %w", err)
+	}
+	daily, err := s.repo.GetDailyStatsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily stats: %w", err)
+	}
+
+	byPass := make(map[string]*PassUsage)
+	get := func(pass string) *PassUsage {
+		if byPass[pass] == nil {
+			byPass[pass] = &PassUsage{Pass: pass}
+		}
+		return byPass[pass]
+	}
+	for _, day := range rents {
+		for pass, count := range day {
+			get(pass).Rents += count
+		}
+	}
+	for _, day := range usage {
+		for pass, seconds := range day {
+			get(pass).RentedMinutes += seconds / 60
+		}
+	}
+
+	result := &PassStats{
+		From:   dates[0],
+		To:     dates[len(dates)-1],
+		Passes: make([]PassUsage, 0, len(byPass)),
+	}
+	for _, count := range daily {
+		result.Rents += count
+	}
+	for _, p := range byPass {
+		result.Passes = append(result.Passes, *p)
+		result.PassRents += p.Rents
+	}
+	if result.Rents > 0 {
+		result.PassShare = float64(result.PassRents) / float64(result.Rents)
+	}
+	sort.Slice(result.Passes, func(i, j int) bool {
+		if result.Passes[i].Rents != result.Passes[j].Rents {
+			return result.Passes[i].Rents > result.Passes[j].Rents
+		}
+		return result.Passes[i].Pass < result.Passes[j].Pass
+	})
+
+	return result, nil
+}
@@ -2,40 +2,322 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"bike-rental/stats-service/internal/repository"
 )
 
+const (
+	dateLayout = "2006-01-02"
+
+	// MaxRangeDays caps range queries so a single request cannot scan years
+	// of keys.
+	MaxRangeDays = 366
+
+	defaultRangeDays   = 7
+	defaultHeatmapDays = 28
+)
+
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// ErrInvalidArgument marks errors caused by bad query parameters.
+var ErrInvalidArgument = errors.New("invalid argument")
+
 type Service interface {
-	GetDailyStats(ctx context.Context, date string) (int64, error)
+	GetDailyStats(ctx context.Context, date string) (*DailyStats, error)
 	GetActiveRents(ctx context.Context) (int64, error)
+	// GetPausedRents returns how many of the active rents are paused.
+	GetPausedRents(ctx context.Context) (int64, error)
 	GetLocationStats(ctx context.Context, date string) (map[string]int64, error)
+	GetRangeStats(ctx context.Context, from, to, granularity string) (*RangeStats, error)
+	GetHourlyStats(ctx context.Context, date string) (*HourlyStats, error)
+	GetLocationBreakdown(ctx context.Context, from, to string) (*LocationBreakdown, error)
+	GetDurationStats(ctx context.Context, from, to, location string) (*DurationStats, error)
+	GetUtilization(ctx context.Context, from, to string) (*UtilizationStats, error)
+	GetTopStats(ctx context.Context, from, to string, limit int) (*TopStats, error)
+	GetHeatmap(ctx context.Context, from, to, location string) (*Heatmap, error)
+	GetPassStats(ctx context.Context, from, to string) (*PassStats, error)
+}
+
+type DailyStats struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+	// PausedMinutes is the time rents spent paused on the day
+	PausedMinutes float64 `json:"paused_minutes"`
+}
+
+// SeriesPoint is one bucket of a time series. Period is the bucket label:
+// 2024-01-15 for days, 2024-W03 for ISO weeks, 2024-01 for months.
+type SeriesPoint struct {
+	Period string `json:"period"`
+	Start  string `json:"start"`
+	Count  int64  `json:"count"`
+}
+
+type RangeStats struct {
+	From        string        `json:"from"`
+	To          string        `json:"to"`
+	Granularity string        `json:"granularity"`
+	Total       int64         `json:"total"`
+	Series      []SeriesPoint `json:"series"`
+}
+
+type HourBucket struct {
+	Hour  int   `json:"hour"`
+	Count int64 `json:"count"`
+}
+
+type HourlyStats struct {
+	Date  string       `json:"date"`
+	Total int64        `json:"total"`
+	Hours []HourBucket `json:"hours"`
+}
+
+type LocationCount struct {
+	Location string `json:"location"`
+	Count    int64  `json:"count"`
+}
+
+type LocationBreakdown struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Total     int64           `json:"total"`
+	Locations []LocationCount `json:"locations"`
 }
 
 type service struct {
 	repo repository.Repository
+	loc  *time.Location
+	now  func() time.Time
 }
 
-func NewService(repo repository.Repository) Service {
-	return &service{repo: repo}
+// NewService creates the stats service. Defaults such as "today" and
+// hour-of-week buckets are evaluated in loc.
+func NewService(repo repository.Repository, loc *time.Location) Service {
+	return &service{repo: repo, loc: loc, now: time.Now}
 }
 
-func (s *service) GetDailyStats(ctx context.Context, date string) (int64, error) {
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+// GetDailyStats returns the rents started on date (default today) in the
+// stats time zone.
+func (s *service) GetDailyStats(ctx context.Context, date string) (*DailyStats, error) {
+	date, err := s.resolveDate(date)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.GetDailyStats(ctx, date)
+
+	count, err := s.repo.GetDailyStats(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily stats: %w", err)
+	}
+
+	paused, err := s.repo.GetPausedTime(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paused time: %w", err)
+	}
+
+	return &DailyStats{Date: date, Count: count, PausedMinutes: paused.Minutes()}, nil
 }
 
 func (s *service) GetActiveRents(ctx context.Context) (int64, error) {
 	return s.repo.GetActiveRents(ctx)
 }
 
+func (s *service) GetPausedRents(ctx context.Context) (int64, error) {
+	return s.repo.GetPausedRents(ctx)
+}
+
 func (s *service) GetLocationStats(ctx context.Context, date string) (map[string]int64, error) {
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+	date, err := s.resolveDate(date)
+	if err != nil {
+		return nil, err
 	}
 	return s.repo.GetLocationStats(ctx, date)
 }
 
+// GetRangeStats returns rent counts between from and to (inclusive), rolled
+// up by day, ISO week or calendar month. Buckets at the edges only include
+// days inside the range.
+func (s *service) GetRangeStats(ctx context.Context, from, to, granularity string) (*RangeStats, error) {
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	if granularity != GranularityDay && granularity != GranularityWeek && granularity != GranularityMonth {
+		return nil, fmt.Errorf("%w: granularity must be one of day, week, month", ErrInvalidArgument)
+	}
+
+	days, err := s.parseRange(from, to, defaultRangeDays)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := formatDates(days)
+	counts, err := s.repo.GetDailyStatsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily stats: %w", err)
+	}
+
+	result := &RangeStats{
+		From:        dates[0],
+		To:          dates[len(dates)-1],
+		Granularity: granularity,
+		Series:      []SeriesPoint{},
+	}
+	for i, day := range days {
+		period := periodLabel(day, granularity)
+		last := len(result.Series) - 1
+		if last < 0 || result.Series[last].Period != period {
+			result.Series = append(result.Series, SeriesPoint{Period: period, Start: dates[i]})
+			last++
+		}
+		result.Series[last].Count += counts[i]
+		result.Total += counts[i]
+	}
+
+	return result, nil
+}
+
+// GetHourlyStats returns 24 hourly buckets for date (default today).
+func (s *service) GetHourlyStats(ctx context.Context, date string) (*HourlyStats, error) {
+	date, err := s.resolveDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.GetHourlyStats(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly stats: %w", err)
+	}
+
+	result := &HourlyStats{Date: date, Hours: make([]HourBucket, 24)}
+	for hour := range result.Hours {
+		result.Hours[hour] = HourBucket{Hour: hour, Count: counts[hour]}
+		result.Total += counts[hour]
+	}
+
+	return result, nil
+}
+
+// GetLocationBreakdown sums per-location rent counts over the range, busiest
+// location first.
+func (s *service) GetLocationBreakdown(ctx context.Context, from, to string) (*LocationBreakdown, error) {
+	days, err := s.parseRange(from, to, defaultRangeDays)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := formatDates(days)
+	perDay, err := s.repo.GetLocationStatsRange(ctx, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location stats: %w", err)
+	}
+
+	totals := make(map[string]int64)
+	for _, day := range perDay {
+		for location, count := range day {
+			totals[location] += count
+		}
+	}
+
+	result := &LocationBreakdown{
+		From:      dates[0],
+		To:        dates[len(dates)-1],
+		Locations: make([]LocationCount, 0, len(totals)),
+	}
+	for location, count := range totals {
+		result.Locations = append(result.Locations, LocationCount{Location: location, Count: count})
+		result.Total += count
+	}
+	sort.Slice(result.Locations, func(i, j int) bool {
+		if result.Locations[i].Count != result.Locations[j].Count {
+			return result.Locations[i].Count > result.Locations[j].Count
+		}
+		return result.Locations[i].Location < result.Locations[j].Location
+	})
+
+	return result, nil
+}
+
+// parseRange validates from/to and returns every day between them. An empty
+// to means today; an empty from means the defaultDays days up to to.
+func (s *service) parseRange(from, to string, defaultDays int) ([]time.Time, error) {
+	end := s.today()
+	if to != "" {
+		parsed, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to must be in YYYY-MM-DD format", ErrInvalidArgument)
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, 1-defaultDays)
+	if from != "" {
+		parsed, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from must be in YYYY-MM-DD format", ErrInvalidArgument)
+		}
+		start = parsed
+	}
+
+	if start.After(end) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidArgument)
+	}
+	if int(end.Sub(start).Hours()/24)+1 > MaxRangeDays {
+		return nil, fmt.Errorf("%w: range must not exceed %d days", ErrInvalidArgument, MaxRangeDays)
+	}
+
+	var days []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// resolveDate validates a YYYY-MM-DD date; an empty date means today.
+func (s *service) resolveDate(date string) (string, error) {
+	if date == "" {
+		return s.today().Format(dateLayout), nil
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return "", fmt.Errorf("%w: date must be in YYYY-MM-DD format", ErrInvalidArgument)
+	}
+	return date, nil
+}
+
+// today returns the current date in the stats time zone as a UTC midnight,
+// the same form time.Parse gives for a date parameter.
+func (s *service) today() time.Time {
+	year, month, day := s.now().In(s.loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// startOfDay returns midnight of a calendar date in the stats time zone.
+func (s *service) startOfDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, s.loc)
+}
+
+func formatDates(days []time.Time) []string {
+	dates := make([]string, len(days))
+	for i, day := range days {
+		dates[i] = day.Format(dateLayout)
+	}
+	return dates
+}
+
+func periodLabel(day time.Time, granularity string) string {
+	switch granularity {
+	case GranularityWeek:
+		year, week := day.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GranularityMonth:
+		return day.Format("2006-01")
+	default:
+		return day.Format(dateLayout)
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bike-rental/stats-service/internal/repository"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeRepository - in-memory хранилище счётчиков для тестов
+type fakeRepository struct {
+	repository.Repository
+	daily     map[string]int64
+	hourly    map[string]map[int]int64
+	locations map[string]map[string]int64
+	durations map[string][]float64
+	bikeUsage map[string]map[string]float64
+	bikeRents map[string]map[string]float64
+	heatmap   map[string]map[string]int64
+	paused    map[string]time.Duration
+	passRents map[string]map[string]int64
+	passUsage map[string]map[string]float64
+}
+
+func (f *fakeRepository) GetDailyStats(ctx context.Context, date string) (int64, error) {
+	return f.daily[date], nil
+}
+
+func (f *fakeRepository) GetPausedTime(ctx context.Context, date string) (time.Duration, error) {
+	return f.paused[date], nil
+}
+
+func (f *fakeRepository) GetDailyStatsRange(ctx context.Context, dates []string) ([]int64, error) {
+	counts := make([]int64, len(dates))
+	for i, date := range dates {
+		counts[i] = f.daily[date]
+	}
+	return counts, nil
+}
+
+func (f *fakeRepository) GetLocationStatsRange(ctx context.Context, dates []string) ([]map[string]int64, error) {
+	result := make([]map[string]int64, len(dates))
+	for i, date := range dates {
+		result[i] = f.locations[date]
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) GetHourlyStats(ctx context.Context, date string) (map[int]int64, error) {
+	return f.hourly[date], nil
+}
+
+func (f *fakeRepository) GetDurations(ctx context.Context, dates []string, location string) ([][]float64, error) {
+	result := make([][]float64, len(dates))
+	for i, date := range dates {
+		key := date
+		if location != "" {
+			key = date + "/" + location
+		}
+		result[i] = f.durations[key]
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) GetBikeUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return f.scores(f.bikeUsage, dates), nil
+}
+
+func (f *fakeRepository) GetBikeRentsRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return f.scores(f.bikeRents, dates), nil
+}
+
+func (f *fakeRepository) GetLocationUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return make([]map[string]float64, len(dates)), nil
+}
+
+func (f *fakeRepository) GetHeatmapRange(ctx context.Context, dates []string) ([]map[string]int64, error) {
+	result := make([]map[string]int64, len(dates))
+	for i, date := range dates {
+		result[i] = f.heatmap[date]
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) GetPassRentsRange(ctx context.Context, dates []string) ([]map[string]int64, error) {
+	result := make([]map[string]int64, len(dates))
+	for i, date := range dates {
+		result[i] = f.passRents[date]
+	}
+	return result, nil
+}
+
+func (f *fakeRepository) GetPassUsageRange(ctx context.Context, dates []string) ([]map[string]float64, error) {
+	return f.scores(f.passUsage, dates), nil
+}
+
+func (f *fakeRepository) scores(data map[string]map[string]float64, dates []string) []map[string]float64 {
+	result := make([]map[string]float64, len(dates))
+	for i, date := range dates {
+		result[i] = data[date]
+	}
+	return result
+}
+
+// StatsServiceTestSuite - тестовый набор для исторической статистики
+type StatsServiceTestSuite struct {
+	suite.Suite
+	repo    *fakeRepository
+	service *service
+	ctx     context.Context
+}
+
+// SetupTest - вызывается перед каждым тестом
+func (suite *StatsServiceTestSuite) SetupTest() {
+	suite.repo = &fakeRepository{
+		daily: map[string]int64{
+			"2024-01-29": 1,
+			"2024-01-31": 2,
+			"2024-02-01": 3,
+			"2024-02-05": 4,
+		},
+		hourly: map[string]map[int]int64{
+			"2024-02-01": {8: 2, 18: 1},
+		},
+		locations: map[string]map[string]int64{
+			"2024-01-31": {"Center": 1, "Park": 1},
+			"2024-02-01": {"Park": 3},
+		},
+		durations: map[string][]float64{
+			"2024-01-31":      {600, 1200},
+			"2024-01-31/Park": {1200},
+			"2024-02-01":      {300},
+			"2024-02-01/Park": {300},
+		},
+		bikeUsage: map[string]map[string]float64{
+			"2024-02-04": {"bike-1": 3600, "bike-2": 720},
+			"2024-02-05": {"bike-1": 1800},
+		},
+		bikeRents: map[string]map[string]float64{
+			"2024-02-04": {"bike-1": 2, "bike-2": 1},
+			"2024-02-05": {"bike-1": 1},
+		},
+		heatmap: map[string]map[string]int64{
+			// 2024-02-04 is a Sunday, 2024-02-05 a Monday
+			"2024-02-04": {"start:8:Park": 2, "end:9:Center": 1},
+			"2024-02-05": {"start:8:Park": 1, "end:8:Park": 1, "bad-field": 1},
+		},
+		passRents: map[string]map[string]int64{
+			"2024-01-31": {"month": 1},
+			"2024-02-01": {"day": 1, "month": 1},
+		},
+		passUsage: map[string]map[string]float64{
+			"2024-01-31": {"month": 1200},
+			"2024-02-01": {"month": 600, "day": 300},
+		},
+	}
+	suite.service = &service{
+		repo: suite.repo,
+		loc:  time.UTC,
+		now:  func() time.Time { return time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC) },
+	}
+	suite.ctx = context.Background()
+}
+
+// TestGetRangeStats_Daily - ряд по дням включает дни без аренд
+func (suite *StatsServiceTestSuite) TestGetRangeStats_Daily() {
+	result, err := suite.service.GetRangeStats(suite.ctx, "2024-01-30", "2024-02-01", "")
+
+	suite.NoError(err)
+	suite.Equal(GranularityDay, result.Granularity)
+	suite.Equal(int64(5), result.Total)
+	suite.Equal([]SeriesPoint{
+		{Period: "2024-01-30", Start: "2024-01-30", Count: 0},
+		{Period: "2024-01-31", Start: "2024-01-31", Count: 2},
+		{Period: "2024-02-01", Start: "2024-02-01", Count: 3},
+	}, result.Series)
+}
+
+// TestGetRangeStats_Week - сводка по ISO неделям
+func (suite *StatsServiceTestSuite) TestGetRangeStats_Week() {
+	result, err := suite.service.GetRangeStats(suite.ctx, "2024-01-29", "2024-02-05", GranularityWeek)
+
+	suite.NoError(err)
+	suite.Equal([]SeriesPoint{
+		{Period: "2024-W05", Start: "2024-01-29", Count: 6},
+		{Period: "2024-W06", Start: "2024-02-05", Count: 4},
+	}, result.Series)
+}
+
+// TestGetRangeStats_Month - сводка по месяцам
+func (suite *StatsServiceTestSuite) TestGetRangeStats_Month() {
+	result, err := suite.service.GetRangeStats(suite.ctx, "2024-01-29", "2024-02-05", GranularityMonth)
+
+	suite.NoError(err)
+	suite.Equal([]SeriesPoint{
+		{Period: "2024-01", Start: "2024-01-29", Count: 3},
+		{Period: "2024-02", Start: "2024-02-01", Count: 7},
+	}, result.Series)
+}
+
+// TestGetRangeStats_DefaultRange - по умолчанию последние 7 дней
+func (suite *StatsServiceTestSuite) TestGetRangeStats_DefaultRange() {
+	result, err := suite.service.GetRangeStats(suite.ctx, "", "", "")
+
+	suite.NoError(err)
+	suite.Equal("2024-01-30", result.From)
+	suite.Equal("2024-02-05", result.To)
+	suite.Len(result.Series, 7)
+}
+
+// TestGetRangeStats_InvalidArguments - некорректные параметры
+func (suite *StatsServiceTestSuite) TestGetRangeStats_InvalidArguments() {
+	cases := []struct{ from, to, granularity string }{
+		{"2024-13-01", "", ""},
+		{"2024-02-01", "2024-01-01", ""},
+		{"2022-01-01", "2024-01-01", ""},
+		{"", "", "year"},
+	}
+	for _, c := range cases {
+		_, err := suite.service.GetRangeStats(suite.ctx, c.from, c.to, c.granularity)
+		suite.True(errors.Is(err, ErrInvalidArgument), "%+v", c)
+	}
+}
+
+// TestGetHourlyStats - 24 часовых интервала
+func (suite *StatsServiceTestSuite) TestGetHourlyStats() {
+	result, err := suite.service.GetHourlyStats(suite.ctx, "2024-02-01")
+
+	suite.NoError(err)
+	suite.Len(result.Hours, 24)
+	suite.Equal(int64(2), result.Hours[8].Count)
+	suite.Equal(int64(3), result.Total)
+}
+
+// TestGetLocationBreakdown - сумма по локациям, самые загруженные первыми
+func (suite *StatsServiceTestSuite) TestGetLocationBreakdown() {
+	result, err := suite.service.GetLocationBreakdown(suite.ctx, "2024-01-31", "2024-02-01")
+
+	suite.NoError(err)
+	suite.Equal(int64(5), result.Total)
+	suite.Equal([]LocationCount{
+		{Location: "Park", Count: 4},
+		{Location: "Center", Count: 1},
+	}, result.Locations)
+}
+
+// TestGetPassStats - аренды и минуты по абонементам суммируются за период, доля считается от всех аренд
+func (suite *StatsServiceTestSuite) TestGetPassStats() {
+	result, err := suite.service.GetPassStats(suite.ctx, "2024-01-31", "2024-02-01")
+
+	suite.NoError(err)
+	suite.Equal(int64(5), result.Rents)
+	suite.Equal(int64(3), result.PassRents)
+	suite.InDelta(0.6, result.PassShare, 1e-9)
+	suite.Equal([]PassUsage{
+		{Pass: "month", Rents: 2, RentedMinutes: 30},
+		{Pass: "day", Rents: 1, RentedMinutes: 5},
+	}, result.Passes)
+}
+
+// TestSummarize - среднее, медиана и p95
+func (suite *StatsServiceTestSuite) TestSummarize() {
+	durations := make([]float64, 0, 20)
+	for i := 20; i >= 1; i-- {
+		durations = append(durations, float64(i*60))
+	}
+
+	summary := summarize(durations)
+
+	suite.Equal(20, summary.Count)
+	suite.Equal(630.0, summary.AvgSeconds)
+	suite.Equal(630.0, summary.MedianSeconds)
+	suite.Equal(1140.0, summary.P95Seconds)
+	suite.Equal(DurationSummary{}, summarize(nil))
+}
+
+// TestGetDurationStats - длительности по дням и локациям
+func (suite *StatsServiceTestSuite) TestGetDurationStats() {
+	result, err := suite.service.GetDurationStats(suite.ctx, "2024-01-31", "2024-02-01", "")
+
+	suite.NoError(err)
+	suite.Equal(3, result.Overall.Count)
+	suite.Equal(700.0, result.Overall.AvgSeconds)
+	suite.Equal(600.0, result.Overall.MedianSeconds)
+	suite.Equal(900.0, result.Days[0].MedianSeconds)
+	suite.Equal([]LocationDurations{
+		{Location: "Park", DurationSummary: DurationSummary{Count: 2, AvgSeconds: 750, MedianSeconds: 750, P95Seconds: 1200}},
+	}, result.Locations)
+}
+
+// TestGetUtilization - загрузка считается до текущего момента
+func (suite *StatsServiceTestSuite) TestGetUtilization() {
+	result, err := suite.service.GetUtilization(suite.ctx, "2024-02-04", "2024-02-05")
+
+	suite.NoError(err)
+	// 2024-02-04 00:00 - 2024-02-05 12:00
+	suite.Equal(36*60.0, result.AvailableMinutes)
+	suite.Equal("bike-1", result.Bikes[0].BikeID)
+	suite.Equal(int64(3), result.Bikes[0].Rents)
+	suite.Equal(90.0, result.Bikes[0].RentedMinutes)
+	suite.InDelta(90.0/2160, result.Bikes[0].Utilization, 1e-9)
+	suite.InDelta(102.0/4320, result.FleetUtilization, 1e-9)
+}
+
+// TestGetTopStats - ограничение количества и проверка limit
+func (suite *StatsServiceTestSuite) TestGetTopStats() {
+	result, err := suite.service.GetTopStats(suite.ctx, "2024-01-31", "2024-02-05", 1)
+
+	suite.NoError(err)
+	suite.Len(result.Bikes, 1)
+	suite.Equal("bike-1", result.Bikes[0].BikeID)
+	suite.Equal([]LocationUsage{{Location: "Park", Rents: 4}}, result.Locations)
+
+	_, err = suite.service.GetTopStats(suite.ctx, "", "", 1000)
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+// TestGetHeatmap - гистограмма по дням недели и часам
+func (suite *StatsServiceTestSuite) TestGetHeatmap() {
+	result, err := suite.service.GetHeatmap(suite.ctx, "", "", "")
+
+	suite.NoError(err)
+	suite.Equal("2024-01-09", result.From)
+	suite.Equal("UTC", result.TimeZone)
+	suite.Require().Len(result.Locations, 2)
+	suite.Equal("Center", result.Locations[0].Location)
+	suite.Equal(int64(1), result.Locations[0].Ends[6][9])
+
+	park := result.Locations[1]
+	suite.Equal(int64(2), park.Starts[6][8])
+	suite.Equal(int64(-2), park.Net[6][8])
+	suite.Equal(int64(1), park.Starts[0][8])
+	suite.Equal(int64(0), park.Net[0][8])
+}
+
+// TestGetHeatmap_FilterByLocation - фильтр по локации
+func (suite *StatsServiceTestSuite) TestGetHeatmap_FilterByLocation() {
+	result, err := suite.service.GetHeatmap(suite.ctx, "2024-02-01", "2024-02-05", "Center")
+
+	suite.NoError(err)
+	suite.Require().Len(result.Locations, 1)
+	suite.Equal("Center", result.Locations[0].Location)
+}
+
+// TestGetDailyStats_DefaultsToBusinessToday - по умолчанию сегодняшний день в часовом поясе бизнеса
+func (suite *StatsServiceTestSuite) TestGetDailyStats_DefaultsToBusinessToday() {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	suite.Require().NoError(err)
+	suite.service.loc = loc
+	// 2024-02-05 20:00 UTC is already 2024-02-06 in Tokyo
+	suite.service.now = func() time.Time { return time.Date(2024, 2, 5, 20, 0, 0, 0, time.UTC) }
+
+	result, err := suite.service.GetDailyStats(suite.ctx, "")
+
+	suite.NoError(err)
+	suite.Equal("2024-02-06", result.Date)
+}
+
+// TestGetDailyStats_PausedMinutes - время на паузе за день отдаётся в минутах
+func (suite *StatsServiceTestSuite) TestGetDailyStats_PausedMinutes() {
+	suite.repo.paused = map[string]time.Duration{"2024-02-05": 90 * time.Second}
+
+	result, err := suite.service.GetDailyStats(suite.ctx, "2024-02-05")
+
+	suite.NoError(err)
+	suite.Equal(int64(4), result.Count)
+	suite.Equal(1.5, result.PausedMinutes)
+}
+
+// TestGetDailyStats_InvalidDate - некорректная дата
+func (suite *StatsServiceTestSuite) TestGetDailyStats_InvalidDate() {
+	_, err := suite.service.GetDailyStats(suite.ctx, "05.02.2024")
+
+	suite.ErrorIs(err, ErrInvalidArgument)
+}
+
+func TestStatsServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(StatsServiceTestSuite))
+}
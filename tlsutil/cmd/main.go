@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"bike-rental/tlsutil"
+)
+
+// Generates a local development CA and service certificates:
+//
+//	go run ./tlsutil/cmd -out certs
+func main() {
+	out := flag.String("out", "certs", "directory to write certificates to")
+	flag.Parse()
+
+	if err := tlsutil.GenerateDevCA(*out, tlsutil.DevLeaves); err != nil {
+		log.Fatalf("Failed to generate certificates: %v", err)
+	}
+
+	log.Printf("Dev CA and certificates written to %s", *out)
+}
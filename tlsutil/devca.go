@@ -0,0 +1,129 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const devCertValidity = 365 * 24 * time.Hour
+
+// Leaf describes a certificate issued by the dev CA.
+type Leaf struct {
+	Name  string
+	Hosts []string
+}
+
+// DevLeaves are the certificates needed for the docker-compose setup.
+// Every leaf can act as both server and client so the same files work for
+// the HTTPS listener and for mutual TLS.
+var DevLeaves = []Leaf{
+	{Name: "rent-service", Hosts: []string{"rent-service", "localhost", "127.0.0.1"}},
+	{Name: "api-gateway", Hosts: []string{"api-gateway", "localhost", "127.0.0.1"}},
+}
+
+// GenerateDevCA writes a self-signed CA (ca.pem, ca-key.pem) and a
+// certificate/key pair per leaf (<name>.pem, <name>-key.pem) into dir.
+// It is meant for local testing only.
+func GenerateDevCA(dir string, leaves []Leaf) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	now := time.Now()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "bike-rental dev CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(devCertValidity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return err
+	}
+	if err := writePair(dir, "ca", caDER, caKey); err != nil {
+		return err
+	}
+
+	for _, leaf := range leaves {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return fmt.Errorf("failed to generate key for %s: %w", leaf.Name, err)
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: serialNumber(),
+			Subject:      pkix.Name{CommonName: leaf.Name},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(devCertValidity),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		for _, host := range leaf.Hosts {
+			if ip := net.ParseIP(host); ip != nil {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			} else {
+				template.DNSNames = append(template.DNSNames, host)
+			}
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			return fmt.Errorf("failed to create certificate for %s: %w", leaf.Name, err)
+		}
+		if err := writePair(dir, leaf.Name, der, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePair(dir, name string, certDER []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key for %s: %w", name, err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o644); err != nil {
+		return fmt.Errorf("failed to write certificate for %s: %w", name, err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600); err != nil {
+		return fmt.Errorf("failed to write key for %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func serialNumber() *big.Int {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate serial number: %v", err))
+	}
+	return n
+}
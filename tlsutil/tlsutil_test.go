@@ -0,0 +1,108 @@
+package tlsutil
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bike-rental/config"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSTestSuite - тестовый набор для TLS/mTLS
+type TLSTestSuite struct {
+	suite.Suite
+	cfg config.GRPCTLSConfig
+}
+
+// SetupTest - генерирует dev CA перед каждым тестом
+func (suite *TLSTestSuite) SetupTest() {
+	dir := suite.T().TempDir()
+	suite.Require().NoError(GenerateDevCA(dir, DevLeaves))
+
+	suite.cfg = config.GRPCTLSConfig{
+		Enabled:           true,
+		CAFile:            filepath.Join(dir, "ca.pem"),
+		ServerCertFile:    filepath.Join(dir, "rent-service.pem"),
+		ServerKeyFile:     filepath.Join(dir, "rent-service-key.pem"),
+		ClientCertFile:    filepath.Join(dir, "api-gateway.pem"),
+		ClientKeyFile:     filepath.Join(dir, "api-gateway-key.pem"),
+		ServerName:        "rent-service",
+		RequireClientCert: true,
+	}
+}
+
+func (suite *TLSTestSuite) handshake(server, client credentials.TransportCredentials) (error, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		_, _, err := server.ServerHandshake(serverConn)
+		if err != nil {
+			serverConn.Close()
+		}
+		serverErr <- err
+	}()
+
+	secure, _, clientErr := client.ClientHandshake(ctx, suite.cfg.ServerName, clientConn)
+	if clientErr != nil {
+		clientConn.Close()
+	} else {
+		// Keep reading so the server can deliver session tickets or alerts.
+		go io.Copy(io.Discard, secure)
+	}
+	return <-serverErr, clientErr
+}
+
+// TestMutualTLS_Success - клиент с сертификатом от dev CA проходит mTLS
+func (suite *TLSTestSuite) TestMutualTLS_Success() {
+	server, err := ServerCredentials(suite.cfg)
+	suite.Require().NoError(err)
+	client, err := ClientCredentials(suite.cfg)
+	suite.Require().NoError(err)
+
+	serverErr, clientErr := suite.handshake(server, client)
+
+	suite.NoError(serverErr)
+	suite.NoError(clientErr)
+}
+
+// TestMutualTLS_MissingClientCert - без клиентского сертификата соединение отклоняется
+func (suite *TLSTestSuite) TestMutualTLS_MissingClientCert() {
+	server, err := ServerCredentials(suite.cfg)
+	suite.Require().NoError(err)
+
+	clientCfg := suite.cfg
+	clientCfg.ClientCertFile = ""
+	clientCfg.ClientKeyFile = ""
+	client, err := ClientCredentials(clientCfg)
+	suite.Require().NoError(err)
+
+	serverErr, _ := suite.handshake(server, client)
+
+	suite.Error(serverErr)
+}
+
+// TestDisabled - при выключенном TLS сервер без credentials, клиент insecure
+func (suite *TLSTestSuite) TestDisabled() {
+	server, err := ServerCredentials(config.GRPCTLSConfig{})
+	suite.NoError(err)
+	suite.Nil(server)
+
+	client, err := ClientCredentials(config.GRPCTLSConfig{})
+	suite.NoError(err)
+	suite.Equal("insecure", client.Info().SecurityProtocol)
+}
+
+func TestTLSTestSuite(t *testing.T) {
+	suite.Run(t, new(TLSTestSuite))
+}